@@ -0,0 +1,131 @@
+package clientcert
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+)
+
+// Template is a template of Validator
+type Template interface {
+	NewValidator(tplEnv domain.TemplateStringEnv) (Validator, error)
+}
+
+// Validator is a object to validate TLS client certificate
+type Validator interface {
+	// certs must be verified certificate chain, first element is the leaf certificate.
+	Validate(ctx context.Context, certs []*x509.Certificate) error
+}
+
+type validatorTemplate struct {
+	cfg *config.ClientCertAuthConfig
+}
+
+type validator struct {
+	subject map[string][]string
+	san     []string // nil if SAN validation is not configured
+}
+
+// NewTemplate creates Template instance.
+func NewTemplate(ctx context.Context, cfg *config.ClientCertAuthConfig) (Template, error) {
+	return &validatorTemplate{cfg: cfg}, nil
+}
+
+// NewStaticValidator creates Validator of the rule that does not use template variables (e.g. rules of admin API).
+func NewStaticValidator(ctx context.Context, cfg *config.ClientCertAuthConfig) (Validator, error) {
+	tpl, err := NewTemplate(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return tpl.NewValidator(map[string]interface{}{})
+}
+
+func (tpl *validatorTemplate) NewValidator(tplEnv domain.TemplateStringEnv) (Validator, error) {
+	subject := make(map[string][]string, len(tpl.cfg.Subject))
+	for name, tpls := range tpl.cfg.Subject {
+		strs, err := tpls.Execute(tplEnv)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to evaluate template string of client certificate subject "%s" configuration "%s": %w`, name, tpls, err)
+		}
+		subject[name] = strs
+	}
+	var san []string
+	if tpl.cfg.SAN != nil {
+		var err error
+		san, err = tpl.cfg.SAN.Execute(tplEnv)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to evaluate template string of client certificate SAN configuration "%s": %w`, tpl.cfg.SAN, err)
+		}
+	}
+	return &validator{subject: subject, san: san}, nil
+}
+
+func (v *validator) Validate(ctx context.Context, certs []*x509.Certificate) error {
+	if len(certs) == 0 {
+		return fmt.Errorf("no verified client certificate presented")
+	}
+	leaf := certs[0]
+
+	actualSubject := SubjectAttributes(leaf)
+	for name, expectations := range v.subject {
+		if !containsAny(actualSubject[name], expectations) {
+			return fmt.Errorf(`required client certificate subject "%s" to be %v by setting but presented certificate has %v`, name, expectations, actualSubject[name])
+		}
+	}
+	if v.san != nil {
+		actualSAN := SANs(leaf)
+		if !containsAny(actualSAN, v.san) {
+			return fmt.Errorf(`required client certificate SAN to be one of %v by setting but presented certificate has %v`, v.san, actualSAN)
+		}
+	}
+	return nil
+}
+
+func containsAny(actual []string, expectations []string) bool {
+	for _, value := range actual {
+		for _, expected := range expectations {
+			if value == expected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SubjectAttributes returns map of subject attributes of the certificate (e.g. "CN", "O").
+func SubjectAttributes(cert *x509.Certificate) map[string][]string {
+	s := cert.Subject
+	result := map[string][]string{
+		"O":          s.Organization,
+		"OU":         s.OrganizationalUnit,
+		"C":          s.Country,
+		"L":          s.Locality,
+		"ST":         s.Province,
+		"STREET":     s.StreetAddress,
+		"POSTALCODE": s.PostalCode,
+	}
+	if s.CommonName != "" {
+		result["CN"] = []string{s.CommonName}
+	}
+	if s.SerialNumber != "" {
+		result["SERIALNUMBER"] = []string{s.SerialNumber}
+	}
+	return result
+}
+
+// SANs returns list of Subject Alternative Names of the certificate.
+func SANs(cert *x509.Certificate) []string {
+	result := make([]string, 0, len(cert.DNSNames)+len(cert.EmailAddresses)+len(cert.IPAddresses)+len(cert.URIs))
+	result = append(result, cert.DNSNames...)
+	result = append(result, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		result = append(result, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		result = append(result, uri.String())
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package clientcert_test
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/saiya/dsps/server/clientcert"
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+)
+
+func loadCert(t *testing.T, filename string) *x509.Certificate {
+	raw, err := ioutil.ReadFile("testdata/" + filename)
+	assert.NoError(t, err)
+	block, _ := pem.Decode(raw)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	assert.NoError(t, err)
+	return cert
+}
+
+func templateStrings(t *testing.T, values ...string) domain.TemplateStrings {
+	tpls := make([]domain.TemplateString, 0, len(values))
+	for _, value := range values {
+		tpl, err := domain.NewTemplateString(value)
+		assert.NoError(t, err)
+		tpls = append(tpls, tpl)
+	}
+	return domain.NewTemplateStrings(tpls...)
+}
+
+func newValidator(t *testing.T, cfg *config.ClientCertAuthConfig) Validator {
+	tpl, err := NewTemplate(context.Background(), cfg)
+	assert.NoError(t, err)
+	v, err := tpl.NewValidator(map[string]interface{}{"channel": map[string]string{"id": "client1"}})
+	assert.NoError(t, err)
+	return v
+}
+
+func TestSubjectValidation(t *testing.T) {
+	ctx := context.Background()
+	client1 := []*x509.Certificate{loadCert(t, "client1.pem"), loadCert(t, "ca.pem")}
+	client2 := []*x509.Certificate{loadCert(t, "client2.pem"), loadCert(t, "ca.pem")}
+
+	v := newValidator(t, &config.ClientCertAuthConfig{
+		Subject: map[string]domain.TemplateStrings{
+			"O":  templateStrings(t, "Example Inc."),
+			"CN": templateStrings(t, "{{.channel.id}}.example.com", "admin.example.com"),
+		},
+	})
+	assert.NoError(t, v.Validate(ctx, client1))
+	err := v.Validate(ctx, client2)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `required client certificate subject "CN" to be [client1.example.com admin.example.com] by setting but presented certificate has [client2.example.com]`)
+
+	err = v.Validate(ctx, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no verified client certificate presented")
+}
+
+func TestSANValidation(t *testing.T) {
+	ctx := context.Background()
+	client1 := []*x509.Certificate{loadCert(t, "client1.pem")}
+	client2 := []*x509.Certificate{loadCert(t, "client2.pem")}
+
+	san := templateStrings(t, "spiffe://example.com/{{.channel.id}}")
+	v := newValidator(t, &config.ClientCertAuthConfig{
+		Subject: map[string]domain.TemplateStrings{},
+		SAN:     &san,
+	})
+	assert.NoError(t, v.Validate(ctx, client1))
+	err := v.Validate(ctx, client2)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `required client certificate SAN to be one of [spiffe://example.com/client1] by setting but presented certificate has [client2.example.com]`)
+}
+
+func TestCertificateAttributes(t *testing.T) {
+	cert := loadCert(t, "client1.pem")
+	subject := SubjectAttributes(cert)
+	assert.Equal(t, []string{"client1.example.com"}, subject["CN"])
+	assert.Equal(t, []string{"Example Inc."}, subject["O"])
+	assert.Equal(t, []string{"backend"}, subject["OU"])
+	assert.Nil(t, subject["SERIALNUMBER"])
+	assert.Equal(t, []string{"client1.example.com", "spiffe://example.com/client1"}, SANs(cert))
+}
@@ -14,13 +14,17 @@ import (
 
 // AdminConfig represents administration feature settings
 type AdminConfig struct {
-	Auth AdminAuthConfig `json:"auth"`
+	// Listen string (e.g. "127.0.0.1:3001") to serve admin and probe endpoints separately, empty to serve them on the HTTP server
+	Listen    string           `json:"listen"`
+	Auth      AdminAuthConfig  `json:"auth"`
+	JwtIssuer *JwtIssuerConfig `json:"jwtIssuer"`
 }
 
 // AdminAuthConfig represents auth settings of admin endpoints
 type AdminAuthConfig struct {
-	Networks     []domain.CIDR `json:"networks"`
-	BearerTokens []string      `json:"bearer"`
+	Networks     []domain.CIDR          `json:"networks"`
+	BearerTokens []string               `json:"bearer"`
+	ClientCerts  []ClientCertAuthConfig `json:"clientCerts"`
 }
 
 func adminConfigDefault() *AdminConfig {
@@ -38,6 +42,19 @@ func PostprocessAdminConfig(config *AdminConfig) error {
 	if len(config.Auth.BearerTokens) == 0 {
 		config.Auth.BearerTokens = []string{generateAdminAuthRandomToken()}
 	}
+	for i := range config.Auth.ClientCerts {
+		if err := postprocessClientCertAuthConfig(&config.Auth.ClientCerts[i]); err != nil {
+			return fmt.Errorf("error on auth.clientCerts[%d]: %w", i, err)
+		}
+		if err := validateStaticClientCertAuthConfig(&config.Auth.ClientCerts[i]); err != nil {
+			return fmt.Errorf("error on auth.clientCerts[%d]: %w", i, err)
+		}
+	}
+	if config.JwtIssuer != nil {
+		if err := postprocessJwtIssuerConfig(config.JwtIssuer); err != nil {
+			return fmt.Errorf("error on jwtIssuer config: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -23,6 +23,7 @@ func TestAdminDefaultConfig(t *testing.T) {
 	assert.Equal(t, domain.PrivateCIDRs[0].String(), cfg.Auth.Networks[0].String())
 	assert.Equal(t, 1, len(cfg.Auth.BearerTokens))
 	assert.NotEmpty(t, cfg.Auth.BearerTokens[0])
+	assert.Equal(t, "", cfg.Listen)
 }
 
 func TestAdminNonDefaultConfig(t *testing.T) {
@@ -50,3 +51,12 @@ admin:
 	assert.Equal(t, "my-api-key1", cfg.Auth.BearerTokens[0])
 	assert.Equal(t, "my-api-key2", cfg.Auth.BearerTokens[1])
 }
+
+func TestAdminListenConfig(t *testing.T) {
+	config, err := ParseConfig(context.Background(), Overrides{}, `admin: { listen: "127.0.0.1:3001" }`)
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:3001", config.Admin.Listen)
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `{ http: { listen: ":3000" }, admin: { listen: ":3000" } }`)
+	assert.EqualError(t, err, `Admin configration problem: listen must be different from listen of the HTTP server`)
+}
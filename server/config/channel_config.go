@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/saiya/dsps/server/domain"
 )
@@ -16,6 +18,8 @@ var channelConfigDefaults = ChannelConfig{
 	Expire: makeDurationPtr("30m"),
 }
 
+var contentTypePatternRegexp = regexp.MustCompile(`^(\*|[a-zA-Z0-9][a-zA-Z0-9!#$&^_.+-]*)/(\*|[a-zA-Z0-9][a-zA-Z0-9!#$&^_.+-]*)$`)
+
 // ChannelsConfig is list of configured channels
 type ChannelsConfig []ChannelConfig
 
@@ -24,8 +28,40 @@ type ChannelConfig struct {
 	Regex  *domain.Regex    `json:"regex"`
 	Expire *domain.Duration `json:"expire"`
 
-	Webhooks []OutgoingWebhookConfig `json:"webhooks"`
-	Jwt      *JwtValidationConfig    `json:"jwt"`
+	Webhooks      []OutgoingWebhookConfig   `json:"webhooks"`
+	Jwt           *JwtValidationConfig      `json:"jwt"`
+	Introspection *TokenIntrospectionConfig `json:"introspection"`
+	ClientCert    *ClientCertAuthConfig     `json:"clientCert"`
+
+	// Media types (e.g. "application/x-protobuf", "text/*") of messages that the channel accepts, nil means JSON only.
+	ContentTypes []string `json:"contentTypes"`
+	// JSON Schema file to validate JSON content of messages, empty if disabled.
+	SchemaFile string `json:"schemaFile"`
+
+	// Maximum count of messages retained in the channel, zero means unlimited.
+	MaxMessages int `json:"maxMessages"`
+	// Maximum total bytes of message contents retained in the channel, zero means unlimited.
+	MaxBytes int64 `json:"maxBytes"`
+	// Behavior when the channel exceeds MaxMessages or MaxBytes.
+	Overflow string `json:"overflow"`
+
+	// Maximum size of a message (request body of the publish API) in bytes, zero means the server-wide limit.
+	MaxMessageBytes int64 `json:"maxMessageBytes"`
+
+	RateLimits *ChannelRateLimitsConfig `json:"rateLimits"`
+}
+
+// Behaviors of the channel when exceeded retention limits
+const (
+	// Drops oldest messages to retain new message
+	ChannelOverflowDropOldest = "dropOldest"
+	// Rejects new message
+	ChannelOverflowReject = "reject"
+)
+
+var validChannelOverflows = map[string]interface{}{
+	ChannelOverflowDropOldest: struct{}{},
+	ChannelOverflowReject:     struct{}{},
 }
 
 // PostprocessChannelsConfig fixes/validates config
@@ -50,6 +86,29 @@ func postprocessChanelConfig(ch *ChannelConfig) error {
 		return err
 	}
 
+	if ch.MaxMessages < 0 {
+		return fmt.Errorf(`maxMessages must not be negative`)
+	}
+	if ch.MaxBytes < 0 {
+		return fmt.Errorf(`maxBytes must not be negative`)
+	}
+	if ch.MaxMessageBytes < 0 {
+		return fmt.Errorf(`maxMessageBytes must not be negative`)
+	}
+	if ch.Overflow == "" {
+		ch.Overflow = ChannelOverflowDropOldest
+	}
+	if _, ok := validChannelOverflows[ch.Overflow]; !ok {
+		return fmt.Errorf(`"%s" is not valid overflow, must be "%s" or "%s"`, ch.Overflow, ChannelOverflowDropOldest, ChannelOverflowReject)
+	}
+
+	for i, contentType := range ch.ContentTypes {
+		if !contentTypePatternRegexp.MatchString(contentType) {
+			return fmt.Errorf(`invalid contentTypes[%d] "%s", must be "type/subtype" form (wildcard "*" is allowed e.g. "text/*")`, i, contentType)
+		}
+		ch.ContentTypes[i] = strings.ToLower(contentType)
+	}
+
 	for i := range ch.Webhooks {
 		webhook := &ch.Webhooks[i]
 		if err := postprocessWebhookConfig(webhook); err != nil {
@@ -61,5 +120,23 @@ func postprocessChanelConfig(ch *ChannelConfig) error {
 			return fmt.Errorf("error on JWT config: %w", err)
 		}
 	}
+	if ch.Introspection != nil {
+		if ch.Jwt != nil {
+			return fmt.Errorf(`could not configure both of "jwt" and "introspection", choose one of them`)
+		}
+		if err := postprocessTokenIntrospectionConfig(ch.Introspection); err != nil {
+			return fmt.Errorf("error on token introspection config: %w", err)
+		}
+	}
+	if ch.ClientCert != nil {
+		if err := postprocessClientCertAuthConfig(ch.ClientCert); err != nil {
+			return fmt.Errorf("error on client certificate config: %w", err)
+		}
+	}
+	if ch.RateLimits != nil {
+		if err := postprocessChannelRateLimitsConfig(ch.RateLimits); err != nil {
+			return fmt.Errorf("error on rateLimits config: %w", err)
+		}
+	}
 	return nil
 }
@@ -29,6 +29,12 @@ channels:
 
 	assert.Equal(t, 0, len(cfg.Webhooks))
 	assert.Nil(t, cfg.Jwt)
+	assert.Nil(t, cfg.ContentTypes)
+	assert.Equal(t, "", cfg.SchemaFile)
+	assert.Equal(t, 0, cfg.MaxMessages)
+	assert.Equal(t, int64(0), cfg.MaxBytes)
+	assert.Equal(t, ChannelOverflowDropOldest, cfg.Overflow)
+	assert.Equal(t, int64(0), cfg.MaxMessageBytes)
 }
 
 func TestChannelNonDefaultConfig(t *testing.T) {
@@ -38,6 +44,12 @@ channels:
 	regex: 'chat-room-(?P<id>\d+)'
 	# Must be larger than final retry attempt time
 	expire: 15m
+	contentTypes: [ "application/json", "Application/X-Protobuf", "text/*", "*/*" ]
+	schemaFile: schemas/chat-message.json
+	maxMessages: 1000
+	maxBytes: 1048576
+	overflow: reject
+	maxMessageBytes: 4096
 `, "\t", "  ")
 	config, err := ParseConfig(context.Background(), Overrides{}, configYaml)
 	if err != nil {
@@ -48,4 +60,31 @@ channels:
 	cfg := config.Channels[0]
 	assert.Equal(t, "chat-room-(?P<id>\\d+)", cfg.Regex.String())
 	assert.Equal(t, MakeDurationPtr("15m"), cfg.Expire)
+	assert.Equal(t, []string{"application/json", "application/x-protobuf", "text/*", "*/*"}, cfg.ContentTypes)
+	assert.Equal(t, "schemas/chat-message.json", cfg.SchemaFile)
+	assert.Equal(t, 1000, cfg.MaxMessages)
+	assert.Equal(t, int64(1048576), cfg.MaxBytes)
+	assert.Equal(t, ChannelOverflowReject, cfg.Overflow)
+	assert.Equal(t, int64(4096), cfg.MaxMessageBytes)
+}
+
+func TestChannelContentTypesConfigError(t *testing.T) {
+	for _, contentType := range []string{"application", "application/json; charset=utf-8", "/json", "*"} {
+		_, err := ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: ".+", contentTypes: [ "`+contentType+`" ] } ]`)
+		assert.EqualError(t, err, `Channel configration problem: error on channels[0]: invalid contentTypes[0] "`+contentType+`", must be "type/subtype" form (wildcard "*" is allowed e.g. "text/*")`)
+	}
+}
+
+func TestChannelRetentionConfigError(t *testing.T) {
+	_, err := ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: ".+", maxMessages: -1 } ]`)
+	assert.EqualError(t, err, `Channel configration problem: error on channels[0]: maxMessages must not be negative`)
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: ".+", maxBytes: -1 } ]`)
+	assert.EqualError(t, err, `Channel configration problem: error on channels[0]: maxBytes must not be negative`)
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: ".+", maxMessageBytes: -1 } ]`)
+	assert.EqualError(t, err, `Channel configration problem: error on channels[0]: maxMessageBytes must not be negative`)
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: ".+", overflow: "dropNewest" } ]`)
+	assert.EqualError(t, err, `Channel configration problem: error on channels[0]: "dropNewest" is not valid overflow, must be "dropOldest" or "reject"`)
 }
@@ -39,6 +39,12 @@ type ServerConfig struct {
 	Sentry     *SentryConfig     `json:"sentry"`
 	Channels   ChannelsConfig    `json:"channels"`
 	Admin      *AdminConfig      `json:"admin"`
+
+	WebhookDelivery  *WebhookDeliveryConfig `json:"webhookDelivery"`
+	IncomingWebhooks IncomingWebhooksConfig `json:"incomingWebhooks"`
+
+	// Path of the configuration file, empty if the configuration is not loaded from a file (default or stdin).
+	Source string `json:"-"`
 }
 
 // BuildInfo represents compile time metadata.
@@ -64,7 +70,14 @@ func LoadConfigFile(ctx context.Context, configFile string, configOverrides Over
 	if err != nil {
 		return ServerConfig{}, err
 	}
-	return ParseConfig(ctx, configOverrides, string(yamlBytes))
+	config, err := ParseConfig(ctx, configOverrides, string(yamlBytes))
+	if err != nil {
+		return config, err
+	}
+	if configFile != "-" {
+		config.Source = configFile
+	}
+	return config, nil
 }
 
 // ParseConfig constructs post-processed configuration object.
@@ -77,6 +90,8 @@ func ParseConfig(ctx context.Context, overrides Overrides, yaml string) (ServerC
 		Sentry:     DefaultSentryConfig(),
 		HTTPServer: httpServerConfigDefault(),
 		Admin:      adminConfigDefault(),
+
+		WebhookDelivery: webhookDeliveryConfigDefault(),
 	}
 
 	if strings.Contains(yaml, "\t") {
@@ -110,6 +125,18 @@ func ParseConfig(ctx context.Context, overrides Overrides, yaml string) (ServerC
 	if err := PostprocessAdminConfig(config.Admin); err != nil {
 		return config, fmt.Errorf("Admin configration problem: %w", err)
 	}
+	if config.Admin.Listen != "" && config.Admin.Listen == config.HTTPServer.Listen {
+		return config, fmt.Errorf("Admin configration problem: listen must be different from listen of the HTTP server")
+	}
+	if err := PostprocessWebhookDeliveryConfig(config.WebhookDelivery); err != nil {
+		return config, fmt.Errorf("Webhook delivery configration problem: %w", err)
+	}
+	if err := PostprocessIncomingWebhooksConfig(&config.IncomingWebhooks); err != nil {
+		return config, fmt.Errorf("Incoming webhook configration problem: %w", err)
+	}
+	if clientCertAuthConfigured(&config) && (config.HTTPServer.TLS == nil || config.HTTPServer.TLS.ClientAuth == nil) {
+		return config, fmt.Errorf("Client certificate authentication requires http.tls.clientAuth configuration")
+	}
 
 	return config, nil
 }
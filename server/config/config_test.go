@@ -32,12 +32,14 @@ logging:
 	cfg, err := LoadConfigFile(context.Background(), "", Overrides{})
 	assert.NoError(t, err)
 	assert.Equal(t, "", cfg.Logging.Category["*"])
+	assert.Equal(t, "", cfg.Source)
 
 	// Read from file
 	WithTextFile(t, configYaml, func(filename string) {
 		cfg, err := LoadConfigFile(context.Background(), filename, Overrides{})
 		assert.NoError(t, err)
 		assert.Equal(t, "DEBUG", cfg.Logging.Category["*"])
+		assert.Equal(t, filename, cfg.Source)
 	})
 
 	// Read from stdin
@@ -51,6 +53,7 @@ logging:
 		cfg, err := LoadConfigFile(context.Background(), "-", Overrides{})
 		assert.NoError(t, err)
 		assert.Equal(t, "DEBUG", cfg.Logging.Category["*"])
+		assert.Equal(t, "", cfg.Source)
 	})
 
 	// Invalid config
@@ -16,6 +16,11 @@ type HTTPServerConfig struct {
 	TrustedProxyRanges          []domain.CIDR     `json:"trustedProxyRanges"`
 	DiscloseAuthRejectionDetail bool              `json:"discloseAuthRejectionDetail"`
 	DefaultHeaders              map[string]string `json:"defaultHeaders"`
+	MaxBodyBytes                int64             `json:"maxBodyBytes"`
+
+	TLS *HTTPServerTLSConfig `json:"tls"`
+	// Serve HTTP/2 without TLS (h2c), only for plain HTTP server
+	H2C bool `json:"h2c"`
 
 	IdleTimeout             domain.Duration `json:"idleTimeout"`
 	ReadTimeout             domain.Duration `json:"readTimeout"`
@@ -26,7 +31,8 @@ type HTTPServerConfig struct {
 
 func httpServerConfigDefault() *HTTPServerConfig {
 	return &HTTPServerConfig{
-		Port: 3000,
+		Port:         3000,
+		MaxBodyBytes: 1 << 20,
 
 		IdleTimeout:             makeDuration("1h30m"),
 		ReadTimeout:             makeDuration("10s"),
@@ -63,6 +69,12 @@ func PostprocessHTTPServerConfig(config *HTTPServerConfig, overrides Overrides)
 		config.TrustedProxyRanges = make([]domain.CIDR, len(domain.PrivateCIDRs))
 		copy(config.TrustedProxyRanges, domain.PrivateCIDRs)
 	}
+	if config.MaxBodyBytes < 0 {
+		return fmt.Errorf("maxBodyBytes must not be negative")
+	}
+	if config.MaxBodyBytes == 0 {
+		config.MaxBodyBytes = httpServerConfigDefault().MaxBodyBytes
+	}
 	if config.IdleTimeout.Duration == 0 {
 		config.IdleTimeout = httpServerConfigDefault().IdleTimeout
 	}
@@ -90,5 +102,14 @@ func PostprocessHTTPServerConfig(config *HTTPServerConfig, overrides Overrides)
 
 	// Remove "/" prefix and suffix
 	config.PathPrefix = strings.TrimPrefix(strings.TrimSuffix(config.PathPrefix, "/"), "/")
+
+	if config.TLS != nil {
+		if err := postprocessHTTPServerTLSConfig(config.TLS); err != nil {
+			return fmt.Errorf("error on tls config: %w", err)
+		}
+		if config.H2C {
+			return fmt.Errorf("h2c cannot be used with tls, HTTPS server negotiates HTTP/2 without h2c")
+		}
+	}
 	return nil
 }
@@ -25,6 +25,7 @@ func TestHttpServerDefaultValues(t *testing.T) {
 	assert.Equal(t, len(domain.PrivateCIDRs), len(cfg.TrustedProxyRanges))
 	assert.Equal(t, domain.PrivateCIDRs[0].String(), cfg.TrustedProxyRanges[0].String())
 	assert.Equal(t, `deny`, cfg.DefaultHeaders["X-Frame-Options"])
+	assert.Equal(t, int64(1048576), cfg.MaxBodyBytes)
 	assert.Equal(t, 90*time.Minute, cfg.IdleTimeout.Duration)
 	assert.Equal(t, 10*time.Second, cfg.ReadTimeout.Duration)
 	assert.Equal(t, 60*time.Second, cfg.WriteTimeout.Duration)
@@ -40,6 +41,7 @@ http:
 		- 1.2.3.4/16
 	defaultHeaders:
 		X-Frame-Options:
+	maxBodyBytes: 2048
 	idleTimeout: 123s
 	readTimeout: 1s
 	writeTimeout: 2s
@@ -57,6 +59,7 @@ http:
 	assert.Equal(t, 1, len(cfg.TrustedProxyRanges))
 	assert.Equal(t, "1.2.3.4/16", cfg.TrustedProxyRanges[0].String())
 	assert.Equal(t, ``, cfg.DefaultHeaders["X-Frame-Options"])
+	assert.Equal(t, int64(2048), cfg.MaxBodyBytes)
 	assert.Equal(t, 123*time.Second, cfg.IdleTimeout.Duration)
 	assert.Equal(t, 1*time.Second, cfg.ReadTimeout.Duration)
 	assert.Equal(t, 2*time.Second, cfg.WriteTimeout.Duration)
@@ -64,6 +67,11 @@ http:
 	assert.Equal(t, 4*time.Second, cfg.GracefulShutdownTimeout.Duration)
 }
 
+func TestHttpServerConfigError(t *testing.T) {
+	_, err := ParseConfig(context.Background(), Overrides{}, `http: { maxBodyBytes: -1 }`)
+	assert.EqualError(t, err, `HTTP server configration problem: maxBodyBytes must not be negative`)
+}
+
 func TestHttpServerConfigOverride(t *testing.T) {
 	cfg := HTTPServerConfig{}
 	assert.NoError(t, PostprocessHTTPServerConfig(&cfg, Overrides{Port: 9876}))
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+// IncomingWebhooksConfig is definition of "incomingWebhooks" configuration (receivers to publish messages from third-party services)
+type IncomingWebhooksConfig []IncomingWebhookConfig
+
+// IncomingWebhookConfig is definition of an incoming-webhook receiver
+type IncomingWebhookConfig struct {
+	// Receives requests at /incoming/{name}
+	Name string `json:"name"`
+	// Template of the channel ID to publish
+	Channel   *domain.TemplateString          `json:"channel"`
+	MessageID IncomingWebhookMessageIDConfig  `json:"messageID"`
+	Signature *IncomingWebhookSignatureConfig `json:"signature"`
+	// Accept requests without signature, anyone who knows the URL can publish messages bypassing channel authentication.
+	InsecureNoSignature bool `json:"insecureNoSignature"`
+}
+
+// IncomingWebhookMessageIDConfig is definition of how to extract message ID from the request, configure one of them.
+type IncomingWebhookMessageIDConfig struct {
+	Header   string `json:"header"`
+	JSONPath string `json:"jsonPath"` // Dot separated path of the request body, e.g. "data.object.id"
+}
+
+// IncomingWebhookSignatureConfig is definition of request signature verification
+type IncomingWebhookSignatureConfig struct {
+	Scheme string `json:"scheme"`
+	// Accepts signature made by any of them, list multiple secrets while rotating them.
+	Secrets   []string         `json:"secrets"`
+	Tolerance *domain.Duration `json:"tolerance"`
+}
+
+// Signature schemes of incoming-webhook
+const (
+	IncomingWebhookSignatureDSPS   = "dsps"
+	IncomingWebhookSignatureGitHub = "github"
+	IncomingWebhookSignatureStripe = "stripe"
+)
+
+var incomingWebhookSignatureConfigDefaults = IncomingWebhookSignatureConfig{
+	Scheme:    IncomingWebhookSignatureDSPS,
+	Tolerance: makeDurationPtr("5m"),
+}
+
+var incomingWebhookNameRegexp = regexp.MustCompile("^[0-9a-zA-Z_-]+$")
+
+// PostprocessIncomingWebhooksConfig fixes/validates config
+func PostprocessIncomingWebhooksConfig(list *IncomingWebhooksConfig) error {
+	names := make(map[string]bool, len(*list))
+	for i := range *list {
+		hook := &(*list)[i]
+		if err := postprocessIncomingWebhookConfig(hook); err != nil {
+			return fmt.Errorf("error on incomingWebhooks[%d]: %w", i, err)
+		}
+		if names[hook.Name] {
+			return fmt.Errorf(`error on incomingWebhooks[%d]: duplicated name "%s"`, i, hook.Name)
+		}
+		names[hook.Name] = true
+	}
+	return nil
+}
+
+func postprocessIncomingWebhookConfig(hook *IncomingWebhookConfig) error {
+	if !incomingWebhookNameRegexp.MatchString(hook.Name) {
+		return fmt.Errorf(`name must match with %s`, incomingWebhookNameRegexp)
+	}
+	if hook.Channel == nil || hook.Channel.String() == "" {
+		return fmt.Errorf("channel must not be empty")
+	}
+	if (hook.MessageID.Header == "") == (hook.MessageID.JSONPath == "") {
+		return fmt.Errorf("configure one of messageID.header or messageID.jsonPath")
+	}
+	if hook.Signature != nil {
+		if hook.InsecureNoSignature {
+			return fmt.Errorf("insecureNoSignature cannot be used with signature")
+		}
+		if err := postprocessIncomingWebhookSignatureConfig(hook.Signature); err != nil {
+			return err
+		}
+	} else if !hook.InsecureNoSignature {
+		return fmt.Errorf("signature is required, set insecureNoSignature: true to accept unsigned requests")
+	}
+	return nil
+}
+
+func postprocessIncomingWebhookSignatureConfig(sig *IncomingWebhookSignatureConfig) error {
+	if sig.Scheme == "" {
+		sig.Scheme = incomingWebhookSignatureConfigDefaults.Scheme
+	}
+	if sig.Tolerance == nil {
+		sig.Tolerance = incomingWebhookSignatureConfigDefaults.Tolerance
+	}
+
+	switch sig.Scheme {
+	case IncomingWebhookSignatureDSPS, IncomingWebhookSignatureGitHub, IncomingWebhookSignatureStripe:
+	default:
+		return fmt.Errorf(`signature.scheme must be one of "%s", "%s" or "%s"`, IncomingWebhookSignatureDSPS, IncomingWebhookSignatureGitHub, IncomingWebhookSignatureStripe)
+	}
+	if len(sig.Secrets) == 0 {
+		return fmt.Errorf("signature.secrets must not be empty")
+	}
+	for i, secret := range sig.Secrets {
+		if secret == "" {
+			return fmt.Errorf("signature.secrets[%d] must not be empty", i)
+		}
+	}
+	if err := durationMustBeLargerThanZero("signature.tolerance", *sig.Tolerance); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package config_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/saiya/dsps/server/config"
+	. "github.com/saiya/dsps/server/testing"
+)
+
+func TestIncomingWebhookDefaults(t *testing.T) {
+	config, err := ParseConfig(context.Background(), Overrides{}, ``)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(config.IncomingWebhooks))
+}
+
+func TestIncomingWebhookConfig(t *testing.T) {
+	configYaml := strings.ReplaceAll(`
+incomingWebhooks:
+	- name: github
+		channel: 'github-{{ .body.repository.id }}'
+		messageID: { header: X-GitHub-Delivery }
+		signature: { scheme: github, secrets: [ secret-1, secret-2 ] }
+	- name: my-service
+		channel: my-channel
+		messageID: { jsonPath: data.id }
+		signature: { secrets: [ secret-1 ] }
+	- name: unsigned
+		channel: my-channel
+		messageID: { jsonPath: id }
+		insecureNoSignature: true
+`, "\t", "  ")
+	config, err := ParseConfig(context.Background(), Overrides{}, configYaml)
+	assert.NoError(t, err)
+
+	hooks := config.IncomingWebhooks
+	if !assert.Equal(t, 3, len(hooks)) {
+		return
+	}
+	assert.Equal(t, "github", hooks[0].Name)
+	assert.Equal(t, "github-{{ .body.repository.id }}", hooks[0].Channel.String())
+	assert.Equal(t, "X-GitHub-Delivery", hooks[0].MessageID.Header)
+	assert.Equal(t, "", hooks[0].MessageID.JSONPath)
+	assert.Equal(t, IncomingWebhookSignatureGitHub, hooks[0].Signature.Scheme)
+	assert.Equal(t, []string{"secret-1", "secret-2"}, hooks[0].Signature.Secrets)
+
+	assert.Equal(t, "data.id", hooks[1].MessageID.JSONPath)
+	assert.Equal(t, IncomingWebhookSignatureDSPS, hooks[1].Signature.Scheme)
+	assert.Equal(t, MakeDurationPtr("5m"), hooks[1].Signature.Tolerance)
+
+	assert.Nil(t, hooks[2].Signature)
+	assert.True(t, hooks[2].InsecureNoSignature)
+}
+
+func TestIncomingWebhookConfigValidation(t *testing.T) {
+	for _, tc := range []struct {
+		yaml string
+		err  string
+	}{
+		{`incomingWebhooks: [ { name: a, channel: ch, messageID: { jsonPath: id } } ]`, `signature is required, set insecureNoSignature: true to accept unsigned requests`},
+		{`incomingWebhooks: [ { name: a, channel: ch, messageID: { jsonPath: id }, insecureNoSignature: true, signature: { secrets: [ s ] } } ]`, `insecureNoSignature cannot be used with signature`},
+		{`incomingWebhooks: [ { channel: ch, messageID: { jsonPath: id } } ]`, `name must match with`},
+		{`incomingWebhooks: [ { name: "a/b", channel: ch, messageID: { jsonPath: id } } ]`, `name must match with`},
+		{`incomingWebhooks: [ { name: a, messageID: { jsonPath: id } } ]`, `channel must not be empty`},
+		{`incomingWebhooks: [ { name: a, channel: ch } ]`, `configure one of messageID.header or messageID.jsonPath`},
+		{`incomingWebhooks: [ { name: a, channel: ch, messageID: { header: X-ID, jsonPath: id } } ]`, `configure one of messageID.header or messageID.jsonPath`},
+		{`incomingWebhooks: [ { name: a, channel: ch, messageID: { jsonPath: id }, signature: { scheme: foo, secrets: [ s ] } } ]`, `signature.scheme must be one of "dsps", "github" or "stripe"`},
+		{`incomingWebhooks: [ { name: a, channel: ch, messageID: { jsonPath: id }, signature: { secrets: [] } } ]`, `signature.secrets must not be empty`},
+		{`incomingWebhooks: [ { name: a, channel: ch, messageID: { jsonPath: id }, signature: { secrets: [ "" ] } } ]`, `signature.secrets\[0\] must not be empty`},
+		{`incomingWebhooks: [ { name: a, channel: ch, messageID: { jsonPath: id }, signature: { secrets: [ s ], tolerance: 0s } } ]`, `signature.tolerance must not be negative nor zero`},
+		{`incomingWebhooks: [ { name: a, channel: ch, messageID: { jsonPath: id }, insecureNoSignature: true }, { name: a, channel: ch, messageID: { jsonPath: id }, insecureNoSignature: true } ]`, `error on incomingWebhooks\[1\]: duplicated name "a"`},
+	} {
+		_, err := ParseConfig(context.Background(), Overrides{}, tc.yaml)
+		assert.Regexp(t, "Incoming webhook configration problem: .*"+tc.err, err)
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/saiya/dsps/server/domain"
+	jwtpkg "github.com/saiya/dsps/server/jwt"
+)
+
+// JwtIssuerConfig is configuration to issue (sign) JWT by admin API
+type JwtIssuerConfig struct {
+	Iss     domain.JwtIss   `json:"iss"`
+	Aud     []domain.JwtAud `json:"aud"`
+	Alg     domain.JwtAlg   `json:"alg"`
+	KeyFile string          `json:"keyFile"`
+
+	Expire    *domain.Duration `json:"expire"`
+	MaxExpire *domain.Duration `json:"maxExpire"`
+}
+
+var jwtIssuerConfigDefaults = JwtIssuerConfig{
+	Expire:    makeDurationPtr("1h"),
+	MaxExpire: makeDurationPtr("24h"),
+}
+
+func postprocessJwtIssuerConfig(issuer *JwtIssuerConfig) error {
+	if issuer.Expire == nil {
+		issuer.Expire = jwtIssuerConfigDefaults.Expire
+	}
+	if issuer.MaxExpire == nil {
+		issuer.MaxExpire = jwtIssuerConfigDefaults.MaxExpire
+	}
+
+	if issuer.Iss == "" {
+		return fmt.Errorf(`must supply "iss" (issuer claim) value`)
+	}
+	if err := durationMustBeLargerThanZero("expire", *issuer.Expire); err != nil {
+		return err
+	}
+	if err := durationMustBeLargerThanZero("maxExpire", *issuer.MaxExpire); err != nil {
+		return err
+	}
+	if issuer.MaxExpire.Duration < issuer.Expire.Duration {
+		return fmt.Errorf("maxExpire must be equal to or larger than expire")
+	}
+
+	if err := jwtpkg.ValidateAlg(issuer.Alg); err != nil {
+		return fmt.Errorf(`invalid signing algorithm name given "%s": %w`, issuer.Alg, err)
+	}
+	if issuer.Alg.IsNone() {
+		// Unsigned JWTs would be trusted by any channel that accepts alg=none.
+		return fmt.Errorf(`cannot issue unsigned JWT (alg=none), use signing algorithm such as "RS256"`)
+	}
+	if issuer.KeyFile == "" {
+		return fmt.Errorf("must supply key file to sign JWT for alg=%s", issuer.Alg)
+	}
+	if err := jwtpkg.ValidateSigningKey(issuer.Alg, issuer.KeyFile); err != nil {
+		return fmt.Errorf("failed to load keyFile: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package config_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/saiya/dsps/server/config"
+	. "github.com/saiya/dsps/server/testing"
+)
+
+func TestJwtIssuerNotConfigured(t *testing.T) {
+	config, err := ParseConfig(context.Background(), Overrides{}, ``)
+	assert.NoError(t, err)
+	assert.Nil(t, config.Admin.JwtIssuer)
+}
+
+func TestJwtIssuerDefaultConfig(t *testing.T) {
+	configYaml := strings.ReplaceAll(`
+admin:
+	jwtIssuer:
+		iss: https://issuer.example.com/issuer-url
+		alg: RS256
+		keyFile: "../jwt/testdata/RS256-2048bit-private.pem"
+`, "\t", "  ")
+	config, err := ParseConfig(context.Background(), Overrides{}, configYaml)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cfg := config.Admin.JwtIssuer
+	assert.Equal(t, "https://issuer.example.com/issuer-url", string(cfg.Iss))
+	assert.Equal(t, 0, len(cfg.Aud))
+	assert.Equal(t, "RS256", string(cfg.Alg))
+	assert.Equal(t, MakeDurationPtr("1h"), cfg.Expire)
+	assert.Equal(t, MakeDurationPtr("24h"), cfg.MaxExpire)
+}
+
+func TestJwtIssuerConfigErrors(t *testing.T) {
+	for _, testcase := range []struct {
+		yaml   string
+		errMsg string
+	}{
+		{`{ alg: none }`, `must supply "iss" (issuer claim) value`},
+		{`{ iss: "https://example.com", alg: XX256 }`, `invalid signing algorithm name given "XX256"`},
+		{`{ iss: "https://example.com", alg: RS256 }`, `must supply key file to sign JWT for alg=RS256`},
+		{`{ iss: "https://example.com", alg: RS256, keyFile: "../jwt/testdata/RS256-2048bit-public.pem" }`, `failed to load keyFile`},
+		{`{ iss: "https://example.com", alg: none }`, `cannot issue unsigned JWT (alg=none)`},
+		{`{ iss: "https://example.com", alg: none, keyFile: "../jwt/testdata/HS256.rand" }`, `cannot issue unsigned JWT (alg=none)`},
+		{`{ iss: "https://example.com", alg: HS256, keyFile: "../jwt/testdata/HS256.rand", expire: 0s }`, `expire must not be negative nor zero`},
+		{`{ iss: "https://example.com", alg: HS256, keyFile: "../jwt/testdata/HS256.rand", maxExpire: -1s }`, `maxExpire must not be negative nor zero`},
+		{`{ iss: "https://example.com", alg: HS256, keyFile: "../jwt/testdata/HS256.rand", expire: 2h, maxExpire: 1h }`, `maxExpire must be equal to or larger than expire`},
+	} {
+		_, err := ParseConfig(context.Background(), Overrides{}, `admin: { jwtIssuer: `+testcase.yaml+` }`)
+		if assert.Error(t, err, testcase.yaml) {
+			assert.Contains(t, err.Error(), testcase.errMsg)
+		}
+	}
+}
@@ -2,8 +2,10 @@ package config
 
 // OnmemoryStorageConfig is definition of "storage.onmemory" configuration
 type OnmemoryStorageConfig struct {
-	DisablePubSub bool `json:"__disablePubSub"`
-	DisableJwt    bool `json:"__disableJwt"`
+	DisablePubSub    bool `json:"__disablePubSub"`
+	DisableJwt       bool `json:"__disableJwt"`
+	DisableWebhook   bool `json:"__disableWebhook"`
+	DisableRateLimit bool `json:"__disableRateLimit"`
 
 	RunGCOnShutdown bool `json:"__runGcOnShutdown"`
 }
@@ -10,11 +10,21 @@ import (
 // OutgoingWebhookConfig is webhook configuration of a channel
 type OutgoingWebhookConfig struct {
 	Method     string                           `json:"method"`
+	Format     string                           `json:"format"`
 	URL        *domain.TemplateString           `json:"url"`
 	Timeout    *domain.Duration                 `json:"timeout"`
 	Connection OutgoingWebhookConnectionConfig  `json:"connection"`
 	Retry      OutgoingWebhookRetryConfig       `json:"retry"`
 	Headers    map[string]domain.TemplateString `json:"headers"`
+	Signing    *OutgoingWebhookSigningConfig    `json:"signing"`
+	Batch      *OutgoingWebhookBatchConfig      `json:"batch"`
+
+	CircuitBreaker OutgoingWebhookCircuitBreakerConfig `json:"circuitBreaker"`
+
+	// Template evaluated for each message, sends the message only if the result is "true".
+	Filter *domain.TemplateString `json:"filter"`
+	// Template of the request body, overrides the body of the format.
+	Body *domain.TemplateString `json:"body"`
 
 	MaxRedirects *int `json:"maxRedirects"`
 }
@@ -24,6 +34,19 @@ var validWebhookMethods = map[string]interface{}{
 	"POST": struct{}{},
 }
 
+// Request body formats of outgoing-webhook
+const (
+	OutgoingWebhookFormatDSPS                  = "dsps"
+	OutgoingWebhookFormatCloudEventsStructured = "cloudevents-structured"
+	OutgoingWebhookFormatCloudEventsBinary     = "cloudevents-binary"
+)
+
+var validWebhookFormats = map[string]interface{}{
+	OutgoingWebhookFormatDSPS:                  struct{}{},
+	OutgoingWebhookFormatCloudEventsStructured: struct{}{},
+	OutgoingWebhookFormatCloudEventsBinary:     struct{}{},
+}
+
 // OutgoingWebhookConnectionConfig is HTTP/TCP connection config
 type OutgoingWebhookConnectionConfig struct {
 	Max         *int             `json:"max"`
@@ -38,8 +61,33 @@ type OutgoingWebhookRetryConfig struct {
 	IntervalJitter     *domain.Duration `json:"intervalJitter"`
 }
 
+// OutgoingWebhookCircuitBreakerConfig is config to stop calling failing webhook temporarily
+type OutgoingWebhookCircuitBreakerConfig struct {
+	Disable          bool             `json:"disable"`
+	FailureThreshold *int             `json:"failureThreshold"`
+	OpenDuration     *domain.Duration `json:"openDuration"`
+}
+
+// OutgoingWebhookSigningConfig is request signature config
+type OutgoingWebhookSigningConfig struct {
+	// Signs with each secret, list multiple secrets while rotating them.
+	Secrets []string `json:"secrets"`
+}
+
+// OutgoingWebhookBatchConfig is config to aggregate messages into single request
+type OutgoingWebhookBatchConfig struct {
+	MaxMessages *int             `json:"maxMessages"`
+	Window      *domain.Duration `json:"window"`
+}
+
+var outgoingWebhookBatchConfigDefaults = OutgoingWebhookBatchConfig{
+	MaxMessages: makeIntPtr(100),
+	Window:      makeDurationPtr("1s"),
+}
+
 var outgoingWebhookConfigDefaults = OutgoingWebhookConfig{
 	Method:  "PUT",
+	Format:  OutgoingWebhookFormatDSPS,
 	Timeout: makeDurationPtr("30s"),
 	Connection: OutgoingWebhookConnectionConfig{
 		Max:         makeIntPtr(1024),
@@ -51,6 +99,10 @@ var outgoingWebhookConfigDefaults = OutgoingWebhookConfig{
 		IntervalMultiplier: makeFloat64Ptr(1.5),
 		IntervalJitter:     makeDurationPtr("1s500ms"),
 	},
+	CircuitBreaker: OutgoingWebhookCircuitBreakerConfig{
+		FailureThreshold: makeIntPtr(5),
+		OpenDuration:     makeDurationPtr("30s"),
+	},
 	MaxRedirects: makeIntPtr(10),
 }
 
@@ -59,6 +111,10 @@ func postprocessWebhookConfig(webhook *OutgoingWebhookConfig) error {
 		webhook.Method = outgoingWebhookConfigDefaults.Method
 	}
 	webhook.Method = strings.ToUpper(webhook.Method)
+	if webhook.Format == "" {
+		webhook.Format = outgoingWebhookConfigDefaults.Format
+	}
+	webhook.Format = strings.ToLower(webhook.Format)
 	if webhook.Timeout == nil {
 		webhook.Timeout = outgoingWebhookConfigDefaults.Timeout
 	}
@@ -75,10 +131,30 @@ func postprocessWebhookConfig(webhook *OutgoingWebhookConfig) error {
 	if err := postprocessWebhookConnectionConfig(webhook); err != nil {
 		return err
 	}
+	if err := postprocessWebhookCircuitBreakerConfig(webhook); err != nil {
+		return err
+	}
+	if err := postprocessWebhookSigningConfig(webhook); err != nil {
+		return err
+	}
+	if err := postprocessWebhookBatchConfig(webhook); err != nil {
+		return err
+	}
+	if webhook.Body != nil {
+		if webhook.Format != OutgoingWebhookFormatDSPS {
+			return fmt.Errorf(`body is not supported with "%s" format`, webhook.Format)
+		}
+		if webhook.Batch != nil {
+			return fmt.Errorf(`body is not supported with batch`)
+		}
+	}
 
 	if _, ok := validWebhookMethods[webhook.Method]; !ok {
 		return fmt.Errorf(`"%s" is not valid outgoing-webhook HTTP method`, webhook.Method)
 	}
+	if _, ok := validWebhookFormats[webhook.Format]; !ok {
+		return fmt.Errorf(`"%s" is not valid outgoing-webhook format`, webhook.Format)
+	}
 	if err := durationMustBeLargerThanZero("timeout", *webhook.Timeout); err != nil {
 		return err
 	}
@@ -134,3 +210,58 @@ func postprocessWebhookConnectionConfig(webhook *OutgoingWebhookConfig) error {
 
 	return nil
 }
+
+func postprocessWebhookCircuitBreakerConfig(webhook *OutgoingWebhookConfig) error {
+	if webhook.CircuitBreaker.FailureThreshold == nil {
+		webhook.CircuitBreaker.FailureThreshold = outgoingWebhookConfigDefaults.CircuitBreaker.FailureThreshold
+	}
+	if webhook.CircuitBreaker.OpenDuration == nil {
+		webhook.CircuitBreaker.OpenDuration = outgoingWebhookConfigDefaults.CircuitBreaker.OpenDuration
+	}
+
+	if err := intMustBeLargerThanZero("circuitBreaker.failureThreshold", *webhook.CircuitBreaker.FailureThreshold); err != nil {
+		return err
+	}
+	if err := durationMustBeLargerThanZero("circuitBreaker.openDuration", *webhook.CircuitBreaker.OpenDuration); err != nil {
+		return err
+	}
+	return nil
+}
+
+func postprocessWebhookSigningConfig(webhook *OutgoingWebhookConfig) error {
+	if webhook.Signing == nil {
+		return nil
+	}
+	if len(webhook.Signing.Secrets) == 0 {
+		return fmt.Errorf("signing.secrets must not be empty")
+	}
+	for i, secret := range webhook.Signing.Secrets {
+		if secret == "" {
+			return fmt.Errorf("signing.secrets[%d] must not be empty", i)
+		}
+	}
+	return nil
+}
+
+func postprocessWebhookBatchConfig(webhook *OutgoingWebhookConfig) error {
+	if webhook.Batch == nil {
+		return nil
+	}
+	if webhook.Batch.MaxMessages == nil {
+		webhook.Batch.MaxMessages = outgoingWebhookBatchConfigDefaults.MaxMessages
+	}
+	if webhook.Batch.Window == nil {
+		webhook.Batch.Window = outgoingWebhookBatchConfigDefaults.Window
+	}
+
+	if err := intMustBeLargerThanZero("batch.maxMessages", *webhook.Batch.MaxMessages); err != nil {
+		return err
+	}
+	if err := durationMustBeLargerThanZero("batch.window", *webhook.Batch.Window); err != nil {
+		return err
+	}
+	if webhook.Format == OutgoingWebhookFormatCloudEventsBinary {
+		return fmt.Errorf(`batch is not supported with "%s" format`, webhook.Format)
+	}
+	return nil
+}
@@ -31,6 +31,7 @@ channels:
 
 	webhook := cfg.Webhooks[0]
 	assert.Equal(t, "PUT", webhook.Method)
+	assert.Equal(t, "dsps", webhook.Format)
 	assert.Equal(t, "http://localhost:3001/you-got-message/room/{{.channel.id}}", webhook.URL.String())
 	assert.Equal(t, MakeDurationPtr("30s"), webhook.Timeout)
 	assert.Equal(t, MakeIntPtr(3), webhook.Retry.Count)
@@ -38,6 +39,11 @@ channels:
 	assert.Equal(t, 1.5, *webhook.Retry.IntervalMultiplier)
 	assert.Equal(t, MakeDurationPtr("1.5s"), webhook.Retry.IntervalJitter)
 	assert.Equal(t, 0, len(webhook.Headers))
+	assert.False(t, webhook.CircuitBreaker.Disable)
+	assert.Equal(t, MakeIntPtr(5), webhook.CircuitBreaker.FailureThreshold)
+	assert.Equal(t, MakeDurationPtr("30s"), webhook.CircuitBreaker.OpenDuration)
+	assert.Nil(t, webhook.Signing)
+	assert.Nil(t, webhook.Batch)
 	assert.Equal(t, 10, *cfg.Webhooks[0].MaxRedirects)
 }
 
@@ -49,6 +55,7 @@ channels:
 	webhooks:
 		-
 			method: post
+			format: CloudEvents-Structured
 			url: 'http://localhost:3001/you-got-message/room/{{.channel.id}}'
 			timeout: 61s
 			retry:
@@ -56,9 +63,20 @@ channels:
 				interval: 3.5s
 				intervalMultiplier: 3.1
 				intervalJitter: 2s500ms
+			circuitBreaker:
+				disable: true
+				failureThreshold: 10
+				openDuration: 1m
 			headers:
 				User-Agent: my DSPS server
 				X-Chat-Room-ID: '{{.channel.id}}'
+			signing:
+				secrets:
+					- new-secret
+					- old-secret
+			batch:
+				maxMessages: 50
+				window: 200ms
 			maxRedirects: 123
 `, "\t", "  ")
 	config, err := ParseConfig(context.Background(), Overrides{}, configYaml)
@@ -72,14 +90,21 @@ channels:
 
 	webhook := cfg.Webhooks[0]
 	assert.Equal(t, "POST", webhook.Method)
+	assert.Equal(t, OutgoingWebhookFormatCloudEventsStructured, webhook.Format)
 	assert.Equal(t, "http://localhost:3001/you-got-message/room/{{.channel.id}}", webhook.URL.String())
 	assert.Equal(t, MakeDurationPtr("61s"), webhook.Timeout)
 	assert.Equal(t, MakeIntPtr(4), webhook.Retry.Count)
 	assert.Equal(t, MakeDurationPtr("3.5s"), webhook.Retry.Interval)
 	assert.Equal(t, 3.1, *webhook.Retry.IntervalMultiplier)
 	assert.Equal(t, MakeDurationPtr("2.5s"), webhook.Retry.IntervalJitter)
+	assert.True(t, webhook.CircuitBreaker.Disable)
+	assert.Equal(t, MakeIntPtr(10), webhook.CircuitBreaker.FailureThreshold)
+	assert.Equal(t, MakeDurationPtr("1m"), webhook.CircuitBreaker.OpenDuration)
 	assert.Equal(t, "my DSPS server", webhook.Headers["User-Agent"].String())
 	assert.Equal(t, "{{.channel.id}}", webhook.Headers["X-Chat-Room-ID"].String())
+	assert.Equal(t, []string{"new-secret", "old-secret"}, webhook.Signing.Secrets)
+	assert.Equal(t, 50, *webhook.Batch.MaxMessages)
+	assert.Equal(t, MakeDurationPtr("200ms"), webhook.Batch.Window)
 	assert.Equal(t, 123, *cfg.Webhooks[0].MaxRedirects)
 }
 
@@ -87,6 +112,9 @@ func TestInvalidWebhookConfig(t *testing.T) {
 	_, err := ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: '.+', webhooks: [ { method: "INVALID", url: "http://localhost:3000" } ] } ]`)
 	assert.Regexp(t, `error on webhooks\[0\]: "INVALID" is not valid outgoing-webhook HTTP method`, err.Error())
 
+	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: '.+', webhooks: [ { format: "INVALID", url: "http://localhost:3000" } ] } ]`)
+	assert.Regexp(t, `error on webhooks\[0\]: "invalid" is not valid outgoing-webhook format`, err.Error())
+
 	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: '.+', webhooks: [ { url: "http://localhost:3000", retry: { count: 0 } } ] } ]`)
 	assert.Regexp(t, `error on webhooks\[0\]: retry.count must not be negative nor zero`, err.Error())
 
@@ -110,4 +138,50 @@ func TestInvalidWebhookConfig(t *testing.T) {
 
 	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: '.+', webhooks: [ { url: "http://localhost:3000", maxRedirects: -1 } ] } ]`)
 	assert.Regexp(t, `error on webhooks\[0\]: maxRedirects must not be negative`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: '.+', webhooks: [ { url: "http://localhost:3000", batch: { maxMessages: 0 } } ] } ]`)
+	assert.Regexp(t, `error on webhooks\[0\]: batch.maxMessages must not be negative nor zero`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: '.+', webhooks: [ { url: "http://localhost:3000", batch: { window: 0s } } ] } ]`)
+	assert.Regexp(t, `error on webhooks\[0\]: batch.window must not be negative nor zero`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: '.+', webhooks: [ { url: "http://localhost:3000", format: cloudevents-binary, batch: {} } ] } ]`)
+	assert.Regexp(t, `error on webhooks\[0\]: batch is not supported with "cloudevents-binary" format`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: '.+', webhooks: [ { url: "http://localhost:3000", circuitBreaker: { failureThreshold: 0 } } ] } ]`)
+	assert.Regexp(t, `error on webhooks\[0\]: circuitBreaker.failureThreshold must not be negative nor zero`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: '.+', webhooks: [ { url: "http://localhost:3000", circuitBreaker: { openDuration: 0s } } ] } ]`)
+	assert.Regexp(t, `error on webhooks\[0\]: circuitBreaker.openDuration must not be negative nor zero`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: '.+', webhooks: [ { url: "http://localhost:3000", signing: { secrets: [] } } ] } ]`)
+	assert.Regexp(t, `error on webhooks\[0\]: signing.secrets must not be empty`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: '.+', webhooks: [ { url: "http://localhost:3000", signing: { secrets: [ "secret", "" ] } } ] } ]`)
+	assert.Regexp(t, `error on webhooks\[0\]: signing.secrets\[1\] must not be empty`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: '.+', webhooks: [ { url: "http://localhost:3000", format: cloudevents-structured, body: "{}" } ] } ]`)
+	assert.Regexp(t, `error on webhooks\[0\]: body is not supported with "cloudevents-structured" format`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: '.+', webhooks: [ { url: "http://localhost:3000", batch: {}, body: "{}" } ] } ]`)
+	assert.Regexp(t, `error on webhooks\[0\]: body is not supported with batch`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: '.+', webhooks: [ { url: "http://localhost:3000", filter: "{{ .message" } ] } ]`)
+	assert.Regexp(t, `Unable to parse Template`, err.Error())
+}
+
+func TestWebhookFilterAndBodyConfig(t *testing.T) {
+	config, err := ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: '.+', webhooks: [ { url: "http://localhost:3000", filter: '{{ eq .message.content.severity "critical" }}', body: '{"text":{{json .message.content.text}}}' } ] } ]`)
+	assert.NoError(t, err)
+	webhook := config.Channels[0].Webhooks[0]
+	assert.Equal(t, `{{ eq .message.content.severity "critical" }}`, webhook.Filter.String())
+	assert.Equal(t, `{"text":{{json .message.content.text}}}`, webhook.Body.String())
+}
+
+func TestWebhookBatchDefaultConfig(t *testing.T) {
+	config, err := ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: '.+', webhooks: [ { url: "http://localhost:3000", batch: {} } ] } ]`)
+	assert.NoError(t, err)
+	batch := config.Channels[0].Webhooks[0].Batch
+	assert.Equal(t, 100, *batch.MaxMessages)
+	assert.Equal(t, MakeDurationPtr("1s"), batch.Window)
 }
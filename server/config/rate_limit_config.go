@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+// ChannelRateLimitsConfig is rate limit configuration of a channel, each item is nil if not limited.
+type ChannelRateLimitsConfig struct {
+	Channel *RateLimitConfig `json:"channel"` // Shared by all clients of the channel
+	IP      *RateLimitConfig `json:"ip"`      // For each client IP address
+	Sub     *RateLimitConfig `json:"sub"`     // For each "sub" claim of JWT
+}
+
+// RateLimitConfig is token bucket configuration
+type RateLimitConfig struct {
+	// Count of requests permitted in the `per` duration.
+	Rate int `json:"rate"`
+	// Unit time of the rate.
+	Per *domain.Duration `json:"per"`
+	// Max count of requests permitted in a moment, default is same as rate.
+	Burst int `json:"burst"`
+}
+
+var rateLimitConfigDefaults = RateLimitConfig{
+	Per: makeDurationPtr("1s"),
+}
+
+func postprocessChannelRateLimitsConfig(config *ChannelRateLimitsConfig) error {
+	for _, item := range []struct {
+		name   string
+		config *RateLimitConfig
+	}{
+		{"channel", config.Channel},
+		{"ip", config.IP},
+		{"sub", config.Sub},
+	} {
+		if item.config == nil {
+			continue
+		}
+		if err := postprocessRateLimitConfig(item.config); err != nil {
+			return fmt.Errorf("error on %s: %w", item.name, err)
+		}
+	}
+	return nil
+}
+
+func postprocessRateLimitConfig(config *RateLimitConfig) error {
+	if config.Rate <= 0 {
+		return fmt.Errorf("rate must be larger than zero")
+	}
+	if config.Per == nil {
+		config.Per = rateLimitConfigDefaults.Per
+	}
+	if err := durationMustBeLargerThanZero("per", *config.Per); err != nil {
+		return err
+	}
+	if config.Burst == 0 {
+		config.Burst = config.Rate
+	}
+	if config.Burst < 0 {
+		return fmt.Errorf("burst must not be negative")
+	}
+	return nil
+}
+
+// RateLimit returns domain object of the configuration
+func (config *RateLimitConfig) RateLimit() domain.RateLimit {
+	return domain.RateLimit{
+		Rate:  config.Rate,
+		Per:   *config.Per,
+		Burst: config.Burst,
+	}
+}
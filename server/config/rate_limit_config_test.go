@@ -0,0 +1,65 @@
+package config_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+)
+
+func TestRateLimitsDefaultConfig(t *testing.T) {
+	config, err := ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: ".+" } ]`)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Nil(t, config.Channels[0].RateLimits)
+
+	config, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: ".+", rateLimits: { ip: { rate: 10 } } } ]`)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	cfg := config.Channels[0].RateLimits
+	assert.Nil(t, cfg.Channel)
+	assert.Nil(t, cfg.Sub)
+	assert.Equal(t, domain.RateLimit{Rate: 10, Per: domain.Duration{Duration: time.Second}, Burst: 10}, cfg.IP.RateLimit())
+}
+
+func TestRateLimitsNonDefaultConfig(t *testing.T) {
+	configYaml := strings.ReplaceAll(`
+channels:
+-
+	regex: '.+'
+	rateLimits:
+		channel: { rate: 1000, per: 1m, burst: 100 }
+		ip: { rate: 10, per: 10s, burst: 5 }
+		sub: { rate: 3, per: 2s, burst: 1 }
+`, "\t", "  ")
+	config, err := ParseConfig(context.Background(), Overrides{}, configYaml)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	cfg := config.Channels[0].RateLimits
+	assert.Equal(t, domain.RateLimit{Rate: 1000, Per: domain.Duration{Duration: time.Minute}, Burst: 100}, cfg.Channel.RateLimit())
+	assert.Equal(t, domain.RateLimit{Rate: 10, Per: domain.Duration{Duration: 10 * time.Second}, Burst: 5}, cfg.IP.RateLimit())
+	assert.Equal(t, domain.RateLimit{Rate: 3, Per: domain.Duration{Duration: 2 * time.Second}, Burst: 1}, cfg.Sub.RateLimit())
+}
+
+func TestRateLimitsConfigError(t *testing.T) {
+	_, err := ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: ".+", rateLimits: { channel: {} } } ]`)
+	assert.EqualError(t, err, `Channel configration problem: error on channels[0]: error on rateLimits config: error on channel: rate must be larger than zero`)
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: ".+", rateLimits: { ip: { rate: 1, per: 0s } } } ]`)
+	assert.EqualError(t, err, `Channel configration problem: error on channels[0]: error on rateLimits config: error on ip: per must not be negative nor zero`)
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: ".+", rateLimits: { sub: { rate: 1, burst: -1 } } } ]`)
+	assert.EqualError(t, err, `Channel configration problem: error on channels[0]: error on rateLimits config: error on sub: burst must not be negative`)
+}
@@ -13,8 +13,10 @@ type RedisStorageConfig struct {
 	SingleNode *string   `json:"singleNode"`
 	Cluster    *[]string `json:"cluster"`
 
-	DisablePubSub bool `json:"disablePubSub"`
-	DisableJwt    bool `json:"disableJwt"`
+	DisablePubSub    bool `json:"disablePubSub"`
+	DisableJwt       bool `json:"disableJwt"`
+	DisableWebhook   bool `json:"disableWebhook"`
+	DisableRateLimit bool `json:"disableRateLimit"`
 
 	Username string `json:"username"`
 	Password string `json:"password"`
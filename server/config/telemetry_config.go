@@ -37,8 +37,10 @@ type OpenTelemetryTracingBatchConfig struct {
 
 // OpenTelemetryExportersConfig configure exporters
 type OpenTelemetryExportersConfig struct {
-	Stdout OpenTelemetryExporterStdoutConfig `json:"stdout"`
-	GCP    OpenTelemetryExporterGCPConfig    `json:"gcp"`
+	Stdout     OpenTelemetryExporterStdoutConfig     `json:"stdout"`
+	GCP        OpenTelemetryExporterGCPConfig        `json:"gcp"`
+	Prometheus OpenTelemetryExporterPrometheusConfig `json:"prometheus"`
+	OTLP       OpenTelemetryExporterOTLPConfig       `json:"otlp"`
 }
 
 // OpenTelemetryExporterStdoutConfig configure stdout exporter
@@ -53,6 +55,40 @@ type OpenTelemetryExporterGCPConfig struct {
 	ProjectID   string `json:"projectID"`
 }
 
+// OpenTelemetryExporterPrometheusConfig configure Prometheus metrics exporter
+type OpenTelemetryExporterPrometheusConfig struct {
+	Enable bool `json:"enable"`
+	// Serve metrics under admin API (/admin/metrics) rather than /metrics
+	Admin bool `json:"admin"`
+	// Upper bounds of histogram buckets in seconds
+	HistogramBoundaries []float64 `json:"histogramBoundaries"`
+}
+
+// OpenTelemetryExporterOTLPConfig configure OTLP (OpenTelemetry protocol) exporter
+type OpenTelemetryExporterOTLPConfig struct {
+	EnableTrace   bool `json:"enableTrace"`
+	EnableMetrics bool `json:"enableMetrics"`
+
+	Protocol string            `json:"protocol"`
+	Endpoint string            `json:"endpoint"` // host:port of the collector
+	Insecure bool              `json:"insecure"` // true to not use TLS
+	Headers  map[string]string `json:"headers"`
+	Timeout  *domain.Duration  `json:"timeout"`
+
+	MetricsInterval *domain.Duration `json:"metricsInterval"`
+	// Upper bounds of histogram buckets in seconds
+	HistogramBoundaries []float64 `json:"histogramBoundaries"`
+}
+
+// Protocols of OTLP exporter
+const (
+	OTLPProtocolGRPC = "grpc"
+	// OTLP/HTTP is not supported because OTLP exporter of the OpenTelemetry SDK version currently used only supports gRPC.
+	otlpProtocolHTTP = "http"
+)
+
+var defaultHistogramBoundaries = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
 func tracingConfigDefault() *TelemetryConfig {
 	return &TelemetryConfig{
 		OT: &OpenTelemetryConfig{
@@ -63,6 +99,9 @@ func tracingConfigDefault() *TelemetryConfig {
 
 // PostprocessTelemetryConfig fixes/validates config
 func PostprocessTelemetryConfig(config *TelemetryConfig) error {
+	if config.OT.Tracing == nil {
+		config.OT.Tracing = &OpenTelemetryTracingConfig{Enable: false}
+	}
 	if err := postprocessOTTracingConfig(config.OT.Tracing); err != nil {
 		return fmt.Errorf(`OT tracing configuration error: %w`, err)
 	}
@@ -70,6 +109,12 @@ func PostprocessTelemetryConfig(config *TelemetryConfig) error {
 	if len(config.OT.Exporters.Stdout.Quantiles) == 0 {
 		config.OT.Exporters.Stdout.Quantiles = []float64{0.5, 0.9, 0.99}
 	}
+	if err := postprocessOTPrometheusConfig(&config.OT.Exporters.Prometheus); err != nil {
+		return fmt.Errorf(`OT Prometheus exporter configuration error: %w`, err)
+	}
+	if err := postprocessOTLPConfig(&config.OT.Exporters.OTLP); err != nil {
+		return fmt.Errorf(`OT OTLP exporter configuration error: %w`, err)
+	}
 
 	return nil
 }
@@ -91,3 +136,51 @@ func postprocessOTTracingConfig(config *OpenTelemetryTracingConfig) error {
 
 	return nil
 }
+
+func postprocessOTPrometheusConfig(config *OpenTelemetryExporterPrometheusConfig) error {
+	if len(config.HistogramBoundaries) == 0 {
+		config.HistogramBoundaries = defaultHistogramBoundaries
+	}
+	return histogramBoundariesMustBeSorted(config.HistogramBoundaries)
+}
+
+func postprocessOTLPConfig(config *OpenTelemetryExporterOTLPConfig) error {
+	if config.Protocol == "" {
+		config.Protocol = OTLPProtocolGRPC
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "localhost:4317"
+	}
+	if config.Timeout == nil {
+		config.Timeout = makeDurationPtr("10s")
+	}
+	if config.MetricsInterval == nil {
+		config.MetricsInterval = makeDurationPtr("60s")
+	}
+	if len(config.HistogramBoundaries) == 0 {
+		config.HistogramBoundaries = defaultHistogramBoundaries
+	}
+
+	if config.Protocol == otlpProtocolHTTP {
+		return fmt.Errorf(`protocol "%s" (OTLP/HTTP) is not supported yet, use "%s"`, otlpProtocolHTTP, OTLPProtocolGRPC)
+	}
+	if config.Protocol != OTLPProtocolGRPC {
+		return fmt.Errorf(`protocol must be "%s"`, OTLPProtocolGRPC)
+	}
+	if err := durationMustBeLargerThanZero("timeout", *config.Timeout); err != nil {
+		return err
+	}
+	if err := durationMustBeLargerThanZero("metricsInterval", *config.MetricsInterval); err != nil {
+		return err
+	}
+	return histogramBoundariesMustBeSorted(config.HistogramBoundaries)
+}
+
+func histogramBoundariesMustBeSorted(boundaries []float64) error {
+	for i, bound := range boundaries {
+		if i > 0 && bound <= boundaries[i-1] {
+			return fmt.Errorf(`histogramBoundaries must be sorted in ascending order`)
+		}
+	}
+	return nil
+}
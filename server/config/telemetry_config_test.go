@@ -23,6 +23,18 @@ func TestTracingEmptyConfig(t *testing.T) {
 	assert.NotNil(t, ott)
 	assert.False(t, ott.Enable)
 	assert.False(t, config.Telemetry.OT.Exporters.Stdout.Enable)
+	assert.False(t, config.Telemetry.OT.Exporters.Prometheus.Enable)
+	assert.Equal(t, 13, len(config.Telemetry.OT.Exporters.Prometheus.HistogramBoundaries))
+
+	otlp := config.Telemetry.OT.Exporters.OTLP
+	assert.False(t, otlp.EnableTrace)
+	assert.False(t, otlp.EnableMetrics)
+	assert.Equal(t, OTLPProtocolGRPC, otlp.Protocol)
+	assert.Equal(t, "localhost:4317", otlp.Endpoint)
+	assert.False(t, otlp.Insecure)
+	assert.Equal(t, 10*time.Second, otlp.Timeout.Duration)
+	assert.Equal(t, 60*time.Second, otlp.MetricsInterval.Duration)
+	assert.Equal(t, 13, len(otlp.HistogramBoundaries))
 }
 
 func TestTracingFullConfig(t *testing.T) {
@@ -47,6 +59,20 @@ telemetry:
 		exporters:
 			stdout:
 				enable: true
+			prometheus:
+				enable: true
+				admin: true
+				histogramBoundaries: [ 0.1, 1, 10 ]
+			otlp:
+				enableTrace: true
+				enableMetrics: true
+				protocol: grpc
+				insecure: true
+				headers:
+					Authorization: Bearer my-token
+				timeout: 3s
+				metricsInterval: 15s
+				histogramBoundaries: [ 0.5, 5 ]
 `, "\t", "  ")
 	config, err := ParseConfig(context.Background(), Overrides{}, configYaml)
 	if err != nil {
@@ -68,9 +94,44 @@ telemetry:
 	assert.Equal(t, 3.14, ott.Attributes["float"])
 
 	assert.True(t, config.Telemetry.OT.Exporters.Stdout.Enable)
+
+	prometheus := config.Telemetry.OT.Exporters.Prometheus
+	assert.True(t, prometheus.Enable)
+	assert.True(t, prometheus.Admin)
+	assert.Equal(t, []float64{0.1, 1, 10}, prometheus.HistogramBoundaries)
+
+	otlp := config.Telemetry.OT.Exporters.OTLP
+	assert.True(t, otlp.EnableTrace)
+	assert.True(t, otlp.EnableMetrics)
+	assert.Equal(t, OTLPProtocolGRPC, otlp.Protocol)
+	assert.Equal(t, "localhost:4317", otlp.Endpoint)
+	assert.True(t, otlp.Insecure)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer my-token"}, otlp.Headers)
+	assert.Equal(t, 3*time.Second, otlp.Timeout.Duration)
+	assert.Equal(t, 15*time.Second, otlp.MetricsInterval.Duration)
+	assert.Equal(t, []float64{0.5, 5}, otlp.HistogramBoundaries)
 }
 
 func TestTracingConfigError(t *testing.T) {
 	_, err := ParseConfig(context.Background(), Overrides{}, `telemetry: { ot: { tracing: { enable: true, sampling: -2.0 } } }`)
 	assert.Regexp(t, `sampling ratio must be within \[0.0, 1.0\]`, err.Error())
 }
+
+func TestPrometheusConfigError(t *testing.T) {
+	_, err := ParseConfig(context.Background(), Overrides{}, `telemetry: { ot: { exporters: { prometheus: { enable: true, histogramBoundaries: [ 1, 0.5 ] } } } }`)
+	assert.Regexp(t, `OT Prometheus exporter configuration error: histogramBoundaries must be sorted in ascending order`, err.Error())
+}
+
+func TestOTLPConfigError(t *testing.T) {
+	_, err := ParseConfig(context.Background(), Overrides{}, `telemetry: { ot: { exporters: { otlp: { protocol: udp } } } }`)
+	assert.Regexp(t, `OT OTLP exporter configuration error: protocol must be "grpc"`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `telemetry: { ot: { exporters: { otlp: { protocol: http } } } }`)
+	assert.Regexp(t, `OT OTLP exporter configuration error: protocol "http" \(OTLP/HTTP\) is not supported yet, use "grpc"`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `telemetry: { ot: { exporters: { otlp: { metricsInterval: 0s } } } }`)
+	assert.Regexp(t, `OT OTLP exporter configuration error: metricsInterval must not be negative nor zero`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, `telemetry: { ot: { exporters: { otlp: { histogramBoundaries: [ 1, 1 ] } } } }`)
+	assert.Regexp(t, `OT OTLP exporter configuration error: histogramBoundaries must be sorted in ascending order`, err.Error())
+}
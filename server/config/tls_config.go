@@ -0,0 +1,128 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+// HTTPServerTLSConfig represents TLS settings of HTTP server
+type HTTPServerTLSConfig struct {
+	CertFile   string               `json:"certFile"`
+	KeyFile    string               `json:"keyFile"`
+	ClientAuth *TLSClientAuthConfig `json:"clientAuth"`
+
+	// Interval to check modification of certFile and keyFile to reload them
+	ReloadInterval domain.Duration `json:"reloadInterval"`
+}
+
+// TLSClientAuthConfig represents TLS client certificate verification settings
+type TLSClientAuthConfig struct {
+	CAFiles  []string `json:"caFiles"`
+	Required bool     `json:"required"`
+}
+
+// ClientCertAuthConfig is a rule to authenticate TLS client certificate
+type ClientCertAuthConfig struct {
+	// Key is attribute name of the subject (e.g. "CN", "O", "OU"), value is list of acceptable values.
+	Subject map[string]domain.TemplateStrings `json:"subject"`
+	// List of acceptable Subject Alternative Names (DNS, email, IP address or URI).
+	SAN *domain.TemplateStrings `json:"san"`
+}
+
+// ClientCertSubjectAttributes is list of supported attribute names of ClientCertAuthConfig.Subject
+var ClientCertSubjectAttributes = []string{"CN", "O", "OU", "C", "L", "ST", "STREET", "POSTALCODE", "SERIALNUMBER"}
+
+func postprocessHTTPServerTLSConfig(config *HTTPServerTLSConfig) error {
+	if config.CertFile == "" || config.KeyFile == "" {
+		return fmt.Errorf(`must supply both of "certFile" and "keyFile"`)
+	}
+	if _, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile); err != nil {
+		return fmt.Errorf(`failed to load TLS certificate "%s" and key "%s": %w`, config.CertFile, config.KeyFile, err)
+	}
+	if config.ReloadInterval.Duration < 0 {
+		return fmt.Errorf(`"reloadInterval" must not be negative`)
+	}
+	if config.ReloadInterval.Duration == 0 {
+		config.ReloadInterval = makeDuration("1m")
+	}
+	if config.ClientAuth != nil {
+		if len(config.ClientAuth.CAFiles) == 0 {
+			return fmt.Errorf(`must supply one or more "clientAuth.caFiles"`)
+		}
+		if _, err := LoadCertPool(config.ClientAuth.CAFiles); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadCertPool loads PEM encoded CA certificates.
+func LoadCertPool(caFiles []string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, caFile := range caFiles {
+		pem, err := ioutil.ReadFile(caFile) //nolint:gosec // Only loads file specified by server configuration file
+		if err != nil {
+			return nil, fmt.Errorf(`failed to read CA certificate file "%s": %w`, caFile, err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf(`no PEM encoded certificate found in CA certificate file "%s"`, caFile)
+		}
+	}
+	return pool, nil
+}
+
+func postprocessClientCertAuthConfig(config *ClientCertAuthConfig) error {
+	if config.Subject == nil {
+		config.Subject = make(map[string]domain.TemplateStrings)
+	}
+	if len(config.Subject) == 0 && config.SAN == nil {
+		return fmt.Errorf(`must supply "subject" or "san" rule`)
+	}
+	for name := range config.Subject {
+		if !isClientCertSubjectAttribute(name) {
+			return fmt.Errorf(`unsupported subject attribute "%s", supported attributes are %v`, name, ClientCertSubjectAttributes)
+		}
+	}
+	return nil
+}
+
+// validateStaticClientCertAuthConfig rejects rule that cannot be evaluated without template variables (e.g. rules of admin API).
+func validateStaticClientCertAuthConfig(config *ClientCertAuthConfig) error {
+	env := map[string]interface{}{}
+	for name, tpls := range config.Subject {
+		if _, err := tpls.Execute(env); err != nil {
+			return fmt.Errorf(`failed to evaluate subject "%s" rule "%s" (template variable is not available): %w`, name, tpls, err)
+		}
+	}
+	if config.SAN != nil {
+		if _, err := config.SAN.Execute(env); err != nil {
+			return fmt.Errorf(`failed to evaluate san rule "%s" (template variable is not available): %w`, config.SAN, err)
+		}
+	}
+	return nil
+}
+
+func isClientCertSubjectAttribute(name string) bool {
+	for _, supported := range ClientCertSubjectAttributes {
+		if name == supported {
+			return true
+		}
+	}
+	return false
+}
+
+func clientCertAuthConfigured(config *ServerConfig) bool {
+	if len(config.Admin.Auth.ClientCerts) > 0 {
+		return true
+	}
+	for _, ch := range config.Channels {
+		if ch.ClientCert != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package config_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/saiya/dsps/server/config"
+)
+
+const tlsConfigYaml = `
+http:
+	tls:
+		certFile: ../clientcert/testdata/server.pem
+		keyFile: ../clientcert/testdata/server-key.pem
+		clientAuth:
+			caFiles: [ ../clientcert/testdata/ca.pem ]
+			required: true
+`
+
+func TestTLSConfig(t *testing.T) {
+	configYaml := strings.ReplaceAll(tlsConfigYaml+`
+admin:
+	auth:
+		clientCerts:
+			- subject: { CN: [ admin.example.com ] }
+channels:
+-
+	regex: 'chat-room-(?P<id>\d+)'
+	clientCert:
+		subject:
+			O: [ 'Example Inc.' ]
+			OU: [ backend ]
+		san: [ 'spiffe://example.com/chat-room-{{.channel.id}}' ]
+`, "\t", "  ")
+	config, err := ParseConfig(context.Background(), Overrides{}, configYaml)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tls := config.HTTPServer.TLS
+	assert.Equal(t, "../clientcert/testdata/server.pem", tls.CertFile)
+	assert.Equal(t, "../clientcert/testdata/server-key.pem", tls.KeyFile)
+	assert.Equal(t, []string{"../clientcert/testdata/ca.pem"}, tls.ClientAuth.CAFiles)
+	assert.True(t, tls.ClientAuth.Required)
+	assert.Equal(t, "1m0s", tls.ReloadInterval.String())
+
+	assert.Equal(t, 1, len(config.Admin.Auth.ClientCerts))
+	assert.Equal(t, "admin.example.com", config.Admin.Auth.ClientCerts[0].Subject["CN"].Templates[0].String())
+	assert.Nil(t, config.Admin.Auth.ClientCerts[0].SAN)
+
+	cc := config.Channels[0].ClientCert
+	assert.Equal(t, "Example Inc.", cc.Subject["O"].Templates[0].String())
+	assert.Equal(t, "backend", cc.Subject["OU"].Templates[0].String())
+	assert.Equal(t, "spiffe://example.com/chat-room-{{.channel.id}}", cc.SAN.Templates[0].String())
+}
+
+func TestTLSConfigWithoutClientAuth(t *testing.T) {
+	configYaml := strings.ReplaceAll(`
+http:
+	tls:
+		certFile: ../clientcert/testdata/server.pem
+		keyFile: ../clientcert/testdata/server-key.pem
+`, "\t", "  ")
+	config, err := ParseConfig(context.Background(), Overrides{}, configYaml)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Nil(t, config.HTTPServer.TLS.ClientAuth)
+
+	config, err = ParseConfig(context.Background(), Overrides{}, ``)
+	assert.NoError(t, err)
+	assert.Nil(t, config.HTTPServer.TLS)
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	_, err := ParseConfig(context.Background(), Overrides{}, strings.ReplaceAll(`
+http:
+	tls:
+		certFile: ../clientcert/testdata/server.pem
+`, "\t", "  "))
+	assert.Regexp(t, `must supply both of "certFile" and "keyFile"`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, strings.ReplaceAll(`
+http:
+	tls:
+		certFile: ../clientcert/testdata/server.pem
+		keyFile: ../clientcert/testdata/client1-key.pem
+`, "\t", "  "))
+	assert.Regexp(t, `failed to load TLS certificate`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, strings.ReplaceAll(`
+http:
+	tls:
+		certFile: ../clientcert/testdata/server.pem
+		keyFile: ../clientcert/testdata/server-key.pem
+		reloadInterval: -1s
+`, "\t", "  "))
+	assert.Regexp(t, `"reloadInterval" must not be negative`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, strings.ReplaceAll(`
+http:
+	h2c: true
+	tls:
+		certFile: ../clientcert/testdata/server.pem
+		keyFile: ../clientcert/testdata/server-key.pem
+`, "\t", "  "))
+	assert.Regexp(t, `h2c cannot be used with tls`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, strings.ReplaceAll(`
+http:
+	tls:
+		certFile: ../clientcert/testdata/server.pem
+		keyFile: ../clientcert/testdata/server-key.pem
+		clientAuth: { caFiles: [] }
+`, "\t", "  "))
+	assert.Regexp(t, `must supply one or more "clientAuth.caFiles"`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, strings.ReplaceAll(`
+http:
+	tls:
+		certFile: ../clientcert/testdata/server.pem
+		keyFile: ../clientcert/testdata/server-key.pem
+		clientAuth: { caFiles: [ ../clientcert/testdata/Makefile ] }
+`, "\t", "  "))
+	assert.Regexp(t, `no PEM encoded certificate found in CA certificate file`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, strings.ReplaceAll(tlsConfigYaml+`
+channels:
+-
+	regex: '.+'
+	clientCert: {}
+`, "\t", "  "))
+	assert.Regexp(t, `must supply "subject" or "san" rule`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, strings.ReplaceAll(tlsConfigYaml+`
+channels:
+-
+	regex: '.+'
+	clientCert: { subject: { EMAIL: [ 'foo@example.com' ] } }
+`, "\t", "  "))
+	assert.Regexp(t, `unsupported subject attribute "EMAIL"`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, strings.ReplaceAll(`
+channels:
+-
+	regex: '.+'
+	clientCert: { subject: { CN: [ 'client1.example.com' ] } }
+`, "\t", "  "))
+	assert.Regexp(t, `Client certificate authentication requires http.tls.clientAuth configuration`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, strings.ReplaceAll(tlsConfigYaml+`
+admin:
+	auth:
+		clientCerts:
+			- subject: { CN: [ '{{.channel.id}}.example.com' ] }
+`, "\t", "  "))
+	assert.Regexp(t, `error on auth.clientCerts\[0\]: failed to evaluate subject "CN" rule`, err.Error())
+
+	_, err = ParseConfig(context.Background(), Overrides{}, strings.ReplaceAll(tlsConfigYaml+`
+admin:
+	auth:
+		clientCerts:
+			- san: [ 'spiffe://example.com/{{.channel.id}}' ]
+`, "\t", "  "))
+	assert.Regexp(t, `error on auth.clientCerts\[0\]: failed to evaluate san rule`, err.Error())
+}
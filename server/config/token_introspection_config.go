@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+// TokenIntrospectionConfig is OAuth2 token introspection (RFC 7662) configuration of a channel
+type TokenIntrospectionConfig struct {
+	URL          string `json:"url"`
+	ClientID     string `json:"clientID"`
+	ClientSecret string `json:"clientSecret"`
+
+	Iss    []domain.JwtIss                   `json:"iss"`
+	Aud    []domain.JwtAud                   `json:"aud"`
+	Claims map[string]domain.TemplateStrings `json:"claims"`
+
+	Timeout         *domain.Duration `json:"timeout"`
+	CacheTTL        *domain.Duration `json:"cacheTTL"`
+	ClockSkewLeeway *domain.Duration `json:"clockSkewLeeway"`
+}
+
+var tokenIntrospectionConfigDefaults = TokenIntrospectionConfig{
+	Timeout:         makeDurationPtr("10s"),
+	CacheTTL:        makeDurationPtr("1m"),
+	ClockSkewLeeway: makeDurationPtr("5m"),
+}
+
+func postprocessTokenIntrospectionConfig(cfg *TokenIntrospectionConfig) error {
+	if cfg.Claims == nil {
+		cfg.Claims = make(map[string]domain.TemplateStrings)
+	}
+	if cfg.Timeout == nil {
+		cfg.Timeout = tokenIntrospectionConfigDefaults.Timeout
+	}
+	if cfg.CacheTTL == nil {
+		cfg.CacheTTL = tokenIntrospectionConfigDefaults.CacheTTL
+	}
+	if cfg.ClockSkewLeeway == nil {
+		cfg.ClockSkewLeeway = tokenIntrospectionConfigDefaults.ClockSkewLeeway
+	}
+
+	if cfg.URL == "" {
+		return fmt.Errorf(`must supply "url" of the introspection endpoint`)
+	}
+	if u, err := url.Parse(cfg.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf(`invalid introspection endpoint URL "%s"`, cfg.URL)
+	}
+	if err := durationMustBeLargerThanZero("timeout", *cfg.Timeout); err != nil {
+		return err
+	}
+	if cfg.CacheTTL.Duration < 0 {
+		return fmt.Errorf("cacheTTL must not be negative")
+	}
+	if cfg.ClockSkewLeeway.Duration < 0 {
+		return fmt.Errorf("clockSkewLeeway must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package config_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/saiya/dsps/server/config"
+	. "github.com/saiya/dsps/server/testing"
+)
+
+func TestTokenIntrospectionDefaultConfig(t *testing.T) {
+	configYaml := strings.ReplaceAll(`
+channels:
+-
+	regex: 'chat-room-(?P<id>\d+)'
+	introspection:
+		url: https://auth.example.com/oauth2/introspect
+`, "\t", "  ")
+	config, err := ParseConfig(context.Background(), Overrides{}, configYaml)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cfg := config.Channels[0].Introspection
+	assert.Equal(t, "https://auth.example.com/oauth2/introspect", cfg.URL)
+	assert.Equal(t, "", cfg.ClientID)
+	assert.Equal(t, 0, len(cfg.Iss))
+	assert.Equal(t, 0, len(cfg.Aud))
+	assert.Equal(t, 0, len(cfg.Claims))
+	assert.Equal(t, MakeDurationPtr("10s"), cfg.Timeout)
+	assert.Equal(t, MakeDurationPtr("1m"), cfg.CacheTTL)
+	assert.Equal(t, MakeDurationPtr("5m"), cfg.ClockSkewLeeway)
+}
+
+func TestTokenIntrospectionFullConfig(t *testing.T) {
+	configYaml := strings.ReplaceAll(`
+channels:
+-
+	regex: 'chat-room-(?P<id>\d+)'
+	introspection:
+		url: https://auth.example.com/oauth2/introspect
+		clientID: my-client
+		clientSecret: my-secret
+		iss: [ https://auth.example.com/ ]
+		aud: [ https://my-service.example.com/ ]
+		claims:
+			chatroom: '{{.channel.id}}'
+		timeout: 3s
+		cacheTTL: 30s
+		clockSkewLeeway: 1m
+`, "\t", "  ")
+	config, err := ParseConfig(context.Background(), Overrides{}, configYaml)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cfg := config.Channels[0].Introspection
+	assert.Equal(t, "my-client", cfg.ClientID)
+	assert.Equal(t, "my-secret", cfg.ClientSecret)
+	assert.Equal(t, "https://auth.example.com/", string(cfg.Iss[0]))
+	assert.Equal(t, "https://my-service.example.com/", string(cfg.Aud[0]))
+	assert.Equal(t, `TemplateStrings{"{{.channel.id}}"}`, cfg.Claims["chatroom"].String())
+	assert.Equal(t, MakeDurationPtr("3s"), cfg.Timeout)
+	assert.Equal(t, MakeDurationPtr("30s"), cfg.CacheTTL)
+	assert.Equal(t, MakeDurationPtr("1m"), cfg.ClockSkewLeeway)
+}
+
+func TestTokenIntrospectionConfigErrors(t *testing.T) {
+	for _, testcase := range []struct {
+		yaml   string
+		errMsg string
+	}{
+		{`introspection: {}`, `must supply "url" of the introspection endpoint`},
+		{`introspection: { url: "ftp://example.com" }`, `invalid introspection endpoint URL "ftp://example.com"`},
+		{`introspection: { url: "https://example.com", timeout: 0s }`, `timeout must not be negative nor zero`},
+		{`introspection: { url: "https://example.com", cacheTTL: -1s }`, `cacheTTL must not be negative`},
+		{`introspection: { url: "https://example.com", clockSkewLeeway: -1s }`, `clockSkewLeeway must not be negative`},
+		{`introspection: { url: "https://example.com" }, jwt: { iss: [ "https://example.com" ], keys: { none: [] } }`, `could not configure both of "jwt" and "introspection"`},
+	} {
+		_, err := ParseConfig(context.Background(), Overrides{}, `channels: [ { regex: ".+", `+testcase.yaml+` } ]`)
+		if assert.Error(t, err, testcase.yaml) {
+			assert.Contains(t, err.Error(), testcase.errMsg)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+// WebhookDeliveryConfig is definition of "webhookDelivery" configuration (asynchronous outgoing-webhook delivery)
+type WebhookDeliveryConfig struct {
+	Disable bool `json:"disable"`
+
+	Workers      *int             `json:"workers"`
+	BatchSize    *int             `json:"batchSize"`
+	PollInterval *domain.Duration `json:"pollInterval"`
+	Lease        *domain.Duration `json:"lease"`
+
+	MaxAttempts             *int             `json:"maxAttempts"`
+	RetryInterval           *domain.Duration `json:"retryInterval"`
+	RetryIntervalMultiplier *float64         `json:"retryIntervalMultiplier"`
+	MaxRetryInterval        *domain.Duration `json:"maxRetryInterval"`
+
+	// Max count of delivery records to retain for each channel.
+	HistorySize *int `json:"historySize"`
+	// Delivery records of a channel are discarded after this period since the last delivery attempt.
+	HistoryRetention *domain.Duration `json:"historyRetention"`
+}
+
+var webhookDeliveryConfigDefaults = WebhookDeliveryConfig{
+	Workers:      makeIntPtr(4),
+	BatchSize:    makeIntPtr(16),
+	PollInterval: makeDurationPtr("500ms"),
+	Lease:        makeDurationPtr("10m"),
+
+	MaxAttempts:             makeIntPtr(10),
+	RetryInterval:           makeDurationPtr("30s"),
+	RetryIntervalMultiplier: makeFloat64Ptr(2.0),
+	MaxRetryInterval:        makeDurationPtr("1h"),
+
+	HistorySize:      makeIntPtr(100),
+	HistoryRetention: makeDurationPtr("24h"),
+}
+
+func webhookDeliveryConfigDefault() *WebhookDeliveryConfig {
+	return &WebhookDeliveryConfig{}
+}
+
+// PostprocessWebhookDeliveryConfig fixes/validates config
+func PostprocessWebhookDeliveryConfig(config *WebhookDeliveryConfig) error {
+	if config.Workers == nil {
+		config.Workers = webhookDeliveryConfigDefaults.Workers
+	}
+	if config.BatchSize == nil {
+		config.BatchSize = webhookDeliveryConfigDefaults.BatchSize
+	}
+	if config.PollInterval == nil {
+		config.PollInterval = webhookDeliveryConfigDefaults.PollInterval
+	}
+	if config.Lease == nil {
+		config.Lease = webhookDeliveryConfigDefaults.Lease
+	}
+	if config.MaxAttempts == nil {
+		config.MaxAttempts = webhookDeliveryConfigDefaults.MaxAttempts
+	}
+	if config.RetryInterval == nil {
+		config.RetryInterval = webhookDeliveryConfigDefaults.RetryInterval
+	}
+	if config.RetryIntervalMultiplier == nil {
+		config.RetryIntervalMultiplier = webhookDeliveryConfigDefaults.RetryIntervalMultiplier
+	}
+	if config.MaxRetryInterval == nil {
+		config.MaxRetryInterval = webhookDeliveryConfigDefaults.MaxRetryInterval
+	}
+	if config.HistorySize == nil {
+		config.HistorySize = webhookDeliveryConfigDefaults.HistorySize
+	}
+	if config.HistoryRetention == nil {
+		config.HistoryRetention = webhookDeliveryConfigDefaults.HistoryRetention
+	}
+
+	if err := intMustBeLargerThanZero("workers", *config.Workers); err != nil {
+		return err
+	}
+	if err := intMustBeLargerThanZero("batchSize", *config.BatchSize); err != nil {
+		return err
+	}
+	if err := durationMustBeLargerThanZero("pollInterval", *config.PollInterval); err != nil {
+		return err
+	}
+	if err := durationMustBeLargerThanZero("lease", *config.Lease); err != nil {
+		return err
+	}
+	if err := intMustBeLargerThanZero("maxAttempts", *config.MaxAttempts); err != nil {
+		return err
+	}
+	if err := durationMustBeLargerThanZero("retryInterval", *config.RetryInterval); err != nil {
+		return err
+	}
+	if *config.RetryIntervalMultiplier < 1.0 {
+		return fmt.Errorf("retryIntervalMultiplier must be equal to or larger than 1.0")
+	}
+	if config.MaxRetryInterval.Duration < config.RetryInterval.Duration {
+		return fmt.Errorf("maxRetryInterval must be equal to or larger than retryInterval")
+	}
+	if err := intMustBeLargerThanZero("historySize", *config.HistorySize); err != nil {
+		return err
+	}
+	if err := durationMustBeLargerThanZero("historyRetention", *config.HistoryRetention); err != nil {
+		return err
+	}
+	return nil
+}
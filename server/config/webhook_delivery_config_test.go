@@ -0,0 +1,82 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/saiya/dsps/server/config"
+)
+
+func TestWebhookDeliveryDefaults(t *testing.T) {
+	config, err := ParseConfig(context.Background(), Overrides{}, "")
+	assert.NoError(t, err)
+
+	wd := config.WebhookDelivery
+	assert.False(t, wd.Disable)
+	assert.Equal(t, 4, *wd.Workers)
+	assert.Equal(t, 16, *wd.BatchSize)
+	assert.Equal(t, 500*time.Millisecond, wd.PollInterval.Duration)
+	assert.Equal(t, 10*time.Minute, wd.Lease.Duration)
+	assert.Equal(t, 10, *wd.MaxAttempts)
+	assert.Equal(t, 30*time.Second, wd.RetryInterval.Duration)
+	assert.Equal(t, 2.0, *wd.RetryIntervalMultiplier)
+	assert.Equal(t, time.Hour, wd.MaxRetryInterval.Duration)
+	assert.Equal(t, 100, *wd.HistorySize)
+	assert.Equal(t, 24*time.Hour, wd.HistoryRetention.Duration)
+}
+
+func TestWebhookDeliveryConfig(t *testing.T) {
+	yaml := `
+webhookDelivery:
+  disable: true
+  workers: 2
+  batchSize: 8
+  pollInterval: 1s
+  lease: 3m
+  maxAttempts: 5
+  retryInterval: 10s
+  retryIntervalMultiplier: 1.5
+  maxRetryInterval: 20m
+  historySize: 10
+  historyRetention: 1h
+`
+	config, err := ParseConfig(context.Background(), Overrides{}, yaml)
+	assert.NoError(t, err)
+
+	wd := config.WebhookDelivery
+	assert.True(t, wd.Disable)
+	assert.Equal(t, 2, *wd.Workers)
+	assert.Equal(t, 8, *wd.BatchSize)
+	assert.Equal(t, time.Second, wd.PollInterval.Duration)
+	assert.Equal(t, 3*time.Minute, wd.Lease.Duration)
+	assert.Equal(t, 5, *wd.MaxAttempts)
+	assert.Equal(t, 10*time.Second, wd.RetryInterval.Duration)
+	assert.Equal(t, 1.5, *wd.RetryIntervalMultiplier)
+	assert.Equal(t, 20*time.Minute, wd.MaxRetryInterval.Duration)
+	assert.Equal(t, 10, *wd.HistorySize)
+	assert.Equal(t, time.Hour, wd.HistoryRetention.Duration)
+}
+
+func TestWebhookDeliveryConfigValidation(t *testing.T) {
+	for _, tc := range []struct {
+		yaml string
+		err  string
+	}{
+		{"webhookDelivery: { workers: 0 }", "workers must not be negative nor zero"},
+		{"webhookDelivery: { batchSize: -1 }", "batchSize must not be negative nor zero"},
+		{"webhookDelivery: { pollInterval: 0s }", "pollInterval must not be negative nor zero"},
+		{"webhookDelivery: { lease: 0s }", "lease must not be negative nor zero"},
+		{"webhookDelivery: { maxAttempts: 0 }", "maxAttempts must not be negative nor zero"},
+		{"webhookDelivery: { retryInterval: 0s }", "retryInterval must not be negative nor zero"},
+		{"webhookDelivery: { retryIntervalMultiplier: 0.5 }", "retryIntervalMultiplier must be equal to or larger than 1.0"},
+		{"webhookDelivery: { retryInterval: 1m, maxRetryInterval: 30s }", "maxRetryInterval must be equal to or larger than retryInterval"},
+		{"webhookDelivery: { historySize: 0 }", "historySize must not be negative nor zero"},
+		{"webhookDelivery: { historyRetention: 0s }", "historyRetention must not be negative nor zero"},
+	} {
+		_, err := ParseConfig(context.Background(), Overrides{}, tc.yaml)
+		assert.Regexp(t, "Webhook delivery configration problem: "+tc.err, err)
+	}
+}
@@ -2,8 +2,11 @@ package domain
 
 import (
 	"context"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 )
 
 // ChannelID is ID of the PubSub channel, system-wide unique value
@@ -25,13 +28,65 @@ type ChannelProvider interface {
 // Channel struct holds all objects/information of a channel
 type Channel interface {
 	Expire() Duration
+	// Limits of messages retained in the channel.
+	Retention() ChannelRetention
+	// Maximum size of a message in bytes, zero means no channel specific limit.
+	MaxMessageBytes() int64
+	// Rate limits of requests to the channel, all of them must permit a request.
+	RateLimits() []ChannelRateLimit
 
+	// Validates bearer token with JWT validation and/or token introspection.
 	// Note that this method does not check revocation list.
 	ValidateJwt(ctx context.Context, jwt string) error
+	// Validates verified TLS client certificate chain (leaf certificate first).
+	// Returns ErrClientCertAuthNotConfigured if the channel does not have client certificate authentication.
+	ValidateClientCert(ctx context.Context, certs []*x509.Certificate) error
 
+	// Returns true if the channel accepts messages of the given media type (e.g. "application/x-protobuf").
+	// Empty string means "application/json".
+	AcceptsContentType(mediaType string) bool
+	// Validates JSON content of the message with JSON Schema of the channel.
+	// Returns *MessageSchemaViolation if invalid, returns nil if the channel has no schema or the content is not JSON.
+	ValidateMessageContent(ctx context.Context, msg Message) error
+
+	// Returns true if the channel has at least one outgoing webhook.
+	HasOutgoingWebhook() bool
 	SendOutgoingWebhook(ctx context.Context, msg Message) error
 }
 
+// ChannelRetention is limits of messages retained in a channel, independent of the channel expiration.
+type ChannelRetention struct {
+	MaxMessages int   // Zero means unlimited
+	MaxBytes    int64 // Total size of message contents, zero means unlimited
+	// If true, storage rejects new messages rather than dropping oldest messages when exceeded the limits.
+	RejectOverflow bool
+}
+
+// IsLimited returns true if any limit is configured
+func (r ChannelRetention) IsLimited() bool {
+	return r.MaxMessages > 0 || r.MaxBytes > 0
+}
+
+// ErrClientCertAuthNotConfigured : Channel has no client certificate authentication configuration
+var ErrClientCertAuthNotConfigured = errors.New("client certificate authentication is not configured")
+
+// ErrMessageSchemaViolation : Message content does not match with JSON Schema of the channel
+var ErrMessageSchemaViolation = NewErrorWithCode("dsps.channel.message-schema-violation")
+
+// MessageSchemaViolation is an error that has validation errors of the message content
+type MessageSchemaViolation struct {
+	Errors []string // Human readable validation errors, e.g. "#/user/name: expected string, but got number"
+}
+
+func (e *MessageSchemaViolation) Error() string {
+	return fmt.Sprintf("message content does not match with the schema: %s", strings.Join(e.Errors, ", "))
+}
+
+// Unwrap returns ErrMessageSchemaViolation to be errors.Is compatible
+func (e *MessageSchemaViolation) Unwrap() error {
+	return ErrMessageSchemaViolation
+}
+
 // see: doc/interface/validation_rule.md
 var (
 	channelIDRegexp = regexp.MustCompile("^[0-9a-z][0-9a-z_-]{0,62}$")
@@ -2,10 +2,16 @@ package channel
 
 import (
 	"context"
+	"crypto/x509"
 	"fmt"
+	"strings"
 
+	"github.com/saiya/dsps/server/clientcert"
+	"github.com/saiya/dsps/server/config"
 	"github.com/saiya/dsps/server/domain"
 	jwtv "github.com/saiya/dsps/server/jwt/validator"
+	"github.com/saiya/dsps/server/oauth2/introspection"
+	"github.com/saiya/dsps/server/schema"
 	"github.com/saiya/dsps/server/webhook/outgoing"
 	"golang.org/x/xerrors"
 )
@@ -14,19 +20,46 @@ type channelImpl struct {
 	id    domain.ChannelID
 	atoms []*channelAtom
 
-	expire          domain.Duration
-	jwtValidators   []jwtv.Validator
-	outgoingWebhook outgoing.Client
+	expire                  domain.Duration
+	retention               domain.ChannelRetention
+	maxMessageBytes         int64
+	rateLimits              []domain.ChannelRateLimit
+	jwtValidators           []jwtv.Validator
+	introspectionValidators []introspection.Validator
+	clientCertValidators    []clientcert.Validator
+	contentTypes            [][]string // contentTypes of each atom, nil if no atom configures it
+	schemaValidators        []schema.Validator
+	outgoingWebhook         outgoing.Client
+	hasOutgoingWebhook      bool
 }
 
 func (c *channelImpl) Expire() domain.Duration {
 	return c.expire
 }
 
+func (c *channelImpl) Retention() domain.ChannelRetention {
+	return c.retention
+}
+
+func (c *channelImpl) MaxMessageBytes() int64 {
+	return c.maxMessageBytes
+}
+
+func (c *channelImpl) RateLimits() []domain.ChannelRateLimit {
+	return c.rateLimits
+}
+
 func newChannelImpl(id domain.ChannelID, atoms []*channelAtom) (*channelImpl, error) {
 	expire := domain.Duration{Duration: 0}
+	retention := domain.ChannelRetention{}
+	maxMessageBytes := int64(0)
+	var rateLimits []domain.ChannelRateLimit
 	jwtValidators := make([]jwtv.Validator, 0, len(atoms))
+	introspectionValidators := make([]introspection.Validator, 0, len(atoms))
+	clientCertValidators := make([]clientcert.Validator, 0, len(atoms))
 	outgoingWebhooks := make([]outgoing.Client, 0, len(atoms)*2)
+	var contentTypes [][]string
+	var schemaValidators []schema.Validator
 	for _, atom := range atoms {
 		tplEnv := atom.TemplateEnvironmentOf(id)
 		if tplEnv == nil {
@@ -36,6 +69,24 @@ func newChannelImpl(id domain.ChannelID, atoms []*channelAtom) (*channelImpl, er
 		if expire.Duration < atom.Expire().Duration {
 			expire = atom.Expire()
 		}
+		// Strictest limit wins
+		if max := atom.config.MaxMessages; max > 0 && (retention.MaxMessages == 0 || max < retention.MaxMessages) {
+			retention.MaxMessages = max
+		}
+		if max := atom.config.MaxBytes; max > 0 && (retention.MaxBytes == 0 || max < retention.MaxBytes) {
+			retention.MaxBytes = max
+		}
+		if atom.config.Overflow == config.ChannelOverflowReject {
+			retention.RejectOverflow = true
+		}
+		if max := atom.config.MaxMessageBytes; max > 0 && (maxMessageBytes == 0 || max < maxMessageBytes) {
+			maxMessageBytes = max
+		}
+		if cfg := atom.config.RateLimits; cfg != nil {
+			rateLimits = appendRateLimit(rateLimits, domain.RateLimitPerChannel, cfg.Channel)
+			rateLimits = appendRateLimit(rateLimits, domain.RateLimitPerIP, cfg.IP)
+			rateLimits = appendRateLimit(rateLimits, domain.RateLimitPerSubject, cfg.Sub)
+		}
 
 		if atom.JwtValidatorTemplate != nil {
 			jv, err := atom.JwtValidatorTemplate.NewValidator(tplEnv)
@@ -44,6 +95,26 @@ func newChannelImpl(id domain.ChannelID, atoms []*channelAtom) (*channelImpl, er
 			}
 			jwtValidators = append(jwtValidators, jv)
 		}
+		if atom.IntrospectionTemplate != nil {
+			iv, err := atom.IntrospectionTemplate.NewValidator(tplEnv)
+			if err != nil {
+				return nil, xerrors.Errorf(`failed to configure token introspection of channel "%s": %w`, id, err)
+			}
+			introspectionValidators = append(introspectionValidators, iv)
+		}
+		if atom.ClientCertTemplate != nil {
+			cv, err := atom.ClientCertTemplate.NewValidator(tplEnv)
+			if err != nil {
+				return nil, xerrors.Errorf(`failed to configure client certificate validation of channel "%s": %w`, id, err)
+			}
+			clientCertValidators = append(clientCertValidators, cv)
+		}
+		if atom.config.ContentTypes != nil {
+			contentTypes = append(contentTypes, atom.config.ContentTypes)
+		}
+		if atom.SchemaValidator != nil {
+			schemaValidators = append(schemaValidators, atom.SchemaValidator)
+		}
 
 		for _, tpl := range atom.OutgoingWebHookTemplates {
 			client, err := tpl.NewClient(tplEnv)
@@ -57,21 +128,106 @@ func newChannelImpl(id domain.ChannelID, atoms []*channelAtom) (*channelImpl, er
 		id:    id,
 		atoms: atoms,
 
-		expire:          expire,
-		jwtValidators:   jwtValidators,
-		outgoingWebhook: outgoing.NewMultiplexClient(outgoingWebhooks),
+		expire:                  expire,
+		retention:               retention,
+		maxMessageBytes:         maxMessageBytes,
+		rateLimits:              rateLimits,
+		jwtValidators:           jwtValidators,
+		introspectionValidators: introspectionValidators,
+		clientCertValidators:    clientCertValidators,
+		contentTypes:            contentTypes,
+		schemaValidators:        schemaValidators,
+		outgoingWebhook:         outgoing.NewMultiplexClient(outgoingWebhooks),
+		hasOutgoingWebhook:      len(outgoingWebhooks) > 0,
 	}, nil
 }
 
+func appendRateLimit(list []domain.ChannelRateLimit, scope domain.RateLimitScope, cfg *config.RateLimitConfig) []domain.ChannelRateLimit {
+	if cfg == nil {
+		return list
+	}
+	return append(list, domain.ChannelRateLimit{Scope: scope, RateLimit: cfg.RateLimit()})
+}
+
 func (c *channelImpl) ValidateJwt(ctx context.Context, jwt string) error {
+	if len(c.clientCertValidators) > 0 && len(c.jwtValidators) == 0 && len(c.introspectionValidators) == 0 {
+		// Only client certificate authentication is configured, bearer token is not acceptable.
+		return fmt.Errorf("client certificate required, bearer token authentication is not configured")
+	}
 	for _, jv := range c.jwtValidators {
 		if err := jv.Validate(ctx, jwt); err != nil {
 			return err
 		}
 	}
+	for _, iv := range c.introspectionValidators {
+		if err := iv.Validate(ctx, jwt); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+func (c *channelImpl) ValidateClientCert(ctx context.Context, certs []*x509.Certificate) error {
+	if len(c.clientCertValidators) == 0 {
+		return domain.ErrClientCertAuthNotConfigured
+	}
+	for _, cv := range c.clientCertValidators {
+		if err := cv.Validate(ctx, certs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *channelImpl) AcceptsContentType(mediaType string) bool {
+	if mediaType == "" {
+		mediaType = domain.ContentTypeJSON
+	}
+	mediaType = strings.ToLower(mediaType)
+	if len(c.contentTypes) == 0 {
+		return domain.IsJSONContentType(mediaType)
+	}
+	// Must be allowed by all of channel configurations that restrict content types.
+	for _, patterns := range c.contentTypes {
+		if !matchContentType(patterns, mediaType) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchContentType(patterns []string, mediaType string) bool {
+	slash := strings.IndexByte(mediaType, '/')
+	if slash < 0 {
+		return false
+	}
+	for _, pattern := range patterns {
+		switch {
+		case pattern == "*/*" || pattern == mediaType:
+			return true
+		case strings.HasSuffix(pattern, "/*") && pattern[:len(pattern)-1] == mediaType[:slash+1]:
+			return true
+		}
+	}
+	return false
+}
+
+func (c *channelImpl) ValidateMessageContent(ctx context.Context, msg domain.Message) error {
+	if !msg.IsJSON() {
+		return nil
+	}
+	for _, sv := range c.schemaValidators {
+		if err := sv.Validate(ctx, msg.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *channelImpl) HasOutgoingWebhook() bool {
+	return c.hasOutgoingWebhook
+}
+
 func (c *channelImpl) SendOutgoingWebhook(ctx context.Context, msg domain.Message) error {
 	return c.outgoingWebhook.Send(ctx, msg)
 }
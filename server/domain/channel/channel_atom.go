@@ -6,9 +6,12 @@ import (
 
 	"golang.org/x/xerrors"
 
+	"github.com/saiya/dsps/server/clientcert"
 	"github.com/saiya/dsps/server/config"
 	"github.com/saiya/dsps/server/domain"
 	jwtv "github.com/saiya/dsps/server/jwt/validator"
+	"github.com/saiya/dsps/server/oauth2/introspection"
+	"github.com/saiya/dsps/server/schema"
 	"github.com/saiya/dsps/server/webhook/outgoing"
 )
 
@@ -18,6 +21,9 @@ type channelAtom struct {
 	config *config.ChannelConfig
 
 	JwtValidatorTemplate     jwtv.Template
+	IntrospectionTemplate    introspection.Template
+	ClientCertTemplate       clientcert.Template
+	SchemaValidator          schema.Validator // nil if no schema configured
 	OutgoingWebHookTemplates []outgoing.ClientTemplate
 }
 
@@ -42,10 +48,31 @@ func newChannelAtom(ctx context.Context, config *config.ChannelConfig, deps Prov
 		}
 		atom.JwtValidatorTemplate = jvt
 	}
+	if config.Introspection != nil {
+		it, err := introspection.NewTemplate(ctx, config.Introspection, deps.Clock, deps.Telemetry)
+		if err != nil {
+			return nil, err
+		}
+		atom.IntrospectionTemplate = it
+	}
+	if config.ClientCert != nil {
+		cct, err := clientcert.NewTemplate(ctx, config.ClientCert)
+		if err != nil {
+			return nil, err
+		}
+		atom.ClientCertTemplate = cct
+	}
+	if config.SchemaFile != "" {
+		sv, err := schema.NewValidator(ctx, config.SchemaFile)
+		if err != nil {
+			return nil, err
+		}
+		atom.SchemaValidator = sv
+	}
 
 	atom.OutgoingWebHookTemplates = make([]outgoing.ClientTemplate, 0, len(config.Webhooks))
 	for i := range config.Webhooks {
-		tpl, err := outgoing.NewClientTemplate(ctx, &config.Webhooks[i], deps.Telemetry, deps.Sentry)
+		tpl, err := outgoing.NewClientTemplate(ctx, &config.Webhooks[i], deps.Clock, deps.CircuitBreakers, deps.Telemetry, deps.Sentry)
 		if err != nil {
 			return nil, err
 		}
@@ -56,6 +83,9 @@ func newChannelAtom(ctx context.Context, config *config.ChannelConfig, deps Prov
 }
 
 func (c *channelAtom) Shutdown(ctx context.Context) {
+	if c.IntrospectionTemplate != nil {
+		c.IntrospectionTemplate.Close()
+	}
 	for _, webhook := range c.OutgoingWebHookTemplates {
 		webhook.Close()
 	}
@@ -102,6 +132,25 @@ func (c *channelAtom) validateTemplateStrings() error {
 			}
 		}
 	}
+	if introspection := c.config.Introspection; introspection != nil {
+		for claim, tpls := range introspection.Claims {
+			for i, tpl := range tpls.Templates {
+				templates[fmt.Sprintf("introspection.claims.%s[%d]", claim, i)] = tpl
+			}
+		}
+	}
+	if clientCert := c.config.ClientCert; clientCert != nil {
+		for name, tpls := range clientCert.Subject {
+			for i, tpl := range tpls.Templates {
+				templates[fmt.Sprintf("clientCert.subject.%s[%d]", name, i)] = tpl
+			}
+		}
+		if clientCert.SAN != nil {
+			for i, tpl := range clientCert.SAN.Templates {
+				templates[fmt.Sprintf("clientCert.san[%d]", i)] = tpl
+			}
+		}
+	}
 
 	dummy := c.dummyTemplateEnvironment()
 	for path, tpl := range templates {
@@ -66,6 +66,15 @@ regex: 'chat-room-(?P<id>\d+)'
 jwt:
 	iss: [ "http://example.com" ]
 	keys: RS256: [ "../../jwt/testdata/RS256-2048bit-public.pem" ]
+	claims:
+		chatroom: '{{.channel.idX}}'`,
+		},
+		{
+			`invalid template found on introspection.claims.chatroom\[0\]:.*map has no entry for key`,
+			`
+regex: 'chat-room-(?P<id>\d+)'
+introspection:
+	url: "https://example.com/introspect"
 	claims:
 		chatroom: '{{.channel.idX}}'`,
 		},
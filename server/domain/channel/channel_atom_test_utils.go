@@ -12,10 +12,15 @@ import (
 	"github.com/saiya/dsps/server/domain"
 	"github.com/saiya/dsps/server/sentry"
 	"github.com/saiya/dsps/server/telemetry"
+	"github.com/saiya/dsps/server/webhook/outgoing"
 )
 
 func newChannelAtomByYaml(t *testing.T, yaml string, validate bool) *channelAtom {
 	yaml = fmt.Sprintf("channels:\n  - %s", strings.ReplaceAll(strings.ReplaceAll(yaml, "\t", "  "), "\n", "\n    "))
+	if strings.Contains(yaml, "clientCert:") {
+		// Client certificate authentication requires TLS client authentication configuration.
+		yaml += "\nhttp:\n  tls: { certFile: ../../clientcert/testdata/server.pem, keyFile: ../../clientcert/testdata/server-key.pem, clientAuth: { caFiles: [ ../../clientcert/testdata/ca.pem ] } }"
+	}
 	cfg, err := config.ParseConfig(context.Background(), config.Overrides{}, yaml)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(cfg.Channels))
@@ -24,6 +29,8 @@ func newChannelAtomByYaml(t *testing.T, yaml string, validate bool) *channelAtom
 		Clock:     domain.RealSystemClock,
 		Telemetry: telemetry.NewEmptyTelemetry(t),
 		Sentry:    sentry.NewEmptySentry(),
+
+		CircuitBreakers: outgoing.NewCircuitBreakerRegistry(domain.RealSystemClock, telemetry.NewEmptyTelemetry(t)),
 	}, validate)
 	assert.NoError(t, err)
 	return atom
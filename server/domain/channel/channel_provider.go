@@ -9,6 +9,7 @@ import (
 	"github.com/saiya/dsps/server/domain"
 	"github.com/saiya/dsps/server/sentry"
 	"github.com/saiya/dsps/server/telemetry"
+	"github.com/saiya/dsps/server/webhook/outgoing"
 )
 
 // ProviderDeps contains objects required by ChannelProvider
@@ -16,6 +17,8 @@ type ProviderDeps struct {
 	Clock     domain.SystemClock
 	Telemetry *telemetry.Telemetry
 	Sentry    sentry.Sentry
+
+	CircuitBreakers outgoing.CircuitBreakerRegistry
 }
 
 func (deps ProviderDeps) validateProviderDeps() error {
@@ -28,6 +31,9 @@ func (deps ProviderDeps) validateProviderDeps() error {
 	if deps.Sentry == nil {
 		return xerrors.Errorf("invalid ProviderDeps: Sentry should not be nil")
 	}
+	if deps.CircuitBreakers == nil {
+		return xerrors.Errorf("invalid ProviderDeps: CircuitBreakers should not be nil")
+	}
 	return nil
 }
 
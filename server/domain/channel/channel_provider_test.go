@@ -13,6 +13,7 @@ import (
 	"github.com/saiya/dsps/server/sentry"
 	"github.com/saiya/dsps/server/telemetry"
 	dspstesting "github.com/saiya/dsps/server/testing"
+	"github.com/saiya/dsps/server/webhook/outgoing"
 )
 
 func TestProvider(t *testing.T) {
@@ -23,6 +24,8 @@ func TestProvider(t *testing.T) {
 		Clock:     clock,
 		Telemetry: telemetry.NewEmptyTelemetry(t),
 		Sentry:    sentry.NewEmptySentry(),
+
+		CircuitBreakers: outgoing.NewCircuitBreakerRegistry(domain.RealSystemClock, telemetry.NewEmptyTelemetry(t)),
 	})
 	assert.NoError(t, err)
 
@@ -49,6 +52,8 @@ func TestJWTClockSkewLeewayMax(t *testing.T) {
 		Clock:     dspstesting.NewStubClock(t),
 		Telemetry: telemetry.NewEmptyTelemetry(t),
 		Sentry:    sentry.NewEmptySentry(),
+
+		CircuitBreakers: outgoing.NewCircuitBreakerRegistry(domain.RealSystemClock, telemetry.NewEmptyTelemetry(t)),
 	})
 	assert.NoError(t, err)
 	assert.Equal(t, domain.Duration{Duration: 15 * time.Minute}, cp.JWTClockSkewLeewayMax())
@@ -64,6 +69,8 @@ func TestValidateProviderDeps(t *testing.T) {
 		Clock:     domain.RealSystemClock,
 		Telemetry: telemetry.NewEmptyTelemetry(t),
 		Sentry:    sentry.NewEmptySentry(),
+
+		CircuitBreakers: outgoing.NewCircuitBreakerRegistry(domain.RealSystemClock, telemetry.NewEmptyTelemetry(t)),
 	}
 	assert.NoError(t, valid.validateProviderDeps())
 
@@ -78,4 +85,8 @@ func TestValidateProviderDeps(t *testing.T) {
 	invalid = valid
 	invalid.Sentry = nil
 	assert.Regexp(t, `invalid ProviderDeps: Sentry should not be nil`, invalid.validateProviderDeps())
+
+	invalid = valid
+	invalid.CircuitBreakers = nil
+	assert.Regexp(t, `invalid ProviderDeps: CircuitBreakers should not be nil`, invalid.validateProviderDeps())
 }
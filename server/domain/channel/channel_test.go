@@ -2,11 +2,19 @@ package channel_test
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/saiya/dsps/server/domain"
 	"github.com/saiya/dsps/server/domain/channel"
 	. "github.com/saiya/dsps/server/jwt/testing"
 )
@@ -21,6 +29,46 @@ func TestChannelExpire(t *testing.T) {
 	}).Expire().Duration)
 }
 
+func TestChannelRetention(t *testing.T) {
+	assert.Equal(t, domain.ChannelRetention{}, channel.NewChannelByAtomYamls(t, "test", []string{
+		`{ regex: '.+' }`,
+	}).Retention())
+	assert.Equal(t, domain.ChannelRetention{MaxMessages: 10, MaxBytes: 1024}, channel.NewChannelByAtomYamls(t, "test", []string{
+		`{ regex: '.+', maxMessages: 10, maxBytes: 1024 }`,
+	}).Retention())
+	assert.Equal(t, domain.ChannelRetention{MaxMessages: 10, MaxBytes: 1024, RejectOverflow: true}, channel.NewChannelByAtomYamls(t, "test", []string{
+		`{ regex: '.+', maxMessages: 10 }`,
+		`{ regex: '.+', maxMessages: 100, maxBytes: 1024, overflow: reject }`,
+		`{ regex: '.+', maxBytes: 2048 }`,
+	}).Retention())
+}
+
+func TestChannelMaxMessageBytes(t *testing.T) {
+	assert.Equal(t, int64(0), channel.NewChannelByAtomYamls(t, "test", []string{
+		`{ regex: '.+' }`,
+	}).MaxMessageBytes())
+	assert.Equal(t, int64(1024), channel.NewChannelByAtomYamls(t, "test", []string{
+		`{ regex: '.+', maxMessageBytes: 4096 }`,
+		`{ regex: '.+' }`,
+		`{ regex: '.+', maxMessageBytes: 1024 }`,
+	}).MaxMessageBytes())
+}
+
+func TestChannelRateLimits(t *testing.T) {
+	assert.Nil(t, channel.NewChannelByAtomYamls(t, "test", []string{
+		`{ regex: '.+' }`,
+	}).RateLimits())
+	assert.Equal(t, []domain.ChannelRateLimit{
+		{Scope: domain.RateLimitPerChannel, RateLimit: domain.RateLimit{Rate: 100, Per: domain.Duration{Duration: time.Minute}, Burst: 100}},
+		{Scope: domain.RateLimitPerSubject, RateLimit: domain.RateLimit{Rate: 1, Per: domain.Duration{Duration: time.Second}, Burst: 1}},
+		{Scope: domain.RateLimitPerIP, RateLimit: domain.RateLimit{Rate: 10, Per: domain.Duration{Duration: time.Second}, Burst: 20}},
+	}, channel.NewChannelByAtomYamls(t, "test", []string{
+		`{ regex: '.+', rateLimits: { channel: { rate: 100, per: 1m }, sub: { rate: 1 } } }`,
+		`{ regex: '.+' }`,
+		`{ regex: '.+', rateLimits: { ip: { rate: 10, burst: 20 } } }`,
+	}).RateLimits())
+}
+
 func TestJwtValidation(t *testing.T) {
 	ctx := context.Background()
 
@@ -74,3 +122,123 @@ func TestJwtValidation(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), `"iss" claim of the presented JWT ("https://example.com/issuer2") does not match with any of expected values`)
 }
+
+func TestIntrospectionValidation(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(fmt.Sprintf(`{ "active": %t, "chatroom": "1234" }`, r.FormValue("token") == "valid-token")))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	ch := channel.NewChannelByAtomYamls(t, "chat-room-1234", []string{
+		fmt.Sprintf(`{ regex: 'chat-room-(?P<id>\d+)', expire: '35m', introspection: { url: "%s", claims: { chatroom: '{{.channel.id}}' } } }`, server.URL),
+	})
+	assert.NoError(t, ch.ValidateJwt(ctx, "valid-token"))
+	err := ch.ValidateJwt(ctx, "invalid-token")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "presented token is not active")
+	err = ch.ValidateJwt(ctx, "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no token presented")
+}
+
+func TestClientCertValidation(t *testing.T) {
+	ctx := context.Background()
+	client1 := []*x509.Certificate{loadCert(t, "client1.pem")}
+	client2 := []*x509.Certificate{loadCert(t, "client2.pem")}
+
+	// No client certificate validation configured.
+	err := channel.NewChannelByAtomYamls(t, "test", []string{
+		`{ regex: '.+', expire: '35m' }`,
+	}).ValidateClientCert(ctx, client1)
+	assert.True(t, errors.Is(err, domain.ErrClientCertAuthNotConfigured))
+
+	ch := channel.NewChannelByAtomYamls(t, "client1", []string{
+		`{ regex: '(?P<id>.+)', expire: '35m', clientCert: { subject: { CN: [ '{{.channel.id}}.example.com' ] } } }`,
+		`{ regex: '.+', expire: '35m', clientCert: { subject: { O: [ 'Example Inc.' ] } } }`,
+	})
+	assert.NoError(t, ch.ValidateClientCert(ctx, client1))
+	err = ch.ValidateClientCert(ctx, client2)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `required client certificate subject "CN" to be [client1.example.com]`)
+	err = ch.ValidateClientCert(ctx, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no verified client certificate presented")
+
+	// Bearer token is not acceptable if only client certificate authentication is configured.
+	err = ch.ValidateJwt(ctx, "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "client certificate required")
+}
+
+func loadCert(t *testing.T, filename string) *x509.Certificate {
+	raw, err := ioutil.ReadFile("../../clientcert/testdata/" + filename)
+	assert.NoError(t, err)
+	block, _ := pem.Decode(raw)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	assert.NoError(t, err)
+	return cert
+}
+
+func TestAcceptsContentType(t *testing.T) {
+	// Only JSON is acceptable by default.
+	c := channel.NewChannelByAtomYamls(t, "test", []string{
+		`{ regex: '.+' }`,
+	})
+	assert.True(t, c.AcceptsContentType(""))
+	assert.True(t, c.AcceptsContentType("application/json"))
+	assert.True(t, c.AcceptsContentType("application/cloudevents+json"))
+	assert.False(t, c.AcceptsContentType("text/plain"))
+
+	c = channel.NewChannelByAtomYamls(t, "test", []string{
+		`{ regex: '.+', contentTypes: [ "application/x-protobuf", "text/*" ] }`,
+	})
+	assert.True(t, c.AcceptsContentType("application/x-protobuf"))
+	assert.True(t, c.AcceptsContentType("Application/X-Protobuf"))
+	assert.True(t, c.AcceptsContentType("text/plain"))
+	assert.True(t, c.AcceptsContentType("text/csv"))
+	assert.False(t, c.AcceptsContentType(""))
+	assert.False(t, c.AcceptsContentType("application/json"))
+	assert.False(t, c.AcceptsContentType("application/cbor"))
+
+	// Must be accepted by all configurations that restrict content types.
+	c = channel.NewChannelByAtomYamls(t, "test", []string{
+		`{ regex: '.+', contentTypes: [ "*/*" ] }`,
+		`{ regex: '.+', contentTypes: [ "application/json", "application/cbor" ] }`,
+		`{ regex: '.+' }`,
+	})
+	assert.True(t, c.AcceptsContentType(""))
+	assert.True(t, c.AcceptsContentType("application/cbor"))
+	assert.False(t, c.AcceptsContentType("text/plain"))
+}
+
+func TestValidateMessageContent(t *testing.T) {
+	ctx := context.Background()
+	msg := domain.Message{
+		MessageLocator: domain.MessageLocator{ChannelID: "test", MessageID: "msg-1"},
+		Content:        []byte(`{"text":"hello"}`),
+	}
+
+	// No schema configured
+	assert.NoError(t, channel.NewChannelByAtomYamls(t, "test", []string{
+		`{ regex: '.+' }`,
+	}).ValidateMessageContent(ctx, msg))
+
+	c := channel.NewChannelByAtomYamls(t, "test", []string{
+		`{ regex: '.+', schemaFile: '../../schema/testdata/chat-message.json', contentTypes: [ "application/json", "text/plain" ] }`,
+	})
+	err := c.ValidateMessageContent(ctx, msg)
+	assert.True(t, errors.Is(err, domain.ErrMessageSchemaViolation))
+	var violation *domain.MessageSchemaViolation
+	assert.True(t, errors.As(err, &violation))
+	assert.Equal(t, []string{`#: missing properties: "user"`}, violation.Errors)
+
+	msg.Content = []byte(`{"text":"hello","user":{"name":"alice"}}`)
+	assert.NoError(t, c.ValidateMessageContent(ctx, msg))
+
+	// Schema is not applicable to non-JSON content
+	msg.Content, msg.ContentType = domain.NewBinaryContent([]byte("hello")), "text/plain"
+	assert.NoError(t, c.ValidateMessageContent(ctx, msg))
+}
@@ -0,0 +1,92 @@
+package channel
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+)
+
+// ReloadableChannelProvider is ChannelProvider that can replace channel configuration at runtime.
+type ReloadableChannelProvider interface {
+	domain.ChannelProvider
+
+	// Reload builds channels from given configuration and swaps with current ones.
+	// If failed, keeps current channels and returns error.
+	// Previous channels are shutdown after retireDelay, because in-flight requests may still use them.
+	Reload(ctx context.Context, config *config.ServerConfig, retireDelay time.Duration) error
+}
+
+// NewReloadableChannelProvider initializes ReloadableChannelProvider
+func NewReloadableChannelProvider(ctx context.Context, config *config.ServerConfig, deps ProviderDeps) (ReloadableChannelProvider, error) {
+	current, err := NewChannelProvider(ctx, config, deps)
+	if err != nil {
+		return nil, err
+	}
+	return &reloadableChannelProvider{
+		deps:    deps,
+		current: current,
+		retired: make(map[domain.ChannelProvider]*time.Timer),
+	}, nil
+}
+
+type reloadableChannelProvider struct {
+	deps ProviderDeps
+
+	lock    sync.RWMutex
+	current domain.ChannelProvider
+	retired map[domain.ChannelProvider]*time.Timer // Previous providers waiting for shutdown
+}
+
+func (p *reloadableChannelProvider) Get(id domain.ChannelID) (domain.Channel, error) {
+	return p.getCurrent().Get(id)
+}
+
+func (p *reloadableChannelProvider) GetFileDescriptorPressure() int {
+	return p.getCurrent().GetFileDescriptorPressure()
+}
+
+func (p *reloadableChannelProvider) JWTClockSkewLeewayMax() domain.Duration {
+	return p.getCurrent().JWTClockSkewLeewayMax()
+}
+
+func (p *reloadableChannelProvider) getCurrent() domain.ChannelProvider {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.current
+}
+
+func (p *reloadableChannelProvider) Reload(ctx context.Context, config *config.ServerConfig, retireDelay time.Duration) error {
+	next, err := NewChannelProvider(ctx, config, p.deps)
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	prev := p.current
+	p.current = next
+	p.retired[prev] = time.AfterFunc(retireDelay, func() {
+		p.lock.Lock()
+		_, ok := p.retired[prev]
+		delete(p.retired, prev)
+		p.lock.Unlock()
+		if ok {
+			prev.Shutdown(context.Background())
+		}
+	})
+	return nil
+}
+
+func (p *reloadableChannelProvider) Shutdown(ctx context.Context) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for prev, timer := range p.retired {
+		timer.Stop()
+		prev.Shutdown(ctx)
+	}
+	p.retired = make(map[domain.ChannelProvider]*time.Timer)
+	p.current.Shutdown(ctx)
+}
@@ -0,0 +1,90 @@
+package channel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/sentry"
+	"github.com/saiya/dsps/server/telemetry"
+	dspstesting "github.com/saiya/dsps/server/testing"
+	"github.com/saiya/dsps/server/webhook/outgoing"
+)
+
+func TestReloadableProvider(t *testing.T) {
+	ctx := context.Background()
+	cfg, err := config.ParseConfig(ctx, config.Overrides{}, `channels: [ { regex: "test.+", expire: "1s" } ]`)
+	assert.NoError(t, err)
+	cp, err := NewReloadableChannelProvider(ctx, &cfg, ProviderDeps{
+		Clock:     dspstesting.NewStubClock(t),
+		Telemetry: telemetry.NewEmptyTelemetry(t),
+		Sentry:    sentry.NewEmptySentry(),
+
+		CircuitBreakers: outgoing.NewCircuitBreakerRegistry(domain.RealSystemClock, telemetry.NewEmptyTelemetry(t)),
+	})
+	assert.NoError(t, err)
+	defer cp.Shutdown(ctx)
+
+	test1, err := cp.Get("test1")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, test1.Expire().Duration)
+	_, err = cp.Get("other1")
+	dspstesting.IsError(t, domain.ErrInvalidChannel, err)
+
+	newCfg, err := config.ParseConfig(ctx, config.Overrides{}, `channels: [ { regex: "other.+", expire: "5s" }, { regex: "test.+", expire: "3s" } ]`)
+	assert.NoError(t, err)
+	assert.NoError(t, cp.Reload(ctx, &newCfg, time.Hour))
+	test1, err = cp.Get("test1")
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, test1.Expire().Duration)
+	other1, err := cp.Get("other1")
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Second, other1.Expire().Duration)
+	assert.Equal(t, 1, len(cp.(*reloadableChannelProvider).retired))
+
+	// Invalid configuration keeps current channels
+	invalidCfg, err := config.ParseConfig(ctx, config.Overrides{}, `channels: [ { regex: "test.+", webhooks: [ { url: "http://localhost/{{.channel.undefined}}" } ] } ]`)
+	assert.NoError(t, err)
+	assert.Error(t, cp.Reload(ctx, &invalidCfg, time.Hour))
+	test1, err = cp.Get("test1")
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, test1.Expire().Duration)
+
+	// Schema file is also loaded on reload
+	invalidCfg, err = config.ParseConfig(ctx, config.Overrides{}, `channels: [ { regex: "test.+", schemaFile: "../../schema/testdata/no-such-file.json" } ]`)
+	assert.NoError(t, err)
+	assert.Regexp(t, `failed to load JSON Schema file`, cp.Reload(ctx, &invalidCfg, time.Hour).Error())
+	schemaCfg, err := config.ParseConfig(ctx, config.Overrides{}, `channels: [ { regex: "test.+", schemaFile: "../../schema/testdata/chat-message.json" } ]`)
+	assert.NoError(t, err)
+	assert.NoError(t, cp.Reload(ctx, &schemaCfg, time.Hour))
+	test1, err = cp.Get("test1")
+	assert.NoError(t, err)
+	dspstesting.IsError(t, domain.ErrMessageSchemaViolation, test1.ValidateMessageContent(ctx, domain.Message{Content: []byte(`{}`)}))
+}
+
+func TestReloadableProviderRetire(t *testing.T) {
+	ctx := context.Background()
+	cfg, err := config.ParseConfig(ctx, config.Overrides{}, `channels: [ { regex: "test.+", expire: "1s" } ]`)
+	assert.NoError(t, err)
+	cp, err := NewReloadableChannelProvider(ctx, &cfg, ProviderDeps{
+		Clock:     dspstesting.NewStubClock(t),
+		Telemetry: telemetry.NewEmptyTelemetry(t),
+		Sentry:    sentry.NewEmptySentry(),
+
+		CircuitBreakers: outgoing.NewCircuitBreakerRegistry(domain.RealSystemClock, telemetry.NewEmptyTelemetry(t)),
+	})
+	assert.NoError(t, err)
+	defer cp.Shutdown(ctx)
+
+	assert.NoError(t, cp.Reload(ctx, &cfg, time.Millisecond))
+	assert.Eventually(t, func() bool {
+		impl := cp.(*reloadableChannelProvider)
+		impl.lock.RLock()
+		defer impl.lock.RUnlock()
+		return len(impl.retired) == 0
+	}, 3*time.Second, 5*time.Millisecond)
+}
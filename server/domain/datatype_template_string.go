@@ -23,8 +23,17 @@ func NewTemplateString(value string) (TemplateString, error) {
 	return result, result.init(value)
 }
 
+// Functions available in templates
+var templateFuncs = template.FuncMap{
+	// Encodes given value as JSON, e.g. {"text": {{json .message.content.text}}}
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
 func (tpl *TemplateString) init(value string) error {
-	parsed, err := template.New("template-string").Option("missingkey=error").Parse(value)
+	parsed, err := template.New("template-string").Option("missingkey=error").Funcs(templateFuncs).Parse(value)
 	if err != nil {
 		return xerrors.Errorf("Unable to parse Template \"%s\" %w", value, err)
 	}
@@ -58,3 +58,12 @@ func TestTemplateStringExecuteError(t *testing.T) {
 	_, err = tpl.Execute(map[string]string{"channel": "not-map"})
 	assert.Regexp(t, `executing "template-string" at <.channel.id>: can't evaluate field id in type string`, err.Error())
 }
+
+func TestTemplateStringJsonFunc(t *testing.T) {
+	tpl, err := NewTemplateString(`{"text":{{json .text}},"list":{{json .list}}}`)
+	assert.NoError(t, err)
+
+	result, err := tpl.Execute(map[string]interface{}{"text": "say \"hi\"", "list": []int{1, 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"text":"say \"hi\"","list":[1,2]}`, result)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
 )
 
 // MessageID is ID of the message, unique within channel
@@ -18,7 +19,81 @@ type MessageLocator struct {
 // Message is an atomic datagram of the PubSub communication
 type Message struct {
 	MessageLocator
+	// JSON content, or base64 encoded JSON string if ContentType is not JSON (see IsJSON).
 	Content json.RawMessage
+	// Media type of the content (e.g. "application/x-protobuf"), empty means "application/json".
+	ContentType string
+
+	// Metadata of the message (e.g. content type, producer, schema version), nil if none.
+	Metadata map[string]string
+
+	// TraceContext of the publisher, to link tracing spans of subscribers to the publisher's one.
+	TraceContext TraceContext
+
+	// Storage discards the message after this time, zero means channel expiration (Channel.Expire) only.
+	ExpireAt Time
+	// Storage hides the message from subscribers until this time, zero means immediately.
+	DeliverAt Time
+}
+
+// ContentTypeJSON is the default content type of messages
+const ContentTypeJSON = "application/json"
+
+// IsJSONContentType returns true if given media type is JSON (e.g. "application/json", "application/cloudevents+json")
+func IsJSONContentType(mediaType string) bool {
+	return mediaType == "" || mediaType == ContentTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+// NewBinaryContent returns Message.Content of non-JSON content
+func NewBinaryContent(data []byte) json.RawMessage {
+	encoded, _ := json.Marshal(data) // Encodes []byte as base64 string, never fails
+	return encoded
+}
+
+// MediaType returns media type of the content
+func (msg Message) MediaType() string {
+	if msg.ContentType == "" {
+		return ContentTypeJSON
+	}
+	return msg.ContentType
+}
+
+// IsJSON returns true if the content is JSON, otherwise Content is base64 encoded JSON string.
+func (msg Message) IsJSON() bool {
+	return IsJSONContentType(msg.ContentType)
+}
+
+// RawContent returns content bytes, decodes base64 if the content is not JSON.
+func (msg Message) RawContent() ([]byte, error) {
+	if msg.IsJSON() {
+		return msg.Content, nil
+	}
+	var data []byte
+	if err := json.Unmarshal(msg.Content, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode %s content: %w", msg.ContentType, err)
+	}
+	return data, nil
+}
+
+// IsExpired returns true if the message has its own expiration time and it has passed.
+func (msg Message) IsExpired(now Time) bool {
+	return !msg.ExpireAt.IsZero() && !now.Before(msg.ExpireAt.Time)
+}
+
+// IsScheduled returns true if the message should not be delivered yet.
+func (msg Message) IsScheduled(now Time) bool {
+	return !msg.DeliverAt.IsZero() && now.Before(msg.DeliverAt.Time)
+}
+
+// TraceContext is W3C Trace Context (https://www.w3.org/TR/trace-context/) captured when the message was published
+type TraceContext struct {
+	TraceParent string // "traceparent" value, empty if not traced
+	TraceState  string // "tracestate" value, could be empty
+}
+
+// IsEmpty returns true if no trace context present
+func (tc TraceContext) IsEmpty() bool {
+	return tc.TraceParent == "" && tc.TraceState == ""
 }
 
 // see: doc/interface/validation_rule.md
@@ -32,6 +107,32 @@ func ParseMessageID(str string) (MessageID, error) {
 	return MessageID(str), nil
 }
 
+// see: doc/interface/validation_rule.md
+var messageMetadataKeyRegexp = regexp.MustCompile("^[0-9a-z][0-9a-z_.-]{0,62}$")
+
+const (
+	// MaxMessageMetadataEntries is maximum number of metadata entries of a message
+	MaxMessageMetadataEntries = 32
+	// MaxMessageMetadataValueLength is maximum length of metadata value in bytes
+	MaxMessageMetadataValueLength = 1024
+)
+
+// ValidateMessageMetadata checks message metadata
+func ValidateMessageMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxMessageMetadataEntries {
+		return fmt.Errorf("Message metadata must not have more than %d entries", MaxMessageMetadataEntries)
+	}
+	for key, value := range metadata {
+		if !messageMetadataKeyRegexp.MatchString(key) {
+			return fmt.Errorf("Message metadata key \"%s\" must match with %s", key, messageMetadataKeyRegexp.String())
+		}
+		if len(value) > MaxMessageMetadataValueLength {
+			return fmt.Errorf("Message metadata value of \"%s\" must not be longer than %d bytes", key, MaxMessageMetadataValueLength)
+		}
+	}
+	return nil
+}
+
 // BelongsToSameChannel returns false if messages belongs to various channels
 func BelongsToSameChannel(msgs []Message) bool {
 	if len(msgs) == 0 {
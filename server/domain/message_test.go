@@ -2,6 +2,8 @@ package domain_test
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 
 	. "github.com/saiya/dsps/server/domain"
@@ -22,6 +24,46 @@ func TestParseMessageID(t *testing.T) {
 	assert.Errorf(t, err, errorMsg)
 }
 
+func TestValidateMessageMetadata(t *testing.T) {
+	assert.NoError(t, ValidateMessageMetadata(nil))
+	assert.NoError(t, ValidateMessageMetadata(map[string]string{"content-type": "application/json", "schema.version": "2", "producer_id": ""}))
+
+	assert.EqualError(t, ValidateMessageMetadata(map[string]string{"Content-Type": "x"}), `Message metadata key "Content-Type" must match with ^[0-9a-z][0-9a-z_.-]{0,62}$`)
+	assert.EqualError(t, ValidateMessageMetadata(map[string]string{"key": strings.Repeat("x", 1025)}), `Message metadata value of "key" must not be longer than 1024 bytes`)
+
+	tooMany := map[string]string{}
+	for i := 0; i < 33; i++ {
+		tooMany[fmt.Sprintf("key-%d", i)] = "value"
+	}
+	assert.EqualError(t, ValidateMessageMetadata(tooMany), `Message metadata must not have more than 32 entries`)
+}
+
+func TestMessageContent(t *testing.T) {
+	assert.True(t, IsJSONContentType(""))
+	assert.True(t, IsJSONContentType("application/json"))
+	assert.True(t, IsJSONContentType("application/cloudevents+json"))
+	assert.False(t, IsJSONContentType("text/plain"))
+
+	msg := Message{Content: json.RawMessage(`{"hi":"hello"}`)}
+	assert.True(t, msg.IsJSON())
+	assert.Equal(t, "application/json", msg.MediaType())
+	raw, err := msg.RawContent()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"hi":"hello"}`, string(raw))
+
+	msg = Message{Content: NewBinaryContent([]byte{0x08, 0x96, 0x01}), ContentType: "application/x-protobuf"}
+	assert.Equal(t, `"CJYB"`, string(msg.Content))
+	assert.False(t, msg.IsJSON())
+	assert.Equal(t, "application/x-protobuf", msg.MediaType())
+	raw, err = msg.RawContent()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x08, 0x96, 0x01}, raw)
+
+	msg = Message{Content: json.RawMessage(`{}`), ContentType: "text/plain"}
+	_, err = msg.RawContent()
+	assert.Contains(t, err.Error(), "failed to decode text/plain content")
+}
+
 func TestBelongsToSameChannel(t *testing.T) {
 	assert.True(t, BelongsToSameChannel([]Message{}))
 	assert.True(t, BelongsToSameChannel([]Message{
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// RateLimit is a token bucket, bucket holds Burst tokens at most and Rate tokens are refilled in each Per duration.
+type RateLimit struct {
+	Rate  int
+	Per   Duration
+	Burst int
+}
+
+// Interval returns duration to refill one token
+func (limit RateLimit) Interval() time.Duration {
+	return limit.Per.Duration / time.Duration(limit.Rate)
+}
+
+func (limit RateLimit) String() string {
+	return fmt.Sprintf("%d/%s(burst:%d)", limit.Rate, limit.Per.Duration, limit.Burst)
+}
+
+// RateLimitScope is a type of the key of rate limit buckets
+type RateLimitScope string
+
+// Scopes of rate limit buckets
+const (
+	// All clients of the channel share a bucket.
+	RateLimitPerChannel RateLimitScope = "channel"
+	// Each client IP address of the channel has a bucket.
+	RateLimitPerIP RateLimitScope = "ip"
+	// Each JWT subject ("sub" claim) of the channel has a bucket.
+	RateLimitPerSubject RateLimitScope = "sub"
+)
+
+// ChannelRateLimit is a rate limit of requests to a channel
+type ChannelRateLimit struct {
+	Scope RateLimitScope
+	RateLimit
+}
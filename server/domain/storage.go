@@ -17,11 +17,16 @@ var (
 	ErrMalformedAckHandle = NewErrorWithCode("dsps.storage.ack-handle-malformed")
 	// ErrMalformedMessageJSON : Given message content is not valid JSON
 	ErrMalformedMessageJSON = NewErrorWithCode("dsps.storage.message-json-malformed")
+	// ErrChannelMaxMessagesExceeded : Channel rejected the message because it retains maxMessages messages
+	ErrChannelMaxMessagesExceeded = NewErrorWithCode("dsps.storage.channel-max-messages-exceeded")
+	// ErrChannelMaxBytesExceeded : Channel rejected the message because it exceeds maxBytes
+	ErrChannelMaxBytesExceeded = NewErrorWithCode("dsps.storage.channel-max-bytes-exceeded")
 )
 
 // IsStorageNonFatalError returns true if given error does not indicate storage system error
 func IsStorageNonFatalError(err error) bool {
-	return errors.Is(err, ErrInvalidChannel) || errors.Is(err, ErrSubscriptionNotFound) || errors.Is(err, ErrMalformedAckHandle)
+	return errors.Is(err, ErrInvalidChannel) || errors.Is(err, ErrSubscriptionNotFound) || errors.Is(err, ErrMalformedAckHandle) ||
+		errors.Is(err, ErrChannelMaxMessagesExceeded) || errors.Is(err, ErrChannelMaxBytesExceeded)
 }
 
 //go:generate mockgen -source=${GOFILE} -package=mock -destination=./mock/${GOFILE}
@@ -40,6 +45,10 @@ type Storage interface {
 	AsPubSubStorage() PubSubStorage
 	// Retruns nil if neither supported nor supported.
 	AsJwtStorage() JwtStorage
+	// Retruns nil if neither supported nor supported.
+	AsWebhookStorage() WebhookStorage
+	// Retruns nil if neither supported nor supported.
+	AsRateLimitStorage() RateLimitStorage
 
 	// Estimated maximum pressure of syscall.RLIMIT_NOFILE
 	GetFileDescriptorPressure() int
@@ -65,3 +74,29 @@ type JwtStorage interface {
 	RevokeJwt(ctx context.Context, exp JwtExp, jti JwtJti) error
 	IsRevokedJwt(ctx context.Context, jti JwtJti) (bool, error)
 }
+
+// WebhookStorage interface is an abstraction layer of outgoing-webhook delivery queue implementations
+type WebhookStorage interface {
+	// Persists given deliveries. If delivery of the same message already exists, overwrites it.
+	EnqueueWebhookDeliveries(ctx context.Context, deliveries []WebhookDelivery) error
+	// Returns deliveries whose NextAttemptAt has come, and hides them from subsequent calls until the lease expires.
+	// Caller should complete or retry the deliveries before lease expiration, otherwise storage returns them again.
+	LeaseWebhookDeliveries(ctx context.Context, max int, lease Duration) ([]WebhookDelivery, error)
+	// Removes the delivery from the queue. Does nothing if not found.
+	CompleteWebhookDelivery(ctx context.Context, msg MessageLocator) error
+	// Updates the delivery and returns it to the queue. Does nothing if not found (e.g. completed by other process).
+	RetryWebhookDelivery(ctx context.Context, delivery WebhookDelivery) error
+
+	// Appends the record to the delivery history of the channel.
+	// Storage retains at most maxRecords latest records for each channel, and discards the history if no record appended within retention.
+	RecordWebhookDelivery(ctx context.Context, record WebhookDeliveryRecord, maxRecords int, retention Duration) error
+	// Returns delivery history of the channel, latest first.
+	ListWebhookDeliveryRecords(ctx context.Context, channelID ChannelID, max int) ([]WebhookDeliveryRecord, error)
+}
+
+// RateLimitStorage interface is an abstraction layer of token bucket implementations
+type RateLimitStorage interface {
+	// Takes a token from the bucket of the key, returns zero if taken.
+	// If the bucket is empty, does not take a token and returns duration until a token is refilled.
+	TakeRateLimitToken(ctx context.Context, key string, limit RateLimit) (Duration, error)
+}
@@ -0,0 +1,53 @@
+package domain
+
+// WebhookDelivery is a pending outgoing-webhook delivery of a message.
+// Delivery is identified by MessageLocator of the message.
+type WebhookDelivery struct {
+	Message
+
+	// Count of failed attempts.
+	Attempts int
+	// Storage does not return this delivery until this time.
+	NextAttemptAt Time
+	// Error message of the last failed attempt, empty if not attempted yet.
+	LastError string
+}
+
+// WebhookDeliveryResult is result of an outgoing-webhook delivery attempt.
+type WebhookDeliveryResult string
+
+// Results of delivery attempt
+const (
+	// Delivered to all webhooks of the channel.
+	WebhookDeliverySucceeded WebhookDeliveryResult = "succeeded"
+	// Failed, will be retried later.
+	WebhookDeliveryRetrying WebhookDeliveryResult = "retrying"
+	// Failed and reached to max attempts, will not be retried.
+	WebhookDeliveryGaveUp WebhookDeliveryResult = "gave-up"
+)
+
+// WebhookDeliveryRecord is a history record of an outgoing-webhook delivery attempt of a message.
+type WebhookDeliveryRecord struct {
+	Message
+
+	// 1-origin count of this attempt.
+	Attempt     int
+	Result      WebhookDeliveryResult
+	AttemptedAt Time
+	// Time taken by the attempt, including retries within the attempt.
+	Latency Duration
+	// Error message of the attempt, empty if succeeded.
+	Error string
+	// HTTP requests sent in the attempt.
+	Requests []WebhookRequestRecord
+}
+
+// WebhookRequestRecord is a history record of an outgoing-webhook HTTP request.
+type WebhookRequestRecord struct {
+	URL string
+	// 0 if no response received (e.g. connection failure, skipped by circuit breaker).
+	StatusCode int
+	Latency    Duration
+	// Error message of the request, empty if succeeded.
+	Error string
+}
@@ -10,27 +10,50 @@ import (
 	"github.com/saiya/dsps/server/logger"
 )
 
-// InitEndpoints registers endpoints of the DSPS server
+// InitEndpoints registers endpoints of the DSPS server.
+// If admin listener is configured, admin and probe endpoints are not registered (see InitAdminEndpoints).
 func InitEndpoints(mainCtx context.Context, rt *router.Router, deps *ServerDependencies) {
-	endpoints.InitProbeEndpoints(rt, deps)
-
-	adminRouter := rt.NewGroup("/admin", middleware.NewAdminAuth(mainCtx, deps))
-	endpoints.InitAdminJwtEndpoints(adminRouter, deps)
-	endpoints.InitAdminLoggingEndpoints(adminRouter, deps)
+	if deps.Config.Admin.Listen == "" {
+		InitAdminEndpoints(mainCtx, rt, deps)
+	}
+	if !deps.Config.Telemetry.OT.Exporters.Prometheus.Admin {
+		endpoints.InitMetricsEndpoints(rt, deps)
+	}
 
+	channelOf := func(c context.Context, args router.MiddlewareArgs) (domain.Channel, error) {
+		id, err := domain.ParseChannelID(args.PS.ByName("channelID"))
+		if err != nil {
+			return nil, err
+		}
+		return deps.ChannelProvider.Get(id)
+	}
 	channelRouter := rt.NewGroup(
 		"/channel/:channelID",
 		router.AsMiddlewareFunc(func(ctx context.Context, args router.MiddlewareArgs, next func(context.Context, router.MiddlewareArgs)) {
 			next(logger.WithAttributes(ctx).WithStr("channelID", args.PS.ByName("channelID")).Build(), args)
 		}),
-		middleware.NewNormalAuth(mainCtx, deps, func(c context.Context, args router.MiddlewareArgs) (domain.Channel, error) {
-			id, err := domain.ParseChannelID(args.PS.ByName("channelID"))
-			if err != nil {
-				return nil, err
-			}
-			return deps.ChannelProvider.Get(id)
-		}),
+		middleware.NewPreAuthRateLimit(deps, channelOf),
+		middleware.NewNormalAuth(mainCtx, deps, channelOf),
+		middleware.NewPostAuthRateLimit(deps, channelOf),
 	)
 	endpoints.InitPublishEndpoints(channelRouter, deps)
 	endpoints.InitSubscriptionPollingEndpoints(channelRouter, deps)
+
+	// Incoming webhook requests are authenticated by signature rather than channel auth.
+	endpoints.InitIncomingWebhookEndpoints(rt, deps)
+}
+
+// InitAdminEndpoints registers admin and probe endpoints of the DSPS server
+func InitAdminEndpoints(mainCtx context.Context, rt *router.Router, deps *ServerDependencies) {
+	endpoints.InitProbeEndpoints(rt, deps)
+
+	adminRouter := rt.NewGroup("/admin", middleware.NewAdminAuth(mainCtx, deps))
+	endpoints.InitAdminJwtEndpoints(adminRouter, deps)
+	endpoints.InitAdminLoggingEndpoints(adminRouter, deps)
+	endpoints.InitAdminWebhookEndpoints(adminRouter, deps)
+	endpoints.InitAdminWebhookDeliveryEndpoints(adminRouter, deps)
+
+	if deps.Config.Telemetry.OT.Exporters.Prometheus.Admin {
+		endpoints.InitMetricsEndpoints(adminRouter, deps)
+	}
 }
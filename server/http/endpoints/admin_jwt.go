@@ -2,16 +2,29 @@ package endpoints
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
 
 	"github.com/saiya/dsps/server/domain"
 	"github.com/saiya/dsps/server/http/router"
 	"github.com/saiya/dsps/server/http/utils"
+	"github.com/saiya/dsps/server/jwt/issuer"
 )
 
 // AdminJwtEndpointDependency is to inject required objects to the endpoint
 type AdminJwtEndpointDependency interface {
 	GetStorage() domain.Storage
 	GetChannelProvider() domain.ChannelProvider
+	GetJwtIssuer() issuer.Issuer
+	RequestBodyDependency
+}
+
+type issueJwtRequest struct {
+	Channel    string                 `json:"channel"`
+	Subscriber string                 `json:"subscriber"`
+	ExpireIn   *domain.Duration       `json:"expireIn"`
+	Claims     map[string]interface{} `json:"claims"`
 }
 
 // InitAdminJwtEndpoints registers endpoints
@@ -48,4 +61,60 @@ func InitAdminJwtEndpoints(adminRouter *router.Router, deps AdminJwtEndpointDepe
 			"exp": exp.Int64(),
 		})
 	})
+	adminRouter.POST("/jwt/issue", func(ctx context.Context, args router.HandlerArgs) {
+		jwtIssuer := deps.GetJwtIssuer()
+		if jwtIssuer == nil {
+			utils.SendJwtIssuerUnavailableError(ctx, args.W)
+			return
+		}
+
+		var req issueJwtRequest
+		body, ok := readRequestBody(ctx, args, deps, 0)
+		if !ok {
+			return
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			utils.SendError(ctx, args.W, http.StatusBadRequest, "Request body is not valid JSON", err)
+			return
+		}
+
+		channelID, err := domain.ParseChannelID(req.Channel)
+		if err != nil {
+			utils.SendInvalidParameter(ctx, args.W, "channel", err)
+			return
+		}
+		if _, err := cp.Get(channelID); err != nil {
+			utils.SendInvalidParameter(ctx, args.W, "channel", err)
+			return
+		}
+		var subscriberID domain.SubscriberID
+		if req.Subscriber != "" {
+			subscriberID, err = domain.ParseSubscriberID(req.Subscriber)
+			if err != nil {
+				utils.SendInvalidParameter(ctx, args.W, "subscriber", err)
+				return
+			}
+		}
+
+		issued, err := jwtIssuer.Issue(ctx, issuer.Request{
+			ChannelID:    channelID,
+			SubscriberID: subscriberID,
+			ExpireIn:     req.ExpireIn,
+			Claims:       req.Claims,
+		})
+		if err != nil {
+			if errors.Is(err, issuer.ErrInvalidRequest) {
+				utils.SendError(ctx, args.W, http.StatusBadRequest, "Could not issue JWT with given parameters", err)
+			} else {
+				utils.SendInternalServerError(ctx, args.W, err)
+			}
+			return
+		}
+
+		utils.SendJSON(ctx, args.W, 200, map[string]interface{}{
+			"jwt": issued.JWT,
+			"jti": issued.Jti,
+			"exp": issued.Exp.Int64(),
+		})
+	})
 }
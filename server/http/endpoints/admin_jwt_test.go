@@ -14,6 +14,7 @@ import (
 	. "github.com/saiya/dsps/server/domain/mock"
 	. "github.com/saiya/dsps/server/http"
 	. "github.com/saiya/dsps/server/http/testing"
+	"github.com/saiya/dsps/server/jwt/issuer"
 )
 
 func TestJwtRevokeWithoutPubSubSupport(t *testing.T) {
@@ -22,6 +23,8 @@ func TestJwtRevokeWithoutPubSubSupport(t *testing.T) {
 	storage := NewMockStorage(ctrl)
 	storage.EXPECT().AsPubSubStorage().Return(nil).AnyTimes()
 	storage.EXPECT().AsJwtStorage().Return(nil).AnyTimes()
+	storage.EXPECT().AsWebhookStorage().Return(nil).AnyTimes()
+	storage.EXPECT().AsRateLimitStorage().Return(nil).AnyTimes()
 
 	WithServer(t, `logging: category: "*": FATAL`, func(deps *ServerDependencies) {
 		deps.Storage = storage
@@ -107,3 +110,72 @@ func TestJwtRevokeFailure(t *testing.T) {
 		AssertInternalServerErrorResponse(t, res)
 	})
 }
+
+func TestJwtIssueWithoutIssuerConfig(t *testing.T) {
+	WithServer(t, `logging: category: "*": FATAL`, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		res := DoHTTPRequestWithHeaders(t, "POST", baseURL+"/admin/jwt/issue", AdminAuthHeaders(t, deps), `{ "channel": "my-channel" }`)
+		AssertErrorResponse(t, res, 501, nil, `JWT issuer is not configured`)
+	})
+}
+
+func TestJwtIssueSuccess(t *testing.T) {
+	ctx := context.Background()
+	configYaml := `{
+		logging: { category: { "*": FATAL } },
+		admin: { jwtIssuer: { iss: "https://issuer.example.com/issuer-url", alg: HS256, keyFile: "../../jwt/testdata/HS256.rand", maxExpire: 2h } },
+		channels: [ { regex: "test-(?P<id>.+)", expire: "1s", jwt: { iss: [ "https://issuer.example.com/issuer-url" ], keys: { HS256: [ "../../jwt/testdata/HS256.rand" ] }, claims: { channel: "test-{{.channel.id}}", role: "admin" } } } ]
+	}`
+	WithServer(t, configYaml, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		res := DoHTTPRequestWithHeaders(t, "POST", baseURL+"/admin/jwt/issue", AdminAuthHeaders(t, deps), `{ "channel": "test-1", "subscriber": "sbsc-1", "expireIn": "1h30m", "claims": { "role": "admin" } }`)
+		body := AssertResponseJSON(t, res, 200, map[string]interface{}{})
+		jwt, jti := body["jwt"].(string), body["jti"].(string)
+		assert.NotEmpty(t, jwt)
+		assert.NotEmpty(t, jti)
+		assert.InDelta(t, float64(time.Now().Add(90*time.Minute).Unix()), body["exp"], 5)
+
+		// Issued JWT should be accepted by the channel
+		res = DoHTTPRequestWithHeaders(t, "PUT", baseURL+"/channel/test-1/subscription/polling/sbsc-1", map[string]string{"Authorization": "Bearer " + jwt}, ``)
+		assert.Equal(t, 200, res.StatusCode)
+		assert.NoError(t, res.Body.Close())
+
+		// ...but not by other channels
+		res = DoHTTPRequestWithHeaders(t, "PUT", baseURL+"/channel/test-2/subscription/polling/sbsc-1", map[string]string{"Authorization": "Bearer " + jwt}, ``)
+		assert.Equal(t, 403, res.StatusCode)
+		assert.NoError(t, res.Body.Close())
+
+		// Issued JWT should be revocable
+		res = DoHTTPRequestWithHeaders(t, "PUT", baseURL+fmt.Sprintf("/admin/jwt/revoke?jti=%s&exp=%.0f", jti, body["exp"]), AdminAuthHeaders(t, deps), ``)
+		assert.Equal(t, 200, res.StatusCode)
+		assert.NoError(t, res.Body.Close())
+		revoked, err := deps.Storage.AsJwtStorage().IsRevokedJwt(ctx, domain.JwtJti(jti))
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+	})
+}
+
+func TestJwtIssueFailure(t *testing.T) {
+	configYaml := `{
+		logging: { category: { "*": FATAL } },
+		admin: { jwtIssuer: { iss: "https://issuer.example.com/issuer-url", alg: HS256, keyFile: "../../jwt/testdata/HS256.rand" } },
+		channels: [ { regex: "test-.+", expire: "1s" } ]
+	}`
+	WithServer(t, configYaml, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		res := DoHTTPRequestWithHeaders(t, "POST", baseURL+"/admin/jwt/issue", AdminAuthHeaders(t, deps), `{ "channel": `)
+		AssertErrorResponse(t, res, 400, nil, `Request body is not valid JSON`)
+
+		res = DoHTTPRequestWithHeaders(t, "POST", baseURL+"/admin/jwt/issue", AdminAuthHeaders(t, deps), `{}`)
+		AssertErrorResponse(t, res, 400, nil, `Invalid "channel" parameter`)
+
+		res = DoHTTPRequestWithHeaders(t, "POST", baseURL+"/admin/jwt/issue", AdminAuthHeaders(t, deps), `{ "channel": "not-test-1" }`)
+		AssertErrorResponse(t, res, 400, domain.ErrInvalidChannel, `Invalid "channel" parameter`)
+
+		res = DoHTTPRequestWithHeaders(t, "POST", baseURL+"/admin/jwt/issue", AdminAuthHeaders(t, deps), `{ "channel": "test-1", "subscriber": "INVALID ID" }`)
+		AssertErrorResponse(t, res, 400, nil, `Invalid "subscriber" parameter`)
+
+		res = DoHTTPRequestWithHeaders(t, "POST", baseURL+"/admin/jwt/issue", AdminAuthHeaders(t, deps), `{ "channel": "test-1", "expireIn": "48h" }`)
+		AssertErrorResponse(t, res, 400, issuer.ErrInvalidRequest, `Could not issue JWT with given parameters`)
+
+		res = DoHTTPRequestWithHeaders(t, "POST", baseURL+"/admin/jwt/issue", AdminAuthHeaders(t, deps), `{ "channel": "test-1", "claims": { "exp": 1 } }`)
+		AssertErrorResponse(t, res, 400, issuer.ErrInvalidRequest, `Could not issue JWT with given parameters`)
+	})
+}
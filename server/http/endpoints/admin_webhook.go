@@ -0,0 +1,37 @@
+package endpoints
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/saiya/dsps/server/http/router"
+	"github.com/saiya/dsps/server/http/utils"
+	"github.com/saiya/dsps/server/webhook/outgoing"
+)
+
+// AdminWebhookEndpointDependency is to inject required objects to the endpoint
+type AdminWebhookEndpointDependency interface {
+	GetCircuitBreakers() outgoing.CircuitBreakerRegistry
+}
+
+// InitAdminWebhookEndpoints registers endpoints
+func InitAdminWebhookEndpoints(adminRouter *router.Router, deps AdminWebhookEndpointDependency) {
+	breakers := deps.GetCircuitBreakers()
+	adminRouter.GET("/webhook/circuit-breakers", func(ctx context.Context, args router.HandlerArgs) {
+		utils.SendJSON(ctx, args.W, 200, map[string]interface{}{
+			"circuitBreakers": breakers.Status(),
+		})
+	})
+	adminRouter.PUT("/webhook/circuit-breakers/reset", func(ctx context.Context, args router.HandlerArgs) {
+		url := args.R.GetQueryParam("url")
+		if url == "" {
+			utils.SendMissingParameter(ctx, args.W, "url")
+			return
+		}
+		if !breakers.Reset(ctx, url) {
+			utils.SendError(ctx, args.W, http.StatusNotFound, "Circuit breaker not found", nil)
+			return
+		}
+		utils.SendNoContent(ctx, args.W)
+	})
+}
@@ -0,0 +1,148 @@
+package endpoints
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/http/router"
+	"github.com/saiya/dsps/server/http/utils"
+	"github.com/saiya/dsps/server/webhook/delivery"
+)
+
+// AdminWebhookDeliveryEndpointDependency is to inject required objects to the endpoint
+type AdminWebhookDeliveryEndpointDependency interface {
+	GetStorage() domain.Storage
+	GetChannelProvider() domain.ChannelProvider
+	GetWebhookDispatcher() delivery.Dispatcher
+	GetWebhookDeliveryConfig() *config.WebhookDeliveryConfig
+}
+
+type webhookDeliveryRecordJSON struct {
+	ChannelID   domain.ChannelID             `json:"channelID"`
+	MessageID   domain.MessageID             `json:"messageID"`
+	Attempt     int                          `json:"attempt"`
+	Result      domain.WebhookDeliveryResult `json:"result"`
+	AttemptedAt domain.Time                  `json:"attemptedAt"`
+	Latency     domain.Duration              `json:"latency"`
+	Error       string                       `json:"error,omitempty"`
+	Requests    []webhookRequestRecordJSON   `json:"requests"`
+}
+
+type webhookRequestRecordJSON struct {
+	URL        string          `json:"url"`
+	StatusCode int             `json:"statusCode,omitempty"`
+	Latency    domain.Duration `json:"latency"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// InitAdminWebhookDeliveryEndpoints registers endpoints
+func InitAdminWebhookDeliveryEndpoints(adminRouter *router.Router, deps AdminWebhookDeliveryEndpointDependency) {
+	storage := deps.GetStorage().AsWebhookStorage()
+	dispatcher := deps.GetWebhookDispatcher()
+	cp := deps.GetChannelProvider()
+	cfg := deps.GetWebhookDeliveryConfig()
+
+	// Returns delivery records of the channel (latest first), returns false if error response has been sent.
+	listRecords := func(ctx context.Context, args router.HandlerArgs) (domain.ChannelID, []domain.WebhookDeliveryRecord, bool) {
+		if storage == nil || dispatcher == nil {
+			utils.SendWebhookDeliveryUnsupportedError(ctx, args.W)
+			return "", nil, false
+		}
+
+		channelID, err := domain.ParseChannelID(args.PS.ByName("channelID"))
+		if err != nil {
+			utils.SendInvalidParameter(ctx, args.W, "channelID", err)
+			return "", nil, false
+		}
+		if _, err := cp.Get(channelID); err != nil {
+			utils.SendInvalidParameter(ctx, args.W, "channelID", err)
+			return "", nil, false
+		}
+
+		records, err := storage.ListWebhookDeliveryRecords(ctx, channelID, *cfg.HistorySize)
+		if err != nil {
+			utils.SendInternalServerError(ctx, args.W, err)
+			return "", nil, false
+		}
+		return channelID, records, true
+	}
+
+	adminRouter.GET("/channel/:channelID/webhooks/deliveries", func(ctx context.Context, args router.HandlerArgs) {
+		_, records, ok := listRecords(ctx, args)
+		if !ok {
+			return
+		}
+		var messageID domain.MessageID
+		if param := args.R.GetQueryParam("messageID"); param != "" {
+			var err error
+			messageID, err = domain.ParseMessageID(param)
+			if err != nil {
+				utils.SendInvalidParameter(ctx, args.W, "messageID", err)
+				return
+			}
+		}
+
+		result := make([]webhookDeliveryRecordJSON, 0, len(records))
+		for _, record := range records {
+			if messageID != "" && record.MessageID != messageID {
+				continue
+			}
+			result = append(result, newWebhookDeliveryRecordJSON(record))
+		}
+		utils.SendJSON(ctx, args.W, 200, map[string]interface{}{
+			"deliveries": result,
+		})
+	})
+	adminRouter.POST("/channel/:channelID/webhooks/redeliver/:messageID", func(ctx context.Context, args router.HandlerArgs) {
+		channelID, records, ok := listRecords(ctx, args)
+		if !ok {
+			return
+		}
+		messageID, err := domain.ParseMessageID(args.PS.ByName("messageID"))
+		if err != nil {
+			utils.SendInvalidParameter(ctx, args.W, "messageID", err)
+			return
+		}
+
+		for _, record := range records {
+			if record.MessageID != messageID {
+				continue
+			}
+			// Records are latest first, redeliver the latest content of the message.
+			if err := dispatcher.Enqueue(ctx, []domain.Message{record.Message}); err != nil {
+				utils.SendInternalServerError(ctx, args.W, err)
+				return
+			}
+			utils.SendJSON(ctx, args.W, http.StatusAccepted, map[string]interface{}{
+				"channelID": channelID,
+				"messageID": messageID,
+			})
+			return
+		}
+		utils.SendError(ctx, args.W, http.StatusNotFound, "Delivery record of the message not found", nil)
+	})
+}
+
+func newWebhookDeliveryRecordJSON(record domain.WebhookDeliveryRecord) webhookDeliveryRecordJSON {
+	requests := make([]webhookRequestRecordJSON, 0, len(record.Requests))
+	for _, req := range record.Requests {
+		requests = append(requests, webhookRequestRecordJSON{
+			URL:        req.URL,
+			StatusCode: req.StatusCode,
+			Latency:    req.Latency,
+			Error:      req.Error,
+		})
+	}
+	return webhookDeliveryRecordJSON{
+		ChannelID:   record.ChannelID,
+		MessageID:   record.MessageID,
+		Attempt:     record.Attempt,
+		Result:      record.Result,
+		AttemptedAt: record.AttemptedAt,
+		Latency:     record.Latency,
+		Error:       record.Error,
+		Requests:    requests,
+	}
+}
@@ -0,0 +1,115 @@
+package endpoints_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/domain"
+	. "github.com/saiya/dsps/server/http"
+	. "github.com/saiya/dsps/server/http/testing"
+)
+
+func TestWebhookDeliveryEndpoints(t *testing.T) {
+	var status int32 = 403 // Not retried in the attempt
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer webhookServer.Close()
+
+	WithServer(t, `
+logging: category: "*": FATAL
+webhookDelivery: { pollInterval: 10ms, retryInterval: 1h, maxRetryInterval: 1h }
+channels:
+	- regex: "test.+"
+		webhooks:
+			- url: "`+webhookServer.URL+`"
+				circuitBreaker: { disable: true }
+`, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		deliveriesURL := baseURL + "/admin/channel/test1/webhooks/deliveries"
+		AssertResponseJSON(t, DoHTTPRequestWithHeaders(t, "GET", deliveriesURL, AdminAuthHeaders(t, deps), ""), 200, map[string]interface{}{
+			"deliveries": []interface{}{},
+		})
+
+		res := DoHTTPRequest(t, "PUT", baseURL+"/channel/test1/message/msg-1", `{"hi":"hello"}`)
+		assert.Equal(t, 200, res.StatusCode)
+		deliveries := waitWebhookDeliveries(t, deps, deliveriesURL, 1)
+		if assert.Equal(t, 1, len(deliveries)) {
+			delivery := deliveries[0].(map[string]interface{})
+			assert.Equal(t, "test1", delivery["channelID"])
+			assert.Equal(t, "msg-1", delivery["messageID"])
+			assert.Equal(t, float64(1), delivery["attempt"])
+			assert.Equal(t, "retrying", delivery["result"])
+			assert.NotEmpty(t, delivery["attemptedAt"])
+			assert.NotEmpty(t, delivery["latency"])
+			assert.Regexp(t, `status code 403 returned`, delivery["error"])
+			assert.NotContains(t, delivery, "content")
+			requests := delivery["requests"].([]interface{})
+			if assert.Equal(t, 1, len(requests)) {
+				request := requests[0].(map[string]interface{})
+				assert.Equal(t, webhookServer.URL, request["url"])
+				assert.Equal(t, float64(403), request["statusCode"])
+				assert.Equal(t, "status code 403 returned", request["error"])
+			}
+		}
+
+		// Redelivery sends the message immediately
+		atomic.StoreInt32(&status, 200)
+		AssertResponseJSON(t, DoHTTPRequestWithHeaders(t, "POST", baseURL+"/admin/channel/test1/webhooks/redeliver/msg-1", AdminAuthHeaders(t, deps), ""), 202, map[string]interface{}{
+			"channelID": "test1",
+			"messageID": "msg-1",
+		})
+		deliveries = waitWebhookDeliveries(t, deps, deliveriesURL, 2)
+		if assert.Equal(t, 2, len(deliveries)) {
+			delivery := deliveries[0].(map[string]interface{})
+			assert.Equal(t, float64(1), delivery["attempt"])
+			assert.Equal(t, "succeeded", delivery["result"])
+			assert.NotContains(t, delivery, "error")
+		}
+
+		// Filter by message ID
+		res = DoHTTPRequest(t, "PUT", baseURL+"/channel/test1/message/msg-2", `{}`)
+		assert.Equal(t, 200, res.StatusCode)
+		waitWebhookDeliveries(t, deps, deliveriesURL, 3)
+		res = DoHTTPRequestWithHeaders(t, "GET", deliveriesURL+"?messageID=msg-2", AdminAuthHeaders(t, deps), "")
+		assert.Equal(t, 1, len(BodyJSONMapOfRes(t, res)["deliveries"].([]interface{})))
+	})
+}
+
+func TestWebhookDeliveryEndpointsFailure(t *testing.T) {
+	WithServer(t, `
+logging: category: "*": FATAL
+channels:
+	- regex: "test.+"
+		webhooks:
+			- url: "http://localhost:9999"
+`, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		headers := AdminAuthHeaders(t, deps)
+		AssertErrorResponse(t, DoHTTPRequestWithHeaders(t, "POST", baseURL+"/admin/channel/test1/webhooks/redeliver/msg-1", headers, ""), 404, nil, `Delivery record of the message not found`)
+		AssertErrorResponse(t, DoHTTPRequestWithHeaders(t, "GET", baseURL+"/admin/channel/no-such-channel/webhooks/deliveries", headers, ""), 400, domain.ErrInvalidChannel, `Invalid "channelID" parameter`)
+		AssertErrorResponse(t, DoHTTPRequestWithHeaders(t, "GET", baseURL+"/admin/channel/test1/webhooks/deliveries?messageID=%20", headers, ""), 400, nil, `Invalid "messageID" parameter`)
+		AssertErrorResponse(t, DoHTTPRequestWithHeaders(t, "POST", baseURL+"/admin/channel/test1/webhooks/redeliver/msg-1", nil, ""), 403, nil, ``)
+	})
+
+	WithServer(t, `
+logging: category: "*": FATAL
+webhookDelivery: { disable: true }
+`, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		AssertErrorResponse(t, DoHTTPRequestWithHeaders(t, "GET", baseURL+"/admin/channel/test1/webhooks/deliveries", AdminAuthHeaders(t, deps), ""), 501, nil, `Asynchronous outgoing-webhook delivery is not available`)
+	})
+}
+
+func waitWebhookDeliveries(t *testing.T, deps *ServerDependencies, url string, count int) []interface{} {
+	var deliveries []interface{}
+	assert.Eventually(t, func() bool {
+		res := DoHTTPRequestWithHeaders(t, "GET", url, AdminAuthHeaders(t, deps), "")
+		deliveries = BodyJSONMapOfRes(t, res)["deliveries"].([]interface{})
+		return len(deliveries) >= count
+	}, 3*time.Second, 10*time.Millisecond, fmt.Sprintf("expected %d deliveries", count))
+	return deliveries
+}
@@ -0,0 +1,77 @@
+package endpoints_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/domain"
+	. "github.com/saiya/dsps/server/http"
+	. "github.com/saiya/dsps/server/http/testing"
+)
+
+func TestCircuitBreakerEndpoints(t *testing.T) {
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(403) // Not retried
+	}))
+	defer webhookServer.Close()
+
+	WithServer(t, `
+webhookDelivery: { disable: true }
+channels:
+	- regex: "test.+"
+		webhooks:
+			- url: "`+webhookServer.URL+`"
+				circuitBreaker: { failureThreshold: 1, openDuration: 1h }
+`, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		AssertResponseJSON(t, DoHTTPRequestWithHeaders(t, "GET", baseURL+"/admin/webhook/circuit-breakers", AdminAuthHeaders(t, deps), ""), 200, map[string]interface{}{
+			"circuitBreakers": []interface{}{},
+		})
+
+		ch, err := deps.ChannelProvider.Get("test1")
+		assert.NoError(t, err)
+		msg := domain.Message{MessageLocator: domain.MessageLocator{ChannelID: "test1", MessageID: "msg-1"}, Content: json.RawMessage(`{}`)}
+		assert.Regexp(t, `status code 403 returned`, ch.SendOutgoingWebhook(context.Background(), msg))
+		assert.Regexp(t, `circuit breaker is open`, ch.SendOutgoingWebhook(context.Background(), msg))
+
+		res := BodyJSONMapOfRes(t, DoHTTPRequestWithHeaders(t, "GET", baseURL+"/admin/webhook/circuit-breakers", AdminAuthHeaders(t, deps), ""))
+		breakers := res["circuitBreakers"].([]interface{})
+		if assert.Equal(t, 1, len(breakers)) {
+			breaker := breakers[0].(map[string]interface{})
+			assert.Equal(t, webhookServer.URL, breaker["url"])
+			assert.Equal(t, "open", breaker["state"])
+			assert.Equal(t, float64(1), breaker["consecutiveFailures"])
+			assert.Regexp(t, `status code 403 returned`, breaker["lastError"])
+			assert.NotEmpty(t, breaker["openedAt"])
+		}
+
+		resetURL := baseURL + "/admin/webhook/circuit-breakers/reset?url=" + url.QueryEscape(webhookServer.URL)
+		assert.Equal(t, 204, DoHTTPRequestWithHeaders(t, "PUT", resetURL, AdminAuthHeaders(t, deps), "").StatusCode)
+		assert.Equal(t, 0, len(deps.CircuitBreakers.Status()))
+		assert.Regexp(t, `status code 403 returned`, ch.SendOutgoingWebhook(context.Background(), msg))
+	})
+}
+
+func TestCircuitBreakerResetFailure(t *testing.T) {
+	WithServer(t, ``, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		AssertErrorResponse(
+			t,
+			DoHTTPRequestWithHeaders(t, "PUT", baseURL+"/admin/webhook/circuit-breakers/reset", AdminAuthHeaders(t, deps), ""),
+			400,
+			nil,
+			`Missing "url" parameter`,
+		)
+		AssertErrorResponse(
+			t,
+			DoHTTPRequestWithHeaders(t, "PUT", baseURL+"/admin/webhook/circuit-breakers/reset?url=http%3A%2F%2Flocalhost%2Fnot-found", AdminAuthHeaders(t, deps), ""),
+			404,
+			nil,
+			`Circuit breaker not found`,
+		)
+	})
+}
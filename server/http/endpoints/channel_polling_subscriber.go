@@ -188,10 +188,26 @@ func subscriberGetEndpoint(deps PollingEndpointDependency) router.Handler {
 
 			resultMsgs := make([]interface{}, 0, len(msgs))
 			for _, msg := range msgs {
-				resultMsgs = append(resultMsgs, map[string]interface{}{
+				resultMsg := map[string]interface{}{
 					"messageID": msg.MessageID,
 					"content":   msg.Content,
-				})
+				}
+				if msg.ContentType != "" {
+					resultMsg["contentType"] = msg.ContentType
+				}
+				if !msg.IsJSON() {
+					resultMsg["contentEncoding"] = "base64"
+				}
+				if len(msg.Metadata) > 0 {
+					resultMsg["metadata"] = msg.Metadata
+				}
+				if msg.TraceContext.TraceParent != "" {
+					resultMsg["traceparent"] = msg.TraceContext.TraceParent
+				}
+				if msg.TraceContext.TraceState != "" {
+					resultMsg["tracestate"] = msg.TraceContext.TraceState
+				}
+				resultMsgs = append(resultMsgs, resultMsg)
 			}
 			result := map[string]interface{}{
 				"channelID":    channelID,
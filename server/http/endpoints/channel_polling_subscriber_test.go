@@ -23,6 +23,8 @@ func TestPollingEndpointsWithoutPubSubSupport(t *testing.T) {
 	storage := NewMockStorage(ctrl)
 	storage.EXPECT().AsPubSubStorage().Return(nil).AnyTimes()
 	storage.EXPECT().AsJwtStorage().Return(nil).AnyTimes()
+	storage.EXPECT().AsWebhookStorage().Return(nil).AnyTimes()
+	storage.EXPECT().AsRateLimitStorage().Return(nil).AnyTimes()
 
 	sl := domain.SubscriberLocator{
 		ChannelID:    "my-channel",
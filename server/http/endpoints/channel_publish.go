@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"mime"
 	"net/http"
+	"strings"
+	"time"
 
 	"golang.org/x/xerrors"
 
@@ -13,12 +16,17 @@ import (
 	"github.com/saiya/dsps/server/http/router"
 	"github.com/saiya/dsps/server/http/utils"
 	"github.com/saiya/dsps/server/logger"
+	"github.com/saiya/dsps/server/telemetry"
+	"github.com/saiya/dsps/server/webhook/delivery"
 )
 
 // PublishEndpointDependency is to inject required objects to the endpoint
 type PublishEndpointDependency interface {
+	GetSystemClock() domain.SystemClock
 	GetStorage() domain.Storage
 	GetChannelProvider() domain.ChannelProvider
+	GetWebhookDispatcher() delivery.Dispatcher
+	RequestBodyDependency
 }
 
 // InitPublishEndpoints registers endpoints
@@ -43,12 +51,36 @@ func InitPublishEndpoints(channelRouter *router.Router, deps PublishEndpointDepe
 			return
 		}
 
-		content, err := args.R.ReadBody()
-		if err == nil && !json.Valid(content) {
-			err = xerrors.New("Is not valid JSON")
+		ch, ok := getChannel(ctx, args.W, deps, channelID)
+		if !ok {
+			return
+		}
+
+		metadata := messageMetadataOf(args.R.Header)
+		schedule := messageSchedule{
+			ExpireIn:  args.R.GetQueryParam("expireIn"),
+			DeliverAt: args.R.GetQueryParam("deliverAt"),
+		}
+		mediaType := mediaTypeOf(args.R.Header)
+		content, ok := readRequestBody(ctx, args, deps, ch.MaxMessageBytes())
+		if !ok {
+			return
 		}
+		if mediaType == messageEnvelopeContentType {
+			content, metadata, schedule, err = decodeMessageEnvelope(content, metadata, schedule)
+			mediaType = ""
+			if err != nil {
+				utils.SendError(ctx, args.W, http.StatusBadRequest, "Request body is not JSON", err)
+				return
+			}
+		}
+		content, contentType, status, err := messageContentOf(ch, mediaType, content)
 		if err != nil {
-			utils.SendError(ctx, args.W, http.StatusBadRequest, "Request body is not JSON", err)
+			utils.SendError(ctx, args.W, status, err.Error(), err)
+			return
+		}
+		if err := domain.ValidateMessageMetadata(metadata); err != nil {
+			utils.SendInvalidParameter(ctx, args.W, "metadata", err)
 			return
 		}
 
@@ -57,27 +89,24 @@ func InitPublishEndpoints(channelRouter *router.Router, deps PublishEndpointDepe
 				ChannelID: channelID,
 				MessageID: messageID,
 			},
-			Content: content,
+			Content:     content,
+			ContentType: contentType,
+			Metadata:    metadata,
 		}
-
-		err = pubsub.PublishMessages(ctx, []domain.Message{message})
-		if err != nil {
-			if errors.Is(err, domain.ErrInvalidChannel) {
-				// Could not create/access to the channel because not permitted by configuration
-				utils.SendError(ctx, args.W, http.StatusForbidden, err.Error(), err)
-			} else {
-				utils.SendInternalServerError(ctx, args.W, err)
-			}
+		if param, err := applyMessageSchedule(ch, schedule, deps.GetSystemClock().Now().Time, &message); err != nil {
+			utils.SendInvalidParameter(ctx, args.W, param, err)
 			return
 		}
-
-		ch, err := deps.GetChannelProvider().Get(channelID)
-		if err != nil {
-			utils.SendInternalServerError(ctx, args.W, err)
+		if !message.DeliverAt.IsZero() && ch.HasOutgoingWebhook() && deps.GetWebhookDispatcher() == nil {
+			utils.SendInvalidParameter(ctx, args.W, "deliverAt", xerrors.New("Scheduled delivery of outgoing-webhook requires asynchronous webhook delivery (webhookDelivery)"))
 			return
 		}
-		if err := ch.SendOutgoingWebhook(ctx, message); err != nil {
-			logger.Of(ctx).WarnError(logger.CatOutgoingWebhook, fmt.Sprintf(`failed to send outgoing-webhook (channel: %s, msgID: %s): %%w`, channelID, messageID), err)
+		if !validateMessage(ctx, args.W, ch, message) {
+			return
+		}
+
+		if !publishMessage(ctx, args.W, deps, message) {
+			return
 		}
 
 		utils.SendJSON(ctx, args.W, http.StatusOK, map[string]interface{}{
@@ -86,3 +115,205 @@ func InitPublishEndpoints(channelRouter *router.Router, deps PublishEndpointDepe
 		})
 	})
 }
+
+// Publisher can supply message metadata with headers such as "X-DSPS-Meta-Content-Type: application/json", key of it is "content-type".
+const messageMetadataHeaderPrefix = "X-Dsps-Meta-"
+
+// Request body of this content type is an envelope that has both content and metadata of the message.
+const messageEnvelopeContentType = "application/vnd.dsps.message+json"
+
+type messageEnvelope struct {
+	Content  json.RawMessage   `json:"content"`
+	Metadata map[string]string `json:"metadata"`
+	messageSchedule
+}
+
+// messageSchedule is per-message expiration and delivery time supplied by query parameters or message envelope, empty if not supplied.
+type messageSchedule struct {
+	ExpireIn  string `json:"expireIn"`  // Duration (e.g. "30s")
+	DeliverAt string `json:"deliverAt"` // RFC 3339 timestamp
+}
+
+// messageMetadataOf returns metadata supplied by request headers, nil if no metadata headers.
+func messageMetadataOf(header http.Header) map[string]string {
+	var metadata map[string]string
+	for name, values := range header {
+		if !strings.HasPrefix(name, messageMetadataHeaderPrefix) || len(values) == 0 {
+			continue
+		}
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata[strings.ToLower(strings.TrimPrefix(name, messageMetadataHeaderPrefix))] = values[0]
+	}
+	return metadata
+}
+
+// mediaTypeOf returns media type of the Content-Type header (e.g. "text/plain" for "text/plain; charset=utf-8"), empty if absent or malformed.
+func mediaTypeOf(header http.Header) string {
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// messageContentOf returns content and content type to store, or HTTP status code and error if the channel does not accept the body.
+func messageContentOf(ch domain.Channel, mediaType string, body []byte) (json.RawMessage, string, int, error) {
+	if !domain.IsJSONContentType(mediaType) && ch.AcceptsContentType(mediaType) {
+		return domain.NewBinaryContent(body), mediaType, 0, nil
+	}
+	if !json.Valid(body) {
+		if !domain.IsJSONContentType(mediaType) {
+			return nil, "", http.StatusUnsupportedMediaType, xerrors.Errorf("Content type %s is not allowed in this channel", mediaType)
+		}
+		return nil, "", http.StatusBadRequest, xerrors.New("Request body is not JSON")
+	}
+	if !domain.IsJSONContentType(mediaType) || mediaType == domain.ContentTypeJSON {
+		// Note: for backward compatibility, JSON body with other content type (e.g. curl's default "application/x-www-form-urlencoded") is treated as JSON.
+		mediaType = ""
+	}
+	if !ch.AcceptsContentType(mediaType) {
+		if mediaType == "" {
+			mediaType = domain.ContentTypeJSON
+		}
+		return nil, "", http.StatusUnsupportedMediaType, xerrors.Errorf("Content type %s is not allowed in this channel", mediaType)
+	}
+	return body, mediaType, 0, nil
+}
+
+// decodeMessageEnvelope returns content, metadata and schedule of the envelope, metadata and schedule in the envelope overwrite given ones.
+func decodeMessageEnvelope(body []byte, metadata map[string]string, schedule messageSchedule) (json.RawMessage, map[string]string, messageSchedule, error) {
+	envelope := messageEnvelope{}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, nil, schedule, xerrors.Errorf("Is not valid message envelope: %w", err)
+	}
+	if len(envelope.Content) == 0 {
+		return nil, nil, schedule, xerrors.New(`Message envelope must have "content"`)
+	}
+	for key, value := range envelope.Metadata {
+		if metadata == nil {
+			metadata = make(map[string]string, len(envelope.Metadata))
+		}
+		metadata[key] = value
+	}
+	if envelope.ExpireIn != "" {
+		schedule.ExpireIn = envelope.ExpireIn
+	}
+	if envelope.DeliverAt != "" {
+		schedule.DeliverAt = envelope.DeliverAt
+	}
+	return envelope.Content, metadata, schedule, nil
+}
+
+// applyMessageSchedule sets ExpireAt and DeliverAt of the message, returns name of the invalid parameter and error if the schedule is not valid.
+func applyMessageSchedule(ch domain.Channel, schedule messageSchedule, now time.Time, message *domain.Message) (string, error) {
+	channelExpireAt := now.Add(ch.Expire().Duration)
+	if schedule.ExpireIn != "" {
+		expireIn, err := time.ParseDuration(schedule.ExpireIn)
+		if err != nil {
+			return "expireIn", err
+		}
+		if expireIn <= 0 || expireIn > ch.Expire().Duration {
+			return "expireIn", xerrors.Errorf("expireIn must be positive and not longer than expiration of the channel (%s)", ch.Expire().Duration)
+		}
+		message.ExpireAt = domain.Time{Time: now.Add(expireIn)}
+	}
+	if schedule.DeliverAt != "" {
+		deliverAt, err := time.Parse(time.RFC3339, schedule.DeliverAt)
+		if err != nil {
+			return "deliverAt", err
+		}
+		if !deliverAt.Before(channelExpireAt) {
+			return "deliverAt", xerrors.Errorf("deliverAt must be earlier than expiration of the channel (%s later)", ch.Expire().Duration)
+		}
+		if !message.ExpireAt.IsZero() && !deliverAt.Before(message.ExpireAt.Time) {
+			return "deliverAt", xerrors.New("deliverAt must be earlier than expireIn")
+		}
+		if deliverAt.After(now) { // Past time means immediate delivery
+			message.DeliverAt = domain.Time{Time: deliverAt}
+		}
+	}
+	return "", nil
+}
+
+// getChannel returns the channel, or sends error response and returns false if the channel is not available.
+func getChannel(ctx context.Context, w http.ResponseWriter, deps PublishEndpointDependency, channelID domain.ChannelID) (domain.Channel, bool) {
+	ch, err := deps.GetChannelProvider().Get(channelID)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidChannel) {
+			// Channel not permitted by configuration
+			utils.SendError(ctx, w, http.StatusForbidden, err.Error(), err)
+		} else {
+			utils.SendInternalServerError(ctx, w, err)
+		}
+		return nil, false
+	}
+	return ch, true
+}
+
+// validateMessage checks content type and content of the message against the channel configuration.
+// Returns false if the message is not acceptable, in that case error response has been sent.
+func validateMessage(ctx context.Context, w http.ResponseWriter, ch domain.Channel, message domain.Message) bool {
+	if !ch.AcceptsContentType(message.ContentType) {
+		contentType := message.ContentType
+		if contentType == "" {
+			contentType = domain.ContentTypeJSON
+		}
+		err := xerrors.Errorf("Content type %s is not allowed in this channel", contentType)
+		utils.SendError(ctx, w, http.StatusUnsupportedMediaType, err.Error(), err)
+		return false
+	}
+	if err := ch.ValidateMessageContent(ctx, message); err != nil {
+		var violation *domain.MessageSchemaViolation
+		if errors.As(err, &violation) {
+			utils.SendErrorWithDetails(ctx, w, http.StatusUnprocessableEntity, "Message content does not match with the schema of the channel", err, map[string]interface{}{
+				"validationErrors": violation.Errors,
+			})
+		} else {
+			utils.SendInternalServerError(ctx, w, err)
+		}
+		return false
+	}
+	return true
+}
+
+// publishMessage publishes the message and sends outgoing-webhook of it.
+// Returns false if failed, in that case error response has been sent.
+func publishMessage(ctx context.Context, w http.ResponseWriter, deps PublishEndpointDependency, message domain.Message) bool {
+	ctx, end := deps.GetTelemetry().StartMessageSpan(ctx, telemetry.Send, message)
+	defer end()
+	message.TraceContext = deps.GetTelemetry().CaptureTraceContext(ctx)
+
+	err := deps.GetStorage().AsPubSubStorage().PublishMessages(ctx, []domain.Message{message})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidChannel) {
+			// Could not create/access to the channel because not permitted by configuration
+			utils.SendError(ctx, w, http.StatusForbidden, err.Error(), err)
+		} else if errors.Is(err, domain.ErrChannelMaxMessagesExceeded) {
+			utils.SendError(ctx, w, http.StatusTooManyRequests, "Channel has reached the maximum number of messages", err)
+		} else if errors.Is(err, domain.ErrChannelMaxBytesExceeded) {
+			utils.SendError(ctx, w, http.StatusInsufficientStorage, "Channel has reached the maximum total size of messages", err)
+		} else {
+			utils.SendInternalServerError(ctx, w, err)
+		}
+		return false
+	}
+
+	if webhookDispatcher := deps.GetWebhookDispatcher(); webhookDispatcher != nil {
+		if err := webhookDispatcher.Enqueue(ctx, []domain.Message{message}); err != nil {
+			utils.SendInternalServerError(ctx, w, err)
+			return false
+		}
+	} else {
+		ch, err := deps.GetChannelProvider().Get(message.ChannelID)
+		if err != nil {
+			utils.SendInternalServerError(ctx, w, err)
+			return false
+		}
+		if err := ch.SendOutgoingWebhook(ctx, message); err != nil {
+			logger.Of(ctx).WarnError(logger.CatOutgoingWebhook, fmt.Sprintf(`failed to send outgoing-webhook (channel: %s, msgID: %s): %%w`, message.ChannelID, message.MessageID), err)
+		}
+	}
+	return true
+}
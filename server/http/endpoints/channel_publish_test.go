@@ -4,7 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -12,7 +17,9 @@ import (
 	"github.com/saiya/dsps/server/domain"
 	. "github.com/saiya/dsps/server/domain/mock"
 	. "github.com/saiya/dsps/server/http"
+	"github.com/saiya/dsps/server/http/router"
 	. "github.com/saiya/dsps/server/http/testing"
+	dspstesting "github.com/saiya/dsps/server/testing"
 )
 
 func TestPublishEndpointsWithoutPubSubSupport(t *testing.T) {
@@ -21,6 +28,8 @@ func TestPublishEndpointsWithoutPubSubSupport(t *testing.T) {
 	storage := NewMockStorage(ctrl)
 	storage.EXPECT().AsPubSubStorage().Return(nil).AnyTimes()
 	storage.EXPECT().AsJwtStorage().Return(nil).AnyTimes()
+	storage.EXPECT().AsWebhookStorage().Return(nil).AnyTimes()
+	storage.EXPECT().AsRateLimitStorage().Return(nil).AnyTimes()
 
 	chID := "my-channel"
 	msgID := "msg-1"
@@ -97,3 +106,429 @@ func TestChannelPublishFailure(t *testing.T) {
 		AssertInternalServerErrorResponse(t, res)
 	})
 }
+
+func TestChannelPublishWithOutgoingWebhook(t *testing.T) {
+	received := make(chan string, 10)
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		received <- string(body)
+	}))
+	defer webhookServer.Close()
+
+	chID := "my-channel"
+	msgID := "msg-1"
+	content := `{"hi":"hello!"}`
+	WithServer(t, strings.ReplaceAll(`
+logging: category: "*": FATAL
+webhookDelivery: { pollInterval: 10ms }
+channels:
+	- regex: ".+"
+		webhooks:
+			- url: "`+webhookServer.URL+`"
+`, "\t", "  "), func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		assert.NotNil(t, deps.WebhookDispatcher)
+
+		res := DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/%s/message/%s", baseURL, chID, msgID), content)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{
+			"channelID": chID,
+			"messageID": msgID,
+		})
+
+		select {
+		case body := <-received:
+			assert.Contains(t, body, msgID)
+		case <-time.After(3 * time.Second):
+			assert.Fail(t, "outgoing-webhook not delivered")
+		}
+	})
+}
+
+func TestChannelPublishTraceContextPropagation(t *testing.T) {
+	received := make(chan http.Header, 10)
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header
+	}))
+	defer webhookServer.Close()
+
+	ctx := context.Background()
+	sl := domain.SubscriberLocator{ChannelID: "my-channel", SubscriberID: "sbsc-1"}
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tracestate := "congo=t61rcWkgMzE"
+	WithServer(t, strings.ReplaceAll(`
+logging: category: "*": FATAL
+webhookDelivery: { pollInterval: 10ms }
+channels:
+	- regex: ".+"
+		webhooks:
+			- url: "`+webhookServer.URL+`"
+`, "\t", "  "), func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		assert.NoError(t, deps.Storage.AsPubSubStorage().NewSubscriber(ctx, sl))
+
+		res := DoHTTPRequestWithHeaders(t, "PUT", fmt.Sprintf("%s/channel/%s/message/msg-1", baseURL, sl.ChannelID), map[string]string{
+			"traceparent": traceparent,
+			"tracestate":  tracestate,
+		}, `{"hi":"hello!"}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{
+			"channelID": string(sl.ChannelID),
+			"messageID": "msg-1",
+		})
+
+		// Tracing of the server is disabled in this test, so that trace context of the publisher should be propagated as is.
+		res = DoHTTPRequest(t, "GET", fmt.Sprintf("%s/channel/%s/subscription/polling/%s?timeout=0ms", baseURL, sl.ChannelID, sl.SubscriberID), ``)
+		body := AssertResponseJSON(t, res, 200, map[string]interface{}{
+			"channelID": string(sl.ChannelID),
+			"messages": []interface{}{
+				map[string]interface{}{
+					"messageID":   "msg-1",
+					"content":     map[string]interface{}{"hi": "hello!"},
+					"traceparent": traceparent,
+					"tracestate":  tracestate,
+				},
+			},
+			"moreMessages": false,
+		})
+		assert.Contains(t, body, "ackHandle")
+
+		select {
+		case header := <-received:
+			assert.Equal(t, traceparent, header.Get("traceparent"))
+			assert.Equal(t, tracestate, header.Get("tracestate"))
+		case <-time.After(3 * time.Second):
+			assert.Fail(t, "outgoing-webhook not delivered")
+		}
+	})
+}
+
+func TestChannelPublishMetadata(t *testing.T) {
+	ctx := context.Background()
+	sl := domain.SubscriberLocator{ChannelID: "my-channel", SubscriberID: "sbsc-1"}
+	WithServer(t, `logging: category: "*": FATAL`, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		assert.NoError(t, deps.Storage.AsPubSubStorage().NewSubscriber(ctx, sl))
+
+		// Metadata headers
+		res := DoHTTPRequestWithHeaders(t, "PUT", fmt.Sprintf("%s/channel/%s/message/msg-1", baseURL, sl.ChannelID), map[string]string{
+			"X-DSPS-Meta-Content-Type":   "application/json",
+			"x-dsps-meta-schema.version": "1",
+		}, `{"hi":"hello!"}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": string(sl.ChannelID), "messageID": "msg-1"})
+
+		// Envelope, metadata in envelope overwrites headers
+		res = DoHTTPRequestWithHeaders(t, "PUT", fmt.Sprintf("%s/channel/%s/message/msg-2", baseURL, sl.ChannelID), map[string]string{
+			"Content-Type":           "application/vnd.dsps.message+json; charset=utf-8",
+			"X-DSPS-Meta-Producer":   "header",
+			"X-DSPS-Meta-Request-Id": "req-1",
+		}, `{"content":{"hi":"hello!"},"metadata":{"producer":"envelope"}}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": string(sl.ChannelID), "messageID": "msg-2"})
+
+		// Without metadata
+		res = DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/%s/message/msg-3", baseURL, sl.ChannelID), `{"hi":"hello!"}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": string(sl.ChannelID), "messageID": "msg-3"})
+
+		res = DoHTTPRequest(t, "GET", fmt.Sprintf("%s/channel/%s/subscription/polling/%s?timeout=0ms", baseURL, sl.ChannelID, sl.SubscriberID), ``)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{
+			"channelID": string(sl.ChannelID),
+			"messages": []interface{}{
+				map[string]interface{}{
+					"messageID": "msg-1",
+					"content":   map[string]interface{}{"hi": "hello!"},
+					"metadata":  map[string]interface{}{"content-type": "application/json", "schema.version": "1"},
+				},
+				map[string]interface{}{
+					"messageID": "msg-2",
+					"content":   map[string]interface{}{"hi": "hello!"},
+					"metadata":  map[string]interface{}{"producer": "envelope", "request-id": "req-1"},
+				},
+				map[string]interface{}{
+					"messageID": "msg-3",
+					"content":   map[string]interface{}{"hi": "hello!"},
+				},
+			},
+			"moreMessages": false,
+		})
+	})
+}
+
+func TestChannelPublishMetadataFailure(t *testing.T) {
+	WithServer(t, `logging: category: "*": FATAL`, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		url := fmt.Sprintf("%s/channel/my-channel/message/msg-1", baseURL)
+
+		res := DoHTTPRequestWithHeaders(t, "PUT", url, map[string]string{"X-DSPS-Meta-Too-Long": strings.Repeat("x", 1025)}, `{}`)
+		AssertErrorResponse(t, res, 400, nil, `Invalid "metadata" parameter`)
+
+		res = DoHTTPRequestWithHeaders(t, "PUT", url, map[string]string{"Content-Type": "application/vnd.dsps.message+json"}, `{"content":{},"metadata":{"INVALID":"x"}}`)
+		AssertErrorResponse(t, res, 400, nil, `Invalid "metadata" parameter`)
+
+		res = DoHTTPRequestWithHeaders(t, "PUT", url, map[string]string{"Content-Type": "application/vnd.dsps.message+json"}, `{"metadata":{}}`)
+		AssertErrorResponse(t, res, 400, nil, `Request body is not JSON`)
+
+		res = DoHTTPRequestWithHeaders(t, "PUT", url, map[string]string{"Content-Type": "application/vnd.dsps.message+json"}, `{"content":`)
+		AssertErrorResponse(t, res, 400, nil, `Request body is not JSON`)
+	})
+}
+
+func TestChannelPublishContentType(t *testing.T) {
+	ctx := context.Background()
+	sl := domain.SubscriberLocator{ChannelID: "binary-1", SubscriberID: "sbsc-1"}
+	WithServer(t, strings.ReplaceAll(`
+logging: category: "*": FATAL
+channels:
+	- regex: "binary-.+"
+		contentTypes: [ "application/json", "application/x-protobuf", "text/*" ]
+	- regex: "json-.+"
+	- regex: "text-only-.+"
+		contentTypes: [ "text/plain" ]
+`, "\t", "  "), func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		assert.NoError(t, deps.Storage.AsPubSubStorage().NewSubscriber(ctx, sl))
+
+		res := DoHTTPRequestWithHeaders(t, "PUT", fmt.Sprintf("%s/channel/%s/message/msg-1", baseURL, sl.ChannelID), map[string]string{
+			"Content-Type": "application/x-protobuf",
+		}, "\x08\x96\x01")
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": string(sl.ChannelID), "messageID": "msg-1"})
+
+		// Not JSON even if the body is valid JSON
+		res = DoHTTPRequestWithHeaders(t, "PUT", fmt.Sprintf("%s/channel/%s/message/msg-2", baseURL, sl.ChannelID), map[string]string{
+			"Content-Type": "text/plain; charset=utf-8",
+		}, `"hello"`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": string(sl.ChannelID), "messageID": "msg-2"})
+
+		res = DoHTTPRequestWithHeaders(t, "PUT", fmt.Sprintf("%s/channel/%s/message/msg-3", baseURL, sl.ChannelID), map[string]string{
+			"Content-Type": "application/json",
+		}, `{"hi":"hello!"}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": string(sl.ChannelID), "messageID": "msg-3"})
+
+		res = DoHTTPRequest(t, "GET", fmt.Sprintf("%s/channel/%s/subscription/polling/%s?timeout=0ms", baseURL, sl.ChannelID, sl.SubscriberID), ``)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{
+			"channelID": string(sl.ChannelID),
+			"messages": []interface{}{
+				map[string]interface{}{
+					"messageID":       "msg-1",
+					"content":         "CJYB",
+					"contentType":     "application/x-protobuf",
+					"contentEncoding": "base64",
+				},
+				map[string]interface{}{
+					"messageID":       "msg-2",
+					"content":         "ImhlbGxvIg==",
+					"contentType":     "text/plain",
+					"contentEncoding": "base64",
+				},
+				map[string]interface{}{
+					"messageID": "msg-3",
+					"content":   map[string]interface{}{"hi": "hello!"},
+				},
+			},
+			"moreMessages": false,
+		})
+
+		// JSON body with other content type is treated as JSON for backward compatibility
+		res = DoHTTPRequestWithHeaders(t, "PUT", fmt.Sprintf("%s/channel/json-1/message/msg-1", baseURL), map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		}, `{"hi":"hello!"}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": "json-1", "messageID": "msg-1"})
+	})
+}
+
+func TestChannelPublishContentTypeFailure(t *testing.T) {
+	WithServer(t, strings.ReplaceAll(`
+logging: category: "*": FATAL
+channels:
+	- regex: "json-.+"
+	- regex: "text-only-.+"
+		contentTypes: [ "text/plain" ]
+`, "\t", "  "), func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		res := DoHTTPRequestWithHeaders(t, "PUT", fmt.Sprintf("%s/channel/json-1/message/msg-1", baseURL), map[string]string{
+			"Content-Type": "application/x-protobuf",
+		}, "\x08\x96\x01")
+		AssertErrorResponse(t, res, 415, nil, `Content type application/x-protobuf is not allowed in this channel`)
+
+		res = DoHTTPRequestWithHeaders(t, "PUT", fmt.Sprintf("%s/channel/text-only-1/message/msg-1", baseURL), map[string]string{
+			"Content-Type": "application/json",
+		}, `{"hi":"hello!"}`)
+		AssertErrorResponse(t, res, 415, nil, `Content type application/json is not allowed in this channel`)
+
+		res = DoHTTPRequestWithHeaders(t, "PUT", fmt.Sprintf("%s/channel/json-1/message/msg-1", baseURL), map[string]string{
+			"Content-Type": "application/json",
+		}, `not JSON`)
+		AssertErrorResponse(t, res, 400, nil, `Request body is not JSON`)
+
+		res = DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/not-configured-1/message/msg-1", baseURL), `{"hi":"hello!"}`)
+		AssertErrorResponse(t, res, 400, domain.ErrInvalidChannel, `Invalid "channelID" parameter`)
+	})
+}
+
+func TestChannelPublishSchemaValidation(t *testing.T) {
+	ctx := context.Background()
+	sl := domain.SubscriberLocator{ChannelID: "chat-1", SubscriberID: "sbsc-1"}
+	WithServer(t, strings.ReplaceAll(`
+logging: category: "*": FATAL
+channels:
+	- regex: "chat-.+"
+		schemaFile: ../../schema/testdata/chat-message.json
+`, "\t", "  "), func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		assert.NoError(t, deps.Storage.AsPubSubStorage().NewSubscriber(ctx, sl))
+
+		res := DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/%s/message/msg-1", baseURL, sl.ChannelID), `{"text":1,"user":{"name":"alice"}}`)
+		body := AssertResponseJSON(t, res, 422, map[string]interface{}{
+			"error":            "Message content does not match with the schema of the channel",
+			"code":             domain.ErrMessageSchemaViolation.Code(),
+			"validationErrors": []interface{}{"#/text: expected string, but got number"},
+		})
+		assert.Equal(t, 3, len(body))
+
+		res = DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/%s/message/msg-2", baseURL, sl.ChannelID), `{"text":"hello","user":{"name":"alice"}}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": string(sl.ChannelID), "messageID": "msg-2"})
+
+		// Rejected message must not be published
+		fetched, _, _, err := deps.Storage.AsPubSubStorage().FetchMessages(ctx, sl, 10, domain.Duration{Duration: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(fetched))
+		assert.Equal(t, "msg-2", string(fetched[0].MessageID))
+	})
+}
+
+func TestChannelPublishSchedule(t *testing.T) {
+	ctx := context.Background()
+	sl := domain.SubscriberLocator{ChannelID: "chat-1", SubscriberID: "sbsc-1"}
+	WithServer(t, strings.ReplaceAll(`
+logging: category: "*": FATAL
+channels:
+	- regex: "chat-.+"
+		expire: 10m
+`, "\t", "  "), func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		assert.NoError(t, deps.Storage.AsPubSubStorage().NewSubscriber(ctx, sl))
+
+		deliverAt := time.Now().Add(500 * time.Millisecond)
+		res := DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/%s/message/msg-1?expireIn=1m&deliverAt=%s", baseURL, sl.ChannelID, deliverAt.UTC().Format(time.RFC3339Nano)), `{}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": string(sl.ChannelID), "messageID": "msg-1"})
+		res = DoHTTPRequestWithHeaders(t, "PUT", fmt.Sprintf("%s/channel/%s/message/msg-2?expireIn=1m", baseURL, sl.ChannelID), map[string]string{"Content-Type": "application/vnd.dsps.message+json"}, `{"content":{},"expireIn":"300ms"}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": string(sl.ChannelID), "messageID": "msg-2"})
+		// Past deliverAt means immediate delivery
+		res = DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/%s/message/msg-3?deliverAt=2020-11-17T12:00:00Z", baseURL, sl.ChannelID), `{}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": string(sl.ChannelID), "messageID": "msg-3"})
+
+		fetched, _, ackHandle, err := deps.Storage.AsPubSubStorage().FetchMessages(ctx, sl, 10, domain.Duration{Duration: 0})
+		assert.NoError(t, err)
+		if assert.Equal(t, 2, len(fetched)) {
+			assert.Equal(t, "msg-2", string(fetched[0].MessageID))
+			assert.WithinDuration(t, time.Now().Add(300*time.Millisecond), fetched[0].ExpireAt.Time, 300*time.Millisecond) // Envelope overwrites query parameter
+			assert.Equal(t, "msg-3", string(fetched[1].MessageID))
+			assert.True(t, fetched[1].DeliverAt.IsZero())
+		}
+		assert.NoError(t, deps.Storage.AsPubSubStorage().AcknowledgeMessages(ctx, ackHandle))
+
+		// Long polling returns scheduled message after deliverAt
+		fetched, _, _, err = deps.Storage.AsPubSubStorage().FetchMessages(ctx, sl, 10, domain.Duration{Duration: 3 * time.Second})
+		assert.NoError(t, err)
+		assert.False(t, time.Now().Before(deliverAt))
+		if assert.Equal(t, 1, len(fetched)) {
+			assert.Equal(t, "msg-1", string(fetched[0].MessageID))
+			assert.WithinDuration(t, time.Now().Add(time.Minute), fetched[0].ExpireAt.Time, 3*time.Second)
+		}
+	})
+}
+
+func TestChannelPublishScheduleWithSystemClock(t *testing.T) {
+	clock := dspstesting.NewStubClock(t)
+	clock.Set(time.Date(2020, 11, 17, 12, 0, 0, 0, time.UTC))
+	WithServer(t, strings.ReplaceAll(`
+logging: category: "*": FATAL
+channels:
+	- regex: "chat-.+"
+		expire: 10m
+`, "\t", "  "), func(deps *ServerDependencies) {
+		deps.Clock = clock
+	}, func(deps *ServerDependencies, baseURL string) {
+		// Schedule is computed with the clock of the server (past time for the real clock means immediate delivery)
+		res := DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/chat-1/message/msg-1?deliverAt=2020-11-17T12:30:00Z", baseURL), `{}`)
+		AssertErrorResponse(t, res, 400, nil, `Invalid "deliverAt" parameter`)
+	})
+}
+
+func TestChannelPublishScheduleFailure(t *testing.T) {
+	WithServer(t, strings.ReplaceAll(`
+logging: category: "*": FATAL
+webhookDelivery: { disable: true }
+channels:
+	- regex: "chat-.+"
+		expire: 10m
+	- regex: "webhook-.+"
+		webhooks:
+			- url: "http://localhost:1"
+`, "\t", "  "), func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		url := fmt.Sprintf("%s/channel/chat-1/message/msg-1", baseURL)
+		for _, query := range []string{"expireIn=INVALID", "expireIn=0s", "expireIn=11m"} {
+			res := DoHTTPRequest(t, "PUT", url+"?"+query, `{}`)
+			AssertErrorResponse(t, res, 400, nil, `Invalid "expireIn" parameter`)
+		}
+
+		deliverAt := func(d time.Duration) string { return time.Now().Add(d).UTC().Format(time.RFC3339) }
+		for _, query := range []string{"deliverAt=INVALID", "deliverAt=" + deliverAt(time.Hour), "expireIn=1m&deliverAt=" + deliverAt(2*time.Minute)} {
+			res := DoHTTPRequest(t, "PUT", url+"?"+query, `{}`)
+			AssertErrorResponse(t, res, 400, nil, `Invalid "deliverAt" parameter`)
+		}
+
+		res := DoHTTPRequestWithHeaders(t, "PUT", url, map[string]string{"Content-Type": "application/vnd.dsps.message+json"}, `{"content":{},"expireIn":"-1s"}`)
+		AssertErrorResponse(t, res, 400, nil, `Invalid "expireIn" parameter`)
+
+		// Synchronous outgoing-webhook cannot be delayed
+		res = DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/webhook-1/message/msg-1?deliverAt=%s", baseURL, deliverAt(time.Minute)), `{}`)
+		AssertErrorResponse(t, res, 400, nil, `Invalid "deliverAt" parameter`)
+	})
+}
+
+func TestChannelPublishRetentionOverflow(t *testing.T) {
+	WithServer(t, strings.ReplaceAll(`
+logging: category: "*": FATAL
+channels:
+	- regex: "count-.+"
+		maxMessages: 1
+		overflow: reject
+	- regex: "bytes-.+"
+		maxBytes: 10
+		overflow: reject
+	- regex: "drop-.+"
+		maxMessages: 1
+`, "\t", "  "), func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		res := DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/count-1/message/msg-1", baseURL), `{}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": "count-1", "messageID": "msg-1"})
+		res = DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/count-1/message/msg-1", baseURL), `{}`) // Retry of the same message
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": "count-1", "messageID": "msg-1"})
+		res = DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/count-1/message/msg-2", baseURL), `{}`)
+		AssertErrorResponse(t, res, 429, domain.ErrChannelMaxMessagesExceeded, `Channel has reached the maximum number of messages`)
+
+		res = DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/bytes-1/message/msg-1", baseURL), `{"a":1}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": "bytes-1", "messageID": "msg-1"})
+		res = DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/bytes-1/message/msg-2", baseURL), `{"a":1}`)
+		AssertErrorResponse(t, res, 507, domain.ErrChannelMaxBytesExceeded, `Channel has reached the maximum total size of messages`)
+
+		for _, msgID := range []string{"msg-1", "msg-2"} {
+			res = DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/drop-1/message/%s", baseURL, msgID), `{}`)
+			AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": "drop-1", "messageID": msgID})
+		}
+	})
+}
+
+func TestChannelPublishBodyTooLarge(t *testing.T) {
+	WithServer(t, strings.ReplaceAll(`
+logging: category: "*": FATAL
+http:
+	maxBodyBytes: 16
+channels:
+	- regex: "small-.+"
+		maxMessageBytes: 8
+	- regex: "large-.+"
+		maxMessageBytes: 1024
+`, "\t", "  "), func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		res := DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/small-1/message/msg-1", baseURL), `{"a":"1234"}`)
+		AssertResponseJSON(t, res, 413, map[string]interface{}{"error": "Request body is too large", "code": router.ErrRequestBodyTooLarge.Code(), "limit": float64(8)})
+		res = DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/small-1/message/msg-1", baseURL), `{"a":1}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": "small-1", "messageID": "msg-1"})
+
+		// Server-wide limit wins if it is smaller than the channel limit
+		res = DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/large-1/message/msg-1", baseURL), `{"a":"1234567890"}`)
+		AssertResponseJSON(t, res, 413, map[string]interface{}{"error": "Request body is too large", "code": router.ErrRequestBodyTooLarge.Code(), "limit": float64(16)})
+		res = DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/large-1/message/msg-1", baseURL), `{"a":"12345678"}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": "large-1", "messageID": "msg-1"})
+	})
+}
@@ -0,0 +1,83 @@
+package endpoints
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/http/middleware"
+	"github.com/saiya/dsps/server/http/router"
+	"github.com/saiya/dsps/server/http/utils"
+	"github.com/saiya/dsps/server/logger"
+	"github.com/saiya/dsps/server/webhook/incoming"
+)
+
+// IncomingWebhookEndpointDependency is to inject required objects to the endpoint
+type IncomingWebhookEndpointDependency interface {
+	PublishEndpointDependency
+	middleware.RateLimitDependency
+	GetIncomingWebhookReceivers() map[string]incoming.Receiver // Rebuilt on configuration reload
+}
+
+// InitIncomingWebhookEndpoints registers endpoints
+func InitIncomingWebhookEndpoints(rt *router.Router, deps IncomingWebhookEndpointDependency) {
+	pubsub := deps.GetStorage().AsPubSubStorage()
+	deps.GetIncomingWebhookReceivers() // Build receivers on startup to log configuration warnings
+
+	rt.POST("/incoming/:hookName", func(ctx context.Context, args router.HandlerArgs) {
+		hookName := args.PS.ByName("hookName")
+		ctx = logger.WithAttributes(ctx).WithStr("incomingWebhook", hookName).Build()
+		receiver, ok := deps.GetIncomingWebhookReceivers()[hookName]
+		if !ok {
+			utils.SendError(ctx, args.W, http.StatusNotFound, "Incoming webhook not found", nil)
+			return
+		}
+		if pubsub == nil {
+			utils.SendPubSubUnsupportedError(ctx, args.W)
+			return
+		}
+
+		// Channel (and its maxMessageBytes) is decided by the body, so that read it up to the server-wide limit at first.
+		body, ok := readRequestBody(ctx, args, deps, 0)
+		if !ok {
+			return
+		}
+		message, err := receiver.Receive(ctx, args.R.Header, body)
+		if err != nil {
+			switch {
+			case errors.Is(err, incoming.ErrSignatureVerification):
+				utils.SendError(ctx, args.W, http.StatusForbidden, "Signature verification failed", err)
+			case errors.Is(err, incoming.ErrInvalidRequest):
+				utils.SendError(ctx, args.W, http.StatusBadRequest, err.Error(), err)
+			default:
+				utils.SendInternalServerError(ctx, args.W, err)
+			}
+			return
+		}
+
+		ch, ok := getChannel(ctx, args.W, deps, message.ChannelID)
+		if !ok {
+			return
+		}
+		// Incoming webhook has no bearer token, so that "sub" rate limits are not applicable.
+		if !middleware.CheckRateLimits(ctx, deps, router.MiddlewareArgs{HandlerArgs: args}, message.ChannelID, ch, domain.RateLimitPerChannel, domain.RateLimitPerIP) {
+			return
+		}
+		if !checkRequestBodySize(ctx, args.W, deps, body, ch.MaxMessageBytes()) {
+			return
+		}
+		if !validateMessage(ctx, args.W, ch, message) {
+			return
+		}
+
+		// Publish deduplicates messages by ID, so that retried requests are published only once.
+		if !publishMessage(ctx, args.W, deps, message) {
+			return
+		}
+		utils.SendJSON(ctx, args.W, http.StatusOK, map[string]interface{}{
+			"channelID": message.ChannelID,
+			"messageID": message.MessageID,
+		})
+	})
+}
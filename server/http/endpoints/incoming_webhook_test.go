@@ -0,0 +1,227 @@
+package endpoints_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+	. "github.com/saiya/dsps/server/domain/mock"
+	. "github.com/saiya/dsps/server/http"
+	"github.com/saiya/dsps/server/http/middleware"
+	"github.com/saiya/dsps/server/http/router"
+	. "github.com/saiya/dsps/server/http/testing"
+	dspstesting "github.com/saiya/dsps/server/testing"
+	"github.com/saiya/dsps/server/webhook/signature"
+)
+
+const incomingWebhookTestConfig = `
+logging: category: "*": FATAL
+channels:
+	- regex: "repo-.+"
+	- regex: "chat-.+"
+		schemaFile: ../../schema/testdata/chat-message.json
+incomingWebhooks:
+	- name: signed
+		channel: 'repo-{{ .body.repo }}'
+		messageID: { header: X-Delivery }
+		signature: { secrets: [ "secret1" ] }
+	- name: unsigned
+		channel: '{{ .body.channel }}'
+		messageID: { jsonPath: id }
+		insecureNoSignature: true
+`
+
+func TestIncomingWebhookSuccess(t *testing.T) {
+	ctx := context.Background()
+	body := `{"repo":"dsps","action":"push"}`
+	sl := domain.SubscriberLocator{ChannelID: "repo-dsps", SubscriberID: "sbsc-1"}
+	WithServer(t, strings.ReplaceAll(incomingWebhookTestConfig, "\t", "  "), func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		assert.NoError(t, deps.Storage.AsPubSubStorage().NewSubscriber(ctx, sl))
+
+		for i := 0; i < 2; i++ { // Retried request should be published only once
+			header := http.Header{}
+			signature.Sign(header, []byte(body), []string{"secret1"}, time.Now())
+			res := DoHTTPRequestWithHeaders(t, "POST", baseURL+"/incoming/signed", map[string]string{
+				"X-Delivery":              "delivery-1",
+				signature.TimestampHeader: header.Get(signature.TimestampHeader),
+				signature.SignatureHeader: header.Get(signature.SignatureHeader),
+			}, body)
+			AssertResponseJSON(t, res, 200, map[string]interface{}{
+				"channelID": "repo-dsps",
+				"messageID": "delivery-1",
+			})
+		}
+
+		fetched, _, _, err := deps.Storage.AsPubSubStorage().FetchMessages(ctx, sl, 10, domain.Duration{Duration: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(fetched))
+		assert.Equal(t, "delivery-1", string(fetched[0].MessageID))
+		assert.JSONEq(t, body, string(fetched[0].Content))
+	})
+}
+
+func TestIncomingWebhookReload(t *testing.T) {
+	clock := dspstesting.NewStubClock(t)
+	clock.Set(time.Date(2020, 11, 17, 12, 0, 0, 0, time.UTC))
+	WithServer(t, strings.ReplaceAll(incomingWebhookTestConfig, "\t", "  "), func(deps *ServerDependencies) {
+		deps.Clock = clock
+	}, func(deps *ServerDependencies, baseURL string) {
+		post := func(id string, secret string) *http.Response {
+			body := `{"repo":"dsps"}`
+			header := http.Header{}
+			signature.Sign(header, []byte(body), []string{secret}, clock.Now().Time) // Timestamp tolerance is checked with the clock of the server
+			return DoHTTPRequestWithHeaders(t, "POST", baseURL+"/incoming/signed", map[string]string{
+				"X-Delivery":              id,
+				signature.TimestampHeader: header.Get(signature.TimestampHeader),
+				signature.SignatureHeader: header.Get(signature.SignatureHeader),
+			}, body)
+		}
+		AssertResponseJSON(t, post("delivery-1", "secret1"), 200, map[string]interface{}{"channelID": "repo-dsps", "messageID": "delivery-1"})
+
+		newConfig, err := config.ParseConfig(context.Background(), config.Overrides{}, strings.ReplaceAll(strings.ReplaceAll(incomingWebhookTestConfig, "secret1", "secret2"), "\t", "  "))
+		assert.NoError(t, err)
+		assert.NoError(t, deps.ReloadConfig(context.Background(), &newConfig))
+		AssertErrorResponse(t, post("delivery-2", "secret1"), 403, nil, `Signature verification failed`)
+		AssertResponseJSON(t, post("delivery-3", "secret2"), 200, map[string]interface{}{"channelID": "repo-dsps", "messageID": "delivery-3"})
+	})
+}
+
+func TestIncomingWebhookFailures(t *testing.T) {
+	WithServer(t, strings.ReplaceAll(incomingWebhookTestConfig, "\t", "  "), func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		res := DoHTTPRequest(t, "POST", baseURL+"/incoming/no-such-hook", `{}`)
+		AssertErrorResponse(t, res, 404, nil, `Incoming webhook not found`)
+
+		res = DoHTTPRequestWithHeaders(t, "POST", baseURL+"/incoming/signed", map[string]string{
+			"X-Delivery":              "delivery-1",
+			signature.TimestampHeader: fmt.Sprintf("%d", time.Now().Unix()),
+			signature.SignatureHeader: "v1=0123456789abcdef",
+		}, `{"repo":"dsps"}`)
+		AssertErrorResponse(t, res, 403, nil, `Signature verification failed`)
+
+		res = DoHTTPRequest(t, "POST", baseURL+"/incoming/unsigned", `{"channel":"repo-dsps"}`)
+		AssertErrorResponse(t, res, 400, nil, `could not extract message ID from "id"`)
+
+		res = DoHTTPRequest(t, "POST", baseURL+"/incoming/unsigned", `{"channel":`)
+		AssertErrorResponse(t, res, 400, nil, `.+`)
+
+		res = DoHTTPRequest(t, "POST", baseURL+"/incoming/unsigned", `{"channel":"not-configured-channel","id":"msg-1"}`)
+		AssertErrorResponse(t, res, 403, domain.ErrInvalidChannel, ``)
+	})
+}
+
+func TestIncomingWebhookSchemaValidation(t *testing.T) {
+	ctx := context.Background()
+	sl := domain.SubscriberLocator{ChannelID: "chat-1", SubscriberID: "sbsc-1"}
+	WithServer(t, strings.ReplaceAll(incomingWebhookTestConfig, "\t", "  "), func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		assert.NoError(t, deps.Storage.AsPubSubStorage().NewSubscriber(ctx, sl))
+
+		res := DoHTTPRequest(t, "POST", baseURL+"/incoming/unsigned", `{"channel":"chat-1","id":"msg-1","text":1,"user":{"name":"alice"}}`)
+		AssertResponseJSON(t, res, 422, map[string]interface{}{
+			"error":            "Message content does not match with the schema of the channel",
+			"code":             domain.ErrMessageSchemaViolation.Code(),
+			"validationErrors": []interface{}{"#/text: expected string, but got number"},
+		})
+
+		res = DoHTTPRequest(t, "POST", baseURL+"/incoming/unsigned", `{"channel":"chat-1","id":"msg-2","text":"hello","user":{"name":"alice"}}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": "chat-1", "messageID": "msg-2"})
+
+		// Rejected message must not be published
+		fetched, _, _, err := deps.Storage.AsPubSubStorage().FetchMessages(ctx, sl, 10, domain.Duration{Duration: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(fetched))
+		assert.Equal(t, "msg-2", string(fetched[0].MessageID))
+	})
+}
+
+func TestIncomingWebhookBodyTooLarge(t *testing.T) {
+	WithServer(t, strings.ReplaceAll(`
+logging: category: "*": FATAL
+http:
+	maxBodyBytes: 64
+channels:
+	- regex: "small-.+"
+		maxMessageBytes: 40
+	- regex: "large-.+"
+incomingWebhooks:
+	- name: unsigned
+		channel: '{{ .body.channel }}'
+		messageID: { jsonPath: id }
+		insecureNoSignature: true
+`, "\t", "  "), func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		res := DoHTTPRequest(t, "POST", baseURL+"/incoming/unsigned", `{"channel":"small-1","id":"msg-1","a":"123456"}`)
+		AssertResponseJSON(t, res, 413, map[string]interface{}{"error": "Request body is too large", "code": router.ErrRequestBodyTooLarge.Code(), "limit": float64(40)})
+		res = DoHTTPRequest(t, "POST", baseURL+"/incoming/unsigned", `{"channel":"small-1","id":"msg-1"}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": "small-1", "messageID": "msg-1"})
+
+		// Server-wide limit applies to channels without maxMessageBytes
+		res = DoHTTPRequest(t, "POST", baseURL+"/incoming/unsigned", `{"channel":"large-1","id":"msg-1","a":"123456789012345678901234567890"}`)
+		AssertResponseJSON(t, res, 413, map[string]interface{}{"error": "Request body is too large", "code": router.ErrRequestBodyTooLarge.Code(), "limit": float64(64)})
+		res = DoHTTPRequest(t, "POST", baseURL+"/incoming/unsigned", `{"channel":"large-1","id":"msg-1","a":"123456"}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": "large-1", "messageID": "msg-1"})
+	})
+}
+
+func TestIncomingWebhookRateLimit(t *testing.T) {
+	WithServer(t, strings.ReplaceAll(`
+logging: category: "*": FATAL
+channels:
+	- regex: "rl-channel-.+"
+		rateLimits:
+			channel: { rate: 1, per: 1m }
+	- regex: "rl-ip-.+"
+		rateLimits:
+			ip: { rate: 2, per: 30s }
+incomingWebhooks:
+	- name: unsigned
+		channel: '{{ .body.channel }}'
+		messageID: { jsonPath: id }
+		insecureNoSignature: true
+`, "\t", "  "), func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		res := DoHTTPRequest(t, "POST", baseURL+"/incoming/unsigned", `{"channel":"rl-channel-1","id":"msg-1"}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": "rl-channel-1", "messageID": "msg-1"})
+		res = DoHTTPRequest(t, "POST", baseURL+"/incoming/unsigned", `{"channel":"rl-channel-1","id":"msg-2"}`)
+		assert.Equal(t, "60", res.Header.Get("Retry-After"))
+		AssertResponseJSON(t, res, 429, map[string]interface{}{
+			"code":  middleware.ErrRateLimited.Code(),
+			"error": "Too many requests",
+			"scope": "channel",
+		})
+
+		for i := 0; i < 2; i++ {
+			res = DoHTTPRequest(t, "POST", baseURL+"/incoming/unsigned", fmt.Sprintf(`{"channel":"rl-ip-1","id":"msg-%d"}`, i))
+			AssertResponseJSON(t, res, 200, map[string]interface{}{"channelID": "rl-ip-1", "messageID": fmt.Sprintf("msg-%d", i)})
+		}
+		res = DoHTTPRequest(t, "POST", baseURL+"/incoming/unsigned", `{"channel":"rl-ip-1","id":"msg-2"}`)
+		assert.Equal(t, "15", res.Header.Get("Retry-After"))
+		AssertResponseJSON(t, res, 429, map[string]interface{}{
+			"code":  middleware.ErrRateLimited.Code(),
+			"error": "Too many requests",
+			"scope": "ip",
+		})
+	})
+}
+
+func TestIncomingWebhookWithoutPubSubSupport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	storage := NewMockStorage(ctrl)
+	storage.EXPECT().AsPubSubStorage().Return(nil).AnyTimes()
+	storage.EXPECT().AsJwtStorage().Return(nil).AnyTimes()
+	storage.EXPECT().AsWebhookStorage().Return(nil).AnyTimes()
+	storage.EXPECT().AsRateLimitStorage().Return(nil).AnyTimes()
+
+	WithServer(t, strings.ReplaceAll(incomingWebhookTestConfig, "\t", "  "), func(deps *ServerDependencies) {
+		deps.Storage = storage
+	}, func(deps *ServerDependencies, baseURL string) {
+		res := DoHTTPRequest(t, "POST", baseURL+"/incoming/unsigned", `{"channel":"repo-dsps","id":"msg-1"}`)
+		AssertErrorResponse(t, res, 501, nil, `No PubSub compatible storage available`)
+	})
+}
@@ -0,0 +1,24 @@
+package endpoints
+
+import (
+	"context"
+
+	"github.com/saiya/dsps/server/http/router"
+	"github.com/saiya/dsps/server/telemetry"
+)
+
+// MetricsEndpointDependency is to inject required objects to the endpoint
+type MetricsEndpointDependency interface {
+	GetTelemetry() *telemetry.Telemetry
+}
+
+// InitMetricsEndpoints registers endpoints, do nothing if Prometheus exporter is not enabled
+func InitMetricsEndpoints(rt *router.Router, deps MetricsEndpointDependency) {
+	handler := deps.GetTelemetry().PrometheusHandler()
+	if handler == nil {
+		return
+	}
+	rt.GET("/metrics", func(ctx context.Context, args router.HandlerArgs) {
+		handler.ServeHTTP(args.W, args.R.Request)
+	})
+}
@@ -0,0 +1,62 @@
+package endpoints_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/saiya/dsps/server/http"
+	. "github.com/saiya/dsps/server/http/testing"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	WithServer(t, `{ logging: { category: { "*": FATAL } }, telemetry: { ot: { exporters: { prometheus: { enable: true } } } } }`, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		res := DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/%s/message/%s", baseURL, "my-channel", "msg-1"), `{}`)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 200, res.StatusCode)
+
+		res = DoHTTPRequest(t, "GET", baseURL+"/metrics", "")
+		assert.Equal(t, 200, res.StatusCode)
+		body, err := ioutil.ReadAll(res.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, res.Body.Close())
+
+		assert.Contains(t, string(body), `dsps_http_server_requests{http_method="PUT",http_route="/channel/:channelID/message/:messageID",http_status_code="200"} 1`)
+		assert.Contains(t, string(body), `dsps_http_server_duration_seconds_count{http_method="PUT",http_route="/channel/:channelID/message/:messageID",http_status_code="200"} 1`)
+		assert.Contains(t, string(body), `dsps_messages_published{dsps_storage_id="#root"} 1`)
+		assert.Contains(t, string(body), `dsps_storage_operation_duration_seconds_count{dsps_storage_id="#root",dsps_storage_operation="PublishMessages"} 1`)
+		// Sub storages record latency but not message counters, to avoid double counting
+		assert.Regexp(t, `dsps_storage_operation_duration_seconds_count\{dsps_storage_id="[^#"][^"]*",dsps_storage_operation="PublishMessages"\} 1`, string(body))
+		assert.NotRegexp(t, `dsps_messages_published\{dsps_storage_id="[^#"]`, string(body))
+
+		res = DoHTTPRequest(t, "GET", baseURL+"/admin/metrics", "")
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 404, res.StatusCode)
+	})
+}
+
+func TestMetricsEndpointOnAdmin(t *testing.T) {
+	WithServer(t, `{ logging: { category: { "*": FATAL } }, telemetry: { ot: { exporters: { prometheus: { enable: true, admin: true } } } } }`, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		res := DoHTTPRequest(t, "GET", baseURL+"/metrics", "")
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 404, res.StatusCode)
+
+		res = DoHTTPRequest(t, "GET", baseURL+"/admin/metrics", "")
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 403, res.StatusCode)
+
+		res = DoHTTPRequestWithHeaders(t, "GET", baseURL+"/admin/metrics", AdminAuthHeaders(t, deps), "")
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 200, res.StatusCode)
+	})
+}
+
+func TestMetricsEndpointDisabled(t *testing.T) {
+	WithServer(t, `logging: category: "*": FATAL`, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		res := DoHTTPRequest(t, "GET", baseURL+"/metrics", "")
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 404, res.StatusCode)
+	})
+}
@@ -0,0 +1,56 @@
+package endpoints
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/saiya/dsps/server/http/router"
+	"github.com/saiya/dsps/server/http/utils"
+	"github.com/saiya/dsps/server/telemetry"
+)
+
+// RequestBodyDependency is to inject required objects to read request body
+type RequestBodyDependency interface {
+	GetMaxBodyBytes() int64
+	GetTelemetry() *telemetry.Telemetry
+}
+
+// readRequestBody reads request body up to the server-wide limit, or up to maxBytes if it is non-zero and smaller than the server-wide limit.
+// Returns false if failed, in that case error response has been sent.
+func readRequestBody(ctx context.Context, args router.HandlerArgs, deps RequestBodyDependency, maxBytes int64) ([]byte, bool) {
+	limit := deps.GetMaxBodyBytes()
+	if maxBytes > 0 && (limit == 0 || maxBytes < limit) {
+		limit = maxBytes
+	}
+
+	body, err := args.R.ReadBody(limit)
+	if err != nil {
+		var tooLarge *router.RequestBodyTooLargeError
+		if errors.As(err, &tooLarge) {
+			sendRequestBodyTooLarge(ctx, args.W, deps, tooLarge)
+		} else {
+			utils.SendError(ctx, args.W, http.StatusBadRequest, "Failed to read request body", err)
+		}
+		return nil, false
+	}
+	return body, true
+}
+
+// checkRequestBodySize checks size of the already read request body against maxBytes (zero means unlimited).
+// Use this if the limit is unknown until the body is read (e.g. channel is decided by the body).
+// Returns false if the body is too large, in that case error response has been sent.
+func checkRequestBodySize(ctx context.Context, w http.ResponseWriter, deps RequestBodyDependency, body []byte, maxBytes int64) bool {
+	if maxBytes > 0 && int64(len(body)) > maxBytes {
+		sendRequestBodyTooLarge(ctx, w, deps, &router.RequestBodyTooLargeError{Limit: maxBytes})
+		return false
+	}
+	return true
+}
+
+func sendRequestBodyTooLarge(ctx context.Context, w http.ResponseWriter, deps RequestBodyDependency, err *router.RequestBodyTooLargeError) {
+	deps.GetTelemetry().CountRequestBodyTooLarge(ctx)
+	utils.SendErrorWithDetails(ctx, w, http.StatusRequestEntityTooLarge, "Request body is too large", err, map[string]interface{}{
+		"limit": err.Limit,
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/saiya/dsps/server/clientcert"
 	"github.com/saiya/dsps/server/config"
 	"github.com/saiya/dsps/server/http/router"
 	"github.com/saiya/dsps/server/http/utils"
@@ -13,6 +14,7 @@ import (
 type AdminAuthDependency interface {
 	RealIPDependency
 	GetAdminAuthConfig() *config.AdminAuthConfig
+	GetAdminClientCertValidators() []clientcert.Validator // Compiled from GetAdminAuthConfig().ClientCerts
 }
 
 // NewAdminAuth creates middleware for authentication
@@ -37,12 +39,29 @@ func NewAdminAuth(mainCtx context.Context, deps AdminAuthDependency) router.Midd
 		return false
 	}
 
+	allowedClientCert := func(ctx context.Context, args router.MiddlewareArgs) bool {
+		validators := deps.GetAdminClientCertValidators()
+		if len(validators) == 0 {
+			return false
+		}
+		certs := utils.GetVerifiedClientCert(args)
+		if certs == nil {
+			return false
+		}
+		for _, v := range validators {
+			if v.Validate(ctx, certs) == nil {
+				return true
+			}
+		}
+		return false
+	}
+
 	return router.AsMiddlewareFunc(func(ctx context.Context, args router.MiddlewareArgs, next func(context.Context, router.MiddlewareArgs)) {
 		if !allowedIP(args) {
 			utils.SendError(ctx, args.W, 403, ``, fmt.Errorf(`IP address is not in allow list: %s`, GetRealIP(deps, args.R)))
 			return
 		}
-		if !allowedToken(ctx, args) {
+		if !allowedClientCert(ctx, args) && !allowedToken(ctx, args) {
 			utils.SendError(ctx, args.W, 403, ``, fmt.Errorf(`Invalid authentication token or no token`))
 			return
 		}
@@ -1,11 +1,13 @@
 package middleware_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/saiya/dsps/server/config"
 	. "github.com/saiya/dsps/server/http"
 	. "github.com/saiya/dsps/server/http/testing"
 )
@@ -46,3 +48,26 @@ admin:
 		assert.Equal(t, 403, res.StatusCode)
 	})
 }
+
+func TestAdminAuthReload(t *testing.T) {
+	WithServer(t, `
+admin:
+	auth:
+		bearer:
+			- 'my-api-key'
+	`, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		url := fmt.Sprintf("%s/admin/log/level?category=auth&level=ERROR", baseURL)
+
+		newConfig, err := config.ParseConfig(context.Background(), config.Overrides{}, "admin: { auth: { bearer: [ 'new-api-key' ] } }")
+		assert.NoError(t, err)
+		assert.NoError(t, deps.ReloadConfig(context.Background(), &newConfig))
+
+		res := DoHTTPRequestWithHeaders(t, "PUT", url, map[string]string{"Authorization": "Bearer my-api-key"}, ``)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 403, res.StatusCode)
+
+		res = DoHTTPRequestWithHeaders(t, "PUT", url, map[string]string{"Authorization": "Bearer new-api-key"}, ``)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 204, res.StatusCode)
+	})
+}
@@ -0,0 +1,136 @@
+package middleware_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/config"
+	. "github.com/saiya/dsps/server/http"
+	. "github.com/saiya/dsps/server/http/testing"
+	. "github.com/saiya/dsps/server/jwt/testing"
+)
+
+const certDir = "../../clientcert/testdata"
+
+var configTLS = fmt.Sprintf(`
+logging: category: "*": ERROR
+http:
+	tls:
+		certFile: %s/server.pem
+		keyFile: %s/server-key.pem
+		clientAuth:
+			caFiles: [ %s/ca.pem ]
+`, certDir, certDir, certDir)
+
+func TestNormalAuthClientCert(t *testing.T) {
+	WithServer(t, configTLS+`
+channels:
+	-
+		regex: 'cert-test-channel'
+		clientCert:
+			subject: { OU: [ backend ] }
+`, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		putURL := fmt.Sprintf("%s/channel/%s/message/%s", baseURL, "cert-test-channel", "msg-1")
+
+		// Without client certificate
+		res := DoHTTPRequestWithClient(t, NewTLSClient(t, certDir+"/ca.pem", "", ""), "PUT", putURL, map[string]string{}, `{}`)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 403, res.StatusCode)
+
+		// With valid client certificate
+		res = DoHTTPRequestWithClient(t, NewTLSClient(t, certDir+"/ca.pem", certDir+"/client1.pem", certDir+"/client1-key.pem"), "PUT", putURL, map[string]string{}, `{}`)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 200, res.StatusCode)
+
+		// With client certificate that does not match with the rule
+		res = DoHTTPRequestWithClient(t, NewTLSClient(t, certDir+"/ca.pem", certDir+"/client2.pem", certDir+"/client2-key.pem"), "PUT", putURL, map[string]string{}, `{}`)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 403, res.StatusCode)
+	})
+}
+
+func TestNormalAuthClientCertOrJwt(t *testing.T) {
+	WithServer(t, configTLS+`
+channels:
+	-
+		regex: 'auth-test-channel'
+		clientCert:
+			subject: { OU: [ backend ] }
+		jwt:
+			iss: [ "https://issuer.example.com/issuer-url" ]
+			keys:
+				RS256: [ "../../jwt/testdata/RS256-2048bit-public.pem" ]
+`, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		putURL := fmt.Sprintf("%s/channel/%s/message/%s", baseURL, "auth-test-channel", "msg-1")
+		withoutCert := NewTLSClient(t, certDir+"/ca.pem", "", "")
+
+		// Client certificate is enough
+		res := DoHTTPRequestWithClient(t, NewTLSClient(t, certDir+"/ca.pem", certDir+"/client1.pem", certDir+"/client1-key.pem"), "PUT", putURL, map[string]string{}, `{}`)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 200, res.StatusCode)
+
+		// JWT is also acceptable
+		res = DoHTTPRequestWithClient(t, withoutCert, "PUT", putURL, map[string]string{"Authorization": "Bearer " + GenerateJwt(t, JwtProps{
+			Alg:     "RS256",
+			Keyname: "RS256-2048bit",
+			JwtDir:  jwtDir,
+			Iss:     "https://issuer.example.com/issuer-url",
+		})}, `{}`)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 200, res.StatusCode)
+
+		// Neither
+		res = DoHTTPRequestWithClient(t, withoutCert, "PUT", putURL, map[string]string{}, `{}`)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 403, res.StatusCode)
+	})
+}
+
+func TestAdminAuthClientCert(t *testing.T) {
+	WithServer(t, configTLS+`
+admin:
+	auth:
+		bearer: [ 'my-api-key' ]
+		clientCerts:
+			- subject: { CN: [ client1.example.com ] }
+`, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		url := fmt.Sprintf("%s/admin/log/level?category=auth&level=ERROR", baseURL)
+
+		// Client certificate matches with the rule
+		res := DoHTTPRequestWithClient(t, NewTLSClient(t, certDir+"/ca.pem", certDir+"/client1.pem", certDir+"/client1-key.pem"), "PUT", url, map[string]string{}, ``)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 204, res.StatusCode)
+
+		// Client certificate does not match with the rule
+		client2 := NewTLSClient(t, certDir+"/ca.pem", certDir+"/client2.pem", certDir+"/client2-key.pem")
+		res = DoHTTPRequestWithClient(t, client2, "PUT", url, map[string]string{}, ``)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 403, res.StatusCode)
+
+		// Bearer token is still acceptable
+		res = DoHTTPRequestWithClient(t, client2, "PUT", url, AdminAuthHeaders(t, deps), ``)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 204, res.StatusCode)
+
+		// Reload rules
+		newConfig, err := config.ParseConfig(context.Background(), config.Overrides{}, strings.ReplaceAll(configTLS+`
+admin:
+	auth:
+		bearer: [ 'my-api-key' ]
+		clientCerts:
+			- subject: { CN: [ client2.example.com ] }
+`, "\t", "  "))
+		assert.NoError(t, err)
+		assert.NoError(t, deps.ReloadConfig(context.Background(), &newConfig))
+		res = DoHTTPRequestWithClient(t, client2, "PUT", url, map[string]string{}, ``)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 204, res.StatusCode)
+		res = DoHTTPRequestWithClient(t, NewTLSClient(t, certDir+"/ca.pem", certDir+"/client1.pem", certDir+"/client1-key.pem"), "PUT", url, map[string]string{}, ``)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 403, res.StatusCode)
+	})
+}
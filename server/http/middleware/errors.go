@@ -7,4 +7,6 @@ import (
 var (
 	// ErrAuthRejection : auth rejection
 	ErrAuthRejection = domain.NewErrorWithCode("dsps.auth.rejected")
+	// ErrRateLimited : request rejected by rate limit
+	ErrRateLimited = domain.NewErrorWithCode("dsps.http.rate-limited")
 )
@@ -13,17 +13,20 @@ import (
 	"github.com/saiya/dsps/server/jwt"
 	"github.com/saiya/dsps/server/logger"
 	"github.com/saiya/dsps/server/sentry"
+	"github.com/saiya/dsps/server/telemetry"
 )
 
 // NormalAuthDependency is to inject required objects to the middleware
 type NormalAuthDependency interface {
 	GetStorage() domain.Storage
 	DiscloseAuthRejectionDetail() bool
+	GetTelemetry() *telemetry.Telemetry
 }
 
 // NewNormalAuth creates middleware for authentication
 func NewNormalAuth(mainCtx context.Context, deps NormalAuthDependency, channelOf func(context.Context, router.MiddlewareArgs) (domain.Channel, error)) router.MiddlewareFunc {
 	jwtStorage := deps.GetStorage().AsJwtStorage()
+	telemetry := deps.GetTelemetry()
 	return router.AsMiddlewareFunc(func(ctx context.Context, args router.MiddlewareArgs, next func(context.Context, router.MiddlewareArgs)) {
 		channel, err := channelOf(ctx, args)
 		if err != nil {
@@ -31,6 +34,16 @@ func NewNormalAuth(mainCtx context.Context, deps NormalAuthDependency, channelOf
 			return
 		}
 
+		certErr := channel.ValidateClientCert(ctx, utils.GetVerifiedClientCert(args))
+		if certErr == nil {
+			// Authenticated by TLS client certificate, no need to check bearer token.
+			next(ctx, args)
+			return
+		}
+		if !errors.Is(certErr, domain.ErrClientCertAuthNotConfigured) {
+			logger.Of(ctx).Debugf(logger.CatAuth, `Client certificate verification failure, fallback to bearer token: %v`, certErr)
+		}
+
 		bearerToken := utils.GetBearerToken(ctx, args)
 		authErr := channel.ValidateJwt(ctx, bearerToken)
 		if authErr == nil && jwtStorage != nil {
@@ -48,8 +61,12 @@ func NewNormalAuth(mainCtx context.Context, deps NormalAuthDependency, channelOf
 				}
 			}
 		}
+		if authErr != nil && !errors.Is(certErr, domain.ErrClientCertAuthNotConfigured) {
+			authErr = fmt.Errorf("%w (client certificate verification failure: %v)", authErr, certErr)
+		}
 		if authErr != nil {
 			logger.Of(ctx).Infof(logger.CatAuth, `JWT verification failure: %v`, authErr)
+			telemetry.CountJwtRejection(ctx)
 			sentry.AddBreadcrumb(ctx, &sentrygo.Breadcrumb{
 				Level:    sentrygo.LevelWarning,
 				Category: "auth",
@@ -12,6 +12,7 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/saiya/dsps/server/config"
 	. "github.com/saiya/dsps/server/domain"
 	. "github.com/saiya/dsps/server/http"
 	. "github.com/saiya/dsps/server/http/middleware"
@@ -238,3 +239,25 @@ func TestNormalAuthRejectionWithDetail(t *testing.T) {
 		assert.Regexp(t, `JWT verification failure.+token is malformed`, BodyJSONMapOfRec(t, rec)["reason"])
 	})
 }
+
+func TestNormalAuthReload(t *testing.T) {
+	WithServer(t, ``, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		putURL := fmt.Sprintf("%s/channel/%s/message/%s", baseURL, "auth-test-channel", "msg-1")
+
+		// Invalid configuration must be rejected, keeping current configuration
+		invalidConfig, err := config.ParseConfig(context.Background(), config.Overrides{}, `channels: [ { regex: ".+", webhooks: [ { url: "http://localhost/{{.channel.undefined}}" } ] } ]`)
+		assert.NoError(t, err)
+		assert.Error(t, deps.ReloadConfig(context.Background(), &invalidConfig))
+		res := DoHTTPRequest(t, "PUT", putURL, `{}`)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 200, res.StatusCode)
+
+		// Reload to require JWT
+		newConfig, err := config.ParseConfig(context.Background(), config.Overrides{}, strings.ReplaceAll(configRequiresJWT, "\t", "  "))
+		assert.NoError(t, err)
+		assert.NoError(t, deps.ReloadConfig(context.Background(), &newConfig))
+		res = DoHTTPRequest(t, "PUT", putURL, `{}`)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 403, res.StatusCode)
+	})
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/http/router"
+	"github.com/saiya/dsps/server/http/utils"
+	"github.com/saiya/dsps/server/jwt"
+	"github.com/saiya/dsps/server/logger"
+	"github.com/saiya/dsps/server/telemetry"
+)
+
+// RateLimitDependency is to inject required objects to the middleware
+type RateLimitDependency interface {
+	GetStorage() domain.Storage
+	GetTelemetry() *telemetry.Telemetry
+	RealIPDependency
+}
+
+// NewPreAuthRateLimit creates middleware that rejects requests exceeding "channel" and "ip" rate limits of the channel.
+// Must be placed before authentication middleware so that requests failing authentication (e.g. brute force) are also limited.
+func NewPreAuthRateLimit(deps RateLimitDependency, channelOf func(context.Context, router.MiddlewareArgs) (domain.Channel, error)) router.MiddlewareFunc {
+	return newRateLimit(deps, channelOf, domain.RateLimitPerChannel, domain.RateLimitPerIP)
+}
+
+// NewPostAuthRateLimit creates middleware that rejects requests exceeding "sub" rate limits of the channel.
+// Must be placed after authentication middleware because it trusts "sub" claim of the bearer token.
+func NewPostAuthRateLimit(deps RateLimitDependency, channelOf func(context.Context, router.MiddlewareArgs) (domain.Channel, error)) router.MiddlewareFunc {
+	return newRateLimit(deps, channelOf, domain.RateLimitPerSubject)
+}
+
+func newRateLimit(deps RateLimitDependency, channelOf func(context.Context, router.MiddlewareArgs) (domain.Channel, error), scopes ...domain.RateLimitScope) router.MiddlewareFunc {
+	return router.AsMiddlewareFunc(func(ctx context.Context, args router.MiddlewareArgs, next func(context.Context, router.MiddlewareArgs)) {
+		if deps.GetStorage().AsRateLimitStorage() == nil {
+			next(ctx, args)
+			return
+		}
+		channel, err := channelOf(ctx, args)
+		if err != nil {
+			utils.SendInvalidParameter(ctx, args.W, "channelID", err)
+			return
+		}
+		if !CheckRateLimits(ctx, deps, args, domain.ChannelID(args.PS.ByName("channelID")), channel, scopes...) {
+			return
+		}
+		next(ctx, args)
+	})
+}
+
+// CheckRateLimits takes tokens of the rate limits of the channel in given scopes.
+// If any limit exceeded, sends 429 response and returns false.
+// For endpoints that resolve channel by themselves (e.g. incoming webhook), other endpoints should use rate limit middlewares.
+func CheckRateLimits(ctx context.Context, deps RateLimitDependency, args router.MiddlewareArgs, channelID domain.ChannelID, channel domain.Channel, scopes ...domain.RateLimitScope) bool {
+	storage := deps.GetStorage().AsRateLimitStorage()
+	if storage == nil {
+		return true
+	}
+	for _, limit := range channel.RateLimits() {
+		if !containsRateLimitScope(scopes, limit.Scope) {
+			continue
+		}
+		key := rateLimitKeyOf(ctx, deps, args, channelID, limit)
+		if key == "" {
+			continue
+		}
+		wait, err := storage.TakeRateLimitToken(ctx, key, limit.RateLimit)
+		if err != nil {
+			// Prefer availability rather than strict limit on storage failure.
+			logger.Of(ctx).WarnError(logger.CatHTTP, fmt.Sprintf("failed to check rate limit (%s: %s), skipped it", limit.Scope, limit.RateLimit), err)
+			continue
+		}
+		if wait.Duration > 0 {
+			deps.GetTelemetry().CountRateLimited(ctx, limit.Scope)
+			args.W.Header().Set("Retry-After", strconv.Itoa(retryAfterSecondsOf(wait.Duration)))
+			logger.Of(ctx).Infof(logger.CatHTTP, "Request rejected by rate limit (%s: %s)", limit.Scope, limit.RateLimit)
+			utils.SendJSON(ctx, args.W, 429, map[string]interface{}{
+				"code":  ErrRateLimited.Code(),
+				"error": "Too many requests",
+				"scope": limit.Scope,
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// rateLimitKeyOf returns bucket key of the rate limit, returns empty string if the request is not subject to the limit.
+func rateLimitKeyOf(ctx context.Context, deps RateLimitDependency, args router.MiddlewareArgs, channelID domain.ChannelID, limit domain.ChannelRateLimit) string {
+	switch limit.Scope {
+	case domain.RateLimitPerChannel:
+		return fmt.Sprintf("%s/%s/%s", limit.Scope, channelID, limit.RateLimit)
+	case domain.RateLimitPerIP:
+		ip := GetRealIP(deps, args.R)
+		if ip == "" {
+			return ""
+		}
+		return fmt.Sprintf("%s/%s/%s/%s", limit.Scope, channelID, limit.RateLimit, ip)
+	case domain.RateLimitPerSubject:
+		bearerToken := utils.GetBearerToken(ctx, args)
+		if bearerToken == "" {
+			return ""
+		}
+		sub, err := jwt.ExtractSubject(bearerToken)
+		if err != nil || sub == "" {
+			return "" // Not a JWT or no "sub" claim
+		}
+		return fmt.Sprintf("%s/%s/%s/%s", limit.Scope, channelID, limit.RateLimit, sub)
+	}
+	return ""
+}
+
+func containsRateLimitScope(scopes []domain.RateLimitScope, scope domain.RateLimitScope) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// retryAfterSecondsOf returns value of Retry-After header, rounded up to seconds.
+func retryAfterSecondsOf(wait time.Duration) int {
+	sec := int(math.Ceil(wait.Seconds()))
+	if sec < 1 {
+		return 1
+	}
+	return sec
+}
@@ -0,0 +1,142 @@
+package middleware_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/saiya/dsps/server/http"
+	. "github.com/saiya/dsps/server/http/middleware"
+	. "github.com/saiya/dsps/server/http/testing"
+	. "github.com/saiya/dsps/server/jwt/testing"
+)
+
+var configWithRateLimits = strings.ReplaceAll(`
+logging: category: "*": ERROR
+channels:
+	-
+		regex: 'no-limit'
+	-
+		regex: 'rl-channel-.+'
+		rateLimits:
+			channel: { rate: 1, per: 1m }
+	-
+		regex: 'rl-ip-.+'
+		rateLimits:
+			ip: { rate: 2, per: 1m }
+	-
+		regex: 'rl-sub-.+'
+		rateLimits:
+			sub: { rate: 1, per: 30s }
+	-
+		regex: 'rl-auth-.+'
+		rateLimits:
+			ip: { rate: 2, per: 1m }
+		jwt:
+			iss: [ "https://issuer.example.com/issuer-url" ]
+			keys:
+				RS256: [ "../../jwt/testdata/RS256-2048bit-public.pem" ]
+`, "\t", "  ")
+
+func TestRateLimitPerChannel(t *testing.T) {
+	WithServer(t, configWithRateLimits, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		res := DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/rl-channel-1/message/msg-1", baseURL), `{}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{})
+
+		res = DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/rl-channel-1/message/msg-2", baseURL), `{}`)
+		assert.Equal(t, "60", res.Header.Get("Retry-After"))
+		AssertResponseJSON(t, res, 429, map[string]interface{}{
+			"code":  ErrRateLimited.Code(),
+			"error": "Too many requests",
+			"scope": "channel",
+		})
+
+		// Other channels have own buckets
+		res = DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/rl-channel-2/message/msg-1", baseURL), `{}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{})
+
+		// Channel without rate limits
+		for i := 0; i < 3; i++ {
+			res = DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/no-limit/message/msg-%d", baseURL, i), `{}`)
+			AssertResponseJSON(t, res, 200, map[string]interface{}{})
+		}
+	})
+}
+
+func TestRateLimitPerIP(t *testing.T) {
+	WithServer(t, configWithRateLimits, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		for i := 0; i < 2; i++ {
+			res := DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/rl-ip-1/message/msg-%d", baseURL, i), `{}`)
+			AssertResponseJSON(t, res, 200, map[string]interface{}{})
+		}
+		res := DoHTTPRequest(t, "GET", fmt.Sprintf("%s/channel/rl-ip-1/subscription/polling/sbsc-1", baseURL), ``)
+		assert.Equal(t, "30", res.Header.Get("Retry-After"))
+		AssertResponseJSON(t, res, 429, map[string]interface{}{
+			"code":  ErrRateLimited.Code(),
+			"scope": "ip",
+		})
+	})
+}
+
+func TestRateLimitBeforeAuthentication(t *testing.T) {
+	WithServer(t, configWithRateLimits, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		url := fmt.Sprintf("%s/channel/rl-auth-1/message/msg-1", baseURL)
+		for i := 0; i < 2; i++ {
+			res := DoHTTPRequestWithHeaders(t, "PUT", url, map[string]string{"Authorization": "Bearer invalid-token"}, `{}`)
+			assert.NoError(t, res.Body.Close())
+			assert.Equal(t, 403, res.StatusCode)
+		}
+
+		// Requests failing authentication also consume rate limit of the IP
+		res := DoHTTPRequestWithHeaders(t, "PUT", url, map[string]string{"Authorization": "Bearer invalid-token"}, `{}`)
+		AssertResponseJSON(t, res, 429, map[string]interface{}{
+			"code":  ErrRateLimited.Code(),
+			"scope": "ip",
+		})
+	})
+}
+
+func TestRateLimitPerSubject(t *testing.T) {
+	jwtOf := func(sub string) map[string]string {
+		return map[string]string{
+			"Authorization": "Bearer " + GenerateJwt(t, JwtProps{
+				Alg:     "RS256",
+				Keyname: "RS256-2048bit",
+				JwtDir:  jwtDir,
+				Claims:  map[string]interface{}{"sub": sub},
+			}),
+		}
+	}
+	WithServer(t, configWithRateLimits, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		url := fmt.Sprintf("%s/channel/rl-sub-1/message/msg-1", baseURL)
+		res := DoHTTPRequestWithHeaders(t, "PUT", url, jwtOf("user-a"), `{}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{})
+
+		res = DoHTTPRequestWithHeaders(t, "PUT", url, jwtOf("user-a"), `{}`)
+		assert.Equal(t, "30", res.Header.Get("Retry-After"))
+		AssertResponseJSON(t, res, 429, map[string]interface{}{
+			"code":  ErrRateLimited.Code(),
+			"scope": "sub",
+		})
+
+		res = DoHTTPRequestWithHeaders(t, "PUT", url, jwtOf("user-b"), `{}`)
+		AssertResponseJSON(t, res, 200, map[string]interface{}{})
+
+		// Requests without "sub" are not limited by subject
+		for i := 0; i < 2; i++ {
+			res = DoHTTPRequest(t, "PUT", url, `{}`)
+			AssertResponseJSON(t, res, 200, map[string]interface{}{})
+		}
+	})
+}
+
+func TestRateLimitUnsupportedStorage(t *testing.T) {
+	WithServer(t, configWithRateLimits+`storages: { mem: { onmemory: { __disableRateLimit: true } } }`, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		for i := 0; i < 3; i++ {
+			res := DoHTTPRequest(t, "PUT", fmt.Sprintf("%s/channel/rl-channel-1/message/msg-%d", baseURL, i), `{}`)
+			AssertResponseJSON(t, res, 200, map[string]interface{}{})
+		}
+	})
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"time"
 
 	"github.com/saiya/dsps/server/http/router"
 	"github.com/saiya/dsps/server/telemetry"
@@ -12,16 +13,18 @@ type TracingDependency interface {
 	GetTelemetry() *telemetry.Telemetry
 }
 
-// TracingMiddleware traces incoming HTTP request/response
+// TracingMiddleware traces incoming HTTP request/response, also records metrics of them
 func TracingMiddleware(realIPDeps RealIPDependency, deps TracingDependency) router.MiddlewareFunc {
 	telemetry := deps.GetTelemetry()
 	return func(method, path string) router.Middleware {
 		return func(ctx context.Context, args router.MiddlewareArgs, next func(context.Context, router.MiddlewareArgs)) {
+			startedAt := time.Now()
 			ctx, end := telemetry.StartHTTPSpan(ctx, true, args.R.Request)
 			defer end()
 			telemetry.SetHTTPServerAttributes(ctx, args.R.Request, path, GetRealIP(realIPDeps, args.R))
 			next(ctx, args)
 			telemetry.SetHTTPResponseAttributes(ctx, args.W.Written().StatusCode, int64(args.W.Written().BodyBytes))
+			telemetry.RecordHTTPServerMetrics(ctx, method, path, args.W.Written().StatusCode, time.Since(startedAt))
 		}
 	}
 }
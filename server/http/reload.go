@@ -0,0 +1,63 @@
+package http
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain/channel"
+	"github.com/saiya/dsps/server/logger"
+)
+
+// ReloadConfig applies reloadable part of the given configuration (channels, logging thresholds, admin auth and incoming webhooks) to the running server.
+// If failed, keeps current configuration and returns error.
+// Other configuration items (e.g. http, storages) are not reloaded, those require restart.
+func (deps *ServerDependencies) ReloadConfig(ctx context.Context, newConfig *config.ServerConfig) error {
+	channelProvider, ok := deps.ChannelProvider.(channel.ReloadableChannelProvider)
+	if !ok {
+		return fmt.Errorf("channel provider does not support reload")
+	}
+	// Validate before any change to keep current configuration on failure.
+	if _, err := logger.NewFilter(newConfig.Logging.Category); err != nil {
+		return fmt.Errorf("invalid logging configuration: %w", err)
+	}
+	reloaded, err := newReloadableConfig(ctx, newConfig, deps.Clock)
+	if err != nil {
+		return err
+	}
+
+	// Previous channels may be used by in-flight requests (e.g. long polling) until they finish.
+	retireDelay := deps.Config.HTTPServer.LongPollingMaxTimeout.Duration + deps.Config.HTTPServer.WriteTimeout.Duration
+	if err := channelProvider.Reload(ctx, newConfig, retireDelay); err != nil {
+		return fmt.Errorf("failed to reload channels configuration: %w", err)
+	}
+	if err := deps.LogFilter.ResetThresholds(newConfig.Logging.Category); err != nil {
+		return fmt.Errorf("failed to reload logging configuration: %w", err)
+	}
+
+	deps.reloadLock.Lock()
+	defer deps.reloadLock.Unlock()
+	deps.reloaded = reloaded
+	return nil
+}
+
+func reloadConfig(ctx context.Context, deps *ServerDependencies) {
+	if deps.ConfigLoader == nil {
+		logger.Of(ctx).Warnf(logger.CatServer, "Configuration reload is not supported on this server")
+		return
+	}
+	logger.Of(ctx).Infof(logger.CatServer, "Reloading configuration...")
+	newConfig, err := deps.ConfigLoader(ctx)
+	if err == nil && newConfig.Source == "" {
+		// e.g. stdin could not be read again, applying such configuration silently drops configured channels.
+		err = fmt.Errorf("reloaded configuration is not loaded from a configuration file")
+	}
+	if err == nil {
+		err = deps.ReloadConfig(ctx, &newConfig)
+	}
+	if err != nil {
+		logger.Of(ctx).Error("Failed to reload configuration, keep current configuration", err)
+		return
+	}
+	logger.Of(ctx).Infof(logger.CatServer, "Configuration reloaded")
+}
@@ -1,8 +1,12 @@
 package router
 
 import (
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+
+	"github.com/saiya/dsps/server/domain"
 )
 
 // Request wraps http.Request
@@ -10,11 +14,48 @@ type Request struct {
 	*http.Request
 }
 
-// ReadBody read request body
-func (req Request) ReadBody() ([]byte, error) {
+// ErrRequestBodyTooLarge is an error of the request body larger than the limit, see RequestBodyTooLargeError
+var ErrRequestBodyTooLarge = domain.NewErrorWithCode("dsps.http.request-body-too-large")
+
+// RequestBodyTooLargeError is returned by ReadBody if the request body exceeds the limit
+type RequestBodyTooLargeError struct {
+	Limit int64 // Max size in bytes
+}
+
+func (e *RequestBodyTooLargeError) Error() string {
+	return fmt.Sprintf("request body exceeds the limit (%d bytes)", e.Limit)
+}
+
+// Code returns error code
+func (e *RequestBodyTooLargeError) Code() string {
+	return ErrRequestBodyTooLarge.Code()
+}
+
+// Unwrap returns ErrRequestBodyTooLarge
+func (e *RequestBodyTooLargeError) Unwrap() error {
+	return ErrRequestBodyTooLarge
+}
+
+// ReadBody read request body, returns *RequestBodyTooLargeError if the body is larger than maxBytes (zero means unlimited).
+// It stops reading as soon as the body exceeds the limit, so that too large body is never buffered entirely.
+func (req Request) ReadBody(maxBytes int64) ([]byte, error) {
 	// > The Server will close the request body. The ServeHTTP Handler does not need to.
 	// https://golang.org/pkg/net/http/#ResponseWriter
-	return ioutil.ReadAll(req.Body)
+	if maxBytes <= 0 {
+		return ioutil.ReadAll(req.Body)
+	}
+	if req.ContentLength > maxBytes {
+		return nil, &RequestBodyTooLargeError{Limit: maxBytes}
+	}
+	// ContentLength could be unknown (chunked encoding), read one more byte to detect too large body.
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, &RequestBodyTooLargeError{Limit: maxBytes}
+	}
+	return body, nil
 }
 
 // GetQueryParam returns URL query parameter or ""
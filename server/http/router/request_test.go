@@ -1,6 +1,8 @@
 package router_test
 
 import (
+	"errors"
+	"io/ioutil"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -11,10 +13,31 @@ import (
 )
 
 func TestRequest(t *testing.T) {
-	body, err := Request{Request: httptest.NewRequest("GET", "/", strings.NewReader(`{"hi":"hello"}`))}.ReadBody()
+	body, err := Request{Request: httptest.NewRequest("GET", "/", strings.NewReader(`{"hi":"hello"}`))}.ReadBody(0)
 	assert.NoError(t, err)
 	assert.Equal(t, `{"hi":"hello"}`, string(body))
 
 	assert.Equal(t, "bar baz", Request{Request: httptest.NewRequest("GET", "/?foo=bar%20baz", strings.NewReader(``))}.GetQueryParam("foo"))
 	assert.Equal(t, "", Request{Request: httptest.NewRequest("GET", "/?foo=bar", strings.NewReader(``))}.GetQueryParam("baz"))
 }
+
+func TestRequestBodyLimit(t *testing.T) {
+	body, err := Request{Request: httptest.NewRequest("PUT", "/", strings.NewReader(`{"hi":"hello"}`))}.ReadBody(14)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"hi":"hello"}`, string(body))
+
+	// Content-Length exceeds the limit
+	_, err = Request{Request: httptest.NewRequest("PUT", "/", strings.NewReader(`{"hi":"hello"}`))}.ReadBody(13)
+	assert.True(t, errors.Is(err, ErrRequestBodyTooLarge))
+	var tooLarge *RequestBodyTooLargeError
+	if assert.True(t, errors.As(err, &tooLarge)) {
+		assert.Equal(t, int64(13), tooLarge.Limit)
+	}
+	assert.Equal(t, "request body exceeds the limit (13 bytes)", err.Error())
+
+	// Unknown Content-Length (chunked encoding)
+	req := httptest.NewRequest("PUT", "/", ioutil.NopCloser(strings.NewReader(`{"hi":"hello"}`)))
+	req.ContentLength = -1
+	_, err = Request{Request: req}.ReadBody(13)
+	assert.True(t, errors.Is(err, ErrRequestBodyTooLarge))
+}
@@ -1,24 +1,91 @@
 package http
 
 import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/saiya/dsps/server/clientcert"
 	"github.com/saiya/dsps/server/config"
 	"github.com/saiya/dsps/server/domain"
 	"github.com/saiya/dsps/server/http/lifecycle"
+	"github.com/saiya/dsps/server/jwt/issuer"
 	"github.com/saiya/dsps/server/logger"
 	"github.com/saiya/dsps/server/sentry"
 	"github.com/saiya/dsps/server/telemetry"
+	"github.com/saiya/dsps/server/webhook/delivery"
+	"github.com/saiya/dsps/server/webhook/incoming"
+	"github.com/saiya/dsps/server/webhook/outgoing"
 )
 
 // ServerDependencies struct holds all resource references to build web server
 type ServerDependencies struct {
 	Config          *config.ServerConfig
+	Clock           domain.SystemClock
 	ChannelProvider domain.ChannelProvider
 	Storage         domain.Storage
+	JwtIssuer       issuer.Issuer // nil if not configured
+
+	WebhookDispatcher delivery.Dispatcher // nil if asynchronous outgoing-webhook delivery is not available
+	CircuitBreakers   outgoing.CircuitBreakerRegistry
 
 	Telemetry   *telemetry.Telemetry
 	Sentry      sentry.Sentry
 	LogFilter   *logger.Filter
 	ServerClose lifecycle.ServerClose
+
+	// Loads latest configuration to reload, nil to disable configuration reload.
+	ConfigLoader func(ctx context.Context) (config.ServerConfig, error)
+
+	reloadLock  sync.RWMutex
+	reloaded    *reloadableConfig // Reloaded configuration, nil if not reloaded yet
+	initialOnce sync.Once
+	initial     *reloadableConfig // Compiled from Config
+}
+
+// reloadableConfig is compiled form of reloadable configuration items, immutable once constructed.
+type reloadableConfig struct {
+	adminAuth        *config.AdminAuthConfig
+	adminClientCerts []clientcert.Validator // Compiled from adminAuth.ClientCerts
+	incomingWebhooks map[string]incoming.Receiver
+}
+
+func newReloadableConfig(ctx context.Context, cfg *config.ServerConfig, clock domain.SystemClock) (*reloadableConfig, error) {
+	adminClientCerts, err := newAdminClientCertValidators(ctx, &cfg.Admin.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid admin configuration: %w", err)
+	}
+	return &reloadableConfig{
+		adminAuth:        &cfg.Admin.Auth,
+		adminClientCerts: adminClientCerts,
+		incomingWebhooks: incoming.NewReceivers(ctx, cfg.IncomingWebhooks, clock),
+	}, nil
+}
+
+func (deps *ServerDependencies) getReloadableConfig() *reloadableConfig {
+	deps.reloadLock.RLock()
+	reloaded := deps.reloaded
+	deps.reloadLock.RUnlock()
+	if reloaded != nil {
+		return reloaded
+	}
+
+	deps.initialOnce.Do(func() {
+		ctx := context.Background()
+		initial, err := newReloadableConfig(ctx, deps.Config, deps.Clock)
+		if err != nil {
+			// Should not happen because configuration postprocess validates it, disables features that failed to configure.
+			logger.Of(ctx).Error("failed to configure server", err)
+			initial = &reloadableConfig{adminAuth: &deps.Config.Admin.Auth, incomingWebhooks: map[string]incoming.Receiver{}}
+		}
+		deps.initial = initial
+	})
+	return deps.initial
+}
+
+// GetSystemClock returns SystemClock instance
+func (deps *ServerDependencies) GetSystemClock() domain.SystemClock {
+	return deps.Clock
 }
 
 // GetChannelProvider returns ChannelProvider object
@@ -31,6 +98,26 @@ func (deps *ServerDependencies) GetStorage() domain.Storage {
 	return deps.Storage
 }
 
+// GetJwtIssuer returns JWT Issuer instance or nil
+func (deps *ServerDependencies) GetJwtIssuer() issuer.Issuer {
+	return deps.JwtIssuer
+}
+
+// GetWebhookDispatcher returns outgoing-webhook Dispatcher instance or nil
+func (deps *ServerDependencies) GetWebhookDispatcher() delivery.Dispatcher {
+	return deps.WebhookDispatcher
+}
+
+// GetCircuitBreakers returns outgoing-webhook CircuitBreakerRegistry instance
+func (deps *ServerDependencies) GetCircuitBreakers() outgoing.CircuitBreakerRegistry {
+	return deps.CircuitBreakers
+}
+
+// GetWebhookDeliveryConfig returns configuration value
+func (deps *ServerDependencies) GetWebhookDeliveryConfig() *config.WebhookDeliveryConfig {
+	return deps.Config.WebhookDelivery
+}
+
 // GetDefaultHeaders returns default response headers config
 func (deps *ServerDependencies) GetDefaultHeaders() map[string]string {
 	return deps.Config.HTTPServer.DefaultHeaders
@@ -41,6 +128,11 @@ func (deps *ServerDependencies) GetLongPollingMaxTimeout() domain.Duration {
 	return deps.Config.HTTPServer.LongPollingMaxTimeout
 }
 
+// GetMaxBodyBytes returns configuration value
+func (deps *ServerDependencies) GetMaxBodyBytes() int64 {
+	return deps.Config.HTTPServer.MaxBodyBytes
+}
+
 // DiscloseAuthRejectionDetail returns configuration value
 func (deps *ServerDependencies) DiscloseAuthRejectionDetail() bool {
 	return deps.Config.HTTPServer.DiscloseAuthRejectionDetail
@@ -58,7 +150,29 @@ func (deps *ServerDependencies) GetTrustedProxyRanges() []domain.CIDR {
 
 // GetAdminAuthConfig returns configuration value
 func (deps *ServerDependencies) GetAdminAuthConfig() *config.AdminAuthConfig {
-	return &deps.Config.Admin.Auth
+	return deps.getReloadableConfig().adminAuth
+}
+
+// GetAdminClientCertValidators returns validators compiled from admin client certificate rules
+func (deps *ServerDependencies) GetAdminClientCertValidators() []clientcert.Validator {
+	return deps.getReloadableConfig().adminClientCerts
+}
+
+// GetIncomingWebhookReceivers returns incoming-webhook receivers, key is name of the incoming-webhook
+func (deps *ServerDependencies) GetIncomingWebhookReceivers() map[string]incoming.Receiver {
+	return deps.getReloadableConfig().incomingWebhooks
+}
+
+func newAdminClientCertValidators(ctx context.Context, auth *config.AdminAuthConfig) ([]clientcert.Validator, error) {
+	validators := make([]clientcert.Validator, 0, len(auth.ClientCerts))
+	for i := range auth.ClientCerts {
+		v, err := clientcert.NewStaticValidator(ctx, &auth.ClientCerts[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure admin client certificate authentication (auth.clientCerts[%d]): %w", i, err)
+		}
+		validators = append(validators, v)
+	}
+	return validators, nil
 }
 
 // GetTelemetry returns telemetry facility
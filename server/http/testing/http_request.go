@@ -19,6 +19,11 @@ func DoHTTPRequest(t *testing.T, method string, url string, body string) *http.R
 
 // DoHTTPRequestWithHeaders performs HTTP request
 func DoHTTPRequestWithHeaders(t *testing.T, method string, url string, headers map[string]string, body string) *http.Response {
+	return DoHTTPRequestWithClient(t, http.DefaultClient, method, url, headers, body)
+}
+
+// DoHTTPRequestWithClient performs HTTP request with given client
+func DoHTTPRequestWithClient(t *testing.T, client *http.Client, method string, url string, headers map[string]string, body string) *http.Response {
 	var bodyR io.Reader
 	if body != "" {
 		bodyR = strings.NewReader(body)
@@ -30,7 +35,7 @@ func DoHTTPRequestWithHeaders(t *testing.T, method string, url string, headers m
 		req.Header.Add(name, value)
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := client.Do(req)
 	assert.NoError(t, err)
 	return res
 }
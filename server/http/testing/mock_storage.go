@@ -14,5 +14,7 @@ func NewMockStorages(ctrl *gomock.Controller) (storage *mock.MockStorage, pubsub
 
 	storage.EXPECT().AsPubSubStorage().Return(pubsub).AnyTimes()
 	storage.EXPECT().AsJwtStorage().Return(jwts).AnyTimes()
+	storage.EXPECT().AsWebhookStorage().Return(nil).AnyTimes()
+	storage.EXPECT().AsRateLimitStorage().Return(nil).AnyTimes()
 	return
 }
@@ -2,6 +2,7 @@ package testing
 
 import (
 	"context"
+	"crypto/tls"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -13,11 +14,14 @@ import (
 	"github.com/saiya/dsps/server/domain/channel"
 	"github.com/saiya/dsps/server/http"
 	httplifecycle "github.com/saiya/dsps/server/http/lifecycle"
+	"github.com/saiya/dsps/server/jwt/issuer"
 	"github.com/saiya/dsps/server/logger"
 	"github.com/saiya/dsps/server/sentry"
 	"github.com/saiya/dsps/server/storage"
 	"github.com/saiya/dsps/server/storage/deps"
 	"github.com/saiya/dsps/server/telemetry"
+	"github.com/saiya/dsps/server/webhook/delivery"
+	"github.com/saiya/dsps/server/webhook/outgoing"
 )
 
 // WithServerDeps runs given test function with ServerDependencies
@@ -36,10 +40,13 @@ func WithServerDeps(t *testing.T, configYaml string, f func(*http.ServerDependen
 	assert.NoError(t, err)
 	telemetry, err := telemetry.InitTelemetry(cfg.Telemetry)
 	assert.NoError(t, err)
-	channelProvider, err := channel.NewChannelProvider(ctx, &cfg, channel.ProviderDeps{
+	circuitBreakers := outgoing.NewCircuitBreakerRegistry(clock, telemetry)
+	channelProvider, err := channel.NewReloadableChannelProvider(ctx, &cfg, channel.ProviderDeps{
 		Clock:     clock,
 		Telemetry: telemetry,
 		Sentry:    sentry,
+
+		CircuitBreakers: circuitBreakers,
 	})
 	assert.NoError(t, err)
 	storage, err := storage.NewStorage(ctx, &cfg.Storages, clock, channelProvider, deps.StorageDeps{
@@ -47,13 +54,30 @@ func WithServerDeps(t *testing.T, configYaml string, f func(*http.ServerDependen
 		Sentry:    sentry,
 	})
 	assert.NoError(t, err)
+	jwtIssuer, err := issuer.NewIssuer(ctx, cfg.Admin.JwtIssuer, clock)
+	assert.NoError(t, err)
+	webhookDispatcher, err := delivery.NewDispatcher(ctx, cfg.WebhookDelivery, storage, delivery.Deps{
+		Clock:           clock,
+		ChannelProvider: channelProvider,
+		Telemetry:       telemetry,
+		Sentry:          sentry,
+	})
+	assert.NoError(t, err)
+	if webhookDispatcher != nil {
+		defer func() { assert.NoError(t, webhookDispatcher.Shutdown(ctx)) }()
+	}
 	serverClose := httplifecycle.NewServerClose()
 	defer serverClose.Close()
 
 	f(&http.ServerDependencies{
 		Config:          &cfg,
+		Clock:           clock,
 		ChannelProvider: channelProvider,
 		Storage:         storage,
+		JwtIssuer:       jwtIssuer,
+
+		WebhookDispatcher: webhookDispatcher,
+		CircuitBreakers:   circuitBreakers,
 
 		LogFilter:   logFilter,
 		Telemetry:   telemetry,
@@ -67,8 +91,21 @@ func WithServer(t *testing.T, configYaml string, setup func(deps *http.ServerDep
 	WithServerDeps(t, configYaml, func(deps *http.ServerDependencies) {
 		setup(deps)
 
-		server := http.CreateServer(context.Background(), deps)
-		ts := httptest.NewServer(server)
+		server, err := http.NewHTTPServer(deps.Config, http.CreateServer(context.Background(), deps))
+		if !assert.NoError(t, err) {
+			return
+		}
+		ts := httptest.NewUnstartedServer(server.Handler)
+		if server.TLSConfig != nil {
+			tlsConfig := server.TLSConfig.Clone()
+			tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+			// Use GetConfigForClient because httptest puts its own certificate that takes precedence over GetCertificate
+			ts.TLS = &tls.Config{GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) { return tlsConfig, nil }}
+			ts.EnableHTTP2 = true
+			ts.StartTLS()
+		} else {
+			ts.Start()
+		}
 		defer ts.Close()
 		defer deps.ServerClose.Close() // Close requests first
 
@@ -0,0 +1,28 @@
+package testing
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/config"
+)
+
+// NewTLSClient creates HTTP client that trusts given CA certificate.
+// If certFile and keyFile are not empty, the client presents the client certificate.
+func NewTLSClient(t *testing.T, caFile string, certFile string, keyFile string) *http.Client {
+	pool, err := config.LoadCertPool([]string{caFile})
+	assert.NoError(t, err)
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    pool,
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		assert.NoError(t, err)
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, ForceAttemptHTTP2: true}}
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/logger"
+)
+
+// certificateReloader holds TLS server certificate, reloads it if certificate or key file has been modified.
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	lock        sync.Mutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+	checkedAt   time.Time
+}
+
+func newCertificateReloader(cfg *config.HTTPServerTLSConfig) (*certificateReloader, error) {
+	r := &certificateReloader{
+		certFile: cfg.CertFile,
+		keyFile:  cfg.KeyFile,
+		interval: cfg.ReloadInterval.Duration,
+	}
+	if err := r.reload(context.Background(), time.Now()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate is callback function for tls.Config.
+// Checks modification of the files at most once in the interval, keeps current certificate if failed to reload.
+func (r *certificateReloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.checkedAt) >= r.interval {
+		if err := r.reload(hello.Context(), now); err != nil {
+			logger.Of(hello.Context()).WarnError(logger.CatServer, "Failed to reload TLS certificate, keep using current certificate", err)
+		}
+	}
+	return r.cert, nil
+}
+
+// reload loads certificate if modified, must be called with lock.
+func (r *certificateReloader) reload(ctx context.Context, now time.Time) error {
+	r.checkedAt = now
+
+	certStat, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf(`failed to stat TLS certificate "%s": %w`, r.certFile, err)
+	}
+	keyStat, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fmt.Errorf(`failed to stat TLS key "%s": %w`, r.keyFile, err)
+	}
+	if r.cert != nil && certStat.ModTime().Equal(r.certModTime) && keyStat.ModTime().Equal(r.keyModTime) {
+		return nil // Not modified
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf(`failed to load TLS certificate "%s" and key "%s": %w`, r.certFile, r.keyFile, err)
+	}
+	if r.cert != nil {
+		logger.Of(ctx).Infof(logger.CatServer, `Reloaded TLS certificate "%s"`, r.certFile)
+	}
+	r.cert = &cert
+	r.certModTime = certStat.ModTime()
+	r.keyModTime = keyStat.ModTime()
+	return nil
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"crypto/x509"
 	"regexp"
 
 	"github.com/saiya/dsps/server/http/router"
@@ -18,3 +19,12 @@ func GetBearerToken(ctx context.Context, args router.MiddlewareArgs) string {
 	}
 	return parts[1]
 }
+
+// GetVerifiedClientCert returns verified TLS client certificate chain (leaf certificate first).
+// If client did not present certificate or server is not serving TLS, returns nil.
+func GetVerifiedClientCert(args router.MiddlewareArgs) []*x509.Certificate {
+	if args.R.TLS == nil || len(args.R.TLS.VerifiedChains) == 0 {
+		return nil
+	}
+	return args.R.TLS.VerifiedChains[0]
+}
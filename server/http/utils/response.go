@@ -30,7 +30,15 @@ func SendJSON(ctx context.Context, w http.ResponseWriter, status int, data inter
 
 // SendError send arbital error response with given HTTP status.
 func SendError(ctx context.Context, w http.ResponseWriter, status int, message string, err error) {
+	SendErrorWithDetails(ctx, w, status, message, err, nil)
+}
+
+// SendErrorWithDetails send arbital error response with given HTTP status and additional properties (e.g. validation errors).
+func SendErrorWithDetails(ctx context.Context, w http.ResponseWriter, status int, message string, err error, details map[string]interface{}) {
 	res := map[string]interface{}{"error": message}
+	for key, value := range details {
+		res[key] = value
+	}
 	if errWithCode := domain.NewErrorWithCode(""); errors.As(err, &errWithCode) {
 		res["code"] = errWithCode.Code()
 		ctx = logger.WithAttributes(ctx).WithStr("code", errWithCode.Code()).Build()
@@ -83,3 +91,13 @@ func SendPubSubUnsupportedError(ctx context.Context, w http.ResponseWriter) {
 func SendJwtUnsupportedError(ctx context.Context, w http.ResponseWriter) {
 	SendError(ctx, w, http.StatusNotImplemented, "No JWT compatible storage available.", nil)
 }
+
+// SendJwtIssuerUnavailableError send 501
+func SendJwtIssuerUnavailableError(ctx context.Context, w http.ResponseWriter) {
+	SendError(ctx, w, http.StatusNotImplemented, "JWT issuer is not configured.", nil)
+}
+
+// SendWebhookDeliveryUnsupportedError send 501
+func SendWebhookDeliveryUnsupportedError(ctx context.Context, w http.ResponseWriter) {
+	SendError(ctx, w, http.StatusNotImplemented, "Asynchronous outgoing-webhook delivery is not available.", nil)
+}
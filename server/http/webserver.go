@@ -2,14 +2,18 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
 	"github.com/julienschmidt/httprouter"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
 	"github.com/saiya/dsps/server/config"
 	httplifecycle "github.com/saiya/dsps/server/http/lifecycle"
@@ -21,11 +25,28 @@ import (
 // StartServer starts HTTP web server
 func StartServer(mainContext context.Context, deps *ServerDependencies) {
 	engine := CreateServer(mainContext, deps)
-	runServer(mainContext, deps.Config, engine, deps.GetServerClose())
+	adminEngine := CreateAdminServer(mainContext, deps)
+	runServer(mainContext, deps.Config, engine, adminEngine, deps.GetServerClose(), func() { reloadConfig(mainContext, deps) })
 }
 
 // CreateServer creates server (http.Handler) instance.
 func CreateServer(mainContext context.Context, deps *ServerDependencies) http.Handler {
+	r, rt := newRouter(deps)
+	InitEndpoints(mainContext, rt, deps)
+	return r
+}
+
+// CreateAdminServer creates server (http.Handler) instance of admin and probe endpoints, returns nil if admin listener is not configured.
+func CreateAdminServer(mainContext context.Context, deps *ServerDependencies) http.Handler {
+	if deps.Config.Admin.Listen == "" {
+		return nil
+	}
+	r, rt := newRouter(deps)
+	InitAdminEndpoints(mainContext, rt, deps)
+	return r
+}
+
+func newRouter(deps *ServerDependencies) (*httprouter.Router, *router.Router) {
 	r := httprouter.New()
 	r.HandleOPTIONS = false
 	r.HandleMethodNotAllowed = true
@@ -44,49 +65,133 @@ func CreateServer(mainContext context.Context, deps *ServerDependencies) http.Ha
 		middleware.LoggingMiddleware(deps, deps), // Must after tracing middleware to capture panics.
 		middleware.DefaultHeadersMiddleware(deps),
 	)
-	InitEndpoints(mainContext, rt, deps)
-	return r
+	return r, rt
 }
 
-func runServer(mainContext context.Context, config *config.ServerConfig, engine http.Handler, serverClose httplifecycle.ServerClose) {
-	addr := config.HTTPServer.Listen
-
+// NewHTTPServer creates HTTP server to serve given handler.
+// HTTPS server negotiates HTTP/2 with ALPN, plain HTTP server also accepts HTTP/2 if h2c is enabled.
+func NewHTTPServer(config *config.ServerConfig, engine http.Handler) (*http.Server, error) {
 	srv := &http.Server{
-		Addr:           addr,
+		Addr:           config.HTTPServer.Listen,
 		Handler:        engine,
 		IdleTimeout:    config.HTTPServer.IdleTimeout.Duration,
 		ReadTimeout:    config.HTTPServer.ReadTimeout.Duration,
 		WriteTimeout:   config.HTTPServer.LongPollingMaxTimeout.Duration + config.HTTPServer.WriteTimeout.Duration,
 		MaxHeaderBytes: 1 << 20,
 	}
-	go func() {
-		if err := srv.ListenAndServe(); err != nil {
-			if err != http.ErrServerClosed {
-				logger.Of(mainContext).FatalExitProcess(fmt.Sprintf("HTTP server listen failed on %s", addr), err)
-			} else {
-				logger.Of(mainContext).Infof(logger.CatServer, "HTTP server listener closed")
-			}
+	tlsConfig, err := NewTLSConfig(config.HTTPServer.TLS)
+	if err != nil {
+		return nil, err
+	}
+	srv.TLSConfig = tlsConfig
+	if config.HTTPServer.H2C {
+		srv.Handler = h2c.NewHandler(engine, &http2.Server{IdleTimeout: srv.IdleTimeout})
+	}
+	// Note: net/http automatically enables HTTP/2 for HTTPS server (unless TLSNextProto set).
+	return srv, nil
+}
+
+func runServer(mainContext context.Context, config *config.ServerConfig, engine http.Handler, adminEngine http.Handler, serverClose httplifecycle.ServerClose, reload func()) {
+	srv, err := NewHTTPServer(config, engine)
+	if err != nil {
+		logger.Of(mainContext).FatalExitProcess("Failed to configure TLS", err)
+	}
+	servers := []*http.Server{srv}
+	serve(mainContext, "HTTP server", srv)
+	logger.Of(mainContext).Infof(logger.CatServer, "HTTP server (version %s %s) running on %s", config.BuildInfo.BuildVersion, config.BuildInfo.BuildAt, srv.Addr)
+	if adminEngine != nil {
+		adminSrv, err := NewHTTPServer(config, adminEngine)
+		if err != nil {
+			logger.Of(mainContext).FatalExitProcess("Failed to configure TLS", err)
 		}
-	}()
-	logger.Of(mainContext).Infof(logger.CatServer, "HTTP server (version %s %s) running on %s", config.BuildInfo.BuildVersion, config.BuildInfo.BuildAt, addr)
+		adminSrv.Addr = config.Admin.Listen
+		servers = append(servers, adminSrv)
+		serve(mainContext, "Admin HTTP server", adminSrv)
+		logger.Of(mainContext).Infof(logger.CatServer, "Admin HTTP server running on %s", adminSrv.Addr)
+	}
 
 	quit := make(chan os.Signal, 1)
 	// kill (no param) default send syscall.SIGTERM
 	// kill -2 is syscall.SIGINT
 	// kill -9 is syscall.SIGKILL but can't be catch, so don't need add it
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	// kill -HUP is syscall.SIGHUP to reload configuration
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	signal := <-quit // Wait until signal...
+	for signal == syscall.SIGHUP {
+		reload()
+		signal = <-quit
+	}
 	logger.Of(mainContext).Infof(logger.CatServer, "Shutting down server (%v)...", signal)
 	serverClose.Close()
 
 	ctx, cancel := context.WithTimeout(context.Background(), config.HTTPServer.GracefulShutdownTimeout.Duration)
 	defer cancel()
-	if err := srv.Shutdown(ctx); err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			logger.Of(mainContext).Infof(logger.CatServer, "Stopping long-running requests (e.g. long pollings)")
+	shutdownServers(mainContext, ctx, servers)
+	logger.Of(mainContext).Infof(logger.CatServer, "Server exiting...")
+}
+
+// serve starts listener of the server in background.
+func serve(mainContext context.Context, name string, srv *http.Server) {
+	go func() {
+		var err error
+		if srv.TLSConfig != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil {
+			if err != http.ErrServerClosed {
+				logger.Of(mainContext).FatalExitProcess(fmt.Sprintf("%s listen failed on %s", name, srv.Addr), err)
+			} else {
+				logger.Of(mainContext).Infof(logger.CatServer, "%s listener closed", name)
+			}
+		}
+	}()
+}
+
+// shutdownServers gracefully shutdowns all of given servers in parallel.
+func shutdownServers(mainContext context.Context, ctx context.Context, servers []*http.Server) {
+	var wg sync.WaitGroup
+	for _, srv := range servers {
+		wg.Add(1)
+		go func(srv *http.Server) {
+			defer wg.Done()
+			if err := srv.Shutdown(ctx); err != nil {
+				if errors.Is(err, context.DeadlineExceeded) {
+					logger.Of(mainContext).Infof(logger.CatServer, "Stopping long-running requests (e.g. long pollings)")
+				} else {
+					logger.Of(mainContext).Warnf(logger.CatServer, "Server forced to shutdown: %v", err)
+				}
+			}
+		}(srv)
+	}
+	wg.Wait()
+}
+
+// NewTLSConfig creates TLS configuration of the HTTP server, returns nil if TLS is not configured.
+func NewTLSConfig(cfg *config.HTTPServerTLSConfig) (*tls.Config, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	certs, err := newCertificateReloader(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+	}
+	if cfg.ClientAuth != nil {
+		pool, err := config.LoadCertPool(cfg.ClientAuth.CAFiles)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = pool
+		if cfg.ClientAuth.Required {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
 		} else {
-			logger.Of(mainContext).Warnf(logger.CatServer, "Server forced to shutdown: %v", err)
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
 		}
 	}
-	logger.Of(mainContext).Infof(logger.CatServer, "Server exiting...")
+	return tlsConfig, nil
 }
@@ -0,0 +1,140 @@
+package http_test
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+
+	. "github.com/saiya/dsps/server/http"
+	. "github.com/saiya/dsps/server/http/testing"
+)
+
+const certDir = "../clientcert/testdata"
+
+func TestHTTP2OverTLS(t *testing.T) {
+	WithServer(t, strings.ReplaceAll(fmt.Sprintf(`
+logging: category: "*": ERROR
+http:
+	tls:
+		certFile: %s/server.pem
+		keyFile: %s/server-key.pem
+`, certDir, certDir), "\t", "  "), func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		res := DoHTTPRequestWithClient(t, NewTLSClient(t, certDir+"/ca.pem", "", ""), "GET", baseURL+"/probe/liveness", map[string]string{}, ``)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 200, res.StatusCode)
+		assert.Equal(t, 2, res.ProtoMajor)
+	})
+}
+
+func TestH2C(t *testing.T) {
+	WithServer(t, `http: { h2c: true }`, func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		h2cClient := &http.Client{Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		}}
+		res := DoHTTPRequestWithClient(t, h2cClient, "GET", baseURL+"/probe/liveness", map[string]string{}, ``)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 200, res.StatusCode)
+		assert.Equal(t, 2, res.ProtoMajor)
+
+		// HTTP/1.1 is still available
+		res = DoHTTPRequest(t, "GET", baseURL+"/probe/liveness", ``)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 200, res.StatusCode)
+		assert.Equal(t, 1, res.ProtoMajor)
+	})
+}
+
+func TestTLSCertificateReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dsps-tls-reload-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { assert.NoError(t, os.RemoveAll(dir)) }()
+	copyFile := func(src, dst string, modTime time.Time) {
+		content, err := ioutil.ReadFile(filepath.Join(certDir, src)) //nolint:gosec
+		assert.NoError(t, err)
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, dst), content, 0600))
+		assert.NoError(t, os.Chtimes(filepath.Join(dir, dst), modTime, modTime))
+	}
+	copyFile("server.pem", "cert.pem", time.Now().Add(-time.Hour))
+	copyFile("server-key.pem", "key.pem", time.Now().Add(-time.Hour))
+
+	WithServer(t, strings.ReplaceAll(fmt.Sprintf(`
+logging: category: "*": ERROR
+http:
+	tls:
+		certFile: %s/cert.pem
+		keyFile: %s/key.pem
+		reloadInterval: 10ms
+`, dir, dir), "\t", "  "), func(deps *ServerDependencies) {}, func(deps *ServerDependencies, baseURL string) {
+		addr := strings.TrimPrefix(baseURL, "https://")
+		subjectOf := func() string {
+			conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true}) //nolint:gosec // Test only checks the certificate
+			if !assert.NoError(t, err) {
+				return ""
+			}
+			defer func() { assert.NoError(t, conn.Close()) }()
+			return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+		}
+		assert.Equal(t, "localhost", subjectOf())
+
+		copyFile("client1.pem", "cert.pem", time.Now())
+		copyFile("client1-key.pem", "key.pem", time.Now())
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, "client1.example.com", subjectOf())
+
+		// Keep current certificate if failed to load
+		copyFile("server-key.pem", "key.pem", time.Now().Add(time.Minute))
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, "client1.example.com", subjectOf())
+	})
+}
+
+func TestAdminListener(t *testing.T) {
+	WithServerDeps(t, `admin: { listen: "127.0.0.1:0", auth: { networks: [ 127.0.0.0/8 ] } }`, func(deps *ServerDependencies) {
+		public := httptest.NewServer(CreateServer(context.Background(), deps))
+		defer public.Close()
+		admin := httptest.NewServer(CreateAdminServer(context.Background(), deps))
+		defer admin.Close()
+		defer deps.ServerClose.Close()
+
+		// Admin and probe endpoints are not available on the public listener
+		for _, path := range []string{"/probe/liveness", "/probe/readiness", "/admin/log/level"} {
+			res := DoHTTPRequestWithHeaders(t, "GET", public.URL+path, AdminAuthHeaders(t, deps), ``)
+			assert.NoError(t, res.Body.Close())
+			assert.Equal(t, 404, res.StatusCode, path)
+		}
+		res := DoHTTPRequest(t, "PUT", public.URL+"/channel/my-channel/message/msg-1", `{}`)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 200, res.StatusCode)
+
+		res = DoHTTPRequest(t, "GET", admin.URL+"/probe/liveness", ``)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 200, res.StatusCode)
+		res = DoHTTPRequestWithHeaders(t, "PUT", admin.URL+"/admin/log/level?category=http&level=ERROR", AdminAuthHeaders(t, deps), ``)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 204, res.StatusCode)
+		res = DoHTTPRequest(t, "PUT", admin.URL+"/channel/my-channel/message/msg-1", `{}`)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, 404, res.StatusCode)
+	})
+
+	WithServerDeps(t, ``, func(deps *ServerDependencies) {
+		assert.Nil(t, CreateAdminServer(context.Background(), deps))
+	})
+}
@@ -9,7 +9,7 @@ import (
 
 // ValidateAlg validates JWT alg
 func ValidateAlg(alg domain.JwtAlg) error {
-	if alg.IsNone() || IsRSA(alg) || IsECDSA(alg) || IsHMAC(alg) {
+	if alg.IsNone() || IsRSA(alg) || IsECDSA(alg) || IsEdDSA(alg) || IsHMAC(alg) {
 		return nil
 	}
 	return fmt.Errorf(`Unsupported JWT alg "%s"`, alg)
@@ -29,6 +29,11 @@ func IsECDSA(alg domain.JwtAlg) bool {
 	return algECDSA.MatchString(string(alg))
 }
 
+// IsEdDSA returns true for EdDSA algorithm
+func IsEdDSA(alg domain.JwtAlg) bool {
+	return alg == "EdDSA"
+}
+
 // IsHMAC returns true for HS*** algorithms
 func IsHMAC(alg domain.JwtAlg) bool {
 	return algHMAC.MatchString(string(alg))
@@ -17,6 +17,7 @@ func TestValidateAlg(t *testing.T) {
 		"RS256", "RS384", "RS512", // RSASSA-PKCS1
 		"ES256", "ES384", "ES512", // ECDSA
 		"PS256", "PS384", "PS512", // RSASSA-PSS
+		"EdDSA", // RFC 8037
 		"none",
 	} {
 		assert.NoError(t, ValidateAlg(domain.JwtAlg(supported)))
@@ -33,5 +34,7 @@ func TestAlgTypeDetection(t *testing.T) {
 	assert.True(t, IsRSA(domain.JwtAlg("RS512"))) // RSASSA-PKCS1
 	assert.True(t, IsRSA(domain.JwtAlg("PS512"))) // RSASSA-PSS
 	assert.True(t, IsECDSA(domain.JwtAlg("ES512")))
+	assert.True(t, IsEdDSA(domain.JwtAlg("EdDSA")))
+	assert.False(t, IsEdDSA(domain.JwtAlg("ES512")))
 	assert.True(t, IsHMAC(domain.JwtAlg("HS512")))
 }
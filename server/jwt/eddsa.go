@@ -0,0 +1,105 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+
+	jwtgo "github.com/dgrijalva/jwt-go/v4"
+)
+
+// Errors returned by EdDSA signing method
+var (
+	ErrNotEdPublicKey  = errors.New("key is not a valid Ed25519 public key")
+	ErrNotEdPrivateKey = errors.New("key is not a valid Ed25519 private key")
+)
+
+// SigningMethodEdDSA implements EdDSA (RFC 8037) signing method with Ed25519 curve.
+// Because jwt-go does not support it yet, DSPS registers this implementation to jwt-go.
+type SigningMethodEdDSA struct{}
+
+var signingMethodEdDSA = &SigningMethodEdDSA{}
+
+func init() {
+	jwtgo.RegisterSigningMethod(signingMethodEdDSA.Alg(), func() jwtgo.SigningMethod {
+		return signingMethodEdDSA
+	})
+}
+
+// Alg implements SigningMethod
+func (m *SigningMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+// Verify implements SigningMethod, key must be ed25519.PublicKey
+func (m *SigningMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwtgo.NewInvalidKeyTypeError("ed25519.PublicKey", key)
+	}
+	if len(edKey) != ed25519.PublicKeySize {
+		return ErrNotEdPublicKey
+	}
+
+	sig, err := jwtgo.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(edKey, []byte(signingString), sig) {
+		return new(jwtgo.InvalidSignatureError)
+	}
+	return nil
+}
+
+// Sign implements SigningMethod, key must be ed25519.PrivateKey
+func (m *SigningMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwtgo.NewInvalidKeyTypeError("ed25519.PrivateKey", key)
+	}
+	if len(edKey) != ed25519.PrivateKeySize {
+		return "", ErrNotEdPrivateKey
+	}
+
+	// Ed25519 signs message itself rather than digest
+	return jwtgo.EncodeSegment(ed25519.Sign(edKey, []byte(signingString))), nil
+}
+
+// ParseEdPrivateKeyFromPEM parses PEM encoded PKCS8 Ed25519 private key
+func ParseEdPrivateKeyFromPEM(key []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, jwtgo.ErrKeyMustBePEMEncoded
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pkey, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, ErrNotEdPrivateKey
+	}
+	return pkey, nil
+}
+
+// ParseEdPublicKeyFromPEM parses PEM encoded PKIX Ed25519 public key or x509 certificate
+func ParseEdPublicKeyFromPEM(key []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, jwtgo.ErrKeyMustBePEMEncoded
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		cert, certErr := x509.ParseCertificate(block.Bytes)
+		if certErr != nil {
+			return nil, err
+		}
+		parsed = cert.PublicKey
+	}
+	pkey, ok := parsed.(ed25519.PublicKey)
+	if !ok {
+		return nil, ErrNotEdPublicKey
+	}
+	return pkey, nil
+}
@@ -0,0 +1,45 @@
+package jwt_test
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	jwtgo "github.com/dgrijalva/jwt-go/v4"
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/saiya/dsps/server/jwt"
+)
+
+func TestEdDSASignAndVerify(t *testing.T) {
+	method := jwtgo.GetSigningMethod("EdDSA")
+	if !assert.NotNil(t, method) {
+		return
+	}
+	assert.Equal(t, "EdDSA", method.Alg())
+
+	privateKey, err := LoadKey("EdDSA", "./testdata/EdDSA-test1-private.pem", true)
+	assert.NoError(t, err)
+	publicKey, err := LoadKey("EdDSA", "./testdata/EdDSA-test1-public.pem", false)
+	assert.NoError(t, err)
+	otherPublicKey, err := LoadKey("EdDSA", "./testdata/EdDSA-test2-public.pem", false)
+	assert.NoError(t, err)
+
+	sig, err := method.Sign("signing-string", privateKey)
+	assert.NoError(t, err)
+	assert.NoError(t, method.Verify("signing-string", sig, publicKey))
+	assert.IsType(t, &jwtgo.InvalidSignatureError{}, method.Verify("signing-string", sig, otherPublicKey))
+	assert.IsType(t, &jwtgo.InvalidSignatureError{}, method.Verify("tampered-string", sig, publicKey))
+	assert.Error(t, method.Verify("signing-string", "!!!not-base64!!!", publicKey))
+}
+
+func TestEdDSAInvalidKeys(t *testing.T) {
+	method := jwtgo.GetSigningMethod("EdDSA")
+
+	_, err := method.Sign("signing-string", []byte("HMAC key"))
+	assert.IsType(t, &jwtgo.InvalidKeyTypeError{}, err)
+	_, err = method.Sign("signing-string", ed25519.PrivateKey([]byte("too short")))
+	assert.Equal(t, ErrNotEdPrivateKey, err)
+
+	assert.IsType(t, &jwtgo.InvalidKeyTypeError{}, method.Verify("signing-string", "", []byte("HMAC key")))
+	assert.Equal(t, ErrNotEdPublicKey, method.Verify("signing-string", "", ed25519.PublicKey([]byte("too short"))))
+}
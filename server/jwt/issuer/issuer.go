@@ -0,0 +1,138 @@
+package issuer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go/v4"
+	"github.com/google/uuid"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/jwt"
+)
+
+// Issuer is a object to issue (sign) JWT
+type Issuer interface {
+	Issue(ctx context.Context, req Request) (*Result, error)
+}
+
+// Request is a parameter to issue a JWT
+type Request struct {
+	ChannelID    domain.ChannelID
+	SubscriberID domain.SubscriberID // Optional
+	// If nil, use default value of the configuration.
+	ExpireIn *domain.Duration
+	// Additional claims, must not contain reserved claims.
+	Claims map[string]interface{}
+}
+
+// Result represents issued JWT
+type Result struct {
+	JWT string
+	Jti domain.JwtJti
+	Exp domain.JwtExp
+}
+
+// ErrInvalidRequest : Given Request is not acceptable (e.g. too long expiration)
+var ErrInvalidRequest = domain.NewErrorWithCode("dsps.jwt.invalid-issue-request")
+
+// Claim names to hold channel and subscriber of the issued JWT.
+const (
+	ClaimChannel    = "channel"
+	ClaimSubscriber = "subscriber"
+)
+
+// Claims that Request.Claims can not override
+var reservedClaims = map[string]struct{}{
+	"iss": {}, "aud": {}, "exp": {}, "nbf": {}, "iat": {}, "jti": {},
+	ClaimChannel: {}, ClaimSubscriber: {},
+}
+
+type issuer struct {
+	cfg   *config.JwtIssuerConfig
+	clock domain.SystemClock
+
+	method jwtgo.SigningMethod
+	key    interface{}
+}
+
+// NewIssuer creates Issuer instance.
+// Returns (nil, nil) if cfg is nil (JWT issuing not configured).
+func NewIssuer(ctx context.Context, cfg *config.JwtIssuerConfig, clock domain.SystemClock) (Issuer, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	method := jwtgo.GetSigningMethod(string(cfg.Alg))
+	if method == nil {
+		return nil, fmt.Errorf(`Unsupported JWT alg "%s"`, cfg.Alg)
+	}
+	key, err := jwt.LoadSigningKey(cfg.Alg, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &issuer{
+		cfg:   cfg,
+		clock: clock,
+
+		method: method,
+		key:    key,
+	}, nil
+}
+
+func (is *issuer) Issue(ctx context.Context, req Request) (*Result, error) {
+	expireIn := *is.cfg.Expire
+	if req.ExpireIn != nil {
+		expireIn = *req.ExpireIn
+	}
+	if expireIn.Duration <= 0 {
+		return nil, fmt.Errorf("expiration must not be negative nor zero (%w)", ErrInvalidRequest)
+	}
+	if is.cfg.MaxExpire.Duration < expireIn.Duration {
+		return nil, fmt.Errorf("expiration %s exceeds configured limit %s (%w)", expireIn, is.cfg.MaxExpire, ErrInvalidRequest)
+	}
+
+	jtiUUID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate UUID for jti: %w", err)
+	}
+	jti := domain.JwtJti(strings.ReplaceAll(jtiUUID.String(), "-", ""))
+
+	now := is.clock.Now().Time
+	exp := domain.JwtExp(now.Add(expireIn.Duration).Truncate(time.Second))
+
+	claims := jwtgo.MapClaims{}
+	for name, value := range req.Claims {
+		if _, reserved := reservedClaims[name]; reserved {
+			return nil, fmt.Errorf(`claim "%s" is reserved, could not be overridden (%w)`, name, ErrInvalidRequest)
+		}
+		claims[name] = value
+	}
+	claims["iss"] = string(is.cfg.Iss)
+	switch len(is.cfg.Aud) {
+	case 0:
+	case 1:
+		claims["aud"] = string(is.cfg.Aud[0])
+	default:
+		auds := make([]string, len(is.cfg.Aud))
+		for i, aud := range is.cfg.Aud {
+			auds[i] = string(aud)
+		}
+		claims["aud"] = auds
+	}
+	claims["iat"] = now.Unix()
+	claims["exp"] = exp.Int64()
+	claims["jti"] = string(jti)
+	claims[ClaimChannel] = string(req.ChannelID)
+	if req.SubscriberID != "" {
+		claims[ClaimSubscriber] = string(req.SubscriberID)
+	}
+
+	signed, err := jwtgo.NewWithClaims(is.method, claims).SignedString(is.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	return &Result{JWT: signed, Jti: jti, Exp: exp}, nil
+}
@@ -0,0 +1,129 @@
+package issuer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+	. "github.com/saiya/dsps/server/jwt/issuer"
+	"github.com/saiya/dsps/server/jwt/validator"
+	dspstesting "github.com/saiya/dsps/server/testing"
+)
+
+func newIssuerConfig(alg domain.JwtAlg, keyFile string) *config.JwtIssuerConfig {
+	return &config.JwtIssuerConfig{
+		Iss:       "https://example.com/issuer",
+		Aud:       []domain.JwtAud{"https://example.com/audience"},
+		Alg:       alg,
+		KeyFile:   keyFile,
+		Expire:    dspstesting.MakeDurationPtr("1h"),
+		MaxExpire: dspstesting.MakeDurationPtr("24h"),
+	}
+}
+
+func mustTemplateStrings(t *testing.T, str string) domain.TemplateStrings {
+	tpl, err := domain.NewTemplateString(str)
+	assert.NoError(t, err)
+	return domain.NewTemplateStrings(tpl)
+}
+
+func TestNilConfig(t *testing.T) {
+	is, err := NewIssuer(context.Background(), nil, domain.RealSystemClock)
+	assert.NoError(t, err)
+	assert.Nil(t, is)
+}
+
+func TestIssueAndValidate(t *testing.T) {
+	ctx := context.Background()
+	for alg, keyfile := range map[domain.JwtAlg][]string{
+		"RS256": {"../testdata/RS256-2048bit-private.pem", "../testdata/RS256-2048bit-public.pem"},
+		"ES512": {"../testdata/ES512-test1-private.pem", "../testdata/ES512-test1-public.pem"},
+		"EdDSA": {"../testdata/EdDSA-test1-private.pem", "../testdata/EdDSA-test1-public.pem"},
+		"HS256": {"../testdata/HS256.rand", "../testdata/HS256.rand"},
+		"none":  {"", ""},
+	} {
+		is, err := NewIssuer(ctx, newIssuerConfig(alg, keyfile[0]), domain.RealSystemClock)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		issued, err := is.Issue(ctx, Request{
+			ChannelID:    "chat-room-1",
+			SubscriberID: "user-1",
+			Claims:       map[string]interface{}{"role": "admin"},
+		})
+		if !assert.NoError(t, err, "alg=%s", alg) {
+			continue
+		}
+		assert.NotEmpty(t, issued.Jti)
+
+		keys := []string{keyfile[1]}
+		if alg.IsNone() {
+			keys = []string{}
+		}
+		tpl, err := validator.NewTemplate(ctx, &config.JwtValidationConfig{
+			Iss:  []domain.JwtIss{"https://example.com/issuer"},
+			Aud:  []domain.JwtAud{"https://example.com/audience"},
+			Keys: map[domain.JwtAlg][]string{alg: keys},
+			Claims: map[string]domain.TemplateStrings{
+				"channel":    mustTemplateStrings(t, "chat-room-{{.channel.id}}"),
+				"subscriber": mustTemplateStrings(t, "user-1"),
+				"role":       mustTemplateStrings(t, "admin"),
+			},
+			ClockSkewLeeway: dspstesting.MakeDurationPtr("5m"),
+		}, domain.RealSystemClock)
+		assert.NoError(t, err)
+		v, err := tpl.NewValidator(map[string]interface{}{"channel": map[string]string{"id": "1"}})
+		assert.NoError(t, err)
+		assert.NoError(t, v.Validate(ctx, issued.JWT), "alg=%s", alg)
+	}
+}
+
+func TestIssuedClaims(t *testing.T) {
+	ctx := context.Background()
+	clock := dspstesting.NewStubClock(t)
+	is, err := NewIssuer(ctx, newIssuerConfig("HS256", "../testdata/HS256.rand"), clock)
+	assert.NoError(t, err)
+
+	issued, err := is.Issue(ctx, Request{ChannelID: "my-channel", ExpireIn: dspstesting.MakeDurationPtr("15m")})
+	assert.NoError(t, err)
+	assert.Equal(t, clock.Now().Add(15*time.Minute).Unix(), issued.Exp.Int64())
+
+	claims := jwtgo.MapClaims{}
+	_, _, err = jwtgo.NewParser().ParseUnverified(issued.JWT, &claims)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/issuer", claims["iss"])
+	assert.Equal(t, "https://example.com/audience", claims["aud"])
+	assert.Equal(t, string(issued.Jti), claims["jti"])
+	assert.Equal(t, float64(issued.Exp.Int64()), claims["exp"])
+	assert.Equal(t, float64(clock.Now().Unix()), claims["iat"])
+	assert.Equal(t, "my-channel", claims["channel"])
+	assert.NotContains(t, claims, "subscriber")
+}
+
+func TestInvalidRequests(t *testing.T) {
+	ctx := context.Background()
+	is, err := NewIssuer(ctx, newIssuerConfig("HS256", "../testdata/HS256.rand"), domain.RealSystemClock)
+	assert.NoError(t, err)
+
+	_, err = is.Issue(ctx, Request{ChannelID: "my-channel", ExpireIn: dspstesting.MakeDurationPtr("25h")})
+	dspstesting.IsError(t, ErrInvalidRequest, err)
+	assert.Contains(t, err.Error(), "exceeds configured limit")
+
+	_, err = is.Issue(ctx, Request{ChannelID: "my-channel", ExpireIn: dspstesting.MakeDurationPtr("-1s")})
+	dspstesting.IsError(t, ErrInvalidRequest, err)
+
+	for _, reserved := range []string{"iss", "exp", "jti", "channel"} {
+		_, err = is.Issue(ctx, Request{ChannelID: "my-channel", Claims: map[string]interface{}{reserved: "x"}})
+		dspstesting.IsError(t, ErrInvalidRequest, err)
+	}
+}
+
+func TestKeyLoadFailure(t *testing.T) {
+	_, err := NewIssuer(context.Background(), newIssuerConfig("RS256", "../testdata/file-not-found"), domain.RealSystemClock)
+	assert.Error(t, err)
+}
@@ -15,6 +15,17 @@ func ValidateVerificationKey(alg domain.JwtAlg, keyFilePath string) error {
 	return err
 }
 
+// ValidateSigningKey validates plain-text representation of private key
+func ValidateSigningKey(alg domain.JwtAlg, keyFilePath string) error {
+	_, err := LoadSigningKey(alg, keyFilePath)
+	return err
+}
+
+// LoadSigningKey loads private key file
+func LoadSigningKey(alg domain.JwtAlg, keyFilePath string) (interface{}, error) {
+	return LoadKey(alg, keyFilePath, true)
+}
+
 // LoadVerificationKey loads public key file
 func LoadVerificationKey(alg domain.JwtAlg, keyFilePath string) (interface{}, error) {
 	return LoadKey(alg, keyFilePath, false)
@@ -56,6 +67,14 @@ func LoadKey(alg domain.JwtAlg, keyFilePath string, privateKey bool) (interface{
 			return jwtgo.ParseECPublicKeyFromPEM(bytes)
 		})
 	}
+	if IsEdDSA(alg) {
+		return parserWrapper(func(bytes []byte) (interface{}, error) {
+			if privateKey {
+				return ParseEdPrivateKeyFromPEM(bytes)
+			}
+			return ParseEdPublicKeyFromPEM(bytes)
+		})
+	}
 	if IsHMAC(alg) {
 		return parserWrapper(func(bytes []byte) (interface{}, error) { return bytes, nil })
 	}
@@ -2,6 +2,7 @@ package jwt_test
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"testing"
 
@@ -25,11 +26,15 @@ func TestKeyLoading(t *testing.T) {
 		{alg: "HS256", private: true, filename: "HS256.rand"},
 		{alg: "RS256", private: true, filename: "RS256-2048bit-private.pem"},
 		{alg: "RS256", private: false, filename: "RS256-4096bit-public.pem"},
+		{alg: "EdDSA", private: true, filename: "EdDSA-test1-private.pem"},
+		{alg: "EdDSA", private: false, filename: "EdDSA-test1-public.pem"},
 
 		// Error
 		{alg: "ES512", private: true, filename: "file-not-found", errorMsg: "no such file or directory"},
 		{alg: "ES512", private: true, filename: "emptyfile", errorMsg: "expected non-empty file"},
 		{alg: "ES512", private: true, filename: "HS256.rand", errorMsg: "Key must be PEM encoded PKCS1 or PKCS8 private key"},
+		{alg: "EdDSA", private: true, filename: "ES512-test1-private.pem", errorMsg: "x509: failed to parse private key"},
+		{alg: "EdDSA", private: false, filename: "ES512-test1-public.pem", errorMsg: "key is not a valid Ed25519 public key"},
 	} {
 		alg := testcase.alg
 		assertError := func(err error) {
@@ -53,6 +58,12 @@ func TestKeyLoading(t *testing.T) {
 					} else {
 						assert.NotNil(t, key.(*rsa.PublicKey))
 					}
+				} else if IsEdDSA(alg) {
+					if testcase.private {
+						assert.NotNil(t, key.(ed25519.PrivateKey))
+					} else {
+						assert.NotNil(t, key.(ed25519.PublicKey))
+					}
 				} else if IsHMAC(alg) {
 					assert.NotNil(t, key.([]byte))
 				} else {
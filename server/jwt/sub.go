@@ -0,0 +1,16 @@
+package jwt
+
+import (
+	jwtgo "github.com/dgrijalva/jwt-go/v4"
+)
+
+// ExtractSubject read "sub" claim of JWT. Does not perform any JWT validation.
+// Returns empty string if JWT does not have "sub" claim.
+func ExtractSubject(jwtStr string) (string, error) {
+	parser := jwtgo.NewParser()
+	claims := jwtgo.StandardClaims{}
+	if _, _, err := parser.ParseUnverified(jwtStr, &claims); err != nil {
+		return "", err
+	}
+	return claims.Subject, nil
+}
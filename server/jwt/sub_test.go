@@ -0,0 +1,32 @@
+package jwt_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/saiya/dsps/server/jwt"
+	. "github.com/saiya/dsps/server/jwt/testing"
+)
+
+func TestExtractSubject(t *testing.T) {
+	sub, err := ExtractSubject(GenerateJwt(t, JwtProps{
+		Alg:     "ES512",
+		Keyname: "ES512-test1",
+		JwtDir:  ".",
+		Claims:  map[string]interface{}{"sub": "user-123"},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "user-123", sub)
+
+	sub, err = ExtractSubject(GenerateJwt(t, JwtProps{
+		Alg:     "ES512",
+		Keyname: "ES512-test1",
+		JwtDir:  ".",
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "", sub)
+
+	_, err = ExtractSubject(`this-is-not-JWT`)
+	assert.Error(t, err)
+}
@@ -77,15 +77,24 @@ func NewTemplate(ctx context.Context, cfg *config.JwtValidationConfig, clock dom
 }
 
 func (v *validatorTemplate) NewValidator(tplEnv domain.TemplateStringEnv) (Validator, error) {
-	claims := make(map[string][]string, len(v.cfg.Claims))
-	for claim, tpl := range v.cfg.Claims {
+	claims, err := ExecuteClaimTemplates(v.cfg.Claims, tplEnv)
+	if err != nil {
+		return nil, err
+	}
+	return &validator{validatorTemplate: *v, claims: claims}, nil
+}
+
+// ExecuteClaimTemplates evaluates template strings of the custom claims configuration.
+func ExecuteClaimTemplates(templates map[string]domain.TemplateStrings, tplEnv domain.TemplateStringEnv) (map[string][]string, error) {
+	claims := make(map[string][]string, len(templates))
+	for claim, tpl := range templates {
 		strs, err := tpl.Execute(tplEnv)
 		if err != nil {
 			return nil, fmt.Errorf(`failed to evaluate template string of JWT "%s" claim configuration "%s": %w`, claim, tpl, err)
 		}
 		claims[claim] = strs
 	}
-	return &validator{validatorTemplate: *v, claims: claims}, nil
+	return claims, nil
 }
 
 func (v *validatorTemplate) JWTClockSkewLeewayMax() domain.Duration {
@@ -173,7 +182,13 @@ func (v *validator) validateAud(ctx context.Context, claims jwtgo.MapClaims) err
 }
 
 func (v *validator) validateCustomClaims(ctx context.Context, claims jwtgo.MapClaims) error {
-	for claim, expectations := range v.claims {
+	return ValidateCustomClaims(v.claims, claims)
+}
+
+// ValidateCustomClaims ensures each claim has one of expected values.
+// expectations is a result of ExecuteClaimTemplates.
+func ValidateCustomClaims(expectations map[string][]string, claims map[string]interface{}) error {
+	for claim, expectations := range expectations {
 		var value string
 		switch raw := claims[claim].(type) {
 		case string:
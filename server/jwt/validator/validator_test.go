@@ -27,6 +27,10 @@ var pregeneratedPublicKeys = map[domain.JwtAlg][]string{
 		"../testdata/ES512-test1-public.pem",
 		"../testdata/ES512-test2-public.pem",
 	},
+	"EdDSA": {
+		"../testdata/EdDSA-test1-public.pem",
+		"../testdata/EdDSA-test2-public.pem",
+	},
 	"HS256": {
 		"../testdata/HS256.rand",
 	},
@@ -59,6 +63,7 @@ func TestAlgorithms(t *testing.T) {
 		"RS256": "RS256-2048bit", // RSASSA-PKCS1
 		"ES512": "ES512-test1",   // ECDSA
 		"PS256": "RS256-2048bit", // RSASSA-PSS
+		"EdDSA": "EdDSA-test1",   // EdDSA (RFC 8037)
 	} {
 		assert.NoError(t, v.Validate(ctx, GenerateJwt(t, JwtProps{
 			Alg:     domain.JwtAlg(supported),
@@ -159,6 +164,8 @@ func TestMultipleKeyMatching(t *testing.T) {
 		{Keyname: "ES512-test2", Alg: "ES512"},
 		{Keyname: "RS256-2048bit", Alg: "RS256"},
 		{Keyname: "RS256-4096bit", Alg: "RS256"},
+		{Keyname: "EdDSA-test1", Alg: "EdDSA"},
+		{Keyname: "EdDSA-test2", Alg: "EdDSA"},
 	} {
 		props.Iss = "https://example.com/issuer"
 		props.Aud = []domain.JwtAud{"https://example.com/audience"}
@@ -374,3 +381,20 @@ func createDefaultValidator(t *testing.T) Validator {
 	assert.NoError(t, err)
 	return v
 }
+
+func TestEdDSASignatureMismatch(t *testing.T) {
+	ctx := context.Background()
+	tpl, err := NewTemplate(ctx, &config.JwtValidationConfig{
+		Iss:             []domain.JwtIss{"https://example.com/issuer"},
+		Keys:            map[domain.JwtAlg][]string{"EdDSA": {"../testdata/EdDSA-test1-public.pem"}},
+		ClockSkewLeeway: &domain.Duration{},
+	}, domain.RealSystemClock)
+	assert.NoError(t, err)
+	v, err := tpl.NewValidator(map[string]interface{}{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, v.Validate(ctx, GenerateJwt(t, JwtProps{Keyname: "EdDSA-test1", Alg: "EdDSA", Iss: "https://example.com/issuer"})))
+	err = v.Validate(ctx, GenerateJwt(t, JwtProps{Keyname: "EdDSA-test2", Alg: "EdDSA", Iss: "https://example.com/issuer"}))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "JWT validation failed: token signature is invalid")
+}
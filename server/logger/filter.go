@@ -2,26 +2,44 @@ package logger
 
 import (
 	"sync"
+	"sync/atomic"
 )
 
 // Filter controls log verbosity
 type Filter struct {
-	// Do not modify after creation otherwise breaks goroutine-safe
-	thresholds       sync.Map
+	current atomic.Value // *filterThresholds, replaced as a whole to update thresholds atomically
+	writeMu sync.Mutex   // Serializes updates
+}
+
+// filterThresholds is immutable snapshot of thresholds, do not modify after stored to Filter.
+type filterThresholds struct {
+	thresholds       map[Category]Level
 	defaultThreshold Level
 }
 
 func newDefaultFilter() *Filter {
-	return &Filter{
-		thresholds:       sync.Map{},
+	filter := &Filter{}
+	filter.current.Store(&filterThresholds{
+		thresholds:       map[Category]Level{},
 		defaultThreshold: INFO,
-	}
+	})
+	return filter
 }
 
 // NewFilter creates Filter instance with given thresholds configuration.
 func NewFilter(thresholds map[string]string) (*Filter, error) {
-	result := Filter{
-		thresholds:       sync.Map{},
+	parsed, err := parseThresholds(thresholds)
+	if err != nil {
+		return nil, err
+	}
+	filter := &Filter{}
+	filter.current.Store(parsed)
+	return filter, nil
+}
+
+func parseThresholds(thresholds map[string]string) (*filterThresholds, error) {
+	result := &filterThresholds{
+		thresholds:       make(map[Category]Level, len(thresholds)),
 		defaultThreshold: INFO,
 	}
 	for key, value := range thresholds {
@@ -29,25 +47,56 @@ func NewFilter(thresholds map[string]string) (*Filter, error) {
 		if err != nil {
 			return nil, err
 		}
-		result.SetThreshold(ParseCategory(key), level)
+		if cat := ParseCategory(key); cat == "*" {
+			result.defaultThreshold = level
+		} else {
+			result.thresholds[cat] = level
+		}
 	}
-	return &result, nil
+	return result, nil
 }
 
 // Filter determines whether to output (true) the log or not (false).
 func (filter *Filter) Filter(level Level, cat Category) bool {
-	threshold, ok := filter.thresholds.Load(cat)
+	current := filter.current.Load().(*filterThresholds)
+	threshold, ok := current.thresholds[cat]
 	if !ok {
-		threshold = filter.defaultThreshold
+		threshold = current.defaultThreshold
 	}
-	return level >= threshold.(Level)
+	return level >= threshold
 }
 
 // SetThreshold changes threshold immediately
 func (filter *Filter) SetThreshold(cat Category, level Level) {
+	filter.writeMu.Lock()
+	defer filter.writeMu.Unlock()
+
+	current := filter.current.Load().(*filterThresholds)
+	next := &filterThresholds{
+		thresholds:       make(map[Category]Level, len(current.thresholds)+1),
+		defaultThreshold: current.defaultThreshold,
+	}
+	for c, l := range current.thresholds {
+		next.thresholds[c] = l
+	}
 	if cat == "*" {
-		filter.defaultThreshold = level
+		next.defaultThreshold = level
 	} else {
-		filter.thresholds.Store(cat, level)
+		next.thresholds[cat] = level
 	}
+	filter.current.Store(next)
+}
+
+// ResetThresholds replaces all thresholds with given configuration.
+// If given configuration is invalid, returns error without any change.
+func (filter *Filter) ResetThresholds(thresholds map[string]string) error {
+	next, err := parseThresholds(thresholds)
+	if err != nil {
+		return err
+	}
+
+	filter.writeMu.Lock()
+	defer filter.writeMu.Unlock()
+	filter.current.Store(next)
+	return nil
 }
@@ -1,6 +1,8 @@
 package logger_test
 
 import (
+	"context"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -36,3 +38,60 @@ func TestInvalidFilterDefinition(t *testing.T) {
 	_, err := NewFilter(map[string]string{"auth": "INVALID_LEVEL"})
 	assert.EqualError(t, err, `invalid log level string given: "INVALID_LEVEL"`)
 }
+
+func TestFilterResetThresholds(t *testing.T) {
+	filter, err := NewFilter(map[string]string{
+		"*":    "WARN",
+		"auth": "INFO",
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, filter.ResetThresholds(map[string]string{
+		"http": "DEBUG",
+	}))
+	assert.True(t, filter.Filter(INFO, "any")) // Default threshold
+	assert.False(t, filter.Filter(DEBUG, "any"))
+	assert.False(t, filter.Filter(DEBUG, "auth")) // Removed category falls back to default threshold
+	assert.True(t, filter.Filter(DEBUG, "http"))
+
+	// Invalid configuration does not change anything
+	assert.EqualError(t, filter.ResetThresholds(map[string]string{"http": "INVALID_LEVEL"}), `invalid log level string given: "INVALID_LEVEL"`)
+	assert.True(t, filter.Filter(DEBUG, "http"))
+}
+
+func TestFilterResetThresholdsWhileLogging(t *testing.T) {
+	// Run with -race to detect data race
+	filter, err := NewFilter(map[string]string{"*": "FATAL"})
+	assert.NoError(t, err)
+	WithTestLogger(t, filter, func(lc *LogCapture) {
+		var wg sync.WaitGroup
+		stop := make(chan struct{})
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+						Of(context.Background()).Debugf(CatHTTP, "debug log")
+						filter.Filter(INFO, CatAuth)
+					}
+				}
+			}()
+		}
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, filter.ResetThresholds(map[string]string{"*": "FATAL", "http": "DEBUG"}))
+			filter.SetThreshold(CatAuth, DEBUG)
+			assert.NoError(t, filter.ResetThresholds(map[string]string{"*": "FATAL"}))
+		}
+		close(stop)
+		wg.Wait()
+
+		// Default threshold and category thresholds are swapped together
+		assert.NoError(t, filter.ResetThresholds(map[string]string{"*": "DEBUG", "http": "ERROR"}))
+		assert.True(t, filter.Filter(DEBUG, CatAuth))
+		assert.False(t, filter.Filter(WARN, CatHTTP))
+	})
+}
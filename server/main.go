@@ -7,17 +7,20 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/saiya/dsps/server/config"
+	dspsconfig "github.com/saiya/dsps/server/config"
 	"github.com/saiya/dsps/server/domain"
 	"github.com/saiya/dsps/server/domain/channel"
 	"github.com/saiya/dsps/server/http"
 	httplifecycle "github.com/saiya/dsps/server/http/lifecycle"
+	"github.com/saiya/dsps/server/jwt/issuer"
 	"github.com/saiya/dsps/server/logger"
 	"github.com/saiya/dsps/server/sentry"
 	"github.com/saiya/dsps/server/storage"
 	"github.com/saiya/dsps/server/storage/deps"
 	"github.com/saiya/dsps/server/telemetry"
 	"github.com/saiya/dsps/server/unix"
+	"github.com/saiya/dsps/server/webhook/delivery"
+	"github.com/saiya/dsps/server/webhook/outgoing"
 )
 
 // Git commit hash or tag
@@ -54,7 +57,7 @@ func mainImpl(ctx context.Context, args []string, clock domain.SystemClock) erro
 		return err
 	}
 	configFile := flag.Arg(0)
-	configOverrides := config.Overrides{
+	configOverrides := dspsconfig.Overrides{
 		BuildVersion: buildVersion,
 		BuildDist:    buildDist,
 		BuildAt:      buildAt,
@@ -63,7 +66,7 @@ func mainImpl(ctx context.Context, args []string, clock domain.SystemClock) erro
 		Debug:        *debug,
 	}
 
-	config, err := config.LoadConfigFile(ctx, configFile, configOverrides)
+	config, err := dspsconfig.LoadConfigFile(ctx, configFile, configOverrides)
 	if err != nil {
 		return err
 	}
@@ -90,10 +93,13 @@ func mainImpl(ctx context.Context, args []string, clock domain.SystemClock) erro
 	}
 	defer telemetry.Shutdown(ctx)
 
-	channelProvider, err := channel.NewChannelProvider(ctx, &config, channel.ProviderDeps{
+	circuitBreakers := outgoing.NewCircuitBreakerRegistry(clock, telemetry)
+	channelProvider, err := channel.NewReloadableChannelProvider(ctx, &config, channel.ProviderDeps{
 		Clock:     clock,
 		Telemetry: telemetry,
 		Sentry:    sentry,
+
+		CircuitBreakers: circuitBreakers,
 	})
 	if err != nil {
 		return err
@@ -113,19 +119,57 @@ func mainImpl(ctx context.Context, args []string, clock domain.SystemClock) erro
 		}
 	}()
 
+	webhookDispatcher, err := delivery.NewDispatcher(ctx, config.WebhookDelivery, storage, delivery.Deps{
+		Clock:           clock,
+		ChannelProvider: channelProvider,
+		Telemetry:       telemetry,
+		Sentry:          sentry,
+	})
+	if err != nil {
+		return err
+	}
+	if webhookDispatcher != nil {
+		defer func() {
+			if err := webhookDispatcher.Shutdown(ctx); err != nil {
+				logger.Of(ctx).WarnError(logger.CatOutgoingWebhook, "Failed to shutdown outgoing-webhook dispatcher: %w", err)
+			}
+		}()
+	}
+
+	jwtIssuer, err := issuer.NewIssuer(ctx, config.Admin.JwtIssuer, clock)
+	if err != nil {
+		return err
+	}
+
+	var configLoader func(ctx context.Context) (dspsconfig.ServerConfig, error)
+	if configFile == "" || configFile == "-" {
+		logger.Of(ctx).Infof(logger.CatServer, "Configuration reload (SIGHUP) is not supported because configuration is not loaded from a file")
+	} else {
+		configLoader = func(ctx context.Context) (dspsconfig.ServerConfig, error) {
+			return dspsconfig.LoadConfigFile(ctx, configFile, configOverrides)
+		}
+	}
+
 	unix.NotifyUlimit(ctx, unix.UlimitRequirement{
 		NoFiles: channelProvider.GetFileDescriptorPressure() + storage.GetFileDescriptorPressure(),
 	})
 
 	http.StartServer(ctx, &http.ServerDependencies{
 		Config:          &config,
+		Clock:           clock,
 		ChannelProvider: channelProvider,
 		Storage:         storage,
+		JwtIssuer:       jwtIssuer,
+
+		WebhookDispatcher: webhookDispatcher,
+		CircuitBreakers:   circuitBreakers,
 
 		Telemetry:   telemetry,
 		Sentry:      sentry,
 		LogFilter:   logFilter,
 		ServerClose: httplifecycle.NewServerClose(),
+
+		ConfigLoader: configLoader,
 	})
 	return nil
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/domain"
+	. "github.com/saiya/dsps/server/http/testing"
+	. "github.com/saiya/dsps/server/testing"
+)
+
+func TestReloadWithStdinConfig(t *testing.T) {
+	configYaml := strings.ReplaceAll(`
+logging: category: "*": ERROR
+channels:
+	-
+		regex: 'auth-test-channel'
+		jwt:
+			iss: [ "https://issuer.example.com/issuer-url" ]
+			keys:
+				RS256: [ "jwt/testdata/RS256-2048bit-public.pem" ]
+`, "\t", "  ")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	addr := listener.Addr().String()
+	assert.NoError(t, listener.Close())
+	baseURL := "http://" + addr
+
+	// Prevent signals from terminating test process even if the server has not started to handle them.
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	WithTextFile(t, configYaml, func(filename string) {
+		realStdin := os.Stdin
+		defer func() { os.Stdin = realStdin }()
+		stdin, err := os.OpenFile(filename, os.O_RDONLY, os.ModePerm) //nolint:gosec
+		assert.NoError(t, err)
+		defer func() { assert.NoError(t, stdin.Close()) }()
+		os.Stdin = stdin
+
+		done := make(chan error, 1)
+		go func() { done <- mainImpl(context.Background(), []string{"-listen", addr, "-"}, domain.RealSystemClock) }()
+		for i := 0; ; i++ {
+			if conn, err := net.Dial("tcp", addr); err == nil {
+				assert.NoError(t, conn.Close())
+				break
+			}
+			if i > 100 {
+				assert.FailNow(t, fmt.Sprintf("server did not start on %s", addr))
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		time.Sleep(100 * time.Millisecond) // Wait for the server to start handling signals
+
+		publish := func() int {
+			res := DoHTTPRequest(t, "PUT", baseURL+"/channel/auth-test-channel/message/msg-1", `{}`)
+			assert.NoError(t, res.Body.Close())
+			return res.StatusCode
+		}
+		assert.Equal(t, 403, publish())
+
+		// Reload must not replace configured channels with default channels (stdin could not be read again)
+		assert.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, 403, publish())
+
+		assert.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			assert.Fail(t, "server did not shutdown")
+		}
+	})
+}
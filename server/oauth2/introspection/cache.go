@@ -0,0 +1,75 @@
+package introspection
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+// Sweep expired entries when number of entries exceeds this threshold.
+const responseCacheSweepThreshold = 1024
+
+type responseCacheKey [sha256.Size]byte
+
+type responseCacheEntry struct {
+	res    introspectionResponse
+	expire time.Time
+}
+
+// responseCache caches introspection responses, keyed by hash of the token.
+type responseCache struct {
+	clock domain.SystemClock
+
+	lock           sync.Mutex
+	entries        map[responseCacheKey]responseCacheEntry
+	sweepThreshold int
+}
+
+func newResponseCache(clock domain.SystemClock) *responseCache {
+	return &responseCache{
+		clock:          clock,
+		entries:        make(map[responseCacheKey]responseCacheEntry),
+		sweepThreshold: responseCacheSweepThreshold,
+	}
+}
+
+func (c *responseCache) Get(token string) (introspectionResponse, bool) {
+	key := sha256.Sum256([]byte(token))
+	now := c.clock.Now().Time
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !now.Before(entry.expire) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.res, true
+}
+
+func (c *responseCache) Put(token string, res introspectionResponse, ttl domain.Duration) {
+	if ttl.Duration <= 0 {
+		return
+	}
+	key := sha256.Sum256([]byte(token))
+	now := c.clock.Now().Time
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries[key] = responseCacheEntry{res: res, expire: now.Add(ttl.Duration)}
+	if len(c.entries) > c.sweepThreshold {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expire) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries)*2 > c.sweepThreshold {
+			c.sweepThreshold = len(c.entries) * 2
+		}
+	}
+}
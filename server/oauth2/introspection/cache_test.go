@@ -0,0 +1,32 @@
+package introspection
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	dspstesting "github.com/saiya/dsps/server/testing"
+)
+
+func TestResponseCacheSweep(t *testing.T) {
+	clock := dspstesting.NewStubClock(t)
+	c := newResponseCache(clock)
+	c.sweepThreshold = 4
+
+	for i := 0; i < 4; i++ {
+		c.Put(fmt.Sprintf("token-%d", i), introspectionResponse{"active": true}, dspstesting.MakeDuration("1s"))
+	}
+	assert.Equal(t, 4, len(c.entries))
+
+	clock.Add(time.Second)
+	c.Put("token-new", introspectionResponse{"active": true}, dspstesting.MakeDuration("1s"))
+	assert.Equal(t, 1, len(c.entries)) // Expired entries should be swept
+	res, ok := c.Get("token-new")
+	assert.True(t, ok)
+	assert.True(t, res.active())
+
+	_, ok = c.Get("token-0")
+	assert.False(t, ok)
+}
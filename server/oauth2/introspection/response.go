@@ -0,0 +1,47 @@
+package introspection
+
+import (
+	"time"
+)
+
+// introspectionResponse is a response body of RFC 7662 introspection endpoint.
+type introspectionResponse map[string]interface{}
+
+func (res introspectionResponse) active() bool {
+	active, ok := res["active"].(bool)
+	return ok && active
+}
+
+func (res introspectionResponse) exp() (time.Time, bool) {
+	return res.timeClaim("exp")
+}
+
+func (res introspectionResponse) nbf() (time.Time, bool) {
+	return res.timeClaim("nbf")
+}
+
+func (res introspectionResponse) timeClaim(name string) (time.Time, bool) {
+	value, ok := res[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(value), 0), true
+}
+
+// strings returns string or list of string claim value
+func (res introspectionResponse) strings(name string) []string {
+	switch value := res[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		result := make([]string, 0, len(value))
+		for _, item := range value {
+			if str, ok := item.(string); ok {
+				result = append(result, str)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
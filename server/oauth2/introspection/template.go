@@ -0,0 +1,123 @@
+package introspection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+	jwtv "github.com/saiya/dsps/server/jwt/validator"
+	"github.com/saiya/dsps/server/logger"
+	"github.com/saiya/dsps/server/telemetry"
+)
+
+// Template is a template of Validator
+type Template interface {
+	NewValidator(tplEnv domain.TemplateStringEnv) (Validator, error)
+	Close()
+}
+
+// Validator is a object to validate opaque token with introspection endpoint
+type Validator interface {
+	Validate(ctx context.Context, token string) error
+}
+
+type validatorTemplate struct {
+	cfg   *config.TokenIntrospectionConfig
+	clock domain.SystemClock
+
+	h         *http.Client
+	cache     *responseCache
+	telemetry *telemetry.Telemetry
+}
+
+// NewTemplate creates Template instance.
+func NewTemplate(ctx context.Context, cfg *config.TokenIntrospectionConfig, clock domain.SystemClock, telemetry *telemetry.Telemetry) (Template, error) {
+	return &validatorTemplate{
+		cfg:   cfg,
+		clock: clock,
+
+		h:         &http.Client{Timeout: cfg.Timeout.Duration},
+		cache:     newResponseCache(clock),
+		telemetry: telemetry,
+	}, nil
+}
+
+func (tpl *validatorTemplate) NewValidator(tplEnv domain.TemplateStringEnv) (Validator, error) {
+	claims, err := jwtv.ExecuteClaimTemplates(tpl.cfg.Claims, tplEnv)
+	if err != nil {
+		return nil, err
+	}
+	return &validator{validatorTemplate: tpl, claims: claims}, nil
+}
+
+func (tpl *validatorTemplate) Close() {
+	tpl.h.CloseIdleConnections()
+}
+
+// introspect returns introspection response, may use cached one.
+func (tpl *validatorTemplate) introspect(ctx context.Context, token string) (introspectionResponse, error) {
+	if cached, ok := tpl.cache.Get(token); ok {
+		return cached, nil
+	}
+
+	res, err := tpl.callEndpoint(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	tpl.cache.Put(token, res, tpl.cacheTTL(res))
+	return res, nil
+}
+
+// cacheTTL returns TTL bounded by "exp" of the token.
+func (tpl *validatorTemplate) cacheTTL(res introspectionResponse) domain.Duration {
+	ttl := *tpl.cfg.CacheTTL
+	if exp, ok := res.exp(); ok {
+		untilExp := exp.Sub(tpl.clock.Now().Time)
+		if untilExp < ttl.Duration {
+			ttl = domain.Duration{Duration: untilExp}
+		}
+	}
+	return ttl
+}
+
+func (tpl *validatorTemplate) callEndpoint(ctx context.Context, token string) (introspectionResponse, error) {
+	form := url.Values{}
+	form.Set("token", token)
+	form.Set("token_type_hint", "access_token")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tpl.cfg.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token introspection request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if tpl.cfg.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(tpl.cfg.ClientID), url.QueryEscape(tpl.cfg.ClientSecret)) // RFC 6749 section 2.3.1
+	}
+
+	ctx, end := tpl.telemetry.StartHTTPSpan(ctx, false, req)
+	defer end()
+	res, err := tpl.h.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token introspection request failed: %w", err)
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			logger.Of(ctx).Debugf(logger.CatAuth, "failed to close token introspection response body: %v", err)
+		}
+	}()
+	tpl.telemetry.SetHTTPResponseAttributes(ctx, res.StatusCode, res.ContentLength)
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token introspection endpoint returned HTTP %d", res.StatusCode)
+	}
+
+	body := introspectionResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse token introspection response: %w", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,72 @@
+package introspection
+
+import (
+	"context"
+	"fmt"
+
+	jwtv "github.com/saiya/dsps/server/jwt/validator"
+)
+
+type validator struct {
+	*validatorTemplate
+
+	claims map[string][]string
+}
+
+func (v *validator) Validate(ctx context.Context, token string) error {
+	if token == "" {
+		return fmt.Errorf("no token presented")
+	}
+
+	res, err := v.introspect(ctx, token)
+	if err != nil {
+		return err
+	}
+	if !res.active() {
+		return fmt.Errorf("presented token is not active")
+	}
+
+	now := v.clock.Now().Time
+	leeway := v.cfg.ClockSkewLeeway.Duration
+	if exp, ok := res.exp(); ok && !now.Before(exp.Add(leeway)) {
+		return fmt.Errorf("presented token is expired")
+	}
+	if nbf, ok := res.nbf(); ok && now.Add(leeway).Before(nbf) {
+		return fmt.Errorf("presented token is not valid yet")
+	}
+	if err := v.validateIss(res); err != nil {
+		return err
+	}
+	if err := v.validateAud(res); err != nil {
+		return err
+	}
+	return jwtv.ValidateCustomClaims(v.claims, res)
+}
+
+func (v *validator) validateIss(res introspectionResponse) error {
+	if len(v.cfg.Iss) == 0 {
+		return nil
+	}
+	actual, _ := res["iss"].(string)
+	for _, acceptable := range v.cfg.Iss {
+		if actual == string(acceptable) {
+			return nil
+		}
+	}
+	return fmt.Errorf(`"iss" of the presented token ("%s") does not match with any of expected values (%v)`, actual, v.cfg.Iss)
+}
+
+func (v *validator) validateAud(res introspectionResponse) error {
+	if len(v.cfg.Aud) == 0 {
+		return nil
+	}
+	actual := res.strings("aud")
+	for _, acceptable := range v.cfg.Aud {
+		for _, aud := range actual {
+			if aud == string(acceptable) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf(`"aud" of the presented token (%v) does not match with any of expected values (%v)`, actual, v.cfg.Aud)
+}
@@ -0,0 +1,169 @@
+package introspection_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+	. "github.com/saiya/dsps/server/oauth2/introspection"
+	"github.com/saiya/dsps/server/telemetry"
+	dspstesting "github.com/saiya/dsps/server/testing"
+)
+
+// withIntrospectionServer runs stub introspection endpoint that returns response for each token.
+func withIntrospectionServer(t *testing.T, responses map[string]map[string]interface{}, f func(url string, calls *int32)) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "my-client" || password != "my-secret" {
+			w.WriteHeader(401)
+			return
+		}
+
+		res, ok := responses[r.FormValue("token")]
+		if !ok {
+			res = map[string]interface{}{"active": false}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		assert.NoError(t, json.NewEncoder(w).Encode(res))
+	}))
+	defer server.Close()
+	f(server.URL, &calls)
+}
+
+func newValidator(t *testing.T, cfg *config.TokenIntrospectionConfig, clock domain.SystemClock) Validator {
+	if cfg.ClientID == "" {
+		cfg.ClientID = "my-client"
+		cfg.ClientSecret = "my-secret"
+	}
+	if cfg.Claims == nil {
+		cfg.Claims = map[string]domain.TemplateStrings{}
+	}
+	cfg.Timeout = dspstesting.MakeDurationPtr("3s")
+	if cfg.CacheTTL == nil {
+		cfg.CacheTTL = dspstesting.MakeDurationPtr("1m")
+	}
+	cfg.ClockSkewLeeway = dspstesting.MakeDurationPtr("1s")
+
+	tpl, err := NewTemplate(context.Background(), cfg, clock, telemetry.NewEmptyTelemetry(t))
+	assert.NoError(t, err)
+	t.Cleanup(tpl.Close)
+	v, err := tpl.NewValidator(map[string]interface{}{"channel": map[string]string{"id": "1234"}})
+	assert.NoError(t, err)
+	return v
+}
+
+func TestValidation(t *testing.T) {
+	ctx := context.Background()
+	future := float64(time.Now().Add(time.Hour).Unix())
+	past := float64(time.Now().Add(-time.Hour).Unix())
+	chatroomTpl, err := domain.NewTemplateString("{{.channel.id}}")
+	assert.NoError(t, err)
+
+	withIntrospectionServer(t, map[string]map[string]interface{}{
+		"valid":        {"active": true, "exp": future, "iss": "https://example.com/issuer", "aud": []string{"https://example.com/audience"}, "chatroom": "1234"},
+		"inactive":     {"active": false, "exp": future, "iss": "https://example.com/issuer", "aud": "https://example.com/audience", "chatroom": "1234"},
+		"expired":      {"active": true, "exp": past, "iss": "https://example.com/issuer", "aud": "https://example.com/audience", "chatroom": "1234"},
+		"not-yet":      {"active": true, "nbf": future, "iss": "https://example.com/issuer", "aud": "https://example.com/audience", "chatroom": "1234"},
+		"wrong-iss":    {"active": true, "iss": "https://example.com/other", "aud": "https://example.com/audience", "chatroom": "1234"},
+		"wrong-aud":    {"active": true, "iss": "https://example.com/issuer", "aud": "https://example.com/other", "chatroom": "1234"},
+		"wrong-claims": {"active": true, "iss": "https://example.com/issuer", "aud": "https://example.com/audience", "chatroom": "9999"},
+	}, func(url string, calls *int32) {
+		v := newValidator(t, &config.TokenIntrospectionConfig{
+			URL:    url,
+			Iss:    []domain.JwtIss{"https://example.com/issuer"},
+			Aud:    []domain.JwtAud{"https://example.com/audience"},
+			Claims: map[string]domain.TemplateStrings{"chatroom": domain.NewTemplateStrings(chatroomTpl)},
+		}, domain.RealSystemClock)
+
+		assert.NoError(t, v.Validate(ctx, "valid"))
+		for token, errMsg := range map[string]string{
+			"":             "no token presented",
+			"unknown":      "presented token is not active",
+			"inactive":     "presented token is not active",
+			"expired":      "presented token is expired",
+			"not-yet":      "presented token is not valid yet",
+			"wrong-iss":    `"iss" of the presented token ("https://example.com/other") does not match`,
+			"wrong-aud":    `"aud" of the presented token ([https://example.com/other]) does not match`,
+			"wrong-claims": `required "chatroom" claim to be [1234] by setting but presented JWT has value "9999"`,
+		} {
+			err := v.Validate(ctx, token)
+			if assert.Error(t, err, token) {
+				assert.Contains(t, err.Error(), errMsg, token)
+			}
+		}
+	})
+}
+
+func TestEndpointErrors(t *testing.T) {
+	ctx := context.Background()
+	withIntrospectionServer(t, map[string]map[string]interface{}{}, func(url string, calls *int32) {
+		v := newValidator(t, &config.TokenIntrospectionConfig{URL: url, ClientID: "wrong-client"}, domain.RealSystemClock)
+		err := v.Validate(ctx, "my-token")
+		assert.Contains(t, err.Error(), "token introspection endpoint returned HTTP 401")
+
+		// Should not cache errors
+		assert.Error(t, v.Validate(ctx, "my-token"))
+		assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	})
+
+	v := newValidator(t, &config.TokenIntrospectionConfig{URL: "http://localhost:0/introspect"}, domain.RealSystemClock)
+	assert.Contains(t, v.Validate(ctx, "my-token").Error(), "token introspection request failed")
+}
+
+func TestResponseCache(t *testing.T) {
+	ctx := context.Background()
+	clock := dspstesting.NewStubClock(t)
+	withIntrospectionServer(t, map[string]map[string]interface{}{
+		"long-lived":  {"active": true},
+		"short-lived": {"active": true, "exp": float64(clock.Now().Add(10 * time.Second).Unix())},
+	}, func(url string, calls *int32) {
+		v := newValidator(t, &config.TokenIntrospectionConfig{URL: url, CacheTTL: dspstesting.MakeDurationPtr("1m")}, clock)
+
+		// Cached until cacheTTL
+		assert.NoError(t, v.Validate(ctx, "long-lived"))
+		assert.NoError(t, v.Validate(ctx, "long-lived"))
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+		clock.Add(59 * time.Second)
+		assert.NoError(t, v.Validate(ctx, "long-lived"))
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+		clock.Add(1 * time.Second)
+		assert.NoError(t, v.Validate(ctx, "long-lived"))
+		assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+
+		// Cache TTL bounded by "exp"
+		clock.Set(time.Now())
+		assert.NoError(t, v.Validate(ctx, "short-lived"))
+		assert.NoError(t, v.Validate(ctx, "short-lived"))
+		assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+		clock.Add(15 * time.Second)
+		assert.Contains(t, v.Validate(ctx, "short-lived").Error(), "presented token is expired")
+		assert.Equal(t, int32(4), atomic.LoadInt32(calls))
+
+		// Inactive token response also cached
+		assert.Error(t, v.Validate(ctx, "unknown"))
+		assert.Error(t, v.Validate(ctx, "unknown"))
+		assert.Equal(t, int32(5), atomic.LoadInt32(calls))
+	})
+}
+
+func TestCacheDisabled(t *testing.T) {
+	ctx := context.Background()
+	withIntrospectionServer(t, map[string]map[string]interface{}{"my-token": {"active": true}}, func(url string, calls *int32) {
+		v := newValidator(t, &config.TokenIntrospectionConfig{URL: url, CacheTTL: dspstesting.MakeDurationPtr("0s")}, domain.RealSystemClock)
+		assert.NoError(t, v.Validate(ctx, "my-token"))
+		assert.NoError(t, v.Validate(ctx, "my-token"))
+		assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	})
+}
@@ -0,0 +1,82 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v4"
+	"golang.org/x/xerrors"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+// Max number of validation errors to report, to keep error response small
+const maxValidationErrors = 10
+
+// Validator validates message content with JSON Schema
+type Validator interface {
+	// Returns *domain.MessageSchemaViolation if the content does not match with the schema.
+	Validate(ctx context.Context, content []byte) error
+}
+
+type validator struct {
+	file   string
+	schema *jsonschema.Schema
+}
+
+// NewValidator loads JSON Schema file and creates Validator.
+// Schema can refer to other local files with relative path in "$ref".
+func NewValidator(ctx context.Context, file string) (Validator, error) {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return nil, xerrors.Errorf(`failed to resolve path of JSON Schema file "%s": %w`, file, err)
+	}
+	schema, err := jsonschema.NewCompiler().Compile(path)
+	if err != nil {
+		var schemaErr *jsonschema.SchemaError
+		if errors.As(err, &schemaErr) && schemaErr.Err != nil {
+			err = schemaErr.Err
+		}
+		var validationErr *jsonschema.ValidationError
+		if errors.As(err, &validationErr) {
+			// Schema itself does not match with the meta-schema
+			err = xerrors.New(strings.Join(leafErrorsOf(validationErr, nil), ", "))
+		}
+		return nil, xerrors.Errorf(`failed to load JSON Schema file "%s": %v`, file, err)
+	}
+	return &validator{file: file, schema: schema}, nil
+}
+
+func (v *validator) Validate(ctx context.Context, content []byte) error {
+	doc, err := jsonschema.DecodeJSON(bytes.NewReader(content))
+	if err != nil {
+		return &domain.MessageSchemaViolation{Errors: []string{fmt.Sprintf("#: failed to decode JSON: %v", err)}}
+	}
+	err = v.schema.ValidateInterface(doc)
+	if err == nil {
+		return nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return xerrors.Errorf(`failed to validate message content with JSON Schema "%s": %w`, v.file, err)
+	}
+	return &domain.MessageSchemaViolation{Errors: leafErrorsOf(validationErr, nil)}
+}
+
+// leafErrorsOf returns most detailed errors, such as "#/user/name: expected string, but got number".
+func leafErrorsOf(err *jsonschema.ValidationError, result []string) []string {
+	if len(result) >= maxValidationErrors {
+		return result
+	}
+	if len(err.Causes) == 0 {
+		return append(result, fmt.Sprintf("%s: %s", err.InstancePtr, err.Message))
+	}
+	for _, cause := range err.Causes {
+		result = leafErrorsOf(cause, result)
+	}
+	return result
+}
@@ -0,0 +1,46 @@
+package schema_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/domain"
+	. "github.com/saiya/dsps/server/schema"
+)
+
+func TestValidator(t *testing.T) {
+	ctx := context.Background()
+	v, err := NewValidator(ctx, "testdata/chat-message.json")
+	assert.NoError(t, err)
+
+	assert.NoError(t, v.Validate(ctx, []byte(`{"text":"hello","user":{"name":"alice"}}`)))
+
+	err = v.Validate(ctx, []byte(`{"text":1,"user":{}}`))
+	assert.True(t, errors.Is(err, domain.ErrMessageSchemaViolation))
+	var violation *domain.MessageSchemaViolation
+	assert.True(t, errors.As(err, &violation))
+	assert.ElementsMatch(t, []string{
+		`#/text: expected string, but got number`,
+		`#/user: missing properties: "name"`,
+	}, violation.Errors)
+
+	err = v.Validate(ctx, []byte(`"hello"`))
+	assert.True(t, errors.As(err, &violation))
+	assert.Equal(t, []string{`#: expected object, but got string`}, violation.Errors)
+
+	err = v.Validate(ctx, []byte(`{`))
+	assert.True(t, errors.As(err, &violation))
+	assert.Regexp(t, `^#: failed to decode JSON`, violation.Errors[0])
+}
+
+func TestValidatorLoadError(t *testing.T) {
+	ctx := context.Background()
+	_, err := NewValidator(ctx, "testdata/no-such-file.json")
+	assert.Regexp(t, `failed to load JSON Schema file "testdata/no-such-file.json"`, err.Error())
+
+	_, err = NewValidator(ctx, "testdata/invalid.json")
+	assert.Regexp(t, `failed to load JSON Schema file "testdata/invalid.json": #/type: `, err.Error())
+}
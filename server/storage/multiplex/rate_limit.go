@@ -0,0 +1,40 @@
+package multiplex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/logger"
+)
+
+// Note: Unlike other operations, takes a token from only one child, otherwise a request consumes multiple tokens if children share the same backend.
+// Falls back to next child (sorted by ID to choose the same child on all server processes) only if failed.
+func (s *storageMultiplexer) TakeRateLimitToken(ctx context.Context, key string, limit domain.RateLimit) (domain.Duration, error) {
+	ids := make([]string, 0, len(s.children))
+	for id, child := range s.children {
+		if child.AsRateLimitStorage() != nil {
+			ids = append(ids, string(id))
+		}
+	}
+	sort.Strings(ids)
+
+	var firstErr error
+	for _, id := range ids {
+		wait, err := s.children[domain.StorageID(id)].AsRateLimitStorage().TakeRateLimitToken(ctx, key, limit)
+		if err == nil {
+			return wait, nil
+		}
+		err = fmt.Errorf("TakeRateLimitToken failed on storage \"%s\": %w", id, err)
+		if firstErr == nil {
+			firstErr = err
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			break
+		}
+		logger.Of(ctx).WarnError(logger.CatStorage, "Error returned from multiplexed storage", err)
+	}
+	return domain.Duration{}, firstErr
+}
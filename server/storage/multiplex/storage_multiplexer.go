@@ -18,6 +18,8 @@ func NewStorageMultiplexer(children map[domain.StorageID]domain.Storage) (domain
 
 	pubsubSupported := false
 	jwtSupported := false
+	webhookSupported := false
+	rateLimitSupported := false
 	for _, c := range children {
 		if pubsub := c.AsPubSubStorage(); pubsub != nil {
 			pubsubSupported = true
@@ -25,21 +27,31 @@ func NewStorageMultiplexer(children map[domain.StorageID]domain.Storage) (domain
 		if jwt := c.AsJwtStorage(); jwt != nil {
 			jwtSupported = true
 		}
+		if webhook := c.AsWebhookStorage(); webhook != nil {
+			webhookSupported = true
+		}
+		if rateLimit := c.AsRateLimitStorage(); rateLimit != nil {
+			rateLimitSupported = true
+		}
 	}
 
 	return &storageMultiplexer{
 		children: children,
 
-		pubsubSupported: pubsubSupported,
-		jwtSupported:    jwtSupported,
+		pubsubSupported:    pubsubSupported,
+		jwtSupported:       jwtSupported,
+		webhookSupported:   webhookSupported,
+		rateLimitSupported: rateLimitSupported,
 	}, nil
 }
 
 type storageMultiplexer struct {
 	children map[domain.StorageID]domain.Storage
 
-	pubsubSupported bool
-	jwtSupported    bool
+	pubsubSupported    bool
+	jwtSupported       bool
+	webhookSupported   bool
+	rateLimitSupported bool
 }
 
 func (s *storageMultiplexer) AsPubSubStorage() domain.PubSubStorage {
@@ -55,6 +67,20 @@ func (s *storageMultiplexer) AsJwtStorage() domain.JwtStorage {
 	return s
 }
 
+func (s *storageMultiplexer) AsWebhookStorage() domain.WebhookStorage {
+	if !s.webhookSupported {
+		return nil
+	}
+	return s
+}
+
+func (s *storageMultiplexer) AsRateLimitStorage() domain.RateLimitStorage {
+	if !s.rateLimitSupported {
+		return nil
+	}
+	return s
+}
+
 func (s *storageMultiplexer) String() string {
 	return storageMapToString(s.children)
 }
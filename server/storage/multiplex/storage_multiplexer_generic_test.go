@@ -72,3 +72,44 @@ func TestJwt(t *testing.T) {
 		},
 	))
 }
+
+func TestWebhook(t *testing.T) {
+	WebhookTest(t, onmemoryMultiplexCtor(
+		t,
+		config.OnmemoryStorageConfig{
+			DisablePubSub: true,
+			DisableJwt:    true,
+		},
+		config.OnmemoryStorageConfig{
+			DisablePubSub:  true,
+			DisableJwt:     true,
+			DisableWebhook: true, // Storage without feature support
+		},
+		config.OnmemoryStorageConfig{
+			DisablePubSub: true,
+			DisableJwt:    true,
+		},
+	))
+}
+
+func TestRateLimit(t *testing.T) {
+	RateLimitTest(t, onmemoryMultiplexCtor(
+		t,
+		config.OnmemoryStorageConfig{
+			DisablePubSub:    true,
+			DisableJwt:       true,
+			DisableWebhook:   true,
+			DisableRateLimit: true, // Storage without feature support
+		},
+		config.OnmemoryStorageConfig{
+			DisablePubSub:  true,
+			DisableJwt:     true,
+			DisableWebhook: true,
+		},
+		config.OnmemoryStorageConfig{
+			DisablePubSub:  true,
+			DisableJwt:     true,
+			DisableWebhook: true,
+		},
+	))
+}
@@ -192,7 +192,11 @@ func TestOldMessageDurability(t *testing.T) {
 	s1 := NewMockStorage(ctrl)
 	s2 := NewMockStorage(ctrl)
 	s1.EXPECT().AsJwtStorage().AnyTimes().Return(nil)
+	s1.EXPECT().AsWebhookStorage().AnyTimes().Return(nil)
+	s1.EXPECT().AsRateLimitStorage().AnyTimes().Return(nil)
 	s2.EXPECT().AsJwtStorage().AnyTimes().Return(nil)
+	s2.EXPECT().AsWebhookStorage().AnyTimes().Return(nil)
+	s2.EXPECT().AsRateLimitStorage().AnyTimes().Return(nil)
 	pubsub := NewMockPubSubStorage(ctrl)
 	s1.EXPECT().AsPubSubStorage().AnyTimes().Return(pubsub)
 	s2.EXPECT().AsPubSubStorage().AnyTimes().Return(pubsub)
@@ -243,6 +247,33 @@ func TestInsufficientStorages(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, multiWithoutJwt.AsPubSubStorage())
 	assert.Nil(t, multiWithoutJwt.AsJwtStorage())
+
+	webhookDisabledCfg := config.OnmemoryStorageConfig{
+		DisableWebhook: true,
+	}
+	webhookDisabled1, err := onmemory.NewOnmemoryStorage(ctx, &webhookDisabledCfg, domain.RealSystemClock, StubChannelProvider, EmptyDeps(t))
+	assert.NoError(t, err)
+	webhookDisabled2, err := onmemory.NewOnmemoryStorage(ctx, &webhookDisabledCfg, domain.RealSystemClock, StubChannelProvider, EmptyDeps(t))
+	assert.NoError(t, err)
+	multiWithoutWebhook, err := NewStorageMultiplexer(map[domain.StorageID]domain.Storage{
+		"test1": webhookDisabled1,
+		"test2": webhookDisabled2,
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, multiWithoutWebhook.AsPubSubStorage())
+	assert.Nil(t, multiWithoutWebhook.AsWebhookStorage())
+
+	rateLimitDisabledCfg := config.OnmemoryStorageConfig{
+		DisableRateLimit: true,
+	}
+	rateLimitDisabled, err := onmemory.NewOnmemoryStorage(ctx, &rateLimitDisabledCfg, domain.RealSystemClock, StubChannelProvider, EmptyDeps(t))
+	assert.NoError(t, err)
+	multiWithoutRateLimit, err := NewStorageMultiplexer(map[domain.StorageID]domain.Storage{
+		"test1": rateLimitDisabled,
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, multiWithoutRateLimit.AsPubSubStorage())
+	assert.Nil(t, multiWithoutRateLimit.AsRateLimitStorage())
 }
 
 func TestGetFileDescriptorPressure(t *testing.T) {
@@ -253,10 +284,14 @@ func TestGetFileDescriptorPressure(t *testing.T) {
 	mock1.EXPECT().GetFileDescriptorPressure().Return(21)
 	mock1.EXPECT().AsPubSubStorage().Return(nil).AnyTimes()
 	mock1.EXPECT().AsJwtStorage().Return(nil).AnyTimes()
+	mock1.EXPECT().AsWebhookStorage().Return(nil).AnyTimes()
+	mock1.EXPECT().AsRateLimitStorage().Return(nil).AnyTimes()
 	mock2 := NewMockStorage(ctrl)
 	mock2.EXPECT().GetFileDescriptorPressure().Return(300)
 	mock2.EXPECT().AsPubSubStorage().Return(nil).AnyTimes()
 	mock2.EXPECT().AsJwtStorage().Return(nil).AnyTimes()
+	mock2.EXPECT().AsWebhookStorage().Return(nil).AnyTimes()
+	mock2.EXPECT().AsRateLimitStorage().Return(nil).AnyTimes()
 
 	s, err := NewStorageMultiplexer(map[domain.StorageID]domain.Storage{
 		"mock1": mock1,
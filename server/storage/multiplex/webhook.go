@@ -0,0 +1,114 @@
+package multiplex
+
+import (
+	"context"
+	"sort"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+// Note: Deliveries are stored to all children for redundancy, same as PublishMessages.
+// Thus the same delivery could be returned from multiple children, but it is acceptable because webhook delivery is at-least-once.
+
+func (s *storageMultiplexer) EnqueueWebhookDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	_, err := s.parallelAtLeastOneSuccess(ctx, "EnqueueWebhookDeliveries", func(ctx context.Context, _ domain.StorageID, child domain.Storage) (interface{}, error) {
+		if child := child.AsWebhookStorage(); child != nil {
+			return nil, child.EnqueueWebhookDeliveries(ctx, deliveries)
+		}
+		return nil, errMultiplexSkipped
+	})
+	return err
+}
+
+func (s *storageMultiplexer) LeaseWebhookDeliveries(ctx context.Context, max int, lease domain.Duration) ([]domain.WebhookDelivery, error) {
+	results, err := s.parallelAtLeastOneSuccess(ctx, "LeaseWebhookDeliveries", func(ctx context.Context, _ domain.StorageID, child domain.Storage) (interface{}, error) {
+		if child := child.AsWebhookStorage(); child != nil {
+			return child.LeaseWebhookDeliveries(ctx, max, lease)
+		}
+		return nil, errMultiplexSkipped
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Do not truncate to max, otherwise truncated deliveries are left leased without any attempt.
+	deliveries := make([]domain.WebhookDelivery, 0, max)
+	found := make(map[domain.MessageLocator]bool, max)
+	for _, result := range results {
+		for _, delivery := range result.([]domain.WebhookDelivery) {
+			if found[delivery.MessageLocator] {
+				continue
+			}
+			found[delivery.MessageLocator] = true
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (s *storageMultiplexer) CompleteWebhookDelivery(ctx context.Context, msg domain.MessageLocator) error {
+	_, err := s.parallelAtLeastOneSuccess(ctx, "CompleteWebhookDelivery", func(ctx context.Context, _ domain.StorageID, child domain.Storage) (interface{}, error) {
+		if child := child.AsWebhookStorage(); child != nil {
+			return nil, child.CompleteWebhookDelivery(ctx, msg)
+		}
+		return nil, errMultiplexSkipped
+	})
+	return err
+}
+
+func (s *storageMultiplexer) RetryWebhookDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	_, err := s.parallelAtLeastOneSuccess(ctx, "RetryWebhookDelivery", func(ctx context.Context, _ domain.StorageID, child domain.Storage) (interface{}, error) {
+		if child := child.AsWebhookStorage(); child != nil {
+			return nil, child.RetryWebhookDelivery(ctx, delivery)
+		}
+		return nil, errMultiplexSkipped
+	})
+	return err
+}
+
+func (s *storageMultiplexer) RecordWebhookDelivery(ctx context.Context, record domain.WebhookDeliveryRecord, maxRecords int, retention domain.Duration) error {
+	_, err := s.parallelAtLeastOneSuccess(ctx, "RecordWebhookDelivery", func(ctx context.Context, _ domain.StorageID, child domain.Storage) (interface{}, error) {
+		if child := child.AsWebhookStorage(); child != nil {
+			return nil, child.RecordWebhookDelivery(ctx, record, maxRecords, retention)
+		}
+		return nil, errMultiplexSkipped
+	})
+	return err
+}
+
+func (s *storageMultiplexer) ListWebhookDeliveryRecords(ctx context.Context, channelID domain.ChannelID, max int) ([]domain.WebhookDeliveryRecord, error) {
+	results, err := s.parallelAtLeastOneSuccess(ctx, "ListWebhookDeliveryRecords", func(ctx context.Context, _ domain.StorageID, child domain.Storage) (interface{}, error) {
+		if child := child.AsWebhookStorage(); child != nil {
+			return child.ListWebhookDeliveryRecords(ctx, channelID, max)
+		}
+		return nil, errMultiplexSkipped
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	type recordKey struct {
+		msg         domain.MessageLocator
+		attempt     int
+		attemptedAt int64
+	}
+	records := make([]domain.WebhookDeliveryRecord, 0, max)
+	found := make(map[recordKey]bool, max)
+	for _, result := range results {
+		for _, record := range result.([]domain.WebhookDeliveryRecord) {
+			key := recordKey{msg: record.MessageLocator, attempt: record.Attempt, attemptedAt: record.AttemptedAt.UnixNano()}
+			if found[key] {
+				continue
+			}
+			found[key] = true
+			records = append(records, record)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].AttemptedAt.After(records[j].AttemptedAt.Time)
+	})
+	if len(records) > max {
+		records = records[:max]
+	}
+	return records, nil
+}
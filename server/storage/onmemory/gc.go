@@ -31,7 +31,8 @@ func (s *onmemoryStorage) GC(ctx context.Context) error {
 		if err := ctx.Err(); err != nil {
 			return err // Context canceled
 		}
-		expireBefore := s.systemClock.Now().Add(-ch.Expire().Duration)
+		now := s.systemClock.Now()
+		expireBefore := now.Add(-ch.Expire().Duration)
 
 		for sid, sbsc := range ch.subscribers {
 			if err := ctx.Err(); err != nil {
@@ -47,13 +48,25 @@ func (s *onmemoryStorage) GC(ctx context.Context) error {
 			// Remove expired messages from subscriber queue.
 			aliveMsgs := make([]*onmemoryMessage, 0, len(sbsc.messages))
 			for _, msg := range sbsc.messages {
-				if !msg.ExpireAt.Before(expireBefore) {
+				if !msg.ExpireAt.Before(expireBefore) && !msg.Message.IsExpired(now) {
 					aliveMsgs = append(aliveMsgs, msg)
 				}
 			}
 			sbsc.messages = aliveMsgs
 		}
 
+		// Remove scheduled messages expired before delivery.
+		aliveScheduled := make([]*onmemoryMessage, 0, len(ch.scheduled))
+		for _, msg := range ch.scheduled {
+			if !msg.Message.IsExpired(now) {
+				aliveScheduled = append(aliveScheduled, msg)
+			} else {
+				ch.scheduledBytes -= int64(len(msg.Content))
+			}
+		}
+		ch.scheduled = aliveScheduled
+		ch.pruneRetained(now)
+
 		// Remove expired message log.
 		for msgLoc, msg := range ch.log {
 			if err := ctx.Err(); err != nil {
@@ -76,5 +89,27 @@ func (s *onmemoryStorage) GC(ctx context.Context) error {
 			delete(s.revokedJwts, jti)
 		}
 	}
+
+	// Delete expired webhook delivery histories
+	for channelID, history := range s.webhookHistories {
+		if err := ctx.Err(); err != nil {
+			return err // Context canceled
+		}
+
+		if history.expireAt.Before(s.systemClock.Now().Time) {
+			delete(s.webhookHistories, channelID)
+		}
+	}
+
+	// Delete full (initial state) rate limit buckets
+	for key, tat := range s.rateLimitBuckets {
+		if err := ctx.Err(); err != nil {
+			return err // Context canceled
+		}
+
+		if tat.Before(s.systemClock.Now().Time) {
+			delete(s.rateLimitBuckets, key)
+		}
+	}
 	return nil
 }
@@ -3,6 +3,7 @@ package onmemory
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/saiya/dsps/server/config"
 	"github.com/saiya/dsps/server/domain"
@@ -19,8 +20,10 @@ func NewOnmemoryStorage(ctx context.Context, config *config.OnmemoryStorageConfi
 		systemClock:     systemClock,
 		channelProvider: channelProvider,
 
-		pubsubEnabled: !config.DisablePubSub,
-		jwtEnabled:    !config.DisableJwt,
+		pubsubEnabled:    !config.DisablePubSub,
+		jwtEnabled:       !config.DisableJwt,
+		webhookEnabled:   !config.DisableWebhook,
+		rateLimitEnabled: !config.DisableRateLimit,
 
 		runGcOnShutdown: config.RunGCOnShutdown,
 		daemonSystem: sync.NewDaemonSystem("dsps.storage.onmemory", sync.DaemonSystemDeps{
@@ -33,6 +36,11 @@ func NewOnmemoryStorage(ctx context.Context, config *config.OnmemoryStorageConfi
 		channels: map[domain.ChannelID]*onmemoryChannel{},
 
 		revokedJwts: map[domain.JwtJti]domain.JwtExp{},
+
+		webhookDeliveries: map[domain.MessageLocator]*onmemoryWebhookDelivery{},
+		webhookHistories:  map[domain.ChannelID]*onmemoryWebhookHistory{},
+
+		rateLimitBuckets: map[string]time.Time{},
 	}
 
 	s.startGC()
@@ -43,8 +51,10 @@ func NewOnmemoryStorage(ctx context.Context, config *config.OnmemoryStorageConfi
 type onmemoryStorage struct {
 	lock sync.Lock
 
-	pubsubEnabled bool
-	jwtEnabled    bool
+	pubsubEnabled    bool
+	jwtEnabled       bool
+	webhookEnabled   bool
+	rateLimitEnabled bool
 
 	systemClock     domain.SystemClock
 	channelProvider domain.ChannelProvider
@@ -55,6 +65,11 @@ type onmemoryStorage struct {
 	channels map[domain.ChannelID]*onmemoryChannel
 
 	revokedJwts map[domain.JwtJti]domain.JwtExp
+
+	webhookDeliveries map[domain.MessageLocator]*onmemoryWebhookDelivery
+	webhookHistories  map[domain.ChannelID]*onmemoryWebhookHistory
+
+	rateLimitBuckets map[string]time.Time // Theoretical arrival time of each bucket
 }
 
 func (s *onmemoryStorage) String() string {
@@ -81,6 +96,9 @@ func (s *onmemoryStorage) Shutdown(ctx context.Context) error {
 	defer unlock()
 
 	s.channels = map[domain.ChannelID]*onmemoryChannel{} // Drop all data
+	s.webhookDeliveries = map[domain.MessageLocator]*onmemoryWebhookDelivery{}
+	s.webhookHistories = map[domain.ChannelID]*onmemoryWebhookHistory{}
+	s.rateLimitBuckets = map[string]time.Time{}
 	return nil
 }
 
@@ -97,6 +115,20 @@ func (s *onmemoryStorage) AsJwtStorage() domain.JwtStorage {
 	return s
 }
 
+func (s *onmemoryStorage) AsWebhookStorage() domain.WebhookStorage {
+	if !s.webhookEnabled {
+		return nil
+	}
+	return s
+}
+
+func (s *onmemoryStorage) AsRateLimitStorage() domain.RateLimitStorage {
+	if !s.rateLimitEnabled {
+		return nil
+	}
+	return s
+}
+
 func (s *onmemoryStorage) GetFileDescriptorPressure() int {
 	return 0
 }
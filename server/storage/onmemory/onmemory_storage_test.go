@@ -33,22 +33,38 @@ func TestJwt(t *testing.T) {
 	JwtTest(t, storageCtor(t))
 }
 
+func TestWebhook(t *testing.T) {
+	WebhookTest(t, storageCtor(t))
+}
+
+func TestRateLimit(t *testing.T) {
+	RateLimitTest(t, storageCtor(t))
+}
+
 func TestFeatureFlags(t *testing.T) {
 	s, err := NewOnmemoryStorage(context.Background(), &config.OnmemoryStorageConfig{
-		DisablePubSub: true,
-		DisableJwt:    true,
+		DisablePubSub:    true,
+		DisableJwt:       true,
+		DisableWebhook:   true,
+		DisableRateLimit: true,
 	}, domain.RealSystemClock, StubChannelProvider, EmptyDeps(t))
 	assert.NoError(t, err)
 	assert.Nil(t, s.AsPubSubStorage())
 	assert.Nil(t, s.AsJwtStorage())
+	assert.Nil(t, s.AsWebhookStorage())
+	assert.Nil(t, s.AsRateLimitStorage())
 
 	s, err = NewOnmemoryStorage(context.Background(), &config.OnmemoryStorageConfig{
-		DisablePubSub: false,
-		DisableJwt:    false,
+		DisablePubSub:    false,
+		DisableJwt:       false,
+		DisableWebhook:   false,
+		DisableRateLimit: false,
 	}, domain.RealSystemClock, StubChannelProvider, EmptyDeps(t))
 	assert.NoError(t, err)
 	assert.Same(t, s, s.AsPubSubStorage())
 	assert.Same(t, s, s.AsJwtStorage())
+	assert.Same(t, s, s.AsWebhookStorage())
+	assert.Same(t, s, s.AsRateLimitStorage())
 }
 
 func TestGetFileDescriptorPressure(t *testing.T) {
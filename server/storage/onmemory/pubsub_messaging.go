@@ -3,6 +3,7 @@ package onmemory
 import (
 	"context"
 	"encoding/json"
+	"sort"
 	"sync/atomic"
 	"time"
 
@@ -44,27 +45,68 @@ func (s *onmemoryStorage) PublishMessages(ctx context.Context, msgs []domain.Mes
 			continue // Duplicated message
 		}
 
-		ch.channelClock = ch.channelClock + 1 // Must start with 1
+		now := s.systemClock.Now()
 		wrapped := onmemoryMessage{
-			channelClock: ch.channelClock,
-			ExpireAt:     domain.Time{Time: s.systemClock.Now().Add(ch.Expire().Duration)},
-			Message:      msg,
+			ExpireAt: domain.Time{Time: now.Add(ch.Expire().Duration)},
+			Message:  msg,
 		}
 		if err := wrapped.Validate(); err != nil {
 			return err
 		}
+		if err := ch.checkRetention(msg, now); err != nil {
+			return err
+		}
 		ch.log[msg.MessageLocator] = &wrapped
 
-		for _, sbsc := range ch.subscribers {
-			sbsc.addMessage(wrapped)
-			sbsc.lastActivity = s.systemClock.Now()
+		if msg.IsScheduled(now) {
+			ch.schedule(&wrapped)
+			ch.capScheduled()
+			continue
 		}
+		ch.deliver(&wrapped, now)
 	}
 	return nil
 }
 
+// schedule holds the message until its DeliverAt, keeps scheduled messages sorted by DeliverAt.
+func (ch *onmemoryChannel) schedule(msg *onmemoryMessage) {
+	i := sort.Search(len(ch.scheduled), func(i int) bool {
+		return msg.DeliverAt.Before(ch.scheduled[i].DeliverAt.Time)
+	})
+	ch.scheduled = append(ch.scheduled, nil)
+	copy(ch.scheduled[i+1:], ch.scheduled[i:])
+	ch.scheduled[i] = msg
+	ch.scheduledBytes += int64(len(msg.Content))
+}
+
+// releaseScheduledMessages delivers scheduled messages that reached to DeliverAt.
+func (ch *onmemoryChannel) releaseScheduledMessages(now domain.Time) {
+	released := 0
+	for _, msg := range ch.scheduled {
+		if msg.IsScheduled(now) {
+			break
+		}
+		ch.scheduledBytes -= int64(len(msg.Content))
+		if !msg.IsExpired(now) {
+			ch.deliver(msg, now)
+		}
+		released++
+	}
+	ch.scheduled = ch.scheduled[released:]
+}
+
+func (ch *onmemoryChannel) deliver(msg *onmemoryMessage, now domain.Time) {
+	ch.channelClock = ch.channelClock + 1 // Must start with 1
+	msg.channelClock = ch.channelClock
+	for _, sbsc := range ch.subscribers {
+		sbsc.addMessage(*msg)
+		sbsc.lastActivity = now
+	}
+	ch.retain(msg, now)
+}
+
 func (s *onmemoryStorage) FetchMessages(ctx context.Context, sl domain.SubscriberLocator, max int, waituntil domain.Duration) (messages []domain.Message, moreMessages bool, ackHandle domain.AckHandle, err error) {
-	sbsc, err := s.findSubscriberForFetchMessages(ctx, sl)
+	ch, sbsc, err := s.findSubscriberForFetchMessages(ctx, sl)
 	if err != nil {
 		return []domain.Message{}, false, domain.AckHandle{}, err
 	}
@@ -93,9 +135,14 @@ func (s *onmemoryStorage) FetchMessages(ctx context.Context, sl domain.Subscribe
 				}
 				defer unlock()
 
-				sbsc.lastActivity = s.systemClock.Now()
+				now := s.systemClock.Now()
+				sbsc.lastActivity = now
+				ch.releaseScheduledMessages(now)
 				// Fetch messages as possible
 				for _, msg := range sbsc.messages {
+					if msg.IsExpired(now) {
+						continue
+					}
 					select {
 					case received <- msg.Message: // Receive message
 						found = true
@@ -207,7 +254,7 @@ func (s *onmemoryStorage) IsOldMessages(ctx context.Context, sl domain.Subscribe
 	result := map[domain.MessageLocator]bool{}
 	for _, msg := range msgs {
 		wrapped := ch.log[msg]
-		if wrapped != nil && wrapped.channelClock <= sbsc.channelClock {
+		if wrapped != nil && wrapped.channelClock != 0 && wrapped.channelClock <= sbsc.channelClock { // channelClock is 0 until scheduled message delivered
 			result[msg] = true
 		} else {
 			result[msg] = false
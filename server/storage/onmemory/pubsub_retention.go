@@ -0,0 +1,94 @@
+package onmemory
+
+import (
+	"golang.org/x/xerrors"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+// checkRetention returns error if the channel rejects new message because of the retention limits.
+// Scheduled messages are counted as well, so that they never exceed the limits when delivered.
+func (ch *onmemoryChannel) checkRetention(msg domain.Message, now domain.Time) error {
+	retention := ch.Retention()
+	if !retention.IsLimited() || !retention.RejectOverflow {
+		return nil
+	}
+	ch.pruneRetained(now)
+	if retention.MaxMessages > 0 && len(ch.retained)+len(ch.scheduled)+1 > retention.MaxMessages {
+		return xerrors.Errorf("%w: channel retains %d messages (%d scheduled)", domain.ErrChannelMaxMessagesExceeded, len(ch.retained)+len(ch.scheduled), len(ch.scheduled))
+	}
+	if retention.MaxBytes > 0 && ch.retainedBytes+ch.scheduledBytes+int64(len(msg.Content)) > retention.MaxBytes {
+		return xerrors.Errorf("%w: channel retains %d bytes (%d bytes scheduled)", domain.ErrChannelMaxBytesExceeded, ch.retainedBytes+ch.scheduledBytes, ch.scheduledBytes)
+	}
+	return nil
+}
+
+// capScheduled drops scheduled messages that will be delivered earliest until scheduled messages fit the retention limits (unless RejectOverflow).
+// Because messages are delivered in DeliverAt order, they are the oldest messages after delivery.
+func (ch *onmemoryChannel) capScheduled() {
+	retention := ch.Retention()
+	if !retention.IsLimited() || retention.RejectOverflow {
+		return
+	}
+	for len(ch.scheduled) > 1 && ((retention.MaxMessages > 0 && len(ch.scheduled) > retention.MaxMessages) || (retention.MaxBytes > 0 && ch.scheduledBytes > retention.MaxBytes)) {
+		earliest := ch.scheduled[0]
+		ch.scheduledBytes -= int64(len(earliest.Content))
+		ch.log[earliest.MessageLocator] = &onmemoryMessage{ // Keep message locator for deduplication
+			Message:  domain.Message{MessageLocator: earliest.MessageLocator},
+			ExpireAt: earliest.ExpireAt,
+		}
+		ch.scheduled[0] = nil
+		ch.scheduled = ch.scheduled[1:]
+	}
+}
+
+// retain tracks delivered message to enforce the retention limits, drops oldest messages if exceeded (unless RejectOverflow).
+func (ch *onmemoryChannel) retain(msg *onmemoryMessage, now domain.Time) {
+	retention := ch.Retention()
+	if !retention.IsLimited() {
+		return
+	}
+	ch.pruneRetained(now)
+	ch.retained = append(ch.retained, msg)
+	ch.retainedBytes += int64(len(msg.Content))
+	if retention.RejectOverflow {
+		return // Already checked by checkRetention, including scheduled messages.
+	}
+	for len(ch.retained) > 1 && ((retention.MaxMessages > 0 && len(ch.retained) > retention.MaxMessages) || (retention.MaxBytes > 0 && ch.retainedBytes > retention.MaxBytes)) {
+		ch.drop(ch.retained[0])
+		ch.retained[0] = nil
+		ch.retained = ch.retained[1:]
+	}
+}
+
+// pruneRetained forgets expired messages from the retention tracking.
+// Note that it checks only oldest messages, message that has shorter expiration than preceding messages remains until them expire.
+func (ch *onmemoryChannel) pruneRetained(now domain.Time) {
+	for len(ch.retained) > 0 {
+		oldest := ch.retained[0]
+		if now.Before(oldest.ExpireAt.Time) && !oldest.Message.IsExpired(now) {
+			return
+		}
+		ch.retainedBytes -= int64(len(oldest.Content))
+		ch.retained[0] = nil
+		ch.retained = ch.retained[1:]
+	}
+}
+
+// drop removes the message from subscribers, but keeps message locator in the log for deduplication.
+func (ch *onmemoryChannel) drop(msg *onmemoryMessage) {
+	ch.retainedBytes -= int64(len(msg.Content))
+	for _, sbsc := range ch.subscribers {
+		for i, queued := range sbsc.messages {
+			if queued.MessageLocator == msg.MessageLocator {
+				sbsc.messages = append(sbsc.messages[:i], sbsc.messages[i+1:]...)
+				break
+			}
+		}
+	}
+	ch.log[msg.MessageLocator] = &onmemoryMessage{
+		Message:      domain.Message{MessageLocator: msg.MessageLocator},
+		channelClock: msg.channelClock,
+		ExpireAt:     msg.ExpireAt,
+	}
+}
@@ -15,6 +15,12 @@ type onmemoryChannel struct {
 
 	subscribers map[domain.SubscriberID]*onmemorySubscriber
 	log         map[domain.MessageLocator]*onmemoryMessage
+	scheduled   []*onmemoryMessage // Messages not delivered yet, sorted by DeliverAt
+
+	scheduledBytes int64 // Total content size of scheduled messages
+
+	retained      []*onmemoryMessage // Delivered messages in delivery order, only if Retention() is limited
+	retainedBytes int64              // Total content size of retained messages
 }
 
 type onmemorySubscriber struct {
@@ -90,26 +96,26 @@ func (s *onmemoryStorage) getChannel(id domain.ChannelID) (*onmemoryChannel, err
 }
 
 // Note: this method holds lock of the storage!!
-func (s *onmemoryStorage) findSubscriberForFetchMessages(ctx context.Context, sl domain.SubscriberLocator) (*onmemorySubscriber, error) {
+func (s *onmemoryStorage) findSubscriberForFetchMessages(ctx context.Context, sl domain.SubscriberLocator) (*onmemoryChannel, *onmemorySubscriber, error) {
 	// This method is called from FetchMessages function.
 	// It does not want to lock storage during polling.
 	// So that lock storage in this method instead of the FetchMessages.
 	unlock, err := s.lock.Lock(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer unlock()
 
 	ch, err := s.getChannel(sl.ChannelID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	sbsc := ch.subscribers[sl.SubscriberID]
 	if sbsc == nil {
-		return nil, xerrors.Errorf("%w", domain.ErrSubscriptionNotFound)
+		return nil, nil, xerrors.Errorf("%w", domain.ErrSubscriptionNotFound)
 	}
-	return sbsc, nil
+	return ch, sbsc, nil
 }
 
 func (sbsc *onmemorySubscriber) addMessage(msg onmemoryMessage) {
@@ -0,0 +1,31 @@
+package onmemory
+
+import (
+	"context"
+	"time"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+// TakeRateLimitToken implements token bucket with GCRA (generic cell rate algorithm).
+// Each bucket holds only "theoretical arrival time", the time when the bucket becomes full.
+func (s *onmemoryStorage) TakeRateLimitToken(ctx context.Context, key string, limit domain.RateLimit) (domain.Duration, error) {
+	unlock, err := s.lock.Lock(ctx)
+	if err != nil {
+		return domain.Duration{}, err
+	}
+	defer unlock()
+
+	now := s.systemClock.Now().Time
+	tat := s.rateLimitBuckets[key]
+	if tat.Before(now) {
+		tat = now
+	}
+	interval := limit.Interval()
+	nextTat := tat.Add(interval)
+	if wait := nextTat.Sub(now) - interval*time.Duration(limit.Burst); wait > 0 {
+		return domain.Duration{Duration: wait}, nil
+	}
+	s.rateLimitBuckets[key] = nextTat
+	return domain.Duration{}, nil
+}
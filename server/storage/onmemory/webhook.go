@@ -0,0 +1,138 @@
+package onmemory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+type onmemoryWebhookDelivery struct {
+	domain.WebhookDelivery
+
+	// Hidden from LeaseWebhookDeliveries until this time.
+	// Same as NextAttemptAt unless leased.
+	visibleAt domain.Time
+}
+
+func (s *onmemoryStorage) EnqueueWebhookDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	unlock, err := s.lock.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, delivery := range deliveries {
+		s.webhookDeliveries[delivery.MessageLocator] = &onmemoryWebhookDelivery{
+			WebhookDelivery: delivery,
+			visibleAt:       delivery.NextAttemptAt,
+		}
+	}
+	return nil
+}
+
+func (s *onmemoryStorage) LeaseWebhookDeliveries(ctx context.Context, max int, lease domain.Duration) ([]domain.WebhookDelivery, error) {
+	unlock, err := s.lock.Lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	now := s.systemClock.Now()
+	found := make([]*onmemoryWebhookDelivery, 0, max)
+	for _, delivery := range s.webhookDeliveries {
+		if !delivery.visibleAt.After(now.Time) {
+			found = append(found, delivery)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].visibleAt.Before(found[j].visibleAt.Time)
+	})
+	if len(found) > max {
+		found = found[:max]
+	}
+
+	result := make([]domain.WebhookDelivery, 0, len(found))
+	for _, delivery := range found {
+		delivery.visibleAt = domain.Time{Time: now.Add(lease.Duration)}
+		result = append(result, delivery.WebhookDelivery)
+	}
+	return result, nil
+}
+
+func (s *onmemoryStorage) CompleteWebhookDelivery(ctx context.Context, msg domain.MessageLocator) error {
+	unlock, err := s.lock.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	delete(s.webhookDeliveries, msg)
+	return nil
+}
+
+func (s *onmemoryStorage) RetryWebhookDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	unlock, err := s.lock.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, found := s.webhookDeliveries[delivery.MessageLocator]; !found {
+		return nil
+	}
+	s.webhookDeliveries[delivery.MessageLocator] = &onmemoryWebhookDelivery{
+		WebhookDelivery: delivery,
+		visibleAt:       delivery.NextAttemptAt,
+	}
+	return nil
+}
+
+type onmemoryWebhookHistory struct {
+	records  []domain.WebhookDeliveryRecord // Latest first
+	expireAt domain.Time
+}
+
+func (s *onmemoryStorage) RecordWebhookDelivery(ctx context.Context, record domain.WebhookDeliveryRecord, maxRecords int, retention domain.Duration) error {
+	unlock, err := s.lock.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	now := s.systemClock.Now()
+	history := s.webhookHistories[record.ChannelID]
+	if history == nil || history.expireAt.Before(now.Time) {
+		history = &onmemoryWebhookHistory{}
+		s.webhookHistories[record.ChannelID] = history
+	}
+	records := make([]domain.WebhookDeliveryRecord, 0, len(history.records)+1)
+	records = append(records, record)
+	records = append(records, history.records...)
+	if len(records) > maxRecords {
+		records = records[:maxRecords]
+	}
+	history.records = records
+	history.expireAt = domain.Time{Time: now.Add(retention.Duration)}
+	return nil
+}
+
+func (s *onmemoryStorage) ListWebhookDeliveryRecords(ctx context.Context, channelID domain.ChannelID, max int) ([]domain.WebhookDeliveryRecord, error) {
+	unlock, err := s.lock.Lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	history := s.webhookHistories[channelID]
+	if history == nil || history.expireAt.Before(s.systemClock.Now().Time) {
+		return []domain.WebhookDeliveryRecord{}, nil
+	}
+	records := history.records
+	if len(records) > max {
+		records = records[:max]
+	}
+	result := make([]domain.WebhookDeliveryRecord, len(records))
+	copy(result, records)
+	return result, nil
+}
@@ -312,6 +312,7 @@ func (d *dispatcher) repairWorker(ctx context.Context) error {
 
 	if oldWorker != nil {
 		oldWorker.ShutdownCorrupted(ctx)
+		d.telemetry.CountRedisPubSubReconnect(ctx)
 	}
 	return nil
 }
@@ -11,13 +11,73 @@ import (
 type messageEnvelope struct {
 	ID      domain.MessageID `json:"id"`
 	Content json.RawMessage  `json:"content"`
+
+	// Omitted if JSON content (also for backward compatibility)
+	ContentType string `json:"contentType,omitempty"`
+
+	// Omitted if no metadata (also for backward compatibility)
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// W3C Trace Context of the publisher, omitted if not traced (also for backward compatibility)
+	TraceParent string `json:"traceparent,omitempty"`
+	TraceState  string `json:"tracestate,omitempty"`
+
+	// UNIX epoch milliseconds, omitted if not specified (also for backward compatibility)
+	ExpireAt  int64 `json:"expireAt,omitempty"`
+	DeliverAt int64 `json:"deliverAt,omitempty"`
 }
 
-func wrapMessage(msg domain.Message) (string, error) {
-	data, err := json.Marshal(messageEnvelope{
+func newMessageEnvelope(msg domain.Message) messageEnvelope {
+	return messageEnvelope{
 		ID:      msg.MessageID,
 		Content: msg.Content,
-	})
+
+		ContentType: msg.ContentType,
+
+		Metadata: msg.Metadata,
+
+		TraceParent: msg.TraceContext.TraceParent,
+		TraceState:  msg.TraceContext.TraceState,
+
+		ExpireAt:  toOptionalUnixMilli(msg.ExpireAt),
+		DeliverAt: toOptionalUnixMilli(msg.DeliverAt),
+	}
+}
+
+func (envelope messageEnvelope) toMessage(ch domain.ChannelID) domain.Message {
+	return domain.Message{
+		MessageLocator: domain.MessageLocator{
+			ChannelID: ch,
+			MessageID: envelope.ID,
+		},
+		Content:     envelope.Content,
+		ContentType: envelope.ContentType,
+		Metadata:    envelope.Metadata,
+		TraceContext: domain.TraceContext{
+			TraceParent: envelope.TraceParent,
+			TraceState:  envelope.TraceState,
+		},
+		ExpireAt:  fromOptionalUnixMilli(envelope.ExpireAt),
+		DeliverAt: fromOptionalUnixMilli(envelope.DeliverAt),
+	}
+}
+
+func toOptionalUnixMilli(t domain.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return toUnixMilli(t.Time)
+}
+
+func fromOptionalUnixMilli(ms int64) domain.Time {
+	if ms == 0 {
+		return domain.Time{}
+	}
+	return domain.Time{Time: fromUnixMilli(ms)}
+}
+
+func wrapMessage(msg domain.Message) (string, error) {
+	data, err := json.Marshal(newMessageEnvelope(msg))
 	if err != nil {
 		return "", xerrors.Errorf(`%w: %v`, domain.ErrMalformedMessageJSON, err)
 	}
@@ -29,11 +89,6 @@ func unwrapMessage(ch domain.ChannelID, raw string) (*domain.Message, error) {
 	if err := json.Unmarshal([]byte(raw), &envelope); err != nil {
 		return nil, xerrors.Errorf(`Failed to parse message envelope JSON '%s': %w`, string(raw), err)
 	}
-	return &domain.Message{
-		MessageLocator: domain.MessageLocator{
-			ChannelID: ch,
-			MessageID: envelope.ID,
-		},
-		Content: envelope.Content,
-	}, nil
+	msg := envelope.toMessage(ch)
+	return &msg, nil
 }
@@ -1,9 +1,12 @@
 package redis
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/domain"
 )
 
 func TestCorruptedMessageEnvelope(t *testing.T) {
@@ -11,3 +14,60 @@ func TestCorruptedMessageEnvelope(t *testing.T) {
 	_, err := unwrapMessage("ch-1", raw)
 	assert.Contains(t, err.Error(), "Failed to parse message envelope JSON")
 }
+
+func TestMessageEnvelopeAttributes(t *testing.T) {
+	msg := domain.Message{
+		MessageLocator: domain.MessageLocator{ChannelID: "ch-1", MessageID: "msg-1"},
+		Content:        json.RawMessage(`{"hi":"hello"}`),
+		Metadata:       map[string]string{"content-type": "application/json"},
+		TraceContext: domain.TraceContext{
+			TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			TraceState:  "congo=t61rcWkgMzE",
+		},
+	}
+	raw, err := wrapMessage(msg)
+	assert.NoError(t, err)
+	unwrapped, err := unwrapMessage("ch-1", raw)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, *unwrapped)
+
+	binary := domain.Message{
+		MessageLocator: msg.MessageLocator,
+		Content:        domain.NewBinaryContent([]byte{0x08, 0x96, 0x01}),
+		ContentType:    "application/x-protobuf",
+	}
+	raw, err = wrapMessage(binary)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":"msg-1","content":"CJYB","contentType":"application/x-protobuf"}`, raw)
+	unwrapped, err = unwrapMessage("ch-1", raw)
+	assert.NoError(t, err)
+	assert.Equal(t, binary, *unwrapped)
+
+	scheduled := domain.Message{
+		MessageLocator: msg.MessageLocator,
+		Content:        msg.Content,
+		ExpireAt:       domain.Time{Time: fromUnixMilli(1605633588123)},
+		DeliverAt:      domain.Time{Time: fromUnixMilli(1605633000456)},
+	}
+	raw, err = wrapMessage(scheduled)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":"msg-1","content":{"hi":"hello"},"expireAt":1605633588123,"deliverAt":1605633000456}`, raw)
+	unwrapped, err = unwrapMessage("ch-1", raw)
+	assert.NoError(t, err)
+	assert.True(t, scheduled.ExpireAt.Equal(unwrapped.ExpireAt.Time))
+	assert.True(t, scheduled.DeliverAt.Equal(unwrapped.DeliverAt.Time))
+
+	// Envelope written by older version does not have content type, metadata nor trace context
+	unwrapped, err = unwrapMessage("ch-1", `{"id":"msg-1","content":{"hi":"hello"}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "", unwrapped.ContentType)
+	assert.Nil(t, unwrapped.Metadata)
+	assert.Equal(t, domain.TraceContext{}, unwrapped.TraceContext)
+	assert.True(t, unwrapped.ExpireAt.IsZero())
+	assert.True(t, unwrapped.DeliverAt.IsZero())
+
+	// Should omit JSON content type, empty metadata and trace context
+	raw, err = wrapMessage(domain.Message{MessageLocator: msg.MessageLocator, Content: msg.Content})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":"msg-1","content":{"hi":"hello"}}`, raw)
+}
@@ -28,12 +28,24 @@ func (s *redisStorage) PublishMessages(ctx context.Context, msgs []domain.Messag
 			}
 		}
 	}()
+	now := s.clock.Now()
 	for _, msg := range msgs {
 		ttl, err := s.channelRedisTTLSec(msg.ChannelID)
 		if err != nil {
 			return xerrors.Errorf("Unable to calcurate TTL of channel: %w", err)
 		}
-		if err := runPublishMessageScript(ctx, s.RedisCmd, ttl, msg); err != nil {
+		retention, err := s.channelRetention(msg.ChannelID)
+		if err != nil {
+			return err
+		}
+		if msg.IsScheduled(now) {
+			// Subscribers receive it after releaseScheduledMessages
+			if err := runScheduleMessageScript(ctx, s.RedisCmd, ttl, retention, msg); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := runPublishMessageScript(ctx, s.RedisCmd, ttl, retention, msg, now); err != nil {
 			return err
 		}
 		sentMsgs++
@@ -53,23 +65,42 @@ func (s *redisStorage) FetchMessages(ctx context.Context, sl domain.SubscriberLo
 		await, awaitCancel = s.pubsubDispatcher.Await(ctx, s.redisPubSubKeyOf(sl.ChannelID))
 	}
 
+	var nextScheduled *time.Time
+	if nextScheduled, err = s.releaseScheduledMessages(ctx, sl.ChannelID); err != nil {
+		return
+	}
 	if messages, moreMessages, ackHandle, err = s.fetchMessagesNow(ctx, sl, max); err != nil || len(messages) > 0 {
 		return
 	}
 
 	timeout := time.NewTimer(waituntil.Duration)
 	defer timeout.Stop()
+	scheduled := time.NewTimer(0)
+	defer scheduled.Stop()
 	for {
 		var c chan interface{}
 		if await != nil {
 			c = await.Chan()
 		}
+		var scheduledC <-chan time.Time
+		if nextScheduled != nil {
+			resetTimer(scheduled, nextScheduled.Sub(s.clock.Now().Time))
+			scheduledC = scheduled.C
+		}
 		select {
 		case <-timeout.C:
 			return
 		case <-ctx.Done():
 			err = ctx.Err()
 			return
+		case <-scheduledC:
+			// Wake up to deliver scheduled message
+			if nextScheduled, err = s.releaseScheduledMessages(ctx, sl.ChannelID); err != nil {
+				return
+			}
+			if messages, moreMessages, ackHandle, err = s.fetchMessagesNow(ctx, sl, max); err != nil || len(messages) > 0 {
+				return
+			}
 		case <-c:
 			if await != nil && await.Err() != nil {
 				err = await.Err()
@@ -78,12 +109,49 @@ func (s *redisStorage) FetchMessages(ctx context.Context, sl domain.SubscriberLo
 			if messages, moreMessages, ackHandle, err = s.fetchMessagesNow(ctx, sl, max); err != nil || len(messages) > 0 {
 				return
 			}
+			if nextScheduled, err = s.releaseScheduledMessages(ctx, sl.ChannelID); err != nil {
+				return
+			}
 			// Await again because no messages found (spurious wakeup)
 			await, awaitCancel = s.pubsubDispatcher.Await(ctx, s.redisPubSubKeyOf(sl.ChannelID))
 		}
 	}
 }
 
+// releaseScheduledMessages delivers due scheduled messages of the channel, returns time of the next scheduled message (nil if none).
+func (s *redisStorage) releaseScheduledMessages(ctx context.Context, channelID domain.ChannelID) (*time.Time, error) {
+	ttl, err := s.channelRedisTTLSec(channelID)
+	if err != nil {
+		return nil, xerrors.Errorf("Unable to calcurate TTL of channel: %w", err)
+	}
+	retention, err := s.channelRetention(channelID)
+	if err != nil {
+		return nil, err
+	}
+	released, nextAt, err := runReleaseScheduledMessagesScript(ctx, s.RedisCmd, channelID, ttl, retention, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if released > 0 {
+		// Wake up other subscribers awaiting the channel
+		if err := s.RedisCmd.Publish(ctx, s.redisPubSubKeyOf(channelID), "new message"); err != nil {
+			logger.Of(ctx).WarnError(logger.CatStorage, "Redis Pub/Sub publish failed. Subscribers could not receive messages immediately.", err)
+		}
+	}
+	return nextAt, nil
+}
+
+// resetTimer safely resets fired or unfired timer
+func resetTimer(timer *time.Timer, d time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(d)
+}
+
 func (s *redisStorage) fetchMessagesNow(ctx context.Context, sl domain.SubscriberLocator, max int) (messages []domain.Message, moreMessages bool, ackHandle domain.AckHandle, err error) {
 	keys := keyOfChannel(sl.ChannelID)
 	clocks, err := s.RedisCmd.MGet(ctx, keys.Clock(), keys.SubscriberCursor(sl.SubscriberID))
@@ -121,6 +189,7 @@ func (s *redisStorage) fetchMessagesNow(ctx context.Context, sl domain.Subscribe
 		return
 	}
 
+	now := s.clock.Now()
 	var lastMessageClock *channelClock = nil
 	ackHandle = domain.AckHandle{}
 	messages = make([]domain.Message, 0, max)
@@ -136,6 +205,9 @@ func (s *redisStorage) fetchMessagesNow(ctx context.Context, sl domain.Subscribe
 			}
 			continue // may caused by message TTL expiration
 		}
+		if msg.IsExpired(now) {
+			continue // Message body could live a bit longer due to TTL precision of Redis
+		}
 		messages = append(messages, *msg)
 		if lastMessageClock == nil || *lastMessageClock < msgClocks[i] {
 			lastMessageClock = &msgClocks[i]
@@ -15,6 +15,23 @@ import (
 	dspstesting "github.com/saiya/dsps/server/testing"
 )
 
+func TestFetchMessagesReleaseScheduledMessagesError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := randomChannelID(t)
+	sl := domain.SubscriberLocator{ChannelID: ch, SubscriberID: "sbsc-1"}
+
+	s, redisCmd, _ := newMockedRedisStorageAndPubSubDispatcher(ctrl)
+
+	errToReturn := errors.New(`Mocked Redis error`)
+	redisCmd.EXPECT().RunScript(gomock.Any(), releaseScheduledMessagesScript, gomock.Any(), gomock.Any()).Return(nil, errToReturn)
+
+	_, _, _, err := s.FetchMessages(context.Background(), sl, 100, domain.Duration{Duration: 30 * time.Second})
+	dspstesting.IsError(t, errToReturn, err)
+	assert.Contains(t, err.Error(), "Failed to execute releaseScheduledMessagesScript")
+}
+
 func TestFetchMessagesFirstPollingClockGetError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -24,6 +41,7 @@ func TestFetchMessagesFirstPollingClockGetError(t *testing.T) {
 	sl := domain.SubscriberLocator{ChannelID: ch, SubscriberID: "sbsc-1"}
 
 	s, redisCmd, _ := newMockedRedisStorageAndPubSubDispatcher(ctrl)
+	expectNoScheduledMessages(redisCmd)
 
 	// (1st fetchMessagesNow) MGET clock cursor
 	errToReturn := errors.New(`Mocked Redis error`)
@@ -43,6 +61,7 @@ func TestFetchMessagesFirstPollingClockGetInvalidValues(t *testing.T) {
 	sl := domain.SubscriberLocator{ChannelID: ch, SubscriberID: "sbsc-1"}
 
 	s, redisCmd, _ := newMockedRedisStorageAndPubSubDispatcher(ctrl)
+	expectNoScheduledMessages(redisCmd)
 
 	// (1st fetchMessagesNow) MGET clock cursor
 	redisCmd.EXPECT().MGet(gomock.Any(), keys.Clock(), keys.SubscriberCursor(sl.SubscriberID)).Return(strPList(t, "INVALID", "INVALID"), nil)
@@ -60,6 +79,7 @@ func TestFetchMessagesFirstPollingGetMsgBodyError(t *testing.T) {
 	sl := domain.SubscriberLocator{ChannelID: ch, SubscriberID: "sbsc-1"}
 
 	s, redisCmd, _ := newMockedRedisStorageAndPubSubDispatcher(ctrl)
+	expectNoScheduledMessages(redisCmd)
 
 	// (1st fetchMessagesNow) MGET clock cursor
 	clocksMget := redisCmd.EXPECT().MGet(gomock.Any(), keys.Clock(), keys.SubscriberCursor(sl.SubscriberID)).Return(strPList(t, "12", "10"), nil)
@@ -83,6 +103,7 @@ func TestFetchMessagesFirstPollingCorruptedMsgBody(t *testing.T) {
 	sl := domain.SubscriberLocator{ChannelID: ch, SubscriberID: "sbsc-1"}
 
 	s, redisCmd, _ := newMockedRedisStorageAndPubSubDispatcher(ctrl)
+	expectNoScheduledMessages(redisCmd)
 
 	// (1st fetchMessagesNow) MGET clock cursor
 	clocksMget := redisCmd.EXPECT().MGet(gomock.Any(), keys.Clock(), keys.SubscriberCursor(sl.SubscriberID)).Return(strPList(t, "13", "10"), nil)
@@ -110,6 +131,7 @@ func TestFetchMessagesSecondPollingError(t *testing.T) {
 	sl := domain.SubscriberLocator{ChannelID: ch, SubscriberID: "sbsc-1"}
 
 	s, redisCmd, dispatcher := newMockedRedisStorageAndPubSubDispatcher(ctrl)
+	expectNoScheduledMessages(redisCmd)
 
 	// (1st fetchMessagesNow) MGET clock cursor
 	clocksMget1 := redisCmd.EXPECT().MGet(gomock.Any(), keys.Clock(), keys.SubscriberCursor(sl.SubscriberID)).Return(strPList(t, "10", "10"), nil)
@@ -138,6 +160,7 @@ func TestFetchMessagesSpuriousWakeup(t *testing.T) {
 	sl := domain.SubscriberLocator{ChannelID: ch, SubscriberID: "sbsc-1"}
 
 	s, redisCmd, dispatcher := newMockedRedisStorageAndPubSubDispatcher(ctrl)
+	expectNoScheduledMessages(redisCmd)
 
 	// (1st fetchMessagesNow) MGET clock cursor
 	clocksMget1 := redisCmd.EXPECT().MGet(gomock.Any(), keys.Clock(), keys.SubscriberCursor(sl.SubscriberID)).Return(strPList(t, "10", "10"), nil)
@@ -168,6 +191,7 @@ func TestFetchMessagesTTLExtensionError(t *testing.T) {
 	sl := domain.SubscriberLocator{ChannelID: ch, SubscriberID: "sbsc-1"}
 
 	s, redisCmd, dispatcher := newMockedRedisStorageAndPubSubDispatcher(ctrl)
+	expectNoScheduledMessages(redisCmd)
 
 	// (1st fetchMessagesNow) MGET clock cursor
 	clocksMget1 := redisCmd.EXPECT().MGet(gomock.Any(), keys.Clock(), keys.SubscriberCursor(sl.SubscriberID)).Return(strPList(t, "10", "10"), nil)
@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"golang.org/x/xerrors"
@@ -16,20 +17,50 @@ func (s *redisStorage) loadPubSubMessagingScripts(ctx context.Context) error {
 	if err := s.RedisCmd.LoadScript(ctx, publishMessageScript); err != nil {
 		return xerrors.Errorf("Failed to load publishMessageScript: %w", err)
 	}
+	if err := s.RedisCmd.LoadScript(ctx, scheduleMessageScript); err != nil {
+		return xerrors.Errorf("Failed to load scheduleMessageScript: %w", err)
+	}
+	if err := s.RedisCmd.LoadScript(ctx, releaseScheduledMessagesScript); err != nil {
+		return xerrors.Errorf("Failed to load releaseScheduledMessagesScript: %w", err)
+	}
 	if err := s.RedisCmd.LoadScript(ctx, ackScript); err != nil {
 		return xerrors.Errorf("Failed to load ackScript: %w", err)
 	}
 	return nil
 }
 
-var publishMessageScript = redis.NewScript(`
+var publishMessageScript = redis.NewScript(retentionLua + `
 	local clockKey = KEYS[1]	      -- Clock (c.{{channel}}.clock)
 	local msgBodyKeyPrefix = KEYS[2]  -- MessageBodyPrefix (c.{{channel}}.m.)
 	local msgDedupKey = KEYS[3]       -- MessageDedup (c.{{channel}}.mid.{messageID})
+	local retainedKey = KEYS[4]       -- RetainedMessages (c.{{channel}}.retention)
+	local retainedBytesKey = KEYS[5]  -- RetainedBytes (c.{{channel}}.retention.bytes)
+	local scheduledKey = KEYS[6]      -- ScheduledMessages (c.{{channel}}.scheduled)
+	local scheduledBytesKey = KEYS[7] -- ScheduledBytes (c.{{channel}}.scheduled.bytes)
 	local ttlSec = tonumber(ARGV[1])    -- (number) ttl [sec]
 	local content = ARGV[2]             -- (string) content
 	local clockMin = tonumber(ARGV[3])  -- (number) clockMin
 	local clockMax = tonumber(ARGV[4])  -- (number) clockMax
+	local bodyTTLMs = tonumber(ARGV[5]) -- (number) ttl of the message body [ms]
+	local size = tonumber(ARGV[6])        -- (number) content size of the message [bytes]
+	local maxMessages = tonumber(ARGV[7]) -- (number) maxMessages of the channel, 0 if unlimited
+	local maxBytes = tonumber(ARGV[8])    -- (number) maxBytes of the channel, 0 if unlimited
+	local reject = (ARGV[9] == "1")       -- (0 or 1) 1 to reject message rather than dropping oldest messages
+
+	-- Check retention limits
+	local limited = (maxMessages > 0 or maxBytes > 0)
+	if limited then
+		if redis.call("exists", msgDedupKey) == 1 then
+			return false  -- Duplicated message should not be rejected
+		end
+		pruneRetained(retainedKey, retainedBytesKey, msgBodyKeyPrefix)
+		if reject then
+			local rejected = checkRetention(retainedKey, retainedBytesKey, scheduledKey, scheduledBytesKey, size, maxMessages, maxBytes)
+			if rejected then
+				return rejected
+			end
+		end
+	end
 
 	-- Increment chanel clock
 	local nextClock = tonumber(redis.call("incr", clockKey))
@@ -47,11 +78,28 @@ var publishMessageScript = redis.NewScript(`
 		redis.call("expire", clockKey, ttlSec)
 		return false
 	end
-	redis.call("set", msgBodyKeyPrefix .. string.format("%d", nextClock), content, "EX", ttlSec)
+	redis.call("set", msgBodyKeyPrefix .. string.format("%d", nextClock), content, "PX", bodyTTLMs)
+	if limited then
+		retain(retainedKey, retainedBytesKey, msgBodyKeyPrefix, ttlSec, string.format("%d", nextClock), size, maxMessages, maxBytes, reject)
+	end
 	return redis.status_reply("OK")
 `)
 
-func runPublishMessageScript(ctx context.Context, redisCmd internal.RedisCmd, ttl channelTTLSec, msg domain.Message) error {
+// messageBodyTTLMs returns TTL of the message body, shorter than the channel TTL if the message has own expiration.
+func messageBodyTTLMs(ttl channelTTLSec, msg domain.Message, now domain.Time) int64 {
+	result := int64(ttl) * 1000
+	if !msg.ExpireAt.IsZero() {
+		if untilExpire := toUnixMilli(msg.ExpireAt.Time) - toUnixMilli(now.Time); untilExpire < result {
+			result = untilExpire
+		}
+	}
+	if result < 1 {
+		result = 1 // Redis does not accept zero or negative TTL, fetch logic skips expired messages anyway.
+	}
+	return result
+}
+
+func runPublishMessageScript(ctx context.Context, redisCmd internal.RedisCmd, ttl channelTTLSec, retention domain.ChannelRetention, msg domain.Message, now domain.Time) error {
 	wrapped, err := wrapMessage(msg)
 	if err != nil {
 		return xerrors.Errorf("Unable to encode message \"%s\": %w", msg.MessageID, err)
@@ -60,8 +108,8 @@ func runPublishMessageScript(ctx context.Context, redisCmd internal.RedisCmd, tt
 	keys := keyOfChannel(msg.ChannelID)
 	result, err := redisCmd.RunScript(
 		ctx, publishMessageScript,
-		[]string{keys.Clock(), keys.MessageBodyPrefix(), keys.MessageDedup(msg.MessageID)},
-		ttl, wrapped, clockMin, clockMax,
+		[]string{keys.Clock(), keys.MessageBodyPrefix(), keys.MessageDedup(msg.MessageID), keys.RetainedMessages(), keys.RetainedBytes(), keys.ScheduledMessages(), keys.ScheduledBytes()},
+		append([]interface{}{ttl, wrapped, clockMin, clockMax, messageBodyTTLMs(ttl, msg, now), len(msg.Content)}, retentionArgs(retention)...)...,
 	)
 	logger.Of(ctx).Debugf(logger.CatStorage, "runPublishMessageScript(ttl = %d, msg = %v) resulted in %v (%v)", ttl, msg, result, err)
 	if err != nil {
@@ -71,13 +119,189 @@ func runPublishMessageScript(ctx context.Context, redisCmd internal.RedisCmd, tt
 			return xerrors.Errorf("Failed to execute publishMessageScript: %w", err)
 		}
 	} else {
-		if result != "OK" {
+		switch result {
+		case "OK":
+		case "max-messages":
+			return xerrors.Errorf("%w (maxMessages: %d)", domain.ErrChannelMaxMessagesExceeded, retention.MaxMessages)
+		case "max-bytes":
+			return xerrors.Errorf("%w (maxBytes: %d)", domain.ErrChannelMaxBytesExceeded, retention.MaxBytes)
+		default:
 			return xerrors.Errorf("Unexpected result from publishMessageScript: %T(%v)", result, result)
 		}
 	}
 	return nil
 }
 
+var scheduleMessageScript = redis.NewScript(retentionLua + `
+	local msgDedupKey = KEYS[1]        -- MessageDedup (c.{{channel}}.mid.{messageID})
+	local scheduledKey = KEYS[2]       -- ScheduledMessages (c.{{channel}}.scheduled)
+	local scheduledBytesKey = KEYS[3]  -- ScheduledBytes (c.{{channel}}.scheduled.bytes)
+	local msgBodyKeyPrefix = KEYS[4]   -- MessageBodyPrefix (c.{{channel}}.m.)
+	local retainedKey = KEYS[5]        -- RetainedMessages (c.{{channel}}.retention)
+	local retainedBytesKey = KEYS[6]   -- RetainedBytes (c.{{channel}}.retention.bytes)
+	local ttlSec = tonumber(ARGV[1])       -- (number) ttl [sec]
+	local content = ARGV[2]                -- (string) content
+	local deliverAtMs = tonumber(ARGV[3])  -- (number) UNIX epoch milliseconds to deliver the message
+	local size = tonumber(ARGV[4])         -- (number) content size of the message [bytes]
+	local maxMessages = tonumber(ARGV[5])  -- (number) maxMessages of the channel, 0 if unlimited
+	local maxBytes = tonumber(ARGV[6])     -- (number) maxBytes of the channel, 0 if unlimited
+	local reject = (ARGV[7] == "1")        -- (0 or 1) 1 to reject message rather than dropping oldest messages
+
+	-- Check retention limits, scheduled messages are counted as if they have been delivered
+	local limited = (maxMessages > 0 or maxBytes > 0)
+	if limited and reject then
+		if redis.call("exists", msgDedupKey) == 1 then
+			return false  -- Duplicated message should not be rejected
+		end
+		pruneRetained(retainedKey, retainedBytesKey, msgBodyKeyPrefix)
+		local rejected = checkRetention(retainedKey, retainedBytesKey, scheduledKey, scheduledBytesKey, size, maxMessages, maxBytes)
+		if rejected then
+			return rejected
+		end
+	end
+
+	-- Mark as scheduled to dedupe messages, releaseScheduledMessagesScript overwrites it with the channel clock
+	if redis.call("set", msgDedupKey, "scheduled", "EX", ttlSec, "NX") == false then
+		return false
+	end
+	redis.call("zadd", scheduledKey, deliverAtMs, string.format("%d", size) .. ":" .. content)
+	redis.call("incrby", scheduledBytesKey, size)
+	if limited and not reject then
+		capScheduled(scheduledKey, scheduledBytesKey, maxMessages, maxBytes)
+	end
+	if redis.call("ttl", scheduledKey) < ttlSec then
+		redis.call("expire", scheduledKey, ttlSec)
+	end
+	if redis.call("ttl", scheduledBytesKey) < ttlSec then
+		redis.call("expire", scheduledBytesKey, ttlSec)
+	end
+	return redis.status_reply("OK")
+`)
+
+func runScheduleMessageScript(ctx context.Context, redisCmd internal.RedisCmd, ttl channelTTLSec, retention domain.ChannelRetention, msg domain.Message) error {
+	wrapped, err := wrapMessage(msg)
+	if err != nil {
+		return xerrors.Errorf("Unable to encode message \"%s\": %w", msg.MessageID, err)
+	}
+
+	keys := keyOfChannel(msg.ChannelID)
+	result, err := redisCmd.RunScript(
+		ctx, scheduleMessageScript,
+		[]string{keys.MessageDedup(msg.MessageID), keys.ScheduledMessages(), keys.ScheduledBytes(), keys.MessageBodyPrefix(), keys.RetainedMessages(), keys.RetainedBytes()},
+		append([]interface{}{ttl, wrapped, toUnixMilli(msg.DeliverAt.Time), len(msg.Content)}, retentionArgs(retention)...)...,
+	)
+	logger.Of(ctx).Debugf(logger.CatStorage, "runScheduleMessageScript(ttl = %d, msg = %v) resulted in %v (%v)", ttl, msg, result, err)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			logger.Of(ctx).Debugf(logger.CatStorage, "Duplicated message %s / %s", msg.ChannelID, msg.MessageID)
+		} else {
+			return xerrors.Errorf("Failed to execute scheduleMessageScript: %w", err)
+		}
+	} else {
+		switch result {
+		case "OK":
+		case "max-messages":
+			return xerrors.Errorf("%w (maxMessages: %d)", domain.ErrChannelMaxMessagesExceeded, retention.MaxMessages)
+		case "max-bytes":
+			return xerrors.Errorf("%w (maxBytes: %d)", domain.ErrChannelMaxBytesExceeded, retention.MaxBytes)
+		default:
+			return xerrors.Errorf("Unexpected result from scheduleMessageScript: %T(%v)", result, result)
+		}
+	}
+	return nil
+}
+
+// Maximum count of scheduled messages to release in a script call, remaining messages are released in subsequent calls.
+const maxReleaseScheduledMessages = 100
+
+var releaseScheduledMessagesScript = redis.NewScript(retentionLua + `
+	local clockKey = KEYS[1]           -- Clock (c.{{channel}}.clock)
+	local msgBodyKeyPrefix = KEYS[2]   -- MessageBodyPrefix (c.{{channel}}.m.)
+	local msgDedupKeyPrefix = KEYS[3]  -- MessageDedupPrefix (c.{{channel}}.mid.)
+	local scheduledKey = KEYS[4]       -- ScheduledMessages (c.{{channel}}.scheduled)
+	local retainedKey = KEYS[5]        -- RetainedMessages (c.{{channel}}.retention)
+	local retainedBytesKey = KEYS[6]   -- RetainedBytes (c.{{channel}}.retention.bytes)
+	local scheduledBytesKey = KEYS[7]  -- ScheduledBytes (c.{{channel}}.scheduled.bytes)
+	local ttlSec = tonumber(ARGV[1])      -- (number) ttl [sec]
+	local nowMs = tonumber(ARGV[2])       -- (number) current UNIX epoch milliseconds
+	local clockMin = tonumber(ARGV[3])    -- (number) clockMin
+	local clockMax = tonumber(ARGV[4])    -- (number) clockMax
+	local maxRelease = tonumber(ARGV[5])  -- (number) maximum count of messages to release
+	local maxMessages = tonumber(ARGV[6]) -- (number) maxMessages of the channel, 0 if unlimited
+	local maxBytes = tonumber(ARGV[7])    -- (number) maxBytes of the channel, 0 if unlimited
+	local reject = (ARGV[8] == "1")       -- (0 or 1) 1 if the channel rejects messages rather than dropping oldest messages
+	local limited = (maxMessages > 0 or maxBytes > 0)
+
+	local released = 0
+	local due = redis.call("zrangebyscore", scheduledKey, "-inf", nowMs, "LIMIT", 0, maxRelease)
+	for _, member in ipairs(due) do
+		redis.call("zrem", scheduledKey, member)
+		local sep = string.find(member, ":", 1, true)  -- "{size}:{content}"
+		local size = tonumber(string.sub(member, 1, sep - 1))
+		local content = string.sub(member, sep + 1)
+		redis.call("decrby", scheduledBytesKey, size)
+
+		local envelope = cjson.decode(content)
+		local bodyTTLMs = ttlSec * 1000
+		if type(envelope.expireAt) == "number" and envelope.expireAt - nowMs < bodyTTLMs then
+			bodyTTLMs = envelope.expireAt - nowMs
+		end
+		if bodyTTLMs > 0 then  -- Otherwise message expired before delivery
+			local nextClock = tonumber(redis.call("incr", clockKey))
+			if nextClock > clockMax then
+				nextClock = clockMin
+				redis.call("set", clockKey, nextClock)
+			end
+			local nextClockStr = string.format("%d", nextClock)
+			redis.call("set", msgDedupKeyPrefix .. envelope.id, nextClockStr, "EX", ttlSec)
+			redis.call("set", msgBodyKeyPrefix .. nextClockStr, content, "PX", math.floor(bodyTTLMs))
+			if limited then
+				-- Scheduled message is not rejected because it has been counted when accepted
+				pruneRetained(retainedKey, retainedBytesKey, msgBodyKeyPrefix)
+				retain(retainedKey, retainedBytesKey, msgBodyKeyPrefix, ttlSec, nextClockStr, size, maxMessages, maxBytes, reject)
+			end
+			released = released + 1
+		end
+	end
+	if released > 0 then
+		redis.call("expire", clockKey, ttlSec)
+	end
+
+	local next = redis.call("zrange", scheduledKey, 0, 0, "WITHSCORES")
+	if #next == 0 then
+		return {released, -1}
+	end
+	return {released, tonumber(next[2])}
+`)
+
+// runReleaseScheduledMessagesScript delivers scheduled messages due, returns count of released messages and the time of the next scheduled message (nil if none).
+func runReleaseScheduledMessagesScript(ctx context.Context, redisCmd internal.RedisCmd, channelID domain.ChannelID, ttl channelTTLSec, retention domain.ChannelRetention, now domain.Time) (int64, *time.Time, error) {
+	keys := keyOfChannel(channelID)
+	result, err := redisCmd.RunScript(
+		ctx, releaseScheduledMessagesScript,
+		[]string{keys.Clock(), keys.MessageBodyPrefix(), keys.MessageDedupPrefix(), keys.ScheduledMessages(), keys.RetainedMessages(), keys.RetainedBytes(), keys.ScheduledBytes()},
+		append([]interface{}{ttl, toUnixMilli(now.Time), clockMin, clockMax, maxReleaseScheduledMessages}, retentionArgs(retention)...)...,
+	)
+	logger.Of(ctx).Debugf(logger.CatStorage, "runReleaseScheduledMessagesScript(channelID = %s, ttl = %d) resulted in %v (%v)", channelID, ttl, result, err)
+	if err != nil {
+		return 0, nil, xerrors.Errorf("Failed to execute releaseScheduledMessagesScript: %w", err)
+	}
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return 0, nil, xerrors.Errorf("Unexpected result from releaseScheduledMessagesScript: %T(%v)", result, result)
+	}
+	released, ok1 := values[0].(int64)
+	nextAtMs, ok2 := values[1].(int64)
+	if !ok1 || !ok2 {
+		return 0, nil, xerrors.Errorf("Unexpected result from releaseScheduledMessagesScript: %T(%v)", result, result)
+	}
+	if nextAtMs < 0 {
+		return released, nil, nil
+	}
+	nextAt := fromUnixMilli(nextAtMs)
+	return released, &nextAt, nil
+}
+
 var ackScript = redis.NewScript(`
 	local channelClockKey = KEYS[1]  -- Clock of the channel (c.{channel}.clock)
 	local sbscClockKey = KEYS[2]     -- Clock of the subscriber (c.{{channel}}.r.{subscriber})
@@ -58,12 +58,12 @@ func TestPublishMessageScript(t *testing.T) {
 			}
 
 			// 1st publish
-			assert.NoError(t, runPublishMessageScript(ctx, redisCmd, ttl, msg))
+			assert.NoError(t, runPublishMessageScript(ctx, redisCmd, ttl, domain.ChannelRetention{}, msg, domain.RealSystemClock.Now()))
 			assertValueAndTTL(t, redisCmd, keys.Clock(), fmt.Sprintf("%d", clockAfter), time.Duration(ttl)*time.Second)
 
 			// 2nd publish (duplicate)
 			if testcase.duplicateMessage {
-				assert.NoError(t, runPublishMessageScript(ctx, redisCmd, ttl, msg))
+				assert.NoError(t, runPublishMessageScript(ctx, redisCmd, ttl, domain.ChannelRetention{}, msg, domain.RealSystemClock.Now()))
 				// Should not advance clock
 				assertValueAndTTL(t, redisCmd, keys.Clock(), fmt.Sprintf("%d", clockAfter), time.Duration(ttl)*time.Second)
 			}
@@ -99,18 +99,124 @@ func TestPublishMessageScriptAbormalResults(t *testing.T) {
 		assert.Equal(
 			t,
 			`Failed to execute publishMessageScript: ERR Error compiling script (new function): user_script:1: '=' expected near 'tax'`,
-			runPublishMessageScript(ctx, redisCmd, ttl, msg).Error(),
+			runPublishMessageScript(ctx, redisCmd, ttl, domain.ChannelRetention{}, msg, domain.RealSystemClock.Now()).Error(),
 		)
 
 		publishMessageScript = redis.NewScript(`return "What??"`)
 		assert.Equal(
 			t,
 			`Unexpected result from publishMessageScript: string(What??)`,
-			runPublishMessageScript(ctx, redisCmd, ttl, msg).Error(),
+			runPublishMessageScript(ctx, redisCmd, ttl, domain.ChannelRetention{}, msg, domain.RealSystemClock.Now()).Error(),
 		)
 	})
 }
 
+func TestMessageBodyTTLMs(t *testing.T) {
+	now := domain.Time{Time: fromUnixMilli(1605633588000)}
+	msg := domain.Message{}
+	assert.Equal(t, int64(3000), messageBodyTTLMs(channelTTLSec(3), msg, now))
+
+	msg.ExpireAt = domain.Time{Time: now.Add(1500 * time.Millisecond)}
+	assert.Equal(t, int64(1500), messageBodyTTLMs(channelTTLSec(3), msg, now))
+
+	msg.ExpireAt = domain.Time{Time: now.Add(5 * time.Second)}
+	assert.Equal(t, int64(3000), messageBodyTTLMs(channelTTLSec(3), msg, now))
+
+	msg.ExpireAt = domain.Time{Time: now.Add(-time.Second)}
+	assert.Equal(t, int64(1), messageBodyTTLMs(channelTTLSec(3), msg, now))
+}
+
+func TestScheduleAndReleaseMessageScript(t *testing.T) {
+	ctx := context.Background()
+	WithRedisClient(t, func(redisCmd RedisCmd) {
+		channelID := randomChannelID(t)
+		keys := keyOfChannel(channelID)
+		ttl := channelTTLSec(3)
+		now := domain.Time{Time: fromUnixMilli(toUnixMilli(time.Now()))}
+		newMsg := func(id domain.MessageID, deliverAfter time.Duration) domain.Message {
+			return domain.Message{
+				MessageLocator: domain.MessageLocator{ChannelID: channelID, MessageID: id},
+				Content:        json.RawMessage(`{}`),
+				DeliverAt:      domain.Time{Time: now.Add(deliverAfter)},
+			}
+		}
+		msg1 := newMsg("msg1", 1*time.Second)
+		msg2 := newMsg("msg2", 2*time.Second)
+		msg3 := newMsg("msg3", 1*time.Second)
+		msg3.ExpireAt = domain.Time{Time: now.Add(500 * time.Millisecond)} // Expires before delivery
+		for _, msg := range []domain.Message{msg1, msg2, msg3, msg1} {
+			assert.NoError(t, runScheduleMessageScript(ctx, redisCmd, ttl, domain.ChannelRetention{}, msg))
+		}
+		assertValueAndTTL(t, redisCmd, keys.MessageDedup("msg1"), "scheduled", time.Duration(ttl)*time.Second)
+		assertTTL(t, redisCmd, keys.ScheduledMessages(), time.Duration(ttl)*time.Second)
+
+		// Nothing to release yet
+		released, nextAt, err := runReleaseScheduledMessagesScript(ctx, redisCmd, channelID, ttl, domain.ChannelRetention{}, now)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), released)
+		if assert.NotNil(t, nextAt) {
+			assert.True(t, msg1.DeliverAt.Equal(*nextAt))
+		}
+
+		// Release msg1 (msg3 expired)
+		released, nextAt, err = runReleaseScheduledMessagesScript(ctx, redisCmd, channelID, ttl, domain.ChannelRetention{}, domain.Time{Time: now.Add(1 * time.Second)})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), released)
+		if assert.NotNil(t, nextAt) {
+			assert.True(t, msg2.DeliverAt.Equal(*nextAt))
+		}
+		assertValueAndTTL(t, redisCmd, keys.Clock(), "1", time.Duration(ttl)*time.Second)
+		assertValueAndTTL(t, redisCmd, keys.MessageDedup("msg1"), "1", time.Duration(ttl)*time.Second)
+		wrapped1, _ := wrapMessage(msg1)
+		assertValueAndTTL(t, redisCmd, keys.MessageBody(1), wrapped1, time.Duration(ttl)*time.Second)
+
+		// Release msg2
+		released, nextAt, err = runReleaseScheduledMessagesScript(ctx, redisCmd, channelID, ttl, domain.ChannelRetention{}, domain.Time{Time: now.Add(2 * time.Second)})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), released)
+		assert.Nil(t, nextAt)
+		assertValueAndTTL(t, redisCmd, keys.MessageDedup("msg2"), "2", time.Duration(ttl)*time.Second)
+
+		// Published message is deduped with released message
+		assert.NoError(t, runPublishMessageScript(ctx, redisCmd, ttl, domain.ChannelRetention{}, domain.Message{MessageLocator: msg1.MessageLocator, Content: msg1.Content}, now))
+		assertValueAndTTL(t, redisCmd, keys.Clock(), "2", time.Duration(ttl)*time.Second)
+	})
+}
+
+func TestScheduleAndReleaseMessageScriptAbormalResults(t *testing.T) {
+	ctx := context.Background()
+	channelID := randomChannelID(t)
+	ttl := channelTTLSec(3)
+	now := domain.RealSystemClock.Now()
+	msg := domain.Message{
+		MessageLocator: domain.MessageLocator{ChannelID: channelID, MessageID: "msg1"},
+		Content:        json.RawMessage(`{}`),
+		DeliverAt:      domain.Time{Time: now.Add(time.Second)},
+	}
+
+	WithRedisClient(t, func(redisCmd RedisCmd) {
+		originalScheduleScript := scheduleMessageScript
+		originalReleaseScript := releaseScheduledMessagesScript
+		defer func() {
+			scheduleMessageScript = originalScheduleScript
+			releaseScheduledMessagesScript = originalReleaseScript
+		}()
+
+		scheduleMessageScript = redis.NewScript(`return "What??"`)
+		assert.Equal(
+			t,
+			`Unexpected result from scheduleMessageScript: string(What??)`,
+			runScheduleMessageScript(ctx, redisCmd, ttl, domain.ChannelRetention{}, msg).Error(),
+		)
+
+		releaseScheduledMessagesScript = redis.NewScript(`return "What??"`)
+		_, _, err := runReleaseScheduledMessagesScript(ctx, redisCmd, channelID, ttl, domain.ChannelRetention{}, now)
+		if assert.Error(t, err) {
+			assert.Equal(t, `Unexpected result from releaseScheduledMessagesScript: string(What??)`, err.Error())
+		}
+	})
+}
+
 func TestAckScript(t *testing.T) {
 	ctx := context.Background()
 	WithRedisClient(t, func(redisCmd RedisCmd) {
@@ -0,0 +1,93 @@
+package redis
+
+import (
+	"github.com/saiya/dsps/server/domain"
+)
+
+// retentionLua is Lua functions to enforce domain.ChannelRetention, prepend to scripts that deliver messages.
+//
+// RetainedMessages list holds "{clock}:{content size}" of delivered messages (oldest first), and RetainedBytes holds total size of them.
+// Entries of expired messages are removed lazily when the channel checks the limits.
+// ScheduledBytes holds total size of scheduled messages, scheduled messages are also counted to the limits.
+const retentionLua = `
+	local function parseRetainedEntry(entry)
+		local sep = string.find(entry, ":", 1, true)
+		return string.sub(entry, 1, sep - 1), tonumber(string.sub(entry, sep + 1))
+	end
+
+	local function retainedBytes(retainedBytesKey)
+		return tonumber(redis.call("get", retainedBytesKey) or "0")
+	end
+
+	-- Forget expired messages
+	local function pruneRetained(retainedKey, retainedBytesKey, msgBodyKeyPrefix)
+		while true do
+			local oldest = redis.call("lindex", retainedKey, 0)
+			if oldest == false then return end
+			local clock, size = parseRetainedEntry(oldest)
+			if redis.call("exists", msgBodyKeyPrefix .. clock) == 1 then return end
+			redis.call("lpop", retainedKey)
+			redis.call("decrby", retainedBytesKey, size)
+		end
+	end
+
+	-- Track delivered message, drop oldest messages if exceeded the limits (unless reject mode)
+	local function retain(retainedKey, retainedBytesKey, msgBodyKeyPrefix, ttlSec, clockStr, size, maxMessages, maxBytes, reject)
+		redis.call("rpush", retainedKey, clockStr .. ":" .. string.format("%d", size))
+		redis.call("incrby", retainedBytesKey, size)
+		if not reject then
+			while true do
+				local count = redis.call("llen", retainedKey)
+				if count <= 1 then break end
+				if not ((maxMessages > 0 and count > maxMessages) or (maxBytes > 0 and retainedBytes(retainedBytesKey) > maxBytes)) then break end
+
+				local clock, oldestSize = parseRetainedEntry(redis.call("lpop", retainedKey))
+				redis.call("del", msgBodyKeyPrefix .. clock)
+				redis.call("decrby", retainedBytesKey, oldestSize)
+			end
+		end
+		redis.call("expire", retainedKey, ttlSec)
+		redis.call("expire", retainedBytesKey, ttlSec)
+	end
+
+	-- Returns error string if the channel rejects new message of the size (reject mode), counting both retained and scheduled messages
+	local function checkRetention(retainedKey, retainedBytesKey, scheduledKey, scheduledBytesKey, size, maxMessages, maxBytes)
+		if maxMessages > 0 and redis.call("llen", retainedKey) + redis.call("zcard", scheduledKey) + 1 > maxMessages then
+			return "max-messages"
+		end
+		if maxBytes > 0 and retainedBytes(retainedBytesKey) + retainedBytes(scheduledBytesKey) + size > maxBytes then
+			return "max-bytes"
+		end
+		return nil
+	end
+
+	-- Drop scheduled messages that will be delivered earliest until scheduled messages fit the limits (dropOldest mode)
+	local function capScheduled(scheduledKey, scheduledBytesKey, maxMessages, maxBytes)
+		while true do
+			local count = redis.call("zcard", scheduledKey)
+			if count <= 1 then break end
+			if not ((maxMessages > 0 and count > maxMessages) or (maxBytes > 0 and retainedBytes(scheduledBytesKey) > maxBytes)) then break end
+
+			local earliest = redis.call("zrange", scheduledKey, 0, 0)[1]
+			redis.call("zrem", scheduledKey, earliest)
+			redis.call("decrby", scheduledBytesKey, tonumber(string.sub(earliest, 1, string.find(earliest, ":", 1, true) - 1)))
+		end
+	end
+`
+
+// retentionArgs returns Lua script arguments of maxMessages, maxBytes and reject flag
+func retentionArgs(retention domain.ChannelRetention) []interface{} {
+	reject := 0
+	if retention.RejectOverflow {
+		reject = 1
+	}
+	return []interface{}{retention.MaxMessages, retention.MaxBytes, reject}
+}
+
+func (s *redisStorage) channelRetention(channelID domain.ChannelID) (domain.ChannelRetention, error) {
+	ch, err := s.channelProvider.Get(channelID)
+	if err != nil {
+		return domain.ChannelRetention{}, err
+	}
+	return ch.Retention(), nil
+}
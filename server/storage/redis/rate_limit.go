@@ -0,0 +1,11 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+func (s *redisStorage) TakeRateLimitToken(ctx context.Context, key string, limit domain.RateLimit) (domain.Duration, error) {
+	return runTakeRateLimitTokenScript(ctx, s.RedisCmd, key, limit, s.clock.Now().Time)
+}
@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/xerrors"
+
+	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/logger"
+	internal "github.com/saiya/dsps/server/storage/redis/internal"
+)
+
+func (s *redisStorage) loadRateLimitScripts(ctx context.Context) error {
+	if err := s.RedisCmd.LoadScript(ctx, takeRateLimitTokenScript); err != nil {
+		return xerrors.Errorf("Failed to load takeRateLimitTokenScript: %w", err)
+	}
+	return nil
+}
+
+// Token bucket with GCRA (generic cell rate algorithm), bucket holds "theoretical arrival time" (the time when the bucket becomes full).
+var takeRateLimitTokenScript = redis.NewScript(`
+	local bucketKey = KEYS[1]              -- RateLimitBucket (rl.{key})
+	local nowUs = tonumber(ARGV[1])        -- (number) current time [UNIX epoch microseconds]
+	local intervalUs = tonumber(ARGV[2])   -- (number) duration to refill a token [microseconds]
+	local burst = tonumber(ARGV[3])        -- (number) max tokens of the bucket
+
+	local tat = tonumber(redis.call("get", bucketKey) or "0")
+	if tat < nowUs then
+		tat = nowUs
+	end
+	local nextTat = tat + intervalUs
+	local waitUs = nextTat - nowUs - intervalUs * burst
+	if waitUs > 0 then
+		return waitUs
+	end
+	redis.call("set", bucketKey, string.format("%d", nextTat), "PX", math.ceil((nextTat - nowUs) / 1000))
+	return 0
+`)
+
+func runTakeRateLimitTokenScript(ctx context.Context, redisCmd internal.RedisCmd, key string, limit domain.RateLimit, now time.Time) (domain.Duration, error) {
+	result, err := redisCmd.RunScript(
+		ctx, takeRateLimitTokenScript,
+		[]string{keyOfRateLimit(key).Bucket()},
+		now.UnixNano()/int64(time.Microsecond), int64(limit.Interval()/time.Microsecond), limit.Burst,
+	)
+	logger.Of(ctx).Debugf(logger.CatStorage, "runTakeRateLimitTokenScript(key = %s, limit = %v) resulted in %v (%v)", key, limit, result, err)
+	if err != nil {
+		return domain.Duration{}, xerrors.Errorf("Failed to execute takeRateLimitTokenScript: %w", err)
+	}
+	waitUs, ok := result.(int64)
+	if !ok {
+		return domain.Duration{}, xerrors.Errorf("Unexpected result from takeRateLimitTokenScript: %T(%v)", result, result)
+	}
+	return domain.Duration{Duration: time.Duration(waitUs) * time.Microsecond}, nil
+}
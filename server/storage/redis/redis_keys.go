@@ -36,11 +36,42 @@ func (rk channelKeys) MessageBody(clock channelClock) string {
 	return fmt.Sprintf("c.{%s}.m.%d", rk.channelID, clock)
 }
 
-// type of value is channelClock
+// type of value is channelClock, or "scheduled" if the message is not delivered yet
 func (rk channelKeys) MessageDedup(id domain.MessageID) string {
+	// MUST start with MessageDedupPrefix()
 	return fmt.Sprintf("c.{%s}.mid.%s", rk.channelID, id)
 }
 
+// type of value is channelClock
+func (rk channelKeys) MessageDedupPrefix() string {
+	return fmt.Sprintf("c.{%s}.mid.", rk.channelID)
+}
+
+// type of value is sorted set of JSON, score is UNIX epoch milliseconds to deliver the message
+func (rk channelKeys) ScheduledMessages() string {
+	return fmt.Sprintf("c.{%s}.scheduled", rk.channelID)
+}
+
+// type of value is integer, total content size of ScheduledMessages
+func (rk channelKeys) ScheduledBytes() string {
+	return fmt.Sprintf("c.{%s}.scheduled.bytes", rk.channelID)
+}
+
+// type of value is list of "{clock}:{content size}", oldest first
+func (rk channelKeys) RetainedMessages() string {
+	return fmt.Sprintf("c.{%s}.retention", rk.channelID)
+}
+
+// type of value is integer, total content size of RetainedMessages
+func (rk channelKeys) RetainedBytes() string {
+	return fmt.Sprintf("c.{%s}.retention.bytes", rk.channelID)
+}
+
+// type of value is list of JSON, latest first
+func (rk channelKeys) WebhookHistory() string {
+	return fmt.Sprintf("c.{%s}.wh.history", rk.channelID)
+}
+
 type jtiKeys struct {
 	jti domain.JwtJti
 }
@@ -52,3 +83,51 @@ func keyOfJti(jti domain.JwtJti) jtiKeys {
 func (jti jtiKeys) Revocation() string {
 	return fmt.Sprintf("jwt.{%s}.revoke", jti.jti)
 }
+
+type rateLimitKeys struct {
+	key string
+}
+
+func keyOfRateLimit(key string) rateLimitKeys {
+	return rateLimitKeys{key: key}
+}
+
+// type of value is UNIX epoch microseconds
+func (rk rateLimitKeys) Bucket() string {
+	return fmt.Sprintf("rl.{%s}", rk.key)
+}
+
+type webhookKeys struct{}
+
+func keyOfWebhookQueue() webhookKeys {
+	return webhookKeys{}
+}
+
+// All keys must be prefixed with same hash tag because Lua scripts handle multiple keys.
+// Lua scripts also build Delivery keys from DeliveryPrefix without declaring them in KEYS,
+// that works in Redis Cluster only because the hash tag maps all of them to the same slot.
+const webhookKeyPrefix = "wh.{dsps-webhook}."
+
+// type of value is sorted set of webhookMember, score is UNIX epoch milliseconds until that the delivery is not visible.
+func (wk webhookKeys) Queue() string {
+	return webhookKeyPrefix + "queue"
+}
+
+// type of value is JSON
+func (wk webhookKeys) DeliveryPrefix() string {
+	return webhookKeyPrefix + "d."
+}
+
+// type of value is JSON
+func (wk webhookKeys) Delivery(member webhookMember) string {
+	// MUST start with DeliveryPrefix()
+	return fmt.Sprintf("%sd.%s", webhookKeyPrefix, member)
+}
+
+// webhookMember identifies webhook delivery in the queue
+type webhookMember string
+
+func webhookMemberOf(msg domain.MessageLocator) webhookMember {
+	// ChannelID and MessageID never contain "/"
+	return webhookMember(fmt.Sprintf("%s/%s", msg.ChannelID, msg.MessageID))
+}
@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/domain"
 )
 
 func TestChannelKeys(t *testing.T) {
@@ -16,9 +18,16 @@ func TestChannelKeys(t *testing.T) {
 	assert.Contains(t, keys.MessageBodyPrefix(), "{my-channel}")
 	assert.Contains(t, keys.MessageBody(1234), "{my-channel}")
 	assert.Contains(t, keys.MessageDedup("msg-1"), "{my-channel}")
+	assert.Contains(t, keys.MessageDedupPrefix(), "{my-channel}")
+	assert.Contains(t, keys.ScheduledMessages(), "{my-channel}")
+	assert.Contains(t, keys.ScheduledBytes(), "{my-channel}")
+	assert.Contains(t, keys.RetainedMessages(), "{my-channel}")
+	assert.Contains(t, keys.RetainedBytes(), "{my-channel}")
 
 	// MessageBody must start with MessageBodyPrefix
 	assert.True(t, strings.HasPrefix(keys.MessageBody(1234), keys.MessageBodyPrefix()))
+	// MessageDedup must start with MessageDedupPrefix
+	assert.True(t, strings.HasPrefix(keys.MessageDedup("msg-1"), keys.MessageDedupPrefix()))
 
 	// Check uniqueness
 	keys2 := keyOfChannel("my-channel-X")
@@ -30,6 +39,9 @@ func TestChannelKeys(t *testing.T) {
 	assert.NotEqual(t, keys.MessageBody(1234), keys2.MessageBody(1234))
 	assert.NotEqual(t, keys.MessageDedup("msg-1"), keys.MessageDedup("msg-X"))
 	assert.NotEqual(t, keys.MessageDedup("msg-1"), keys2.MessageDedup("msg-1"))
+	assert.NotEqual(t, keys.ScheduledMessages(), keys.ScheduledBytes())
+	assert.NotEqual(t, keys.RetainedMessages(), keys.RetainedBytes())
+	assert.NotEqual(t, keys.RetainedMessages(), keys2.RetainedMessages())
 }
 
 func TestJtiKeys(t *testing.T) {
@@ -39,3 +51,31 @@ func TestJtiKeys(t *testing.T) {
 	keys2 := keyOfJti("my-jwt-X")
 	assert.NotEqual(t, keys.Revocation(), keys2.Revocation())
 }
+
+func TestRateLimitKeys(t *testing.T) {
+	keys := keyOfRateLimit("ip/chat-1/127.0.0.1")
+	assert.Equal(t, "rl.{ip/chat-1/127.0.0.1}", keys.Bucket())
+
+	// Check uniqueness
+	keys2 := keyOfRateLimit("ip/chat-1/127.0.0.2")
+	assert.NotEqual(t, keys.Bucket(), keys2.Bucket())
+}
+
+func TestWebhookKeys(t *testing.T) {
+	keys := keyOfWebhookQueue()
+	member := webhookMemberOf(domain.MessageLocator{ChannelID: "my-channel", MessageID: "msg-1"})
+
+	// All webhook keys must share the same hash tag, otherwise Lua scripts fail due to cross partition operation.
+	// Lua scripts build Delivery keys from DeliveryPrefix, so the prefix itself must carry the hash tag.
+	assert.True(t, strings.HasPrefix(keys.Queue(), "wh.{dsps-webhook}."))
+	assert.True(t, strings.HasPrefix(keys.DeliveryPrefix(), "wh.{dsps-webhook}."))
+	assert.True(t, strings.HasPrefix(keys.Delivery(member), "wh.{dsps-webhook}."))
+
+	// Delivery must start with DeliveryPrefix, as same as Lua scripts do
+	assert.Equal(t, keys.DeliveryPrefix()+string(member), keys.Delivery(member))
+
+	// Check uniqueness
+	member2 := webhookMemberOf(domain.MessageLocator{ChannelID: "my-channel", MessageID: "msg-X"})
+	assert.NotEqual(t, keys.Queue(), keys.Delivery(member))
+	assert.NotEqual(t, keys.Delivery(member), keys.Delivery(member2))
+}
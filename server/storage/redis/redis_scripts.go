@@ -13,5 +13,7 @@ func (s *redisStorage) loadScripts(ctx context.Context) error {
 	g, ctx := errgroup.WithContext(ctx)
 	g.Go(func() error { return s.loadPubSubMessagingScripts(ctx) })
 	g.Go(func() error { return s.loadPubSubSubscriberScripts(ctx) })
+	g.Go(func() error { return s.loadWebhookScripts(ctx) })
+	g.Go(func() error { return s.loadRateLimitScripts(ctx) })
 	return g.Wait()
 }
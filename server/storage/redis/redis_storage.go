@@ -27,8 +27,10 @@ func NewRedisStorage(ctx context.Context, config *config.RedisStorageConfig, sys
 		clock:           systemClock,
 		channelProvider: channelProvider,
 
-		pubsubEnabled: !config.DisablePubSub,
-		jwtEnabled:    !config.DisableJwt,
+		pubsubEnabled:    !config.DisablePubSub,
+		jwtEnabled:       !config.DisableJwt,
+		webhookEnabled:   !config.DisableWebhook,
+		rateLimitEnabled: !config.DisableRateLimit,
 
 		RedisConnection: conn,
 		daemonSystem: sync.NewDaemonSystem("dsps.storage.redis", sync.DaemonSystemDeps{
@@ -55,8 +57,10 @@ type redisStorage struct {
 	clock           domain.SystemClock
 	channelProvider domain.ChannelProvider
 
-	pubsubEnabled bool
-	jwtEnabled    bool
+	pubsubEnabled    bool
+	jwtEnabled       bool
+	webhookEnabled   bool
+	rateLimitEnabled bool
 
 	internal.RedisConnection
 	daemonSystem     *sync.DaemonSystem
@@ -76,6 +80,20 @@ func (s *redisStorage) AsJwtStorage() domain.JwtStorage {
 	return s
 }
 
+func (s *redisStorage) AsWebhookStorage() domain.WebhookStorage {
+	if !s.webhookEnabled {
+		return nil
+	}
+	return s
+}
+
+func (s *redisStorage) AsRateLimitStorage() domain.RateLimitStorage {
+	if !s.rateLimitEnabled {
+		return nil
+	}
+	return s
+}
+
 func (s *redisStorage) String() string {
 	if s.RedisConnection.IsSingleNode {
 		return "redis-singlenode"
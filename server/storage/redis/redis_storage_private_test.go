@@ -61,6 +61,10 @@ func newMockedRedisStorageAndPubSubDispatcher(ctrl *gomock.Controller) (*redisSt
 	}, redisCmd, dispatcher
 }
 
+func expectNoScheduledMessages(redisCmd *MockRedisCmd) {
+	redisCmd.EXPECT().RunScript(gomock.Any(), releaseScheduledMessagesScript, gomock.Any(), gomock.Any()).Return([]interface{}{int64(0), int64(-1)}, nil).AnyTimes()
+}
+
 func TestRedisStorageFeatureFlag(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
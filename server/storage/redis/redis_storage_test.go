@@ -69,3 +69,23 @@ func TestJwtMultiplex(t *testing.T) {
 	// It behaves as single storage because operations are idempotent.
 	JwtTest(t, storageMultiplexCtor(t))
 }
+
+func TestWebhook(t *testing.T) {
+	WebhookTest(t, storageCtor(t))
+}
+
+func TestWebhookMultiplex(t *testing.T) {
+	// Test with two duplicate storages.
+	// It behaves as single storage because operations are idempotent.
+	WebhookTest(t, storageMultiplexCtor(t))
+}
+
+func TestRateLimit(t *testing.T) {
+	RateLimitTest(t, storageCtor(t))
+}
+
+func TestRateLimitMultiplex(t *testing.T) {
+	// Test with two duplicate storages.
+	// It behaves as single storage because multiplexer takes a token from only one storage.
+	RateLimitTest(t, storageMultiplexCtor(t))
+}
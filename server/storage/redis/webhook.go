@@ -0,0 +1,34 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+func (s *redisStorage) EnqueueWebhookDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return runEnqueueWebhookScript(ctx, s.RedisCmd, deliveries)
+}
+
+func (s *redisStorage) LeaseWebhookDeliveries(ctx context.Context, max int, lease domain.Duration) ([]domain.WebhookDelivery, error) {
+	return runLeaseWebhookScript(ctx, s.RedisCmd, s.clock.Now().Time, max, lease.Duration)
+}
+
+func (s *redisStorage) CompleteWebhookDelivery(ctx context.Context, msg domain.MessageLocator) error {
+	return runCompleteWebhookScript(ctx, s.RedisCmd, msg)
+}
+
+func (s *redisStorage) RetryWebhookDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	return runRetryWebhookScript(ctx, s.RedisCmd, delivery)
+}
+
+func (s *redisStorage) RecordWebhookDelivery(ctx context.Context, record domain.WebhookDeliveryRecord, maxRecords int, retention domain.Duration) error {
+	return runRecordWebhookDeliveryScript(ctx, s.RedisCmd, record, maxRecords, retention.Duration)
+}
+
+func (s *redisStorage) ListWebhookDeliveryRecords(ctx context.Context, channelID domain.ChannelID, max int) ([]domain.WebhookDeliveryRecord, error) {
+	return runListWebhookDeliveryRecordsScript(ctx, s.RedisCmd, channelID, max)
+}
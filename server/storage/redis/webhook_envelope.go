@@ -0,0 +1,124 @@
+package redis
+
+import (
+	"encoding/json"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+type webhookDeliveryEnvelope struct {
+	ChannelID domain.ChannelID `json:"ch"`
+	messageEnvelope
+
+	Attempts      int    `json:"attempts"`
+	NextAttemptAt int64  `json:"next"` // UNIX epoch milliseconds
+	LastError     string `json:"lastError,omitempty"`
+}
+
+func wrapWebhookDelivery(delivery domain.WebhookDelivery) (string, error) {
+	data, err := json.Marshal(webhookDeliveryEnvelope{
+		ChannelID:       delivery.ChannelID,
+		messageEnvelope: newMessageEnvelope(delivery.Message),
+		Attempts:        delivery.Attempts,
+		NextAttemptAt:   toUnixMilli(delivery.NextAttemptAt.Time),
+		LastError:       delivery.LastError,
+	})
+	if err != nil {
+		return "", xerrors.Errorf(`%w: %v`, domain.ErrMalformedMessageJSON, err)
+	}
+	return string(data), nil
+}
+
+func unwrapWebhookDelivery(raw string) (*domain.WebhookDelivery, error) {
+	envelope := webhookDeliveryEnvelope{}
+	if err := json.Unmarshal([]byte(raw), &envelope); err != nil {
+		return nil, xerrors.Errorf(`Failed to parse webhook delivery envelope JSON '%s': %w`, raw, err)
+	}
+	return &domain.WebhookDelivery{
+		Message:       envelope.toMessage(envelope.ChannelID),
+		Attempts:      envelope.Attempts,
+		NextAttemptAt: domain.Time{Time: fromUnixMilli(envelope.NextAttemptAt)},
+		LastError:     envelope.LastError,
+	}, nil
+}
+
+type webhookDeliveryRecordEnvelope struct {
+	ChannelID domain.ChannelID `json:"ch"`
+	messageEnvelope
+
+	Attempt     int                            `json:"attempt"`
+	Result      domain.WebhookDeliveryResult   `json:"result"`
+	AttemptedAt int64                          `json:"at"`      // UNIX epoch milliseconds
+	Latency     int64                          `json:"latency"` // milliseconds
+	Error       string                         `json:"error,omitempty"`
+	Requests    []webhookRequestRecordEnvelope `json:"requests,omitempty"`
+}
+
+type webhookRequestRecordEnvelope struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status,omitempty"`
+	Latency    int64  `json:"latency"` // milliseconds
+	Error      string `json:"error,omitempty"`
+}
+
+func wrapWebhookDeliveryRecord(record domain.WebhookDeliveryRecord) (string, error) {
+	requests := make([]webhookRequestRecordEnvelope, 0, len(record.Requests))
+	for _, req := range record.Requests {
+		requests = append(requests, webhookRequestRecordEnvelope{
+			URL:        req.URL,
+			StatusCode: req.StatusCode,
+			Latency:    req.Latency.Milliseconds(),
+			Error:      req.Error,
+		})
+	}
+	data, err := json.Marshal(webhookDeliveryRecordEnvelope{
+		ChannelID:       record.ChannelID,
+		messageEnvelope: newMessageEnvelope(record.Message),
+		Attempt:         record.Attempt,
+		Result:          record.Result,
+		AttemptedAt:     toUnixMilli(record.AttemptedAt.Time),
+		Latency:         record.Latency.Milliseconds(),
+		Error:           record.Error,
+		Requests:        requests,
+	})
+	if err != nil {
+		return "", xerrors.Errorf(`%w: %v`, domain.ErrMalformedMessageJSON, err)
+	}
+	return string(data), nil
+}
+
+func unwrapWebhookDeliveryRecord(raw string) (*domain.WebhookDeliveryRecord, error) {
+	envelope := webhookDeliveryRecordEnvelope{}
+	if err := json.Unmarshal([]byte(raw), &envelope); err != nil {
+		return nil, xerrors.Errorf(`Failed to parse webhook delivery record envelope JSON '%s': %w`, raw, err)
+	}
+	requests := make([]domain.WebhookRequestRecord, 0, len(envelope.Requests))
+	for _, req := range envelope.Requests {
+		requests = append(requests, domain.WebhookRequestRecord{
+			URL:        req.URL,
+			StatusCode: req.StatusCode,
+			Latency:    domain.Duration{Duration: time.Duration(req.Latency) * time.Millisecond},
+			Error:      req.Error,
+		})
+	}
+	return &domain.WebhookDeliveryRecord{
+		Message:     envelope.toMessage(envelope.ChannelID),
+		Attempt:     envelope.Attempt,
+		Result:      envelope.Result,
+		AttemptedAt: domain.Time{Time: fromUnixMilli(envelope.AttemptedAt)},
+		Latency:     domain.Duration{Duration: time.Duration(envelope.Latency) * time.Millisecond},
+		Error:       envelope.Error,
+		Requests:    requests,
+	}, nil
+}
+
+func toUnixMilli(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func fromUnixMilli(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
@@ -0,0 +1,256 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/xerrors"
+
+	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/logger"
+	internal "github.com/saiya/dsps/server/storage/redis/internal"
+)
+
+// Webhook deliveries pending longer than this period are discarded.
+const webhookDeliveryTTL = 7 * 24 * time.Hour
+
+func (s *redisStorage) loadWebhookScripts(ctx context.Context) error {
+	if err := s.RedisCmd.LoadScript(ctx, enqueueWebhookScript); err != nil {
+		return xerrors.Errorf("Failed to load enqueueWebhookScript: %w", err)
+	}
+	if err := s.RedisCmd.LoadScript(ctx, leaseWebhookScript); err != nil {
+		return xerrors.Errorf("Failed to load leaseWebhookScript: %w", err)
+	}
+	if err := s.RedisCmd.LoadScript(ctx, retryWebhookScript); err != nil {
+		return xerrors.Errorf("Failed to load retryWebhookScript: %w", err)
+	}
+	if err := s.RedisCmd.LoadScript(ctx, completeWebhookScript); err != nil {
+		return xerrors.Errorf("Failed to load completeWebhookScript: %w", err)
+	}
+	if err := s.RedisCmd.LoadScript(ctx, recordWebhookDeliveryScript); err != nil {
+		return xerrors.Errorf("Failed to load recordWebhookDeliveryScript: %w", err)
+	}
+	if err := s.RedisCmd.LoadScript(ctx, listWebhookDeliveryRecordsScript); err != nil {
+		return xerrors.Errorf("Failed to load listWebhookDeliveryRecordsScript: %w", err)
+	}
+	return nil
+}
+
+// Builds delivery keys from DeliveryPrefix (KEYS[2]) in Lua, so not all accessed keys are passed in KEYS.
+// This is safe in Redis Cluster only because every webhook key shares the {dsps-webhook} hash tag (see webhookKeyPrefix).
+var enqueueWebhookScript = redis.NewScript(`
+	local queueKey = KEYS[1]             -- Queue (wh.{dsps-webhook}.queue)
+	local deliveryKeyPrefix = KEYS[2]    -- DeliveryPrefix (wh.{dsps-webhook}.d.)
+	local ttlSec = tonumber(ARGV[1])     -- (number) ttl [sec]
+	-- ARGV[2..] is repeat of (member, visibleAt [UNIX epoch ms], delivery JSON)
+
+	for i = 2, #ARGV, 3 do
+		redis.call("set", deliveryKeyPrefix .. ARGV[i], ARGV[i + 2], "EX", ttlSec)
+		redis.call("zadd", queueKey, ARGV[i + 1], ARGV[i])
+	end
+	return redis.status_reply("OK")
+`)
+
+func runEnqueueWebhookScript(ctx context.Context, redisCmd internal.RedisCmd, deliveries []domain.WebhookDelivery) error {
+	keys := keyOfWebhookQueue()
+	args := make([]interface{}, 0, 1+3*len(deliveries))
+	args = append(args, int64(webhookDeliveryTTL.Seconds()))
+	for _, delivery := range deliveries {
+		wrapped, err := wrapWebhookDelivery(delivery)
+		if err != nil {
+			return xerrors.Errorf("Unable to encode webhook delivery of message \"%s\": %w", delivery.MessageID, err)
+		}
+		args = append(args, string(webhookMemberOf(delivery.MessageLocator)), toUnixMilli(delivery.NextAttemptAt.Time), wrapped)
+	}
+
+	result, err := redisCmd.RunScript(ctx, enqueueWebhookScript, []string{keys.Queue(), keys.DeliveryPrefix()}, args...)
+	logger.Of(ctx).Debugf(logger.CatStorage, "runEnqueueWebhookScript(count = %d) resulted in %v (%v)", len(deliveries), result, err)
+	if err != nil {
+		return xerrors.Errorf("Failed to execute enqueueWebhookScript: %w", err)
+	}
+	if result != "OK" {
+		return xerrors.Errorf("Unexpected result from enqueueWebhookScript: %T(%v)", result, result)
+	}
+	return nil
+}
+
+// Builds delivery keys from DeliveryPrefix (KEYS[2]) in Lua, relies on the {dsps-webhook} hash tag as same as enqueueWebhookScript.
+// @returns list of delivery JSON
+var leaseWebhookScript = redis.NewScript(`
+	local queueKey = KEYS[1]             -- Queue (wh.{dsps-webhook}.queue)
+	local deliveryKeyPrefix = KEYS[2]    -- DeliveryPrefix (wh.{dsps-webhook}.d.)
+	local now = ARGV[1]                  -- (number) current time [UNIX epoch ms]
+	local max = tonumber(ARGV[2])        -- (number) max count to lease
+	local leaseUntil = ARGV[3]           -- (number) lease end time [UNIX epoch ms]
+
+	local result = {}
+	local members = redis.call("zrangebyscore", queueKey, "-inf", now, "LIMIT", 0, max)
+	for _, member in ipairs(members) do
+		local delivery = redis.call("get", deliveryKeyPrefix .. member)
+		if delivery == false then
+			redis.call("zrem", queueKey, member)  -- Delivery already expired
+		else
+			redis.call("zadd", queueKey, leaseUntil, member)
+			table.insert(result, delivery)
+		end
+	end
+	return result
+`)
+
+func runLeaseWebhookScript(ctx context.Context, redisCmd internal.RedisCmd, now time.Time, max int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	keys := keyOfWebhookQueue()
+	result, err := redisCmd.RunScript(
+		ctx, leaseWebhookScript,
+		[]string{keys.Queue(), keys.DeliveryPrefix()},
+		toUnixMilli(now), max, toUnixMilli(now.Add(lease)),
+	)
+	logger.Of(ctx).Debugf(logger.CatStorage, "runLeaseWebhookScript(max = %d) resulted in %v (%v)", max, result, err)
+	if err != nil {
+		return nil, xerrors.Errorf("Failed to execute leaseWebhookScript: %w", err)
+	}
+	list, ok := result.([]interface{})
+	if !ok {
+		return nil, xerrors.Errorf("Unexpected result from leaseWebhookScript: %T(%v)", result, result)
+	}
+
+	deliveries := make([]domain.WebhookDelivery, 0, len(list))
+	for _, item := range list {
+		raw, ok := item.(string)
+		if !ok {
+			return nil, xerrors.Errorf("Unexpected result from leaseWebhookScript: %T(%v)", item, item)
+		}
+		delivery, err := unwrapWebhookDelivery(raw)
+		if err != nil {
+			logger.Of(ctx).WarnError(logger.CatStorage, "Discarding corrupted webhook delivery", err)
+			continue
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	return deliveries, nil
+}
+
+var retryWebhookScript = redis.NewScript(`
+	local queueKey = KEYS[1]             -- Queue (wh.{dsps-webhook}.queue)
+	local deliveryKey = KEYS[2]          -- Delivery (wh.{dsps-webhook}.d.{channel}/{message})
+	local ttlSec = tonumber(ARGV[1])     -- (number) ttl [sec]
+	local member = ARGV[2]               -- (string) member of the queue
+	local visibleAt = ARGV[3]            -- (number) [UNIX epoch ms]
+	local delivery = ARGV[4]             -- (string) delivery JSON
+
+	if redis.call("exists", deliveryKey) == 0 then
+		return 0  -- Already completed
+	end
+	redis.call("set", deliveryKey, delivery, "EX", ttlSec)
+	redis.call("zadd", queueKey, visibleAt, member)
+	return 1
+`)
+
+func runRetryWebhookScript(ctx context.Context, redisCmd internal.RedisCmd, delivery domain.WebhookDelivery) error {
+	wrapped, err := wrapWebhookDelivery(delivery)
+	if err != nil {
+		return xerrors.Errorf("Unable to encode webhook delivery of message \"%s\": %w", delivery.MessageID, err)
+	}
+
+	keys := keyOfWebhookQueue()
+	member := webhookMemberOf(delivery.MessageLocator)
+	result, err := redisCmd.RunScript(
+		ctx, retryWebhookScript,
+		[]string{keys.Queue(), keys.Delivery(member)},
+		int64(webhookDeliveryTTL.Seconds()), string(member), toUnixMilli(delivery.NextAttemptAt.Time), wrapped,
+	)
+	logger.Of(ctx).Debugf(logger.CatStorage, "runRetryWebhookScript(member = %s) resulted in %v (%v)", member, result, err)
+	if err != nil {
+		return xerrors.Errorf("Failed to execute retryWebhookScript: %w", err)
+	}
+	return nil
+}
+
+var completeWebhookScript = redis.NewScript(`
+	local queueKey = KEYS[1]             -- Queue (wh.{dsps-webhook}.queue)
+	local deliveryKey = KEYS[2]          -- Delivery (wh.{dsps-webhook}.d.{channel}/{message})
+	local member = ARGV[1]               -- (string) member of the queue
+
+	redis.call("del", deliveryKey)
+	redis.call("zrem", queueKey, member)
+	return redis.status_reply("OK")
+`)
+
+func runCompleteWebhookScript(ctx context.Context, redisCmd internal.RedisCmd, msg domain.MessageLocator) error {
+	keys := keyOfWebhookQueue()
+	member := webhookMemberOf(msg)
+	result, err := redisCmd.RunScript(ctx, completeWebhookScript, []string{keys.Queue(), keys.Delivery(member)}, string(member))
+	logger.Of(ctx).Debugf(logger.CatStorage, "runCompleteWebhookScript(member = %s) resulted in %v (%v)", member, result, err)
+	if err != nil {
+		return xerrors.Errorf("Failed to execute completeWebhookScript: %w", err)
+	}
+	return nil
+}
+
+var recordWebhookDeliveryScript = redis.NewScript(`
+	local historyKey = KEYS[1]           -- WebhookHistory (c.{channel}.wh.history)
+	local record = ARGV[1]               -- (string) record JSON
+	local maxRecords = tonumber(ARGV[2]) -- (number) max count of records to retain
+	local retentionMs = ARGV[3]          -- (number) retention [ms]
+
+	redis.call("lpush", historyKey, record)
+	redis.call("ltrim", historyKey, 0, maxRecords - 1)
+	redis.call("pexpire", historyKey, retentionMs)
+	return redis.status_reply("OK")
+`)
+
+func runRecordWebhookDeliveryScript(ctx context.Context, redisCmd internal.RedisCmd, record domain.WebhookDeliveryRecord, maxRecords int, retention time.Duration) error {
+	wrapped, err := wrapWebhookDeliveryRecord(record)
+	if err != nil {
+		return xerrors.Errorf("Unable to encode webhook delivery record of message \"%s\": %w", record.MessageID, err)
+	}
+
+	result, err := redisCmd.RunScript(
+		ctx, recordWebhookDeliveryScript,
+		[]string{keyOfChannel(record.ChannelID).WebhookHistory()},
+		wrapped, maxRecords, retention.Milliseconds(),
+	)
+	logger.Of(ctx).Debugf(logger.CatStorage, "runRecordWebhookDeliveryScript(channel = %s, msgID = %s) resulted in %v (%v)", record.ChannelID, record.MessageID, result, err)
+	if err != nil {
+		return xerrors.Errorf("Failed to execute recordWebhookDeliveryScript: %w", err)
+	}
+	if result != "OK" {
+		return xerrors.Errorf("Unexpected result from recordWebhookDeliveryScript: %T(%v)", result, result)
+	}
+	return nil
+}
+
+// @returns list of record JSON
+var listWebhookDeliveryRecordsScript = redis.NewScript(`
+	local historyKey = KEYS[1]           -- WebhookHistory (c.{channel}.wh.history)
+	local max = tonumber(ARGV[1])        -- (number) max count of records to return
+
+	return redis.call("lrange", historyKey, 0, max - 1)
+`)
+
+func runListWebhookDeliveryRecordsScript(ctx context.Context, redisCmd internal.RedisCmd, channelID domain.ChannelID, max int) ([]domain.WebhookDeliveryRecord, error) {
+	result, err := redisCmd.RunScript(ctx, listWebhookDeliveryRecordsScript, []string{keyOfChannel(channelID).WebhookHistory()}, max)
+	logger.Of(ctx).Debugf(logger.CatStorage, "runListWebhookDeliveryRecordsScript(channel = %s, max = %d) resulted in %v (%v)", channelID, max, result, err)
+	if err != nil {
+		return nil, xerrors.Errorf("Failed to execute listWebhookDeliveryRecordsScript: %w", err)
+	}
+	list, ok := result.([]interface{})
+	if !ok {
+		return nil, xerrors.Errorf("Unexpected result from listWebhookDeliveryRecordsScript: %T(%v)", result, result)
+	}
+
+	records := make([]domain.WebhookDeliveryRecord, 0, len(list))
+	for _, item := range list {
+		raw, ok := item.(string)
+		if !ok {
+			return nil, xerrors.Errorf("Unexpected result from listWebhookDeliveryRecordsScript: %T(%v)", item, item)
+		}
+		record, err := unwrapWebhookDeliveryRecord(raw)
+		if err != nil {
+			logger.Of(ctx).WarnError(logger.CatStorage, "Skipping corrupted webhook delivery record", err)
+			continue
+		}
+		records = append(records, *record)
+	}
+	return records, nil
+}
@@ -24,7 +24,7 @@ func NewStorage(ctx context.Context, config *config.StoragesConfig, systemClock
 		if err != nil {
 			return nil, fmt.Errorf("Failed to initialize storage \"%s\": %w", id, err)
 		}
-		children[id] = tracing.NewTracingStorage(storage, id, deps)
+		children[id] = tracing.NewSubTracingStorage(storage, id, deps)
 	}
 
 	storage, err := multiplex.NewStorageMultiplexer(children)
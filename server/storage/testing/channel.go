@@ -2,7 +2,9 @@ package testing
 
 import (
 	"context"
+	"crypto/x509"
 	"fmt"
+	"strings"
 
 	"github.com/saiya/dsps/server/domain"
 	dspstesting "github.com/saiya/dsps/server/testing"
@@ -20,14 +22,35 @@ var StubChannelProvider domain.ChannelProvider = dspstesting.ChannelProviderFunc
 		return nil, domain.ErrInvalidChannel
 	}
 	return &stubChannel{
-		id:     id,
-		expire: StubChannelExpire,
+		id:        id,
+		expire:    StubChannelExpire,
+		retention: stubChannelRetentionOf(id),
 	}, nil
 })
 
+// StubRetentionChannelID returns random ChannelID that StubChannelProvider provides with given retention limits.
+func StubRetentionChannelID(retention domain.ChannelRetention) domain.ChannelID {
+	overflow := "drop"
+	if retention.RejectOverflow {
+		overflow = "reject"
+	}
+	return domain.ChannelID(fmt.Sprintf("retention-%d-%d-%s-%s", retention.MaxMessages, retention.MaxBytes, overflow, strings.TrimPrefix(string(randomChannelID()), "ch-")[:8]))
+}
+
+func stubChannelRetentionOf(id domain.ChannelID) domain.ChannelRetention {
+	var retention domain.ChannelRetention
+	var overflow string
+	if n, _ := fmt.Sscanf(strings.ReplaceAll(string(id), "-", " "), "retention %d %d %s", &retention.MaxMessages, &retention.MaxBytes, &overflow); n != 3 {
+		return domain.ChannelRetention{}
+	}
+	retention.RejectOverflow = (overflow == "reject")
+	return retention
+}
+
 type stubChannel struct {
-	id     domain.ChannelID
-	expire domain.Duration
+	id        domain.ChannelID
+	expire    domain.Duration
+	retention domain.ChannelRetention
 }
 
 func (c *stubChannel) String() string {
@@ -38,10 +61,38 @@ func (c *stubChannel) Expire() domain.Duration {
 	return c.expire
 }
 
+func (c *stubChannel) Retention() domain.ChannelRetention {
+	return c.retention
+}
+
+func (c *stubChannel) MaxMessageBytes() int64 {
+	return 0
+}
+
+func (c *stubChannel) RateLimits() []domain.ChannelRateLimit {
+	return nil
+}
+
 func (c *stubChannel) ValidateJwt(ctx context.Context, jwt string) error {
 	return nil
 }
 
+func (c *stubChannel) ValidateClientCert(ctx context.Context, certs []*x509.Certificate) error {
+	return domain.ErrClientCertAuthNotConfigured
+}
+
+func (c *stubChannel) AcceptsContentType(mediaType string) bool {
+	return true
+}
+
+func (c *stubChannel) ValidateMessageContent(ctx context.Context, msg domain.Message) error {
+	return nil
+}
+
+func (c *stubChannel) HasOutgoingWebhook() bool {
+	return false
+}
+
 func (c *stubChannel) SendOutgoingWebhook(ctx context.Context, msg domain.Message) error {
 	return nil
 }
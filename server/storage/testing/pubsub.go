@@ -26,6 +26,11 @@ func PubSubTest(t *testing.T, storageCtor StorageCtor) {
 	storageSubTest(t, storageCtor, "pubSubInvalidChannel", _pubSubInvalidChannelTest)
 	storageSubTest(t, storageCtor, "pubsubInvalidSubscriber", _pubsubInvalidSubscriber)
 	storageSubTest(t, storageCtor, "pubSubInvalidMessage", _pubSubInvalidMessageTest)
+	storageSubTest(t, storageCtor, "pubSubMessageAttributes", _pubSubMessageAttributesTest)
+	storageSubTest(t, storageCtor, "pubSubMessageExpiration", _pubSubMessageExpirationTest)
+	storageSubTest(t, storageCtor, "pubSubScheduledMessage", _pubSubScheduledMessageTest)
+	storageSubTest(t, storageCtor, "pubSubRetentionDropOldest", _pubSubRetentionDropOldestTest)
+	storageSubTest(t, storageCtor, "pubSubRetentionReject", _pubSubRetentionRejectTest)
 }
 
 func _pubSubScenarioTest(t *testing.T, storageCtor StorageCtor) {
@@ -451,6 +456,243 @@ func _pubSubMixedChannelPublishTest(t *testing.T, storageCtor StorageCtor) {
 	}))
 }
 
+func _pubSubMessageAttributesTest(t *testing.T, storageCtor StorageCtor) {
+	ctx := context.Background()
+	s, err := storageCtor(ctx, domain.RealSystemClock, StubChannelProvider)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { assert.NoError(t, s.Shutdown(ctx)) }()
+	storage := s.AsPubSubStorage()
+	assert.NotNil(t, storage)
+
+	sl := domain.SubscriberLocator{ChannelID: randomChannelID(), SubscriberID: "sbsc1"}
+	assert.NoError(t, storage.NewSubscriber(ctx, sl))
+	msgs := []domain.Message{
+		{
+			MessageLocator: domain.MessageLocator{ChannelID: sl.ChannelID, MessageID: "msg1"},
+			Content:        []byte(`{}`),
+			Metadata:       map[string]string{"content-type": "application/json", "schema-version": "2"},
+			TraceContext: domain.TraceContext{
+				TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+				TraceState:  "congo=t61rcWkgMzE",
+			},
+		},
+		{
+			MessageLocator: domain.MessageLocator{ChannelID: sl.ChannelID, MessageID: "msg2"},
+			Content:        []byte(`{}`),
+		},
+		{
+			MessageLocator: domain.MessageLocator{ChannelID: sl.ChannelID, MessageID: "msg3"},
+			Content:        domain.NewBinaryContent([]byte("hello")),
+			ContentType:    "text/plain",
+		},
+	}
+	assert.NoError(t, storage.PublishMessages(ctx, msgs))
+	if received, _, _, err := storage.FetchMessages(ctx, sl, 10, dspstesting.MakeDuration("0ms")); assert.NoError(t, err) {
+		assert.Equal(t, msgs, received)
+	}
+}
+
+func _pubSubMessageExpirationTest(t *testing.T, storageCtor StorageCtor) {
+	ctx := context.Background()
+	s, err := storageCtor(ctx, domain.RealSystemClock, StubChannelProvider)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { assert.NoError(t, s.Shutdown(ctx)) }()
+	storage := s.AsPubSubStorage()
+	assert.NotNil(t, storage)
+
+	sl := domain.SubscriberLocator{ChannelID: randomChannelID(), SubscriberID: "sbsc1"}
+	assert.NoError(t, storage.NewSubscriber(ctx, sl))
+	msgs := []domain.Message{
+		{
+			MessageLocator: domain.MessageLocator{ChannelID: sl.ChannelID, MessageID: "msg1"},
+			Content:        []byte(`{}`),
+			ExpireAt:       domain.Time{Time: time.Now().Add(200 * time.Millisecond).Truncate(time.Millisecond)}, // Storage could have millisecond precision
+		},
+		{
+			MessageLocator: domain.MessageLocator{ChannelID: sl.ChannelID, MessageID: "msg2"},
+			Content:        []byte(`{}`),
+		},
+	}
+	assert.NoError(t, storage.PublishMessages(ctx, msgs))
+	if received, _, _, err := storage.FetchMessages(ctx, sl, 10, dspstesting.MakeDuration("0ms")); assert.NoError(t, err) {
+		dspstesting.MessagesEqual(t, msgs, received)
+	}
+
+	time.Sleep(400 * time.Millisecond)
+	if received, _, _, err := storage.FetchMessages(ctx, sl, 10, dspstesting.MakeDuration("0ms")); assert.NoError(t, err) {
+		dspstesting.MessagesEqual(t, msgs[1:], received)
+	}
+}
+
+func _pubSubScheduledMessageTest(t *testing.T, storageCtor StorageCtor) {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second) // Prevent test blocks
+	defer ctxCancel()
+	s, err := storageCtor(ctx, domain.RealSystemClock, StubChannelProvider)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { assert.NoError(t, s.Shutdown(ctx)) }()
+	storage := s.AsPubSubStorage()
+	assert.NotNil(t, storage)
+
+	sl := domain.SubscriberLocator{ChannelID: randomChannelID(), SubscriberID: "sbsc1"}
+	assert.NoError(t, storage.NewSubscriber(ctx, sl))
+	deliverAt := time.Now().Add(500 * time.Millisecond).Truncate(time.Millisecond) // Storage could have millisecond precision
+	scheduled := domain.Message{
+		MessageLocator: domain.MessageLocator{ChannelID: sl.ChannelID, MessageID: "msg1"},
+		Content:        []byte(`{"scheduled":true}`),
+		DeliverAt:      domain.Time{Time: deliverAt},
+	}
+	immediate := domain.Message{
+		MessageLocator: domain.MessageLocator{ChannelID: sl.ChannelID, MessageID: "msg2"},
+		Content:        []byte(`{}`),
+	}
+	duplicated := domain.Message{
+		MessageLocator: scheduled.MessageLocator,
+		Content:        []byte(`{"scheduled":false}`),
+	}
+	assert.NoError(t, storage.PublishMessages(ctx, []domain.Message{scheduled, immediate}))
+	assert.NoError(t, storage.PublishMessages(ctx, []domain.Message{duplicated}))
+
+	// Scheduled message is hidden until DeliverAt
+	received, _, ackHandle, err := storage.FetchMessages(ctx, sl, 10, dspstesting.MakeDuration("0ms"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	dspstesting.MessagesEqual(t, []domain.Message{immediate}, received)
+	assert.NoError(t, storage.AcknowledgeMessages(ctx, ackHandle))
+	if isOld, err := storage.IsOldMessages(ctx, sl, []domain.MessageLocator{scheduled.MessageLocator}); assert.NoError(t, err) {
+		assert.False(t, isOld[scheduled.MessageLocator])
+	}
+
+	// Long polling wakes up when the scheduled message becomes deliverable
+	received, _, _, err = storage.FetchMessages(ctx, sl, 10, dspstesting.MakeDuration("3s"))
+	receivedAt := time.Now()
+	if assert.NoError(t, err) {
+		dspstesting.MessagesEqual(t, []domain.Message{scheduled}, received)
+		assert.False(t, receivedAt.Before(deliverAt))
+		assert.Less(t, receivedAt.Sub(deliverAt).Milliseconds(), int64(1000))
+	}
+}
+
+func newRetentionTestMessages(ch domain.ChannelID, count int) []domain.Message {
+	msgs := make([]domain.Message, count)
+	for i := range msgs {
+		msgs[i] = domain.Message{
+			MessageLocator: domain.MessageLocator{ChannelID: ch, MessageID: domain.MessageID(fmt.Sprintf("msg-%d", i))},
+			Content:        []byte(fmt.Sprintf(`{"i":%d}`, i)), // 7 bytes
+		}
+	}
+	return msgs
+}
+
+func _pubSubRetentionDropOldestTest(t *testing.T, storageCtor StorageCtor) {
+	ctx := context.Background()
+	s, err := storageCtor(ctx, domain.RealSystemClock, StubChannelProvider)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { assert.NoError(t, s.Shutdown(ctx)) }()
+	storage := s.AsPubSubStorage()
+	assert.NotNil(t, storage)
+
+	// maxMessages
+	sl := domain.SubscriberLocator{ChannelID: StubRetentionChannelID(domain.ChannelRetention{MaxMessages: 2}), SubscriberID: "sbsc1"}
+	assert.NoError(t, storage.NewSubscriber(ctx, sl))
+	msgs := newRetentionTestMessages(sl.ChannelID, 3)
+	assert.NoError(t, storage.PublishMessages(ctx, msgs))
+	assert.NoError(t, storage.PublishMessages(ctx, msgs[0:1])) // Dropped message still dedupes
+	if received, _, _, err := storage.FetchMessages(ctx, sl, 10, dspstesting.MakeDuration("0ms")); assert.NoError(t, err) {
+		dspstesting.MessagesEqual(t, msgs[1:], received)
+	}
+
+	// maxBytes
+	sl = domain.SubscriberLocator{ChannelID: StubRetentionChannelID(domain.ChannelRetention{MaxBytes: 15}), SubscriberID: "sbsc1"}
+	assert.NoError(t, storage.NewSubscriber(ctx, sl))
+	msgs = newRetentionTestMessages(sl.ChannelID, 3)
+	for _, msg := range msgs {
+		assert.NoError(t, storage.PublishMessages(ctx, []domain.Message{msg}))
+	}
+	if received, _, _, err := storage.FetchMessages(ctx, sl, 10, dspstesting.MakeDuration("0ms")); assert.NoError(t, err) {
+		dspstesting.MessagesEqual(t, msgs[1:], received)
+	}
+
+	// Scheduled messages are capped to the limits, earliest ones are dropped
+	sl = domain.SubscriberLocator{ChannelID: StubRetentionChannelID(domain.ChannelRetention{MaxMessages: 2}), SubscriberID: "sbsc1"}
+	assert.NoError(t, storage.NewSubscriber(ctx, sl))
+	msgs = newRetentionTestMessages(sl.ChannelID, 3)
+	deliverAt := time.Now().Add(200 * time.Millisecond).Truncate(time.Millisecond) // Storage could have millisecond precision
+	for i := range msgs {
+		msgs[i].DeliverAt = domain.Time{Time: deliverAt.Add(time.Duration(i) * time.Millisecond)}
+		assert.NoError(t, storage.PublishMessages(ctx, msgs[i:i+1]))
+	}
+	assert.NoError(t, storage.PublishMessages(ctx, msgs[0:1])) // Dropped message still dedupes
+	time.Sleep(400 * time.Millisecond)
+	if received, _, _, err := storage.FetchMessages(ctx, sl, 10, dspstesting.MakeDuration("0ms")); assert.NoError(t, err) {
+		dspstesting.MessagesEqual(t, msgs[1:], received)
+	}
+}
+
+func _pubSubRetentionRejectTest(t *testing.T, storageCtor StorageCtor) {
+	ctx := context.Background()
+	s, err := storageCtor(ctx, domain.RealSystemClock, StubChannelProvider)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { assert.NoError(t, s.Shutdown(ctx)) }()
+	storage := s.AsPubSubStorage()
+	assert.NotNil(t, storage)
+
+	// maxMessages
+	sl := domain.SubscriberLocator{ChannelID: StubRetentionChannelID(domain.ChannelRetention{MaxMessages: 2, RejectOverflow: true}), SubscriberID: "sbsc1"}
+	assert.NoError(t, storage.NewSubscriber(ctx, sl))
+	msgs := newRetentionTestMessages(sl.ChannelID, 3)
+	assert.NoError(t, storage.PublishMessages(ctx, msgs[0:2]))
+	dspstesting.IsError(t, domain.ErrChannelMaxMessagesExceeded, storage.PublishMessages(ctx, msgs[2:3]))
+	assert.NoError(t, storage.PublishMessages(ctx, msgs[0:1])) // Duplicated message is not rejected
+	if received, _, _, err := storage.FetchMessages(ctx, sl, 10, dspstesting.MakeDuration("0ms")); assert.NoError(t, err) {
+		dspstesting.MessagesEqual(t, msgs[0:2], received)
+	}
+
+	// maxBytes
+	sl = domain.SubscriberLocator{ChannelID: StubRetentionChannelID(domain.ChannelRetention{MaxBytes: 15, RejectOverflow: true}), SubscriberID: "sbsc1"}
+	assert.NoError(t, storage.NewSubscriber(ctx, sl))
+	msgs = newRetentionTestMessages(sl.ChannelID, 3)
+	assert.NoError(t, storage.PublishMessages(ctx, msgs[0:2]))
+	dspstesting.IsError(t, domain.ErrChannelMaxBytesExceeded, storage.PublishMessages(ctx, msgs[2:3]))
+
+	// Expired messages are not counted
+	sl = domain.SubscriberLocator{ChannelID: StubRetentionChannelID(domain.ChannelRetention{MaxMessages: 1, RejectOverflow: true}), SubscriberID: "sbsc1"}
+	assert.NoError(t, storage.NewSubscriber(ctx, sl))
+	msgs = newRetentionTestMessages(sl.ChannelID, 2)
+	msgs[0].ExpireAt = domain.Time{Time: time.Now().Add(200 * time.Millisecond)}
+	assert.NoError(t, storage.PublishMessages(ctx, msgs[0:1]))
+	dspstesting.IsError(t, domain.ErrChannelMaxMessagesExceeded, storage.PublishMessages(ctx, msgs[1:2]))
+	time.Sleep(400 * time.Millisecond)
+	assert.NoError(t, storage.PublishMessages(ctx, msgs[1:2]))
+
+	// Scheduled messages are counted
+	sl = domain.SubscriberLocator{ChannelID: StubRetentionChannelID(domain.ChannelRetention{MaxMessages: 2, RejectOverflow: true}), SubscriberID: "sbsc1"}
+	assert.NoError(t, storage.NewSubscriber(ctx, sl))
+	msgs = newRetentionTestMessages(sl.ChannelID, 3)
+	msgs[0].DeliverAt = domain.Time{Time: time.Now().Add(time.Minute)}
+	msgs[2].DeliverAt = domain.Time{Time: time.Now().Add(time.Minute)}
+	assert.NoError(t, storage.PublishMessages(ctx, msgs[0:2]))
+	dspstesting.IsError(t, domain.ErrChannelMaxMessagesExceeded, storage.PublishMessages(ctx, msgs[2:3]))
+	assert.NoError(t, storage.PublishMessages(ctx, msgs[0:1])) // Duplicated message is not rejected
+
+	sl = domain.SubscriberLocator{ChannelID: StubRetentionChannelID(domain.ChannelRetention{MaxBytes: 15, RejectOverflow: true}), SubscriberID: "sbsc1"}
+	assert.NoError(t, storage.NewSubscriber(ctx, sl))
+	msgs = newRetentionTestMessages(sl.ChannelID, 3)
+	msgs[0].DeliverAt = domain.Time{Time: time.Now().Add(time.Minute)}
+	assert.NoError(t, storage.PublishMessages(ctx, msgs[0:2]))
+	dspstesting.IsError(t, domain.ErrChannelMaxBytesExceeded, storage.PublishMessages(ctx, msgs[2:3]))
+}
+
 func _pubSubInvalidChannelTest(t *testing.T, storageCtor StorageCtor) {
 	ctx := context.Background()
 	s, err := storageCtor(ctx, domain.RealSystemClock, StubChannelProvider)
@@ -0,0 +1,73 @@
+package testing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+// RateLimitTest tests common Storage behaviors
+func RateLimitTest(t *testing.T, storageCtor StorageCtor) {
+	storageSubTest(t, storageCtor, "rateLimitBurst", _rateLimitBurstTest)
+	storageSubTest(t, storageCtor, "rateLimitRefill", _rateLimitRefillTest)
+}
+
+func _rateLimitBurstTest(t *testing.T, storageCtor StorageCtor) {
+	ctx := context.Background()
+	s, err := storageCtor(ctx, domain.RealSystemClock, StubChannelProvider)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { assert.NoError(t, s.Shutdown(ctx)) }()
+	storage := s.AsRateLimitStorage()
+	assert.NotNil(t, storage)
+
+	key := string(randomChannelID())
+	limit := domain.RateLimit{Rate: 1, Per: domain.Duration{Duration: time.Hour}, Burst: 3}
+	for i := 0; i < 3; i++ {
+		wait, err := storage.TakeRateLimitToken(ctx, key, limit)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Duration(0), wait.Duration)
+	}
+	wait, err := storage.TakeRateLimitToken(ctx, key, limit)
+	assert.NoError(t, err)
+	assert.InDelta(t, float64(time.Hour), float64(wait.Duration), float64(time.Minute))
+
+	// Other buckets are not affected
+	wait, err = storage.TakeRateLimitToken(ctx, key+"-2", limit)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), wait.Duration)
+}
+
+func _rateLimitRefillTest(t *testing.T, storageCtor StorageCtor) {
+	ctx := context.Background()
+	s, err := storageCtor(ctx, domain.RealSystemClock, StubChannelProvider)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { assert.NoError(t, s.Shutdown(ctx)) }()
+	storage := s.AsRateLimitStorage()
+
+	key := string(randomChannelID())
+	limit := domain.RateLimit{Rate: 2, Per: domain.Duration{Duration: 400 * time.Millisecond}, Burst: 1}
+	wait, err := storage.TakeRateLimitToken(ctx, key, limit)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), wait.Duration)
+
+	// Rejected request does not consume token
+	for i := 0; i < 3; i++ {
+		wait, err = storage.TakeRateLimitToken(ctx, key, limit)
+		assert.NoError(t, err)
+		assert.Greater(t, int64(wait.Duration), int64(0))
+		assert.LessOrEqual(t, int64(wait.Duration), int64(200*time.Millisecond))
+	}
+
+	time.Sleep(wait.Duration + 10*time.Millisecond)
+	wait, err = storage.TakeRateLimitToken(ctx, key, limit)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), wait.Duration)
+}
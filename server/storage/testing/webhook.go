@@ -0,0 +1,262 @@
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/domain"
+	dspstesting "github.com/saiya/dsps/server/testing"
+)
+
+// WebhookTest tests common Storage behaviors
+func WebhookTest(t *testing.T, storageCtor StorageCtor) {
+	storageSubTest(t, storageCtor, "webhookScenario", _webhookScenarioTest)
+	storageSubTest(t, storageCtor, "webhookLeaseExpiration", _webhookLeaseExpirationTest)
+	storageSubTest(t, storageCtor, "webhookLeaseMax", _webhookLeaseMaxTest)
+	storageSubTest(t, storageCtor, "webhookHistory", _webhookHistoryTest)
+	storageSubTest(t, storageCtor, "webhookHistoryRetention", _webhookHistoryRetentionTest)
+}
+
+func _webhookScenarioTest(t *testing.T, storageCtor StorageCtor) {
+	ctx := context.Background()
+	s, err := storageCtor(ctx, domain.RealSystemClock, StubChannelProvider)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { assert.NoError(t, s.Shutdown(ctx)) }()
+	storage := s.AsWebhookStorage()
+	assert.NotNil(t, storage)
+
+	ch := randomChannelID()
+	now := time.Now()
+	due := _webhookDelivery(ch, "msg-1", now.Add(-1*time.Second))
+	future := _webhookDelivery(ch, "msg-2", now.Add(1*time.Hour))
+	assert.NoError(t, storage.EnqueueWebhookDeliveries(ctx, []domain.WebhookDelivery{due, future}))
+
+	// Only deliveries whose NextAttemptAt has come are returned
+	leased := _leaseWebhookDeliveries(t, storage, ch, "1m")
+	if assert.Equal(t, 1, len(leased)) {
+		assert.Equal(t, due.MessageLocator, leased[0].MessageLocator)
+		assert.JSONEq(t, string(due.Content), string(leased[0].Content))
+		assert.Equal(t, 0, leased[0].Attempts)
+		assert.Equal(t, "", leased[0].LastError)
+	}
+	// Leased delivery is hidden
+	assert.Equal(t, 0, len(_leaseWebhookDeliveries(t, storage, ch, "1m")))
+
+	// Retry returns the delivery to the queue
+	retry := leased[0]
+	retry.Attempts = 1
+	retry.LastError = "test error"
+	retry.NextAttemptAt = domain.Time{Time: now.Add(-1 * time.Second)}
+	assert.NoError(t, storage.RetryWebhookDelivery(ctx, retry))
+	leased = _leaseWebhookDeliveries(t, storage, ch, "1m")
+	if assert.Equal(t, 1, len(leased)) {
+		assert.Equal(t, due.MessageLocator, leased[0].MessageLocator)
+		assert.Equal(t, 1, leased[0].Attempts)
+		assert.Equal(t, "test error", leased[0].LastError)
+	}
+
+	// Completed delivery never be returned
+	assert.NoError(t, storage.CompleteWebhookDelivery(ctx, due.MessageLocator))
+	assert.NoError(t, storage.CompleteWebhookDelivery(ctx, due.MessageLocator)) // Must be idempotent
+	assert.NoError(t, storage.RetryWebhookDelivery(ctx, retry))                 // Must not resurrect completed delivery
+	assert.Equal(t, 0, len(_leaseWebhookDeliveries(t, storage, ch, "1m")))
+
+	// Enqueue overwrites existing delivery
+	future.NextAttemptAt = domain.Time{Time: now.Add(-1 * time.Second)}
+	assert.NoError(t, storage.EnqueueWebhookDeliveries(ctx, []domain.WebhookDelivery{future}))
+	leased = _leaseWebhookDeliveries(t, storage, ch, "1m")
+	if assert.Equal(t, 1, len(leased)) {
+		assert.Equal(t, future.MessageLocator, leased[0].MessageLocator)
+	}
+	assert.NoError(t, storage.CompleteWebhookDelivery(ctx, future.MessageLocator))
+}
+
+func _webhookLeaseExpirationTest(t *testing.T, storageCtor StorageCtor) {
+	ctx := context.Background()
+	s, err := storageCtor(ctx, domain.RealSystemClock, StubChannelProvider)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { assert.NoError(t, s.Shutdown(ctx)) }()
+	storage := s.AsWebhookStorage()
+	assert.NotNil(t, storage)
+
+	ch := randomChannelID()
+	delivery := _webhookDelivery(ch, "msg-1", time.Now().Add(-1*time.Second))
+	assert.NoError(t, storage.EnqueueWebhookDeliveries(ctx, []domain.WebhookDelivery{delivery}))
+
+	assert.Equal(t, 1, len(_leaseWebhookDeliveries(t, storage, ch, "50ms")))
+	assert.Equal(t, 0, len(_leaseWebhookDeliveries(t, storage, ch, "50ms")))
+	time.Sleep(100 * time.Millisecond)
+	// Lease expired, storage returns the delivery again
+	assert.Equal(t, 1, len(_leaseWebhookDeliveries(t, storage, ch, "1m")))
+	assert.NoError(t, storage.CompleteWebhookDelivery(ctx, delivery.MessageLocator))
+}
+
+func _webhookLeaseMaxTest(t *testing.T, storageCtor StorageCtor) {
+	ctx := context.Background()
+	s, err := storageCtor(ctx, domain.RealSystemClock, StubChannelProvider)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { assert.NoError(t, s.Shutdown(ctx)) }()
+	storage := s.AsWebhookStorage()
+	assert.NotNil(t, storage)
+
+	ch := randomChannelID()
+	deliveries := make([]domain.WebhookDelivery, 0, 5)
+	for i := 0; i < 5; i++ {
+		deliveries = append(deliveries, _webhookDelivery(ch, domain.MessageID(fmt.Sprintf("msg-%d", i)), time.Now().Add(-1*time.Second)))
+	}
+	assert.NoError(t, storage.EnqueueWebhookDeliveries(ctx, deliveries))
+
+	leased, err := storage.LeaseWebhookDeliveries(ctx, 2, dspstesting.MakeDuration("1m"))
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(leased), 2)
+
+	// Eventually all deliveries are leased exactly once
+	remaining := _leaseWebhookDeliveries(t, storage, ch, "1m")
+	assert.Equal(t, 5, len(remaining)+_countWebhookDeliveriesOf(leased, ch))
+	for _, delivery := range deliveries {
+		assert.NoError(t, storage.CompleteWebhookDelivery(ctx, delivery.MessageLocator))
+	}
+}
+
+func _webhookHistoryTest(t *testing.T, storageCtor StorageCtor) {
+	ctx := context.Background()
+	s, err := storageCtor(ctx, domain.RealSystemClock, StubChannelProvider)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { assert.NoError(t, s.Shutdown(ctx)) }()
+	storage := s.AsWebhookStorage()
+	assert.NotNil(t, storage)
+
+	ch := randomChannelID()
+	records, err := storage.ListWebhookDeliveryRecords(ctx, ch, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(records))
+
+	now := time.Now().Truncate(time.Millisecond)
+	first := _webhookDeliveryRecord(ch, "msg-1", 1, now)
+	first.Result = domain.WebhookDeliveryRetrying
+	first.Error = "status code 500 returned"
+	first.Requests = []domain.WebhookRequestRecord{
+		{URL: "http://localhost/a", StatusCode: 500, Latency: dspstesting.MakeDuration("120ms"), Error: "status code 500 returned"},
+		{URL: "http://localhost/b", StatusCode: 200, Latency: dspstesting.MakeDuration("30ms")},
+	}
+	second := _webhookDeliveryRecord(ch, "msg-1", 2, now.Add(1*time.Second))
+	third := _webhookDeliveryRecord(ch, "msg-2", 1, now.Add(2*time.Second))
+	for _, record := range []domain.WebhookDeliveryRecord{first, second, third} {
+		assert.NoError(t, storage.RecordWebhookDelivery(ctx, record, 10, dspstesting.MakeDuration("1m")))
+	}
+	assert.NoError(t, storage.RecordWebhookDelivery(ctx, _webhookDeliveryRecord(randomChannelID(), "msg-1", 1, now), 10, dspstesting.MakeDuration("1m")))
+
+	// Latest first, only records of the channel
+	records, err = storage.ListWebhookDeliveryRecords(ctx, ch, 10)
+	assert.NoError(t, err)
+	if assert.Equal(t, 3, len(records)) {
+		_assertWebhookDeliveryRecord(t, third, records[0])
+		_assertWebhookDeliveryRecord(t, second, records[1])
+		_assertWebhookDeliveryRecord(t, first, records[2])
+	}
+	records, err = storage.ListWebhookDeliveryRecords(ctx, ch, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(records))
+
+	// Oldest records are discarded
+	assert.NoError(t, storage.RecordWebhookDelivery(ctx, _webhookDeliveryRecord(ch, "msg-3", 1, now.Add(3*time.Second)), 2, dspstesting.MakeDuration("1m")))
+	records, err = storage.ListWebhookDeliveryRecords(ctx, ch, 10)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(records)) {
+		assert.Equal(t, domain.MessageID("msg-3"), records[0].MessageID)
+		assert.Equal(t, domain.MessageID("msg-2"), records[1].MessageID)
+	}
+}
+
+func _webhookHistoryRetentionTest(t *testing.T, storageCtor StorageCtor) {
+	ctx := context.Background()
+	s, err := storageCtor(ctx, domain.RealSystemClock, StubChannelProvider)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { assert.NoError(t, s.Shutdown(ctx)) }()
+	storage := s.AsWebhookStorage()
+	assert.NotNil(t, storage)
+
+	ch := randomChannelID()
+	assert.NoError(t, storage.RecordWebhookDelivery(ctx, _webhookDeliveryRecord(ch, "msg-1", 1, time.Now()), 10, dspstesting.MakeDuration("100ms")))
+	records, err := storage.ListWebhookDeliveryRecords(ctx, ch, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(records))
+
+	time.Sleep(200 * time.Millisecond)
+	records, err = storage.ListWebhookDeliveryRecords(ctx, ch, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(records))
+}
+
+func _webhookDeliveryRecord(ch domain.ChannelID, msgID domain.MessageID, attempt int, attemptedAt time.Time) domain.WebhookDeliveryRecord {
+	return domain.WebhookDeliveryRecord{
+		Message: domain.Message{
+			MessageLocator: domain.MessageLocator{ChannelID: ch, MessageID: msgID},
+			Content:        json.RawMessage(fmt.Sprintf(`{"msg":"%s"}`, msgID)),
+		},
+		Attempt:     attempt,
+		Result:      domain.WebhookDeliverySucceeded,
+		AttemptedAt: domain.Time{Time: attemptedAt},
+		Latency:     dspstesting.MakeDuration("150ms"),
+		Requests:    []domain.WebhookRequestRecord{},
+	}
+}
+
+func _assertWebhookDeliveryRecord(t *testing.T, expected domain.WebhookDeliveryRecord, actual domain.WebhookDeliveryRecord) {
+	assert.Equal(t, expected.MessageLocator, actual.MessageLocator)
+	assert.JSONEq(t, string(expected.Content), string(actual.Content))
+	assert.Equal(t, expected.Attempt, actual.Attempt)
+	assert.Equal(t, expected.Result, actual.Result)
+	assert.True(t, expected.AttemptedAt.Equal(actual.AttemptedAt.Time), "expected %v, actual %v", expected.AttemptedAt, actual.AttemptedAt)
+	assert.Equal(t, expected.Latency, actual.Latency)
+	assert.Equal(t, expected.Error, actual.Error)
+	assert.Equal(t, expected.Requests, actual.Requests)
+}
+
+func _webhookDelivery(ch domain.ChannelID, msgID domain.MessageID, nextAttemptAt time.Time) domain.WebhookDelivery {
+	return domain.WebhookDelivery{
+		Message: domain.Message{
+			MessageLocator: domain.MessageLocator{ChannelID: ch, MessageID: msgID},
+			Content:        json.RawMessage(fmt.Sprintf(`{"msg":"%s"}`, msgID)),
+		},
+		NextAttemptAt: domain.Time{Time: nextAttemptAt},
+	}
+}
+
+// Leases deliveries of the channel, ignores deliveries of other channels (e.g. enqueued by other tests sharing the storage).
+func _leaseWebhookDeliveries(t *testing.T, storage domain.WebhookStorage, ch domain.ChannelID, lease string) []domain.WebhookDelivery {
+	leased, err := storage.LeaseWebhookDeliveries(context.Background(), 1000, dspstesting.MakeDuration(lease))
+	assert.NoError(t, err)
+	result := make([]domain.WebhookDelivery, 0, len(leased))
+	for _, delivery := range leased {
+		if delivery.ChannelID == ch {
+			result = append(result, delivery)
+		}
+	}
+	return result
+}
+
+func _countWebhookDeliveriesOf(deliveries []domain.WebhookDelivery, ch domain.ChannelID) int {
+	count := 0
+	for _, delivery := range deliveries {
+		if delivery.ChannelID == ch {
+			count++
+		}
+	}
+	return count
+}
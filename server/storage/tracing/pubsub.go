@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/telemetry"
 )
 
 func (ts *tracingStorage) NewSubscriber(ctx context.Context, sl domain.SubscriberLocator) error {
@@ -23,21 +24,41 @@ func (ts *tracingStorage) RemoveSubscriber(ctx context.Context, sl domain.Subscr
 func (ts *tracingStorage) PublishMessages(ctx context.Context, msgs []domain.Message) error {
 	ctx, end := ts.t.StartStorageSpan(ctx, ts.id, "PublishMessages")
 	defer end()
-	return ts.pubsub.PublishMessages(ctx, msgs)
+	err := ts.pubsub.PublishMessages(ctx, msgs)
+	if err == nil && ts.recordMessages {
+		ts.t.CountPublishedMessages(ctx, ts.id, len(msgs))
+	}
+	return err
 }
 
 func (ts *tracingStorage) FetchMessages(ctx context.Context, sl domain.SubscriberLocator, max int, waituntil domain.Duration) (messages []domain.Message, moreMessages bool, ackHandle domain.AckHandle, err error) {
 	ctx, end := ts.t.StartStorageSpan(ctx, ts.id, "FetchMessages")
 	ts.t.SetSubscriberAttributes(ctx, sl)
 	defer end()
-	return ts.pubsub.FetchMessages(ctx, sl, max, waituntil)
+	if waituntil.Duration > 0 && ts.recordMessages {
+		defer ts.t.StartLongPolling(ctx, ts.id)()
+	}
+	messages, moreMessages, ackHandle, err = ts.pubsub.FetchMessages(ctx, sl, max, waituntil)
+	if err == nil && ts.recordMessages {
+		ts.t.CountFetchedMessages(ctx, ts.id, len(messages))
+		for _, msg := range messages {
+			// Link each message to its publisher
+			_, endMsg := ts.t.StartMessageSpan(ctx, telemetry.Fetch, msg)
+			endMsg()
+		}
+	}
+	return
 }
 
 func (ts *tracingStorage) AcknowledgeMessages(ctx context.Context, handle domain.AckHandle) error {
 	ctx, end := ts.t.StartStorageSpan(ctx, ts.id, "AcknowledgeMessages")
 	ts.t.SetSubscriberAttributes(ctx, handle.SubscriberLocator)
 	defer end()
-	return ts.pubsub.AcknowledgeMessages(ctx, handle)
+	err := ts.pubsub.AcknowledgeMessages(ctx, handle)
+	if err == nil && ts.recordMessages {
+		ts.t.CountAcknowledgement(ctx, ts.id)
+	}
+	return err
 }
 
 func (ts *tracingStorage) IsOldMessages(ctx context.Context, sl domain.SubscriberLocator, msgs []domain.MessageLocator) (map[domain.MessageLocator]bool, error) {
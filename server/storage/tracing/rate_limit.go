@@ -0,0 +1,13 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+func (ts *tracingStorage) TakeRateLimitToken(ctx context.Context, key string, limit domain.RateLimit) (domain.Duration, error) {
+	ctx, end := ts.t.StartStorageSpan(ctx, ts.id, "TakeRateLimitToken")
+	defer end()
+	return ts.rateLimit.TakeRateLimitToken(ctx, key, limit)
+}
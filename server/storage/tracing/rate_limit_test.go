@@ -0,0 +1,22 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+func TestRateLimitTrace(t *testing.T) {
+	tr := testTracing(t, func(s domain.Storage) {
+		_, err := s.AsRateLimitStorage().TakeRateLimitToken(context.Background(), "test", domain.RateLimit{Rate: 1, Per: domain.Duration{Duration: time.Second}, Burst: 1})
+		assert.NoError(t, err)
+	})
+	tr.OT.AssertSpanBy(trace.SpanKindInternal, "DSPS storage TakeRateLimitToken", map[string]interface{}{
+		"dsps.storage.id": "test",
+	})
+}
@@ -12,20 +12,38 @@ type tracingStorage struct {
 	id domain.StorageID
 	t  *telemetry.Telemetry
 
-	s      domain.Storage
-	pubsub domain.PubSubStorage
-	jwt    domain.JwtStorage
+	recordMessages bool // Record message counters and long polling metrics, false for sub storages to avoid double counting with the root
+
+	s         domain.Storage
+	pubsub    domain.PubSubStorage
+	jwt       domain.JwtStorage
+	webhook   domain.WebhookStorage
+	rateLimit domain.RateLimitStorage
 }
 
 // NewTracingStorage wraps given Storage to trace calls
 func NewTracingStorage(s domain.Storage, id domain.StorageID, deps deps.StorageDeps) domain.Storage {
+	return newTracingStorage(s, id, deps, true)
+}
+
+// NewSubTracingStorage wraps given sub storage of the multiplexer to trace calls.
+// Unlike NewTracingStorage, it does not record message counters and long polling metrics because the root storage records them.
+func NewSubTracingStorage(s domain.Storage, id domain.StorageID, deps deps.StorageDeps) domain.Storage {
+	return newTracingStorage(s, id, deps, false)
+}
+
+func newTracingStorage(s domain.Storage, id domain.StorageID, deps deps.StorageDeps, recordMessages bool) domain.Storage {
 	return &tracingStorage{
 		id: id,
 		t:  deps.Telemetry,
 
-		s:      s,
-		pubsub: s.AsPubSubStorage(),
-		jwt:    s.AsJwtStorage(),
+		recordMessages: recordMessages,
+
+		s:         s,
+		pubsub:    s.AsPubSubStorage(),
+		jwt:       s.AsJwtStorage(),
+		webhook:   s.AsWebhookStorage(),
+		rateLimit: s.AsRateLimitStorage(),
 	}
 }
 
@@ -43,6 +61,20 @@ func (ts *tracingStorage) AsJwtStorage() domain.JwtStorage {
 	return ts
 }
 
+func (ts *tracingStorage) AsWebhookStorage() domain.WebhookStorage {
+	if ts.webhook == nil {
+		return nil
+	}
+	return ts
+}
+
+func (ts *tracingStorage) AsRateLimitStorage() domain.RateLimitStorage {
+	if ts.rateLimit == nil {
+		return nil
+	}
+	return ts
+}
+
 func (ts *tracingStorage) String() string {
 	return ts.s.String()
 }
@@ -85,3 +85,22 @@ func TestJwt(t *testing.T) {
 		}))
 	})
 }
+
+func TestWebhook(t *testing.T) {
+	telemetry.WithStubTracing(t, func(telemetry *telemetry.Telemetry) {
+		WebhookTest(t, onmemoryTracingCtor(t)(telemetry, config.OnmemoryStorageConfig{
+			DisablePubSub: true,
+			DisableJwt:    true,
+		}))
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	telemetry.WithStubTracing(t, func(telemetry *telemetry.Telemetry) {
+		RateLimitTest(t, onmemoryTracingCtor(t)(telemetry, config.OnmemoryStorageConfig{
+			DisablePubSub:  true,
+			DisableJwt:     true,
+			DisableWebhook: true,
+		}))
+	})
+}
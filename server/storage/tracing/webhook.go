@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+func (ts *tracingStorage) EnqueueWebhookDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	ctx, end := ts.t.StartStorageSpan(ctx, ts.id, "EnqueueWebhookDeliveries")
+	defer end()
+	return ts.webhook.EnqueueWebhookDeliveries(ctx, deliveries)
+}
+
+func (ts *tracingStorage) LeaseWebhookDeliveries(ctx context.Context, max int, lease domain.Duration) ([]domain.WebhookDelivery, error) {
+	ctx, end := ts.t.StartStorageSpan(ctx, ts.id, "LeaseWebhookDeliveries")
+	defer end()
+	return ts.webhook.LeaseWebhookDeliveries(ctx, max, lease)
+}
+
+func (ts *tracingStorage) CompleteWebhookDelivery(ctx context.Context, msg domain.MessageLocator) error {
+	ctx, end := ts.t.StartStorageSpan(ctx, ts.id, "CompleteWebhookDelivery")
+	ts.t.SetMessageAttributes(ctx, msg)
+	defer end()
+	return ts.webhook.CompleteWebhookDelivery(ctx, msg)
+}
+
+func (ts *tracingStorage) RetryWebhookDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	ctx, end := ts.t.StartStorageSpan(ctx, ts.id, "RetryWebhookDelivery")
+	ts.t.SetMessageAttributes(ctx, delivery.MessageLocator)
+	defer end()
+	return ts.webhook.RetryWebhookDelivery(ctx, delivery)
+}
+
+func (ts *tracingStorage) RecordWebhookDelivery(ctx context.Context, record domain.WebhookDeliveryRecord, maxRecords int, retention domain.Duration) error {
+	ctx, end := ts.t.StartStorageSpan(ctx, ts.id, "RecordWebhookDelivery")
+	ts.t.SetMessageAttributes(ctx, record.MessageLocator)
+	defer end()
+	return ts.webhook.RecordWebhookDelivery(ctx, record, maxRecords, retention)
+}
+
+func (ts *tracingStorage) ListWebhookDeliveryRecords(ctx context.Context, channelID domain.ChannelID, max int) ([]domain.WebhookDeliveryRecord, error) {
+	ctx, end := ts.t.StartStorageSpan(ctx, ts.id, "ListWebhookDeliveryRecords")
+	ts.t.SetChannelAttributes(ctx, channelID)
+	defer end()
+	return ts.webhook.ListWebhookDeliveryRecords(ctx, channelID, max)
+}
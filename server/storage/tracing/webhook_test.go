@@ -0,0 +1,57 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/saiya/dsps/server/domain"
+	dspstesting "github.com/saiya/dsps/server/testing"
+)
+
+func TestWebhookTrace(t *testing.T) {
+	msg := domain.MessageLocator{ChannelID: "ch-1", MessageID: "msg-1"}
+	tr := testTracing(t, func(s domain.Storage) {
+		ctx := context.Background()
+		delivery := domain.WebhookDelivery{Message: domain.Message{MessageLocator: msg, Content: []byte(`{}`)}}
+		assert.NoError(t, s.AsWebhookStorage().EnqueueWebhookDeliveries(ctx, []domain.WebhookDelivery{delivery}))
+		_, err := s.AsWebhookStorage().LeaseWebhookDeliveries(ctx, 10, dspstesting.MakeDuration("1m"))
+		assert.NoError(t, err)
+		assert.NoError(t, s.AsWebhookStorage().RetryWebhookDelivery(ctx, delivery))
+		assert.NoError(t, s.AsWebhookStorage().CompleteWebhookDelivery(ctx, msg))
+		assert.NoError(t, s.AsWebhookStorage().RecordWebhookDelivery(ctx, domain.WebhookDeliveryRecord{Message: delivery.Message, Attempt: 1}, 10, dspstesting.MakeDuration("1m")))
+		_, err = s.AsWebhookStorage().ListWebhookDeliveryRecords(ctx, msg.ChannelID, 10)
+		assert.NoError(t, err)
+	})
+	tr.OT.AssertSpanBy(trace.SpanKindInternal, "DSPS storage EnqueueWebhookDeliveries", map[string]interface{}{
+		"dsps.storage.id": "test",
+	})
+	tr.OT.AssertSpanBy(trace.SpanKindInternal, "DSPS storage LeaseWebhookDeliveries", map[string]interface{}{
+		"dsps.storage.id": "test",
+	})
+	tr.OT.AssertSpanBy(trace.SpanKindInternal, "DSPS storage RetryWebhookDelivery", map[string]interface{}{
+		"dsps.storage.id":       "test",
+		"messaging.destination": "ch-1",
+		"messaging.message_id":  "msg-1",
+		"messaging.system":      "dsps",
+	})
+	tr.OT.AssertSpanBy(trace.SpanKindInternal, "DSPS storage CompleteWebhookDelivery", map[string]interface{}{
+		"dsps.storage.id":       "test",
+		"messaging.destination": "ch-1",
+		"messaging.message_id":  "msg-1",
+		"messaging.system":      "dsps",
+	})
+	tr.OT.AssertSpanBy(trace.SpanKindInternal, "DSPS storage RecordWebhookDelivery", map[string]interface{}{
+		"dsps.storage.id":       "test",
+		"messaging.destination": "ch-1",
+		"messaging.message_id":  "msg-1",
+		"messaging.system":      "dsps",
+	})
+	tr.OT.AssertSpanBy(trace.SpanKindInternal, "DSPS storage ListWebhookDeliveryRecords", map[string]interface{}{
+		"dsps.storage.id":       "test",
+		"messaging.destination": "ch-1",
+		"messaging.system":      "dsps",
+	})
+}
@@ -1,6 +1,8 @@
 package telemetry
 
 import (
+	"fmt"
+
 	ottrace "go.opentelemetry.io/otel/trace"
 )
 
@@ -14,6 +16,8 @@ const (
 	Fetch
 	// Acknowledge means client received span
 	Acknowledge
+	// WebhookSend means sending message to outgoing-webhook endpoint
+	WebhookSend
 )
 
 // https://github.com/open-telemetry/opentelemetry-specification/blob/master/specification/trace/semantic_conventions/messaging.md
@@ -25,11 +29,22 @@ func (lifecycle MessageLifecycle) otOperationName() string {
 		return "receive"
 	case Acknowledge:
 		return "process"
+	case WebhookSend:
+		return "send" // DSPS server publishes the message to the webhook endpoint
 	default:
 		return ""
 	}
 }
 
+func (lifecycle MessageLifecycle) spanName() string {
+	// In DSPS, channel ID is high cardinality value and it is not easy to find single template name of it.
+	// So that use simple span name as like as HTTP span name.
+	if lifecycle == WebhookSend {
+		return "DSPS webhook send"
+	}
+	return fmt.Sprintf("DSPS %s", lifecycle.otOperationName())
+}
+
 func (lifecycle MessageLifecycle) otSpanKind() ottrace.SpanKind {
 	switch lifecycle {
 	case Send:
@@ -38,6 +53,8 @@ func (lifecycle MessageLifecycle) otSpanKind() ottrace.SpanKind {
 		return ottrace.SpanKindConsumer
 	case Acknowledge:
 		return ottrace.SpanKindConsumer
+	case WebhookSend:
+		return ottrace.SpanKindProducer
 	default:
 		return ottrace.SpanKindInternal
 	}
@@ -0,0 +1,141 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+// metrics holds OT metric instruments.
+// Note that metric labels must be low cardinality values, so that channel ID or subscriber ID must not be used.
+type metrics struct {
+	httpRequests metric.Int64Counter
+	httpDuration metric.Float64ValueRecorder
+	httpTooLarge metric.Int64Counter
+	httpLimited  metric.Int64Counter
+
+	storageDuration metric.Float64ValueRecorder
+
+	publishedMessages metric.Int64Counter
+	fetchedMessages   metric.Int64Counter
+	acknowledgements  metric.Int64Counter
+	activeLongPolls   metric.Int64UpDownCounter
+	longPollDuration  metric.Float64ValueRecorder
+
+	webhookAttempts metric.Int64Counter
+	webhookFailures metric.Int64Counter
+
+	jwtRejections metric.Int64Counter
+
+	redisPubSubReconnects metric.Int64Counter
+}
+
+func newMetrics(meter metric.Meter) *metrics {
+	m := metric.Must(meter)
+	return &metrics{
+		httpRequests: m.NewInt64Counter("dsps.http.server.requests", metric.WithDescription("Count of incoming HTTP requests")),
+		httpDuration: m.NewFloat64ValueRecorder("dsps.http.server.duration.seconds", metric.WithDescription("Duration to process incoming HTTP requests")),
+		httpTooLarge: m.NewInt64Counter("dsps.http.server.body_too_large", metric.WithDescription("Count of incoming HTTP requests rejected because of too large request body")),
+		httpLimited:  m.NewInt64Counter("dsps.http.server.rate_limited", metric.WithDescription("Count of incoming HTTP requests rejected by rate limits")),
+
+		storageDuration: m.NewFloat64ValueRecorder("dsps.storage.operation.duration.seconds", metric.WithDescription("Duration of storage operations")),
+
+		publishedMessages: m.NewInt64Counter("dsps.messages.published", metric.WithDescription("Count of messages published to the storage")),
+		fetchedMessages:   m.NewInt64Counter("dsps.messages.fetched", metric.WithDescription("Count of messages fetched from the storage")),
+		acknowledgements:  m.NewInt64Counter("dsps.messages.acknowledgements", metric.WithDescription("Count of message acknowledgements")),
+		activeLongPolls:   m.NewInt64UpDownCounter("dsps.longpoll.active", metric.WithDescription("Count of long polling requests waiting for messages")),
+		longPollDuration:  m.NewFloat64ValueRecorder("dsps.longpoll.wait.seconds", metric.WithDescription("Duration that long polling requests waited for messages")),
+
+		webhookAttempts: m.NewInt64Counter("dsps.webhook.attempts", metric.WithDescription("Count of outgoing webhook HTTP requests")),
+		webhookFailures: m.NewInt64Counter("dsps.webhook.failures", metric.WithDescription("Count of failed outgoing webhook HTTP requests")),
+
+		jwtRejections: m.NewInt64Counter("dsps.auth.jwt.rejections", metric.WithDescription("Count of requests rejected by JWT validation")),
+
+		redisPubSubReconnects: m.NewInt64Counter("dsps.storage.redis.pubsub.reconnects", metric.WithDescription("Count of Redis PSUBSCRIBE stream reconnections")),
+	}
+}
+
+// PrometheusHandler returns handler of Prometheus metrics endpoint, returns nil if Prometheus exporter is not enabled.
+func (t *Telemetry) PrometheusHandler() http.Handler {
+	if t.ot.Metrics == nil {
+		return nil
+	}
+	return t.ot.Metrics.PrometheusHandler
+}
+
+// RecordHTTPServerMetrics records metrics of incoming HTTP request
+func (t *Telemetry) RecordHTTPServerMetrics(ctx context.Context, method string, routePath string, status int, duration time.Duration) {
+	labels := []label.KeyValue{
+		label.String("http.method", method),
+		label.String("http.route", routePath),
+		label.Int("http.status_code", status),
+	}
+	t.m.httpRequests.Add(ctx, 1, labels...)
+	t.m.httpDuration.Record(ctx, duration.Seconds(), labels...)
+}
+
+// CountRequestBodyTooLarge records request rejected because of the request body size limit
+func (t *Telemetry) CountRequestBodyTooLarge(ctx context.Context) {
+	t.m.httpTooLarge.Add(ctx, 1)
+}
+
+// CountRateLimited records request rejected by the rate limit of the scope (e.g. "ip")
+func (t *Telemetry) CountRateLimited(ctx context.Context, scope domain.RateLimitScope) {
+	t.m.httpLimited.Add(ctx, 1, label.String("dsps.ratelimit.scope", string(scope)))
+}
+
+func (t *Telemetry) recordStorageDuration(ctx context.Context, id domain.StorageID, operation string, duration time.Duration) {
+	t.m.storageDuration.Record(ctx, duration.Seconds(), storageLabel(id), label.String("dsps.storage.operation", operation))
+}
+
+// CountPublishedMessages records count of messages published to the storage
+func (t *Telemetry) CountPublishedMessages(ctx context.Context, id domain.StorageID, count int) {
+	t.m.publishedMessages.Add(ctx, int64(count), storageLabel(id))
+}
+
+// CountFetchedMessages records count of messages fetched from the storage
+func (t *Telemetry) CountFetchedMessages(ctx context.Context, id domain.StorageID, count int) {
+	t.m.fetchedMessages.Add(ctx, int64(count), storageLabel(id))
+}
+
+// CountAcknowledgement records message acknowledgement
+func (t *Telemetry) CountAcknowledgement(ctx context.Context, id domain.StorageID) {
+	t.m.acknowledgements.Add(ctx, 1, storageLabel(id))
+}
+
+// StartLongPolling records start of the long polling, returned function must be called on the end of it.
+func (t *Telemetry) StartLongPolling(ctx context.Context, id domain.StorageID) func() {
+	startedAt := time.Now()
+	t.m.activeLongPolls.Add(ctx, 1, storageLabel(id))
+	return func() {
+		t.m.activeLongPolls.Add(ctx, -1, storageLabel(id))
+		t.m.longPollDuration.Record(ctx, time.Since(startedAt).Seconds(), storageLabel(id))
+	}
+}
+
+// CountWebhookAttempt records outgoing webhook HTTP request
+func (t *Telemetry) CountWebhookAttempt(ctx context.Context, succeeded bool) {
+	t.m.webhookAttempts.Add(ctx, 1)
+	if !succeeded {
+		t.m.webhookFailures.Add(ctx, 1)
+	}
+}
+
+// CountJwtRejection records request rejected by JWT validation
+func (t *Telemetry) CountJwtRejection(ctx context.Context) {
+	t.m.jwtRejections.Add(ctx, 1)
+}
+
+// CountRedisPubSubReconnect records reconnection of Redis PSUBSCRIBE stream
+func (t *Telemetry) CountRedisPubSubReconnect(ctx context.Context) {
+	t.m.redisPubSubReconnects.Add(ctx, 1)
+}
+
+func storageLabel(id domain.StorageID) label.KeyValue {
+	return label.String("dsps.storage.id", string(id))
+}
@@ -0,0 +1,70 @@
+package telemetry
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	cfg := defaultTelemetryConfig()
+	cfg.OT.Exporters.Prometheus.Enable = true
+	cfg.OT.Exporters.Prometheus.HistogramBoundaries = []float64{0.1, 1}
+	telemetry, err := InitTelemetry(cfg)
+	assert.NoError(t, err)
+	defer telemetry.Shutdown(context.Background())
+
+	ctx := context.Background()
+	telemetry.RecordHTTPServerMetrics(ctx, "GET", "/probe/liveness", 200, 500*time.Millisecond)
+	telemetry.CountRequestBodyTooLarge(ctx)
+	telemetry.CountRateLimited(ctx, "ip")
+	_, end := telemetry.StartStorageSpan(ctx, "test", "FetchMessages")
+	end()
+	telemetry.CountPublishedMessages(ctx, "test", 3)
+	telemetry.CountFetchedMessages(ctx, "test", 2)
+	telemetry.CountAcknowledgement(ctx, "test")
+	telemetry.StartLongPolling(ctx, "test")()
+	telemetry.StartLongPolling(ctx, "test") // Still waiting
+	telemetry.CountWebhookAttempt(ctx, true)
+	telemetry.CountWebhookAttempt(ctx, false)
+	telemetry.CountJwtRejection(ctx)
+	telemetry.CountRedisPubSubReconnect(ctx)
+
+	rec := httptest.NewRecorder()
+	telemetry.PrometheusHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, 200, rec.Code)
+	body, err := ioutil.ReadAll(rec.Body)
+	assert.NoError(t, err)
+	for _, line := range []string{
+		`dsps_http_server_requests{http_method="GET",http_route="/probe/liveness",http_status_code="200"} 1`,
+		`dsps_http_server_duration_seconds_bucket{http_method="GET",http_route="/probe/liveness",http_status_code="200",le="0.1"} 0`,
+		`dsps_http_server_duration_seconds_bucket{http_method="GET",http_route="/probe/liveness",http_status_code="200",le="1"} 1`,
+		`dsps_http_server_body_too_large 1`,
+		`dsps_http_server_rate_limited{dsps_ratelimit_scope="ip"} 1`,
+		`dsps_storage_operation_duration_seconds_count{dsps_storage_id="test",dsps_storage_operation="FetchMessages"} 1`,
+		`dsps_messages_published{dsps_storage_id="test"} 3`,
+		`dsps_messages_fetched{dsps_storage_id="test"} 2`,
+		`dsps_messages_acknowledgements{dsps_storage_id="test"} 1`,
+		`dsps_longpoll_active{dsps_storage_id="test"} 1`,
+		`dsps_longpoll_wait_seconds_count{dsps_storage_id="test"} 1`,
+		`dsps_webhook_attempts 2`,
+		`dsps_webhook_failures 1`,
+		`dsps_auth_jwt_rejections 1`,
+		`dsps_storage_redis_pubsub_reconnects 1`,
+	} {
+		assert.Contains(t, string(body), line)
+	}
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	telemetry := initEmptyTelemetry(t)
+	defer telemetry.Shutdown(context.Background())
+	assert.Nil(t, telemetry.PrometheusHandler())
+
+	// Should not fail without exporter
+	telemetry.CountPublishedMessages(context.Background(), "test", 1)
+}
@@ -1,6 +1,8 @@
 package opentelemetry
 
 import (
+	"context"
+
 	gcpexporter "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/trace"
 	"go.opentelemetry.io/otel/exporters/stdout"
 	exporttrace "go.opentelemetry.io/otel/sdk/export/trace"
@@ -27,5 +29,12 @@ func newExporters(config *config.OpenTelemetryConfig) ([]exporttrace.SpanExporte
 		}
 		exporters = append(exporters, exporter)
 	}
+	if config.Exporters.OTLP.EnableTrace {
+		exporter, err := newOTLPExporter(context.Background(), &config.Exporters.OTLP)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to initialize OpenTelemetry OTLP trace exporter: %w", err)
+		}
+		exporters = append(exporters, exporter)
+	}
 	return exporters, nil
 }
@@ -0,0 +1,77 @@
+package opentelemetry
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/exporters/metric/prometheus"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/metric/controller/pull"
+	"go.opentelemetry.io/otel/sdk/metric/controller/push"
+	"go.opentelemetry.io/otel/sdk/metric/processor/basic"
+	"go.opentelemetry.io/otel/sdk/metric/selector/simple"
+	"golang.org/x/xerrors"
+
+	"github.com/saiya/dsps/server/config"
+)
+
+const instrumentationName = "github.com/saiya/dsps"
+
+// Metrics contains OT metrics resources
+type Metrics struct {
+	Meter metric.Meter
+
+	// PrometheusHandler serves metrics in Prometheus exposition format, nil if Prometheus exporter is disabled.
+	PrometheusHandler http.Handler
+
+	otlpController *push.Controller
+	otlpExporter   *otlp.Exporter
+}
+
+func newMetrics(config *config.OpenTelemetryConfig) (*Metrics, error) {
+	setup := &Metrics{}
+	meters := []metric.Meter{}
+	if config.Exporters.Prometheus.Enable {
+		exporter, err := prometheus.NewExportPipeline(
+			prometheus.Config{DefaultHistogramBoundaries: config.Exporters.Prometheus.HistogramBoundaries},
+			// Collect on each scrape, scrape interval is controlled by Prometheus server.
+			pull.WithCachePeriod(0),
+		)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to initialize OpenTelemetry Prometheus exporter: %w", err)
+		}
+		meters = append(meters, exporter.MeterProvider().Meter(instrumentationName))
+		setup.PrometheusHandler = exporter
+	}
+	if otlpConfig := &config.Exporters.OTLP; otlpConfig.EnableMetrics {
+		exporter, err := newOTLPExporter(context.Background(), otlpConfig)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to initialize OpenTelemetry OTLP metrics exporter: %w", err)
+		}
+		setup.otlpExporter = exporter
+		setup.otlpController = push.New(
+			basic.New(simple.NewWithHistogramDistribution(otlpConfig.HistogramBoundaries), exporter),
+			exporter,
+			push.WithPeriod(otlpConfig.MetricsInterval.Duration),
+			push.WithTimeout(otlpConfig.Timeout.Duration),
+		)
+		setup.otlpController.Start()
+		meters = append(meters, setup.otlpController.MeterProvider().Meter(instrumentationName))
+	}
+	// If no exporter enabled, Meter becomes zero value that creates no-op instruments.
+	setup.Meter = newMultiMeter(meters, instrumentationName)
+	return setup, nil
+}
+
+// Shutdown OT metrics
+func (setup *Metrics) Shutdown(ctx context.Context) error {
+	// Prometheus exporter is pull-based, nothing to flush.
+	if setup.otlpController != nil {
+		setup.otlpController.Stop() // Exports metrics one last time
+		if err := setup.otlpExporter.Shutdown(ctx); err != nil {
+			return xerrors.Errorf("failed to shutdown OpenTelemetry OTLP metrics exporter: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/number"
+)
+
+// multiMeterImpl records measurements to multiple metric SDKs (e.g. Prometheus and OTLP).
+// Only supports synchronous instruments because this server does not use asynchronous ones.
+type multiMeterImpl struct {
+	impls []metric.MeterImpl
+}
+
+var _ metric.MeterImpl = &multiMeterImpl{}
+
+func newMultiMeter(meters []metric.Meter, instrumentationName string) metric.Meter {
+	switch len(meters) {
+	case 0:
+		return metric.Meter{}
+	case 1:
+		return meters[0]
+	}
+	impl := &multiMeterImpl{}
+	for _, meter := range meters {
+		impl.impls = append(impl.impls, meter.MeterImpl())
+	}
+	return metric.WrapMeterImpl(impl, instrumentationName)
+}
+
+func (m *multiMeterImpl) RecordBatch(ctx context.Context, labels []label.KeyValue, measurements ...metric.Measurement) {
+	for _, measurement := range measurements {
+		measurement.SyncImpl().RecordOne(ctx, measurement.Number(), labels)
+	}
+}
+
+func (m *multiMeterImpl) NewSyncInstrument(descriptor metric.Descriptor) (metric.SyncImpl, error) {
+	instrument := &multiSyncImpl{descriptor: descriptor}
+	for _, impl := range m.impls {
+		child, err := impl.NewSyncInstrument(descriptor)
+		if err != nil {
+			return nil, err
+		}
+		instrument.children = append(instrument.children, child)
+	}
+	return instrument, nil
+}
+
+func (m *multiMeterImpl) NewAsyncInstrument(descriptor metric.Descriptor, runner metric.AsyncRunner) (metric.AsyncImpl, error) {
+	return nil, fmt.Errorf("asynchronous instrument %s is not supported", descriptor.Name())
+}
+
+type multiSyncImpl struct {
+	descriptor metric.Descriptor
+	children   []metric.SyncImpl
+}
+
+func (s *multiSyncImpl) Implementation() interface{} {
+	return s
+}
+
+func (s *multiSyncImpl) Descriptor() metric.Descriptor {
+	return s.descriptor
+}
+
+func (s *multiSyncImpl) Bind(labels []label.KeyValue) metric.BoundSyncImpl {
+	bound := &multiBoundSyncImpl{}
+	for _, child := range s.children {
+		bound.children = append(bound.children, child.Bind(labels))
+	}
+	return bound
+}
+
+func (s *multiSyncImpl) RecordOne(ctx context.Context, number number.Number, labels []label.KeyValue) {
+	for _, child := range s.children {
+		child.RecordOne(ctx, number, labels)
+	}
+}
+
+type multiBoundSyncImpl struct {
+	children []metric.BoundSyncImpl
+}
+
+func (b *multiBoundSyncImpl) RecordOne(ctx context.Context, number number.Number) {
+	for _, child := range b.children {
+		child.RecordOne(ctx, number)
+	}
+}
+
+func (b *multiBoundSyncImpl) Unbind() {
+	for _, child := range b.children {
+		child.Unbind()
+	}
+}
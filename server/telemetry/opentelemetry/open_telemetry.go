@@ -17,6 +17,7 @@ import (
 // OTFacility holds OT related objects
 type OTFacility struct {
 	Tracing *Tracing
+	Metrics *Metrics
 
 	spanExporters []exporttrace.SpanExporter
 
@@ -59,6 +60,10 @@ func newOTFacility(config *config.OpenTelemetryConfig, injection injection) (fac
 	}
 	injection.tracing.exporters = append(injection.tracing.exporters, facility.spanExporters...)
 	facility.Tracing = newTracing(config.Tracing, injection.tracing)
+	if facility.Metrics, err = newMetrics(config); err != nil {
+		err = fmt.Errorf("failed to initialize OpenTelemetry metrics: %w", err)
+		return
+	}
 	return
 }
 
@@ -67,6 +72,9 @@ func (ot *OTFacility) Shutdown(ctx context.Context) error {
 	g, ctx := errgroup.WithContext(ctx)
 	g.Go(func() error { return ot.TestShutdownError })
 	g.Go(func() error { return ot.Tracing.Shutdown(ctx) })
+	if ot.Metrics != nil {
+		g.Go(func() error { return ot.Metrics.Shutdown(ctx) })
+	}
 	return g.Wait()
 }
 
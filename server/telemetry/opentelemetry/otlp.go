@@ -0,0 +1,37 @@
+package opentelemetry
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/saiya/dsps/server/config"
+)
+
+func newOTLPExporter(ctx context.Context, otlpConfig *config.OpenTelemetryExporterOTLPConfig) (*otlp.Exporter, error) {
+	// Configuration postprocess rejects protocols other than gRPC.
+	opts := []otlp.ExporterOption{
+		otlp.WithAddress(otlpConfig.Endpoint),
+		otlp.WithGRPCDialOption(grpc.WithUnaryInterceptor(otlpTimeoutInterceptor(otlpConfig.Timeout.Duration))),
+	}
+	if len(otlpConfig.Headers) > 0 {
+		opts = append(opts, otlp.WithHeaders(otlpConfig.Headers))
+	}
+	if otlpConfig.Insecure {
+		opts = append(opts, otlp.WithInsecure())
+	} else {
+		opts = append(opts, otlp.WithTLSCredentials(credentials.NewClientTLSFromCert(nil, "")))
+	}
+	return otlp.NewExporter(ctx, opts...)
+}
+
+func otlpTimeoutInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
@@ -0,0 +1,90 @@
+package opentelemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+	ottesting "github.com/saiya/dsps/server/telemetry/opentelemetry/testing"
+)
+
+func TestOTLPExporterGRPC(t *testing.T) {
+	collector := ottesting.NewStubCollector(t)
+	defer collector.Close()
+	ot, err := NewOTFacility(&config.OpenTelemetryConfig{
+		Tracing: tracingConfigDefault(),
+		Exporters: config.OpenTelemetryExportersConfig{
+			OTLP: otlpConfigForTest(config.OTLPProtocolGRPC, collector.GRPCEndpoint()),
+		},
+	})
+	assert.NoError(t, err)
+
+	_, span := ot.Tracing.Tracer.Start(context.Background(), "test-otlp-span")
+	span.End()
+	metric.Must(ot.Metrics.Meter).NewInt64Counter("test.otlp.counter").Add(context.Background(), 1)
+
+	// Shutdown flushes both traces and metrics
+	assert.NoError(t, ot.Shutdown(context.Background()))
+	collector.AssertReceived("/v1/traces", "test-otlp-span", 5*time.Second)
+	collector.AssertReceived("/v1/metrics", "test.otlp.counter", 5*time.Second)
+}
+
+func TestOTLPWithPrometheus(t *testing.T) {
+	collector := ottesting.NewStubCollector(t)
+	defer collector.Close()
+
+	otlpConfig := otlpConfigForTest(config.OTLPProtocolGRPC, collector.GRPCEndpoint())
+	otlpConfig.EnableTrace = false
+	ot, err := NewOTFacility(&config.OpenTelemetryConfig{
+		Tracing: &config.OpenTelemetryTracingConfig{},
+		Exporters: config.OpenTelemetryExportersConfig{
+			Prometheus: config.OpenTelemetryExporterPrometheusConfig{Enable: true, HistogramBoundaries: []float64{1}},
+			OTLP:       otlpConfig,
+		},
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, ot.Metrics.PrometheusHandler)
+
+	m := metric.Must(ot.Metrics.Meter)
+	m.NewInt64Counter("test.multi.counter").Add(context.Background(), 1)
+	m.NewFloat64ValueRecorder("test.multi.recorder").Bind().Record(context.Background(), 0.5)
+
+	assert.NoError(t, ot.Shutdown(context.Background()))
+	collector.AssertReceived("/v1/metrics", "test.multi.counter", 5*time.Second)
+	collector.AssertReceived("/v1/metrics", "test.multi.recorder", 5*time.Second)
+	assert.Empty(t, collector.Requests("/v1/traces"))
+}
+
+func TestOTLPFailure(t *testing.T) {
+	ot, err := NewOTFacility(&config.OpenTelemetryConfig{
+		Tracing: tracingConfigDefault(),
+		Exporters: config.OpenTelemetryExportersConfig{
+			OTLP: otlpConfigForTest(config.OTLPProtocolGRPC, "127.0.0.1:1"), // Nobody listens
+		},
+	})
+	assert.NoError(t, err)
+
+	_, span := ot.Tracing.Tracer.Start(context.Background(), "test-otlp-span")
+	span.End()
+	// Export failure must not break the server
+	_ = ot.Shutdown(context.Background())
+}
+
+func otlpConfigForTest(protocol string, endpoint string) config.OpenTelemetryExporterOTLPConfig {
+	return config.OpenTelemetryExporterOTLPConfig{
+		EnableTrace:         true,
+		EnableMetrics:       true,
+		Protocol:            protocol,
+		Endpoint:            endpoint,
+		Insecure:            true,
+		Headers:             map[string]string{"X-Test-Header": "test"},
+		Timeout:             &domain.Duration{Duration: 3 * time.Second},
+		MetricsInterval:     &domain.Duration{Duration: 1 * time.Hour},
+		HistogramBoundaries: []float64{0.1, 1},
+	}
+}
@@ -0,0 +1,109 @@
+package testing
+
+import (
+	"bytes"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+// StubCollector receives OTLP requests over gRPC
+type StubCollector struct {
+	t *testing.T
+
+	grpcListener net.Listener
+	grpcServer   *grpc.Server
+
+	lock     sync.Mutex
+	requests map[string][][]byte // key is OTLP/HTTP path (e.g. "/v1/traces")
+}
+
+var stubCollectorGRPCMethods = map[string]string{
+	"/opentelemetry.proto.collector.trace.v1.TraceService/Export":     "/v1/traces",
+	"/opentelemetry.proto.collector.metrics.v1.MetricsService/Export": "/v1/metrics",
+}
+
+// NewStubCollector starts new stub collector, caller must call Close() after use
+func NewStubCollector(t *testing.T) *StubCollector {
+	c := &StubCollector{t: t, requests: map[string][][]byte{}}
+
+	var err error
+	c.grpcListener, err = net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	c.grpcServer = grpc.NewServer(
+		grpc.CustomCodec(rawCodec{}), //nolint:staticcheck // To receive raw protobuf bytes without generated types
+		grpc.UnknownServiceHandler(c.handleGRPC),
+	)
+	go func() { _ = c.grpcServer.Serve(c.grpcListener) }()
+	return c
+}
+
+// GRPCEndpoint returns host:port of the OTLP/gRPC receiver
+func (c *StubCollector) GRPCEndpoint() string {
+	return c.grpcListener.Addr().String()
+}
+
+// Close stops the collector
+func (c *StubCollector) Close() {
+	c.grpcServer.Stop()
+}
+
+// Requests returns received request bodies (protobuf encoded) of the OTLP/HTTP path (e.g. "/v1/traces")
+func (c *StubCollector) Requests(path string) [][]byte {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([][]byte{}, c.requests[path]...)
+}
+
+// AssertReceived waits until the collector receives request of the path that contains given string (e.g. span name, metric name)
+func (c *StubCollector) AssertReceived(path string, contains string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		for _, body := range c.Requests(path) {
+			if bytes.Contains(body, []byte(contains)) {
+				return true
+			}
+		}
+		if time.Now().After(deadline) {
+			return assert.Fail(c.t, "OTLP request not received", "path: %s, contains: %s", path, contains)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (c *StubCollector) record(path string, body []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.requests[path] = append(c.requests[path], body)
+}
+
+func (c *StubCollector) handleGRPC(srv interface{}, stream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(stream)
+	var body []byte
+	if err := stream.RecvMsg(&body); err != nil {
+		return err
+	}
+	c.record(stubCollectorGRPCMethods[method], body)
+	// Empty message is valid encoding of the Export*ServiceResponse
+	return stream.SendMsg(&[]byte{})
+}
+
+// rawCodec passes through protobuf bytes as is
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	return *(v.(*[]byte)), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*(v.(*[]byte)) = append([]byte{}, data...)
+	return nil
+}
+
+func (rawCodec) String() string {
+	return "proto" // Pretend to be protobuf codec to accept "application/grpc+proto" requests
+}
@@ -0,0 +1,85 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/propagation"
+	ottrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+// W3C Trace Context (https://www.w3.org/TR/trace-context/)
+var traceContextPropagator = propagation.TraceContext{}
+
+const (
+	traceParentHeader = "traceparent"
+	traceStateHeader  = "tracestate"
+)
+
+// CaptureTraceContext returns W3C Trace Context of the current span, to propagate it with the message.
+// If tracing of DSPS server is disabled, returns trace context of the caller (e.g. traceparent header of the publish request) as is.
+func (t *Telemetry) CaptureTraceContext(ctx context.Context) domain.TraceContext {
+	tc := domain.TraceContext{}
+	traceContextPropagator.Inject(ctx, (*traceContextCarrier)(&tc))
+	if tc.TraceParent == "" {
+		if remote := ottrace.RemoteSpanContextFromContext(ctx); remote.IsValid() {
+			tc.TraceParent = fmt.Sprintf("00-%s-%s-%.2x", remote.TraceID, remote.SpanID, remote.TraceFlags&ottrace.FlagsSampled)
+		}
+	}
+	return tc
+}
+
+// SetTraceContextHeaders sets W3C Trace Context HTTP headers of the message, does nothing if it is empty.
+func SetTraceContextHeaders(headers map[string]string, tc domain.TraceContext) {
+	if tc.TraceParent != "" {
+		headers[traceParentHeader] = tc.TraceParent
+	}
+	if tc.TraceState != "" {
+		headers[traceStateHeader] = tc.TraceState
+	}
+}
+
+func spanContextOf(tc domain.TraceContext) ottrace.SpanContext {
+	if tc.TraceParent == "" {
+		return ottrace.SpanContext{}
+	}
+	ctx := traceContextPropagator.Extract(context.Background(), (*traceContextCarrier)(&tc))
+	return ottrace.RemoteSpanContextFromContext(ctx)
+}
+
+// traceContextCarrier adapts domain.TraceContext to propagation.TextMapCarrier
+type traceContextCarrier domain.TraceContext
+
+func (c *traceContextCarrier) Get(key string) string {
+	switch key {
+	case traceParentHeader:
+		return c.TraceParent
+	case traceStateHeader:
+		return c.TraceState
+	default:
+		return ""
+	}
+}
+
+func (c *traceContextCarrier) Set(key string, value string) {
+	switch key {
+	case traceParentHeader:
+		c.TraceParent = value
+	case traceStateHeader:
+		c.TraceState = value
+	}
+}
+
+// httpHeaderCarrier adapts http.Header to propagation.TextMapCarrier
+type httpHeaderCarrier http.Header
+
+func (c httpHeaderCarrier) Get(key string) string {
+	return http.Header(c).Get(key)
+}
+
+func (c httpHeaderCarrier) Set(key string, value string) {
+	http.Header(c).Set(key, value)
+}
@@ -0,0 +1,99 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ottrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestMessageTraceContextPropagation(t *testing.T) {
+	msg := domain.Message{
+		MessageLocator: domain.MessageLocator{ChannelID: "ch-1", MessageID: "msg-1"},
+		Content:        json.RawMessage("{}"),
+	}
+	var producerSpan ottrace.SpanContext
+	result := WithStubTracing(t, func(tel *Telemetry) {
+		// Publisher calls DSPS with traceparent
+		r := httptest.NewRequest("PUT", "/channel/ch-1/message/msg-1", strings.NewReader("{}"))
+		r.Header.Set("traceparent", testTraceParent)
+		r.Header.Set("tracestate", "congo=t61rcWkgMzE")
+		ctx, end := tel.StartHTTPSpan(context.Background(), true, r)
+		ctx, endSend := tel.StartMessageSpan(ctx, Send, msg)
+		producerSpan = ottrace.SpanContextFromContext(ctx)
+		msg.TraceContext = tel.CaptureTraceContext(ctx)
+		endSend()
+		end()
+
+		_, endFetch := tel.StartMessageSpan(context.Background(), Fetch, msg)
+		endFetch()
+
+		// Outgoing webhook propagates context of the client span, but keeps tracestate of the publisher
+		ctx, endAck := tel.StartMessageSpan(context.Background(), Acknowledge, msg)
+		r = httptest.NewRequest("POST", "http://webhook.example.com/", strings.NewReader("{}"))
+		r.Header.Set("traceparent", msg.TraceContext.TraceParent) // Outgoing webhook client sets trace context of the message first
+		r.Header.Set("tracestate", msg.TraceContext.TraceState)
+		ctx, end = tel.StartHTTPSpan(ctx, false, r)
+		assert.Equal(t, ottrace.SpanContextFromContext(ctx).TraceID.String(), strings.Split(r.Header.Get("traceparent"), "-")[1])
+		assert.Equal(t, "congo=t61rcWkgMzE", r.Header.Get("tracestate"), "should propagate tracestate of the publisher")
+		end()
+		endAck()
+	})
+
+	// Publish request continues trace of the publisher
+	server := result.OT.GetSpans()[1]
+	assert.Equal(t, ottrace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", server.ParentSpanID.String())
+
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+producerSpan.SpanID.String()+"-01", msg.TraceContext.TraceParent)
+	assert.Equal(t, "congo=t61rcWkgMzE", msg.TraceContext.TraceState)
+
+	for _, span := range result.OT.GetSpans() {
+		if span.SpanKind != ottrace.SpanKindConsumer {
+			continue
+		}
+		// Fetch and Acknowledge spans belong to another trace but linked to the producer
+		assert.NotEqual(t, producerSpan.TraceID, span.SpanContext.TraceID)
+		if assert.Len(t, span.Links, 1) {
+			assert.Equal(t, producerSpan.TraceID, span.Links[0].TraceID)
+			assert.Equal(t, producerSpan.SpanID, span.Links[0].SpanID)
+		}
+	}
+}
+
+func TestCaptureTraceContextWithoutTracing(t *testing.T) {
+	tel := NewEmptyTelemetry(t)
+	assert.Equal(t, domain.TraceContext{}, tel.CaptureTraceContext(context.Background()))
+
+	r := httptest.NewRequest("PUT", "/channel/ch-1/message/msg-1", strings.NewReader("{}"))
+	r.Header.Set("traceparent", testTraceParent)
+	ctx, end := tel.StartHTTPSpan(context.Background(), true, r)
+	defer end()
+	ctx, endSend := tel.StartMessageSpan(ctx, Send, domain.Message{})
+	defer endSend()
+	assert.Equal(t, domain.TraceContext{TraceParent: testTraceParent}, tel.CaptureTraceContext(ctx))
+}
+
+func TestSetTraceContextHeaders(t *testing.T) {
+	headers := map[string]string{}
+	SetTraceContextHeaders(headers, domain.TraceContext{})
+	assert.Equal(t, map[string]string{}, headers)
+
+	SetTraceContextHeaders(headers, domain.TraceContext{TraceParent: testTraceParent, TraceState: "a=b"})
+	assert.Equal(t, map[string]string{"traceparent": testTraceParent, "tracestate": "a=b"}, headers)
+}
+
+func TestInvalidTraceContext(t *testing.T) {
+	assert.False(t, spanContextOf(domain.TraceContext{}).IsValid())
+	assert.False(t, spanContextOf(domain.TraceContext{TraceParent: "invalid"}).IsValid())
+	assert.True(t, spanContextOf(domain.TraceContext{TraceParent: testTraceParent}).IsValid())
+}
@@ -11,6 +11,7 @@ import (
 // Telemetry represents tracing/metrics system
 type Telemetry struct {
 	ot *opentelemetry.OTFacility
+	m  *metrics
 }
 
 // InitTelemetry initialize telemetry facility
@@ -19,6 +20,7 @@ func InitTelemetry(config *config.TelemetryConfig) (telemetry *Telemetry, err er
 	if telemetry.ot, err = opentelemetry.NewOTFacility(config.OT); err != nil {
 		return
 	}
+	telemetry.m = newMetrics(telemetry.ot.Metrics.Meter)
 	return
 }
 
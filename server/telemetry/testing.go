@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"go.opentelemetry.io/otel/metric"
 	otsdktrace "go.opentelemetry.io/otel/sdk/trace"
 
 	"github.com/saiya/dsps/server/config"
@@ -39,6 +40,7 @@ func WithStubTracing(t *testing.T, f func(*Telemetry)) *TraceResult {
 				Tracer: otsdktrace.NewTracerProvider(otsdktrace.WithSyncer(tr.OT)).Tracer("test"),
 			},
 		},
+		m: newMetrics(metric.Meter{}),
 	}
 	defer telemetry.Shutdown(context.Background())
 	f(telemetry)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"go.opentelemetry.io/otel/label"
 	ottrace "go.opentelemetry.io/otel/trace"
@@ -29,7 +30,11 @@ func (t *Telemetry) StartHTTPSpan(ctx context.Context, isServer bool, r *http.Re
 		requestURIlabel = "http.url"
 		requestURI = r.URL.String()
 	}
-	return t.startSpan(
+	if isServer {
+		// Continue trace of the caller if present
+		ctx = traceContextPropagator.Extract(ctx, httpHeaderCarrier(r.Header))
+	}
+	ctx, end := t.startSpan(
 		// > Therefore, HTTP client spans SHOULD be using conservative, low cardinality names formed from the available parameters of an HTTP request, such as "HTTP {METHOD_NAME}".
 		// https://github.com/open-telemetry/opentelemetry-specification/blob/master/specification/trace/semantic_conventions/http.md
 		ctx, fmt.Sprintf("HTTP %s %s", r.Method, r.Host),
@@ -44,6 +49,11 @@ func (t *Telemetry) StartHTTPSpan(ctx context.Context, isServer bool, r *http.Re
 			label.Int64("http.request_content_length", r.ContentLength),
 		),
 	)
+	if !isServer {
+		// Propagate trace to the callee, overwrites headers only if this span is valid.
+		traceContextPropagator.Inject(ctx, httpHeaderCarrier(r.Header))
+	}
+	return ctx, end
 }
 
 // SetHTTPServerAttributes adds attributes for incoming HTTP request
@@ -79,21 +89,39 @@ func (t *Telemetry) StartMessageSpan(ctx context.Context, lifecycle MessageLifec
 		// https://github.com/open-telemetry/opentelemetry-specification/blob/master/specification/trace/semantic_conventions/messaging.md
 		labels = append(labels, label.String("messaging.operation", lifecycle.otOperationName()))
 	}
-	return t.startSpan(
-		// In DSPS, channel ID is high cardinality value and it is not easy to find single template name of it.
-		// So that use simple span name as like as HTTP span name.
-		ctx, fmt.Sprintf(`DSPS %s`, lifecycle.otOperationName()),
+	opts := []ottrace.SpanOption{
 		ottrace.WithSpanKind(lifecycle.otSpanKind()),
 		ottrace.WithAttributes(labels...),
-	)
+	}
+	if lifecycle != Send {
+		// Link to the publisher's span because this span belongs to the trace of the subscriber.
+		if producer := spanContextOf(msg.TraceContext); producer.IsValid() {
+			opts = append(opts, ottrace.WithLinks(ottrace.Link{SpanContext: producer}))
+		}
+	}
+	return t.startSpan(ctx, lifecycle.spanName(), opts...)
 }
 
 // StartStorageSpan starts storage operation span
+// Also records duration of the operation as metrics.
 func (t *Telemetry) StartStorageSpan(ctx context.Context, id domain.StorageID, operation string) (context.Context, context.CancelFunc) {
-	return t.startSpan(
+	startedAt := time.Now()
+	ctx, end := t.startSpan(
 		ctx, fmt.Sprintf(`DSPS storage %s`, operation),
 		ottrace.WithSpanKind(ottrace.SpanKindInternal),
-		ottrace.WithAttributes(label.String("dsps.storage.id", string(id))),
+		ottrace.WithAttributes(storageLabel(id)),
+	)
+	return ctx, func() {
+		t.recordStorageDuration(ctx, id, operation, time.Since(startedAt))
+		end()
+	}
+}
+
+// SetChannelAttributes adds attributes of channel
+func (t *Telemetry) SetChannelAttributes(ctx context.Context, channelID domain.ChannelID) {
+	ottrace.SpanFromContext(ctx).SetAttributes(
+		label.String("messaging.system", "dsps"),
+		label.String("messaging.destination", string(channelID)),
 	)
 }
 
@@ -106,6 +134,15 @@ func (t *Telemetry) SetSubscriberAttributes(ctx context.Context, sl domain.Subsc
 	)
 }
 
+// SetMessageAttributes adds attributes of message
+func (t *Telemetry) SetMessageAttributes(ctx context.Context, msg domain.MessageLocator) {
+	ottrace.SpanFromContext(ctx).SetAttributes(
+		label.String("messaging.system", "dsps"),
+		label.String("messaging.destination", string(msg.ChannelID)),
+		label.String("messaging.message_id", string(msg.MessageID)),
+	)
+}
+
 // SetJTI adds attribute of JWT
 func (t *Telemetry) SetJTI(ctx context.Context, jti domain.JwtJti) {
 	ottrace.SpanFromContext(ctx).SetAttributes(
@@ -141,3 +178,14 @@ func (t *Telemetry) startSpan(ctx context.Context, name string, opts ...ottrace.
 func (t *Telemetry) RecordError(ctx context.Context, err error) {
 	ottrace.SpanFromContext(ctx).RecordError(err)
 }
+
+// RecordCircuitBreakerEvent adds outgoing-webhook circuit breaker event to current span.
+func (t *Telemetry) RecordCircuitBreakerEvent(ctx context.Context, url string, event string) {
+	ottrace.SpanFromContext(ctx).AddEvent(
+		"dsps.webhook.circuit_breaker",
+		ottrace.WithAttributes(
+			label.String("dsps.webhook.url", url),
+			label.String("dsps.webhook.circuit_breaker.event", event),
+		),
+	)
+}
@@ -7,9 +7,11 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	ottrace "go.opentelemetry.io/otel/trace"
 
 	"github.com/saiya/dsps/server/domain"
+	ottesting "github.com/saiya/dsps/server/telemetry/opentelemetry/testing"
 )
 
 func TestHTTPSpan(t *testing.T) {
@@ -74,6 +76,8 @@ func TestMessageSpan(t *testing.T) {
 		close()
 		_, close = t.StartMessageSpan(context.Background(), Acknowledge, msg)
 		close()
+		_, close = t.StartMessageSpan(context.Background(), WebhookSend, msg)
+		close()
 	})
 	result.OT.AssertSpan(0, ottrace.SpanKindProducer, "DSPS send", map[string]interface{}{
 		"messaging.system":                     "dsps",
@@ -95,6 +99,12 @@ func TestMessageSpan(t *testing.T) {
 		"messaging.message_payload_size_bytes": int64(len(msg.Content)),
 		"messaging.operation":                  "process",
 	})
+	result.OT.AssertSpan(3, ottrace.SpanKindProducer, "DSPS webhook send", map[string]interface{}{
+		"messaging.system":                     "dsps",
+		"messaging.message_id":                 string(msg.MessageID),
+		"messaging.destination":                string(msg.ChannelID),
+		"messaging.message_payload_size_bytes": int64(len(msg.Content)),
+	})
 }
 
 func TestStorageSpanWithMessagingAttrs(t *testing.T) {
@@ -137,3 +147,22 @@ func TestDaemonSpan(t *testing.T) {
 		"dsps.daemon.name":   "doSomething",
 	})
 }
+
+func TestCircuitBreakerEvent(t *testing.T) {
+	result := WithStubTracing(t, func(t *Telemetry) {
+		ctx, close := t.StartDaemonSpan(context.Background(), "test.daemon", "doSomething")
+		t.RecordCircuitBreakerEvent(ctx, "http://localhost/webhook", "opened")
+		close()
+	})
+	span := result.OT.AssertSpan(0, ottrace.SpanKindInternal, "BackgroundJob test.daemon doSomething", map[string]interface{}{
+		"dsps.daemon.system": "test.daemon",
+		"dsps.daemon.name":   "doSomething",
+	})
+	if assert.Equal(t, 1, len(span.MessageEvents)) {
+		assert.Equal(t, "dsps.webhook.circuit_breaker", span.MessageEvents[0].Name)
+		assert.EqualValues(t, map[string]interface{}{
+			"dsps.webhook.url":                   "http://localhost/webhook",
+			"dsps.webhook.circuit_breaker.event": "opened",
+		}, ottesting.KVPairsToMap(span.MessageEvents[0].Attributes))
+	}
+}
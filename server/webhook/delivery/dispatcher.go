@@ -0,0 +1,202 @@
+package delivery
+
+import (
+	"context"
+	"fmt"
+	"math"
+	gosync "sync"
+	"time"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/logger"
+	"github.com/saiya/dsps/server/sentry"
+	"github.com/saiya/dsps/server/sync"
+	"github.com/saiya/dsps/server/telemetry"
+	"github.com/saiya/dsps/server/webhook/outgoing"
+)
+
+// Dispatcher delivers outgoing-webhooks asynchronously through WebhookStorage.
+type Dispatcher interface {
+	// Enqueue persists outgoing-webhook deliveries of given messages.
+	// Messages of channels without outgoing-webhook are ignored.
+	Enqueue(ctx context.Context, msgs []domain.Message) error
+
+	// Shutdown stops delivery workers, block until in-flight deliveries end.
+	Shutdown(ctx context.Context) error
+}
+
+// Deps is dependencies of the Dispatcher
+type Deps struct {
+	Clock           domain.SystemClock
+	ChannelProvider domain.ChannelProvider
+	Telemetry       *telemetry.Telemetry
+	Sentry          sentry.Sentry
+}
+
+type dispatcher struct {
+	cfg             *config.WebhookDeliveryConfig
+	clock           domain.SystemClock
+	channelProvider domain.ChannelProvider
+	storage         domain.WebhookStorage
+
+	daemonSystem *sync.DaemonSystem
+}
+
+// NewDispatcher creates Dispatcher instance and starts delivery workers.
+// Returns (nil, nil) if disabled by the configuration or the storage does not support WebhookStorage.
+func NewDispatcher(ctx context.Context, cfg *config.WebhookDeliveryConfig, storage domain.Storage, deps Deps) (Dispatcher, error) {
+	if cfg.Disable {
+		return nil, nil
+	}
+	webhookStorage := storage.AsWebhookStorage()
+	if webhookStorage == nil {
+		logger.Of(ctx).Infof(logger.CatOutgoingWebhook, "Storage does not support outgoing-webhook delivery queue, sends outgoing-webhook synchronously")
+		return nil, nil
+	}
+
+	d := &dispatcher{
+		cfg:             cfg,
+		clock:           deps.Clock,
+		channelProvider: deps.ChannelProvider,
+		storage:         webhookStorage,
+		daemonSystem: sync.NewDaemonSystem("dsps.webhook.delivery", sync.DaemonSystemDeps{
+			Telemetry: deps.Telemetry,
+			Sentry:    deps.Sentry,
+		}, func(ctx context.Context, name string, err error) {
+			logger.Of(ctx).WarnError(logger.CatOutgoingWebhook, fmt.Sprintf(`error in outgoing-webhook delivery worker "%s": %%w`, name), err)
+		}),
+	}
+	for i := 0; i < *cfg.Workers; i++ {
+		d.daemonSystem.Start(fmt.Sprintf("worker-%d", i), d.work)
+	}
+	return d, nil
+}
+
+func (d *dispatcher) Enqueue(ctx context.Context, msgs []domain.Message) error {
+	now := d.clock.Now()
+	deliveries := make([]domain.WebhookDelivery, 0, len(msgs))
+	for _, msg := range msgs {
+		ch, err := d.channelProvider.Get(msg.ChannelID)
+		if err != nil {
+			return err
+		}
+		if !ch.HasOutgoingWebhook() {
+			continue
+		}
+		nextAttemptAt := now
+		if msg.IsScheduled(now) {
+			nextAttemptAt = msg.DeliverAt // Scheduled (delayed) message
+		}
+		deliveries = append(deliveries, domain.WebhookDelivery{
+			Message:       msg,
+			NextAttemptAt: nextAttemptAt,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return d.storage.EnqueueWebhookDeliveries(ctx, deliveries)
+}
+
+func (d *dispatcher) Shutdown(ctx context.Context) error {
+	return d.daemonSystem.Shutdown(ctx)
+}
+
+func (d *dispatcher) work(ctx context.Context) (sync.DaemonNextRun, error) {
+	deliveries, err := d.storage.LeaseWebhookDeliveries(ctx, *d.cfg.BatchSize, *d.cfg.Lease)
+	if err != nil {
+		return sync.DaemonNextRun{Interval: d.cfg.PollInterval.Duration}, err
+	}
+	// Deliver concurrently, so that batching outgoing-webhook can aggregate messages.
+	wg := gosync.WaitGroup{}
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			// Shutting down, remaining deliveries will be leased again after lease expiration.
+			break
+		}
+		wg.Add(1)
+		delivery := delivery
+		go func() {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+
+	if len(deliveries) == *d.cfg.BatchSize {
+		// Queue may have more deliveries
+		return sync.DaemonNextRun{Interval: 0}, nil
+	}
+	return sync.DaemonNextRun{Interval: d.cfg.PollInterval.Duration}, nil
+}
+
+func (d *dispatcher) deliver(ctx context.Context, delivery domain.WebhookDelivery) {
+	ch, err := d.channelProvider.Get(delivery.ChannelID)
+	if err != nil {
+		logger.Of(ctx).WarnError(logger.CatOutgoingWebhook, fmt.Sprintf(`discarded outgoing-webhook delivery because channel is not available (channel: %s, msgID: %s): %%w`, delivery.ChannelID, delivery.MessageID), err)
+		d.complete(ctx, delivery)
+		return
+	}
+
+	record := domain.WebhookDeliveryRecord{
+		Message:     delivery.Message,
+		Attempt:     delivery.Attempts + 1,
+		AttemptedAt: d.clock.Now(),
+	}
+	sendCtx, recorder := outgoing.WithRequestRecorder(ctx)
+	startedAt := time.Now()
+	err = ch.SendOutgoingWebhook(sendCtx, delivery.Message)
+	if ctx.Err() != nil {
+		return // Shutting down, will be delivered again after lease expiration.
+	}
+	record.Latency = domain.Duration{Duration: time.Since(startedAt)}
+	record.Requests = recorder.Records()
+	if err == nil {
+		record.Result = domain.WebhookDeliverySucceeded
+		d.record(ctx, record)
+		d.complete(ctx, delivery)
+		return
+	}
+
+	delivery.Attempts++
+	delivery.LastError = err.Error()
+	record.Error = err.Error()
+	if delivery.Attempts >= *d.cfg.MaxAttempts {
+		logger.Of(ctx).Error(fmt.Sprintf(`gave up outgoing-webhook delivery after %d attempts (channel: %s, msgID: %s)`, delivery.Attempts, delivery.ChannelID, delivery.MessageID), err)
+		sentry.RecordError(ctx, err)
+		record.Result = domain.WebhookDeliveryGaveUp
+		d.record(ctx, record)
+		d.complete(ctx, delivery)
+		return
+	}
+	record.Result = domain.WebhookDeliveryRetrying
+	d.record(ctx, record)
+
+	delivery.NextAttemptAt = domain.Time{Time: d.clock.Now().Add(d.retryInterval(delivery.Attempts))}
+	logger.Of(ctx).WarnError(logger.CatOutgoingWebhook, fmt.Sprintf(`failed to send outgoing-webhook, will retry at %s (channel: %s, msgID: %s, attempts: %d): %%w`, delivery.NextAttemptAt.Format(time.RFC3339), delivery.ChannelID, delivery.MessageID, delivery.Attempts), err)
+	if err := d.storage.RetryWebhookDelivery(ctx, delivery); err != nil {
+		logger.Of(ctx).WarnError(logger.CatOutgoingWebhook, fmt.Sprintf(`failed to reschedule outgoing-webhook delivery (channel: %s, msgID: %s): %%w`, delivery.ChannelID, delivery.MessageID), err)
+	}
+}
+
+func (d *dispatcher) complete(ctx context.Context, delivery domain.WebhookDelivery) {
+	if err := d.storage.CompleteWebhookDelivery(ctx, delivery.MessageLocator); err != nil {
+		logger.Of(ctx).WarnError(logger.CatOutgoingWebhook, fmt.Sprintf(`failed to complete outgoing-webhook delivery (channel: %s, msgID: %s): %%w`, delivery.ChannelID, delivery.MessageID), err)
+	}
+}
+
+func (d *dispatcher) record(ctx context.Context, record domain.WebhookDeliveryRecord) {
+	if err := d.storage.RecordWebhookDelivery(ctx, record, *d.cfg.HistorySize, *d.cfg.HistoryRetention); err != nil {
+		logger.Of(ctx).WarnError(logger.CatOutgoingWebhook, fmt.Sprintf(`failed to record outgoing-webhook delivery history (channel: %s, msgID: %s): %%w`, record.ChannelID, record.MessageID), err)
+	}
+}
+
+// retryInterval returns exponential backoff interval after given count of failed attempts.
+func (d *dispatcher) retryInterval(attempts int) time.Duration {
+	interval := float64(d.cfg.RetryInterval.Duration) * math.Pow(*d.cfg.RetryIntervalMultiplier, float64(attempts-1))
+	if max := float64(d.cfg.MaxRetryInterval.Duration); interval > max {
+		return d.cfg.MaxRetryInterval.Duration
+	}
+	return time.Duration(interval)
+}
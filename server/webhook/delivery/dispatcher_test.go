@@ -0,0 +1,342 @@
+package delivery_test
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/sentry"
+	. "github.com/saiya/dsps/server/storage/deps/testing"
+	"github.com/saiya/dsps/server/storage/onmemory"
+	"github.com/saiya/dsps/server/telemetry"
+	dspstesting "github.com/saiya/dsps/server/testing"
+	. "github.com/saiya/dsps/server/webhook/delivery"
+)
+
+func TestDisabledDispatcher(t *testing.T) {
+	ctx := context.Background()
+	cfg := dispatcherConfig(t, `webhookDelivery: { disable: true }`)
+	storage := newStorage(t, config.OnmemoryStorageConfig{})
+	d, err := NewDispatcher(ctx, cfg, storage, dispatcherDeps(t, newFakeChannels()))
+	assert.NoError(t, err)
+	assert.Nil(t, d)
+
+	cfg = dispatcherConfig(t, ``)
+	storage = newStorage(t, config.OnmemoryStorageConfig{DisableWebhook: true})
+	d, err = NewDispatcher(ctx, cfg, storage, dispatcherDeps(t, newFakeChannels()))
+	assert.NoError(t, err)
+	assert.Nil(t, d)
+}
+
+func TestDelivery(t *testing.T) {
+	ctx := context.Background()
+	channels := newFakeChannels()
+	storage := newStorage(t, config.OnmemoryStorageConfig{})
+	d, err := NewDispatcher(ctx, dispatcherConfig(t, `webhookDelivery: { pollInterval: 10ms }`), storage, dispatcherDeps(t, channels))
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, d.Shutdown(ctx)) }()
+
+	msgs := []domain.Message{
+		{MessageLocator: domain.MessageLocator{ChannelID: "with-webhook", MessageID: "msg-1"}, Content: []byte(`{}`)},
+		{MessageLocator: domain.MessageLocator{ChannelID: "without-webhook", MessageID: "msg-2"}, Content: []byte(`{}`)},
+		{MessageLocator: domain.MessageLocator{ChannelID: "with-webhook", MessageID: "msg-3"}, Content: []byte(`{}`)},
+	}
+	assert.NoError(t, d.Enqueue(ctx, msgs))
+	channels.waitSent(t, 2)
+	assert.ElementsMatch(t, []domain.MessageID{"msg-1", "msg-3"}, channels.sentIDs())
+
+	// Completed deliveries are removed from the queue
+	leased, err := storage.AsWebhookStorage().LeaseWebhookDeliveries(ctx, 100, dspstesting.MakeDuration("1m"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(leased))
+
+	var records []domain.WebhookDeliveryRecord
+	assert.Eventually(t, func() bool {
+		records, err = storage.AsWebhookStorage().ListWebhookDeliveryRecords(ctx, "with-webhook", 100)
+		return err == nil && len(records) == 2
+	}, 3*time.Second, 10*time.Millisecond)
+	if assert.Equal(t, 2, len(records)) {
+		for _, record := range records {
+			assert.Equal(t, 1, record.Attempt)
+			assert.Equal(t, domain.WebhookDeliverySucceeded, record.Result)
+			assert.Equal(t, "", record.Error)
+		}
+	}
+}
+
+func TestDeliveryScheduledMessage(t *testing.T) {
+	ctx := context.Background()
+	channels := newFakeChannels()
+	storage := newStorage(t, config.OnmemoryStorageConfig{})
+	d, err := NewDispatcher(ctx, dispatcherConfig(t, `webhookDelivery: { pollInterval: 10ms }`), storage, dispatcherDeps(t, channels))
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, d.Shutdown(ctx)) }()
+
+	deliverAt := time.Now().Add(300 * time.Millisecond)
+	assert.NoError(t, d.Enqueue(ctx, []domain.Message{
+		{MessageLocator: domain.MessageLocator{ChannelID: "with-webhook", MessageID: "msg-1"}, Content: []byte(`{}`), DeliverAt: domain.Time{Time: deliverAt}},
+	}))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 0, len(channels.sentIDs()))
+
+	channels.waitSent(t, 1)
+	assert.False(t, time.Now().Before(deliverAt))
+	assert.Equal(t, []domain.MessageID{"msg-1"}, channels.sentIDs())
+}
+
+func TestEnqueueInvalidChannel(t *testing.T) {
+	ctx := context.Background()
+	d, err := NewDispatcher(ctx, dispatcherConfig(t, ``), newStorage(t, config.OnmemoryStorageConfig{}), dispatcherDeps(t, newFakeChannels()))
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, d.Shutdown(ctx)) }()
+
+	err = d.Enqueue(ctx, []domain.Message{
+		{MessageLocator: domain.MessageLocator{ChannelID: "invalid-channel", MessageID: "msg-1"}, Content: []byte(`{}`)},
+	})
+	assert.True(t, errors.Is(err, domain.ErrInvalidChannel))
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	channels := newFakeChannels()
+	channels.failures = 3
+	storage := &recordingStorage{Storage: newStorage(t, config.OnmemoryStorageConfig{})}
+	storage.webhook = storage.Storage.AsWebhookStorage()
+	d, err := NewDispatcher(ctx, dispatcherConfig(t, `webhookDelivery: { pollInterval: 10ms, retryInterval: 50ms, retryIntervalMultiplier: 2.0, maxRetryInterval: 150ms }`), storage, dispatcherDeps(t, channels))
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, d.Shutdown(ctx)) }()
+
+	assert.NoError(t, d.Enqueue(ctx, []domain.Message{
+		{MessageLocator: domain.MessageLocator{ChannelID: "with-webhook", MessageID: "msg-1"}, Content: []byte(`{}`)},
+	}))
+	channels.waitSent(t, 1)
+	assert.Equal(t, 4, channels.attemptCount())
+
+	retries := storage.recordedRetries()
+	if assert.Equal(t, 3, len(retries)) {
+		for i, expected := range []time.Duration{50 * time.Millisecond, 100 * time.Millisecond, 150 * time.Millisecond} {
+			assert.Equal(t, i+1, retries[i].delivery.Attempts)
+			assert.Equal(t, "test failure", retries[i].delivery.LastError)
+			assert.InDelta(t, expected, retries[i].delivery.NextAttemptAt.Sub(retries[i].at), float64(20*time.Millisecond))
+		}
+	}
+}
+
+func TestGiveUpDelivery(t *testing.T) {
+	ctx := context.Background()
+	channels := newFakeChannels()
+	channels.failures = 1000
+	storage := newStorage(t, config.OnmemoryStorageConfig{})
+	d, err := NewDispatcher(ctx, dispatcherConfig(t, `webhookDelivery: { pollInterval: 10ms, retryInterval: 10ms, maxAttempts: 3 }`), storage, dispatcherDeps(t, channels))
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, d.Shutdown(ctx)) }()
+
+	assert.NoError(t, d.Enqueue(ctx, []domain.Message{
+		{MessageLocator: domain.MessageLocator{ChannelID: "with-webhook", MessageID: "msg-1"}, Content: []byte(`{}`)},
+	}))
+	assert.Eventually(t, func() bool { return channels.attemptCount() >= 3 }, 3*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 3, channels.attemptCount())
+	assert.Equal(t, 0, len(channels.sentIDs()))
+
+	records, err := storage.AsWebhookStorage().ListWebhookDeliveryRecords(ctx, "with-webhook", 100)
+	assert.NoError(t, err)
+	if assert.Equal(t, 3, len(records)) {
+		for i, expected := range []domain.WebhookDeliveryResult{domain.WebhookDeliveryGaveUp, domain.WebhookDeliveryRetrying, domain.WebhookDeliveryRetrying} {
+			assert.Equal(t, 3-i, records[i].Attempt)
+			assert.Equal(t, expected, records[i].Result)
+			assert.Equal(t, "test failure", records[i].Error)
+		}
+	}
+}
+
+func TestDeliveryHistorySize(t *testing.T) {
+	ctx := context.Background()
+	channels := newFakeChannels()
+	storage := newStorage(t, config.OnmemoryStorageConfig{})
+	d, err := NewDispatcher(ctx, dispatcherConfig(t, `webhookDelivery: { pollInterval: 10ms, historySize: 2 }`), storage, dispatcherDeps(t, channels))
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, d.Shutdown(ctx)) }()
+
+	for _, id := range []domain.MessageID{"msg-1", "msg-2", "msg-3"} {
+		assert.NoError(t, d.Enqueue(ctx, []domain.Message{
+			{MessageLocator: domain.MessageLocator{ChannelID: "with-webhook", MessageID: id}, Content: []byte(`{}`)},
+		}))
+	}
+	channels.waitSent(t, 3)
+	assert.Eventually(t, func() bool {
+		records, err := storage.AsWebhookStorage().ListWebhookDeliveryRecords(ctx, "with-webhook", 100)
+		assert.NoError(t, err)
+		return len(records) == 2
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
+func dispatcherConfig(t *testing.T, yaml string) *config.WebhookDeliveryConfig {
+	cfg, err := config.ParseConfig(context.Background(), config.Overrides{}, yaml)
+	assert.NoError(t, err)
+	return cfg.WebhookDelivery
+}
+
+func dispatcherDeps(t *testing.T, channels *fakeChannels) Deps {
+	return Deps{
+		Clock:           domain.RealSystemClock,
+		ChannelProvider: dspstesting.ChannelProviderFunc(channels.get),
+		Telemetry:       telemetry.NewEmptyTelemetry(t),
+		Sentry:          sentry.NewEmptySentry(),
+	}
+}
+
+func newStorage(t *testing.T, cfg config.OnmemoryStorageConfig) domain.Storage {
+	s, err := onmemory.NewOnmemoryStorage(context.Background(), &cfg, domain.RealSystemClock, dspstesting.ChannelProviderFunc(newFakeChannels().get), EmptyDeps(t))
+	assert.NoError(t, err)
+	return s
+}
+
+type fakeChannels struct {
+	lock     sync.Mutex
+	failures int // Count of attempts to fail
+	attempts int
+	sent     []domain.MessageID
+}
+
+func newFakeChannels() *fakeChannels {
+	return &fakeChannels{}
+}
+
+func (f *fakeChannels) get(id domain.ChannelID) (domain.Channel, error) {
+	switch id {
+	case "with-webhook":
+		return &fakeChannel{channels: f, webhook: true}, nil
+	case "without-webhook":
+		return &fakeChannel{channels: f, webhook: false}, nil
+	default:
+		return nil, domain.ErrInvalidChannel
+	}
+}
+
+func (f *fakeChannels) attemptCount() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.attempts
+}
+
+func (f *fakeChannels) sentIDs() []domain.MessageID {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]domain.MessageID{}, f.sent...)
+}
+
+func (f *fakeChannels) waitSent(t *testing.T, count int) {
+	assert.Eventually(t, func() bool { return len(f.sentIDs()) >= count }, 3*time.Second, 10*time.Millisecond)
+}
+
+type fakeChannel struct {
+	channels *fakeChannels
+	webhook  bool
+}
+
+func (c *fakeChannel) Expire() domain.Duration {
+	return dspstesting.MakeDuration("5m")
+}
+
+func (c *fakeChannel) Retention() domain.ChannelRetention {
+	return domain.ChannelRetention{}
+}
+
+func (c *fakeChannel) MaxMessageBytes() int64 {
+	return 0
+}
+
+func (c *fakeChannel) RateLimits() []domain.ChannelRateLimit {
+	return nil
+}
+
+func (c *fakeChannel) ValidateJwt(ctx context.Context, jwt string) error {
+	return nil
+}
+
+func (c *fakeChannel) ValidateClientCert(ctx context.Context, certs []*x509.Certificate) error {
+	return domain.ErrClientCertAuthNotConfigured
+}
+
+func (c *fakeChannel) AcceptsContentType(mediaType string) bool {
+	return true
+}
+
+func (c *fakeChannel) ValidateMessageContent(ctx context.Context, msg domain.Message) error {
+	return nil
+}
+
+func (c *fakeChannel) HasOutgoingWebhook() bool {
+	return c.webhook
+}
+
+func (c *fakeChannel) SendOutgoingWebhook(ctx context.Context, msg domain.Message) error {
+	f := c.channels
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.attempts++
+	if f.attempts <= f.failures {
+		return errors.New("test failure")
+	}
+	f.sent = append(f.sent, msg.MessageID)
+	return nil
+}
+
+type recordedRetry struct {
+	at       time.Time
+	delivery domain.WebhookDelivery
+}
+
+// recordingStorage records RetryWebhookDelivery calls
+type recordingStorage struct {
+	domain.Storage
+	webhook domain.WebhookStorage
+
+	lock    sync.Mutex
+	retries []recordedRetry
+}
+
+func (s *recordingStorage) AsWebhookStorage() domain.WebhookStorage {
+	return s
+}
+
+func (s *recordingStorage) EnqueueWebhookDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	return s.webhook.EnqueueWebhookDeliveries(ctx, deliveries)
+}
+
+func (s *recordingStorage) LeaseWebhookDeliveries(ctx context.Context, max int, lease domain.Duration) ([]domain.WebhookDelivery, error) {
+	return s.webhook.LeaseWebhookDeliveries(ctx, max, lease)
+}
+
+func (s *recordingStorage) CompleteWebhookDelivery(ctx context.Context, msg domain.MessageLocator) error {
+	return s.webhook.CompleteWebhookDelivery(ctx, msg)
+}
+
+func (s *recordingStorage) RetryWebhookDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	s.lock.Lock()
+	s.retries = append(s.retries, recordedRetry{at: time.Now(), delivery: delivery})
+	s.lock.Unlock()
+	return s.webhook.RetryWebhookDelivery(ctx, delivery)
+}
+
+func (s *recordingStorage) RecordWebhookDelivery(ctx context.Context, record domain.WebhookDeliveryRecord, maxRecords int, retention domain.Duration) error {
+	return s.webhook.RecordWebhookDelivery(ctx, record, maxRecords, retention)
+}
+
+func (s *recordingStorage) ListWebhookDeliveryRecords(ctx context.Context, channelID domain.ChannelID, max int) ([]domain.WebhookDeliveryRecord, error) {
+	return s.webhook.ListWebhookDeliveryRecords(ctx, channelID, max)
+}
+
+func (s *recordingStorage) recordedRetries() []recordedRetry {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]recordedRetry{}, s.retries...)
+}
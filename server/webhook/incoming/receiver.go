@@ -0,0 +1,175 @@
+package incoming
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/logger"
+	"github.com/saiya/dsps/server/webhook/signature"
+)
+
+// Errors returned by Receiver
+var (
+	ErrSignatureVerification = errors.New("incoming webhook signature verification failed")
+	ErrInvalidRequest        = errors.New("invalid incoming webhook request")
+)
+
+// Receiver converts incoming-webhook request into a message, see server/doc/incoming-webhook.md for spec.
+type Receiver interface {
+	Receive(ctx context.Context, header http.Header, body []byte) (domain.Message, error)
+}
+
+type receiver struct {
+	cfg   *config.IncomingWebhookConfig
+	clock domain.SystemClock
+}
+
+// NewReceiver creates Receiver instance
+func NewReceiver(cfg *config.IncomingWebhookConfig, clock domain.SystemClock) Receiver {
+	return &receiver{cfg: cfg, clock: clock}
+}
+
+// NewReceivers creates Receiver for each incoming-webhook configuration, key is name of the incoming-webhook.
+func NewReceivers(ctx context.Context, cfg config.IncomingWebhooksConfig, clock domain.SystemClock) map[string]Receiver {
+	receivers := make(map[string]Receiver, len(cfg))
+	for i := range cfg {
+		if cfg[i].InsecureNoSignature {
+			logger.Of(ctx).Warnf(logger.CatServer, `Incoming webhook "%s" accepts unsigned requests (insecureNoSignature), anyone who knows the URL can publish messages bypassing channel authentication`, cfg[i].Name)
+		}
+		receivers[cfg[i].Name] = NewReceiver(&cfg[i], clock)
+	}
+	return receivers
+}
+
+func (r *receiver) Receive(ctx context.Context, header http.Header, body []byte) (domain.Message, error) {
+	if err := r.verify(header, body); err != nil {
+		return domain.Message{}, xerrors.Errorf("%w: %v", ErrSignatureVerification, err)
+	}
+
+	var content interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber() // Not to format large numeric IDs in exponential notation
+	if err := decoder.Decode(&content); err != nil || !json.Valid(body) {
+		return domain.Message{}, xerrors.Errorf("%w: request body is not JSON", ErrInvalidRequest)
+	}
+
+	messageID, err := r.messageID(header, content)
+	if err != nil {
+		return domain.Message{}, err
+	}
+	channelID, err := r.channelID(header, content)
+	if err != nil {
+		return domain.Message{}, err
+	}
+	return domain.Message{
+		MessageLocator: domain.MessageLocator{
+			ChannelID: channelID,
+			MessageID: messageID,
+		},
+		Content: json.RawMessage(body),
+	}, nil
+}
+
+func (r *receiver) verify(header http.Header, body []byte) error {
+	sig := r.cfg.Signature
+	if sig == nil {
+		return nil
+	}
+	switch sig.Scheme {
+	case config.IncomingWebhookSignatureGitHub:
+		return signature.VerifyGitHub(header, body, sig.Secrets)
+	case config.IncomingWebhookSignatureStripe:
+		return signature.VerifyStripe(header, body, sig.Secrets, sig.Tolerance.Duration, r.clock.Now().Time)
+	default:
+		return signature.Verify(header, body, sig.Secrets, sig.Tolerance.Duration, r.clock.Now().Time)
+	}
+}
+
+func (r *receiver) messageID(header http.Header, content interface{}) (domain.MessageID, error) {
+	var raw string
+	if name := r.cfg.MessageID.Header; name != "" {
+		raw = header.Get(name)
+		if raw == "" {
+			return "", xerrors.Errorf(`%w: message ID header "%s" not found`, ErrInvalidRequest, name)
+		}
+	} else {
+		var err error
+		raw, err = extractJSONPath(content, r.cfg.MessageID.JSONPath)
+		if err == nil && raw == "" {
+			err = xerrors.New("value is empty")
+		}
+		if err != nil {
+			return "", xerrors.Errorf(`%w: could not extract message ID from "%s": %v`, ErrInvalidRequest, r.cfg.MessageID.JSONPath, err)
+		}
+	}
+
+	if id, err := domain.ParseMessageID(raw); err == nil {
+		return id, nil
+	}
+	// Third-party IDs may contain characters not allowed in DSPS (e.g. upper case).
+	// Derive ID deterministically, so that retried requests are deduplicated.
+	hash := sha256.Sum256([]byte(raw))
+	return domain.MessageID("h-" + hex.EncodeToString(hash[:])[:60]), nil
+}
+
+func (r *receiver) channelID(header http.Header, content interface{}) (domain.ChannelID, error) {
+	headers := make(map[string]string, len(header))
+	for name := range header {
+		headers[name] = header.Get(name)
+	}
+	result, err := r.cfg.Channel.Execute(map[string]interface{}{
+		"hook":   map[string]interface{}{"name": r.cfg.Name},
+		"header": headers,
+		"body":   content,
+	})
+	if err != nil {
+		return "", xerrors.Errorf(`%w: failed to evaluate channel template "%s": %v`, ErrInvalidRequest, r.cfg.Channel, err)
+	}
+	id, err := domain.ParseChannelID(strings.TrimSpace(result))
+	if err != nil {
+		return "", xerrors.Errorf(`%w: invalid channel ID "%s": %v`, ErrInvalidRequest, result, err)
+	}
+	return id, nil
+}
+
+// extractJSONPath returns string or number at the dot separated path, e.g. "data.items.0.id"
+func extractJSONPath(content interface{}, path string) (string, error) {
+	current := content
+	for _, key := range strings.Split(path, ".") {
+		switch value := current.(type) {
+		case map[string]interface{}:
+			found, ok := value[key]
+			if !ok {
+				return "", xerrors.Errorf(`"%s" not found`, key)
+			}
+			current = found
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || len(value) <= index {
+				return "", xerrors.Errorf(`index "%s" out of range`, key)
+			}
+			current = value[index]
+		default:
+			return "", xerrors.Errorf(`could not get "%s" of non-object value`, key)
+		}
+	}
+	switch value := current.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	default:
+		return "", xerrors.Errorf("value is not string nor number")
+	}
+}
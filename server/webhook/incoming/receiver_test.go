@@ -0,0 +1,129 @@
+package incoming_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+	dspstesting "github.com/saiya/dsps/server/testing"
+	. "github.com/saiya/dsps/server/webhook/incoming"
+	"github.com/saiya/dsps/server/webhook/signature"
+)
+
+func newReceiver(t *testing.T, yaml string, clock domain.SystemClock) Receiver {
+	cfg, err := config.ParseConfig(context.Background(), config.Overrides{}, strings.ReplaceAll("incomingWebhooks:\n\t- "+yaml, "\t", "  "))
+	assert.NoError(t, err)
+	return NewReceiver(&cfg.IncomingWebhooks[0], clock)
+}
+
+func TestReceiveWithHeaderMessageID(t *testing.T) {
+	r := newReceiver(t, `{ name: gh, channel: 'repo-{{ .body.repository.id }}-{{ index .header "X-Github-Event" }}', messageID: { header: X-GitHub-Delivery }, insecureNoSignature: true }`, domain.RealSystemClock)
+	body := []byte(`{"repository":{"id":1296269}}`)
+	msg, err := r.Receive(context.Background(), http.Header{
+		"X-Github-Delivery": []string{"72d3162e-cc78-11e3-81ab-4c9367dc0958"},
+		"X-Github-Event":    []string{"push"},
+	}, body)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ChannelID("repo-1296269-push"), msg.ChannelID)
+	assert.Equal(t, domain.MessageID("72d3162e-cc78-11e3-81ab-4c9367dc0958"), msg.MessageID)
+	assert.JSONEq(t, string(body), string(msg.Content))
+
+	_, err = r.Receive(context.Background(), http.Header{"X-Github-Event": []string{"push"}}, body)
+	assert.True(t, errors.Is(err, ErrInvalidRequest))
+	assert.Regexp(t, `message ID header "X-GitHub-Delivery" not found`, err)
+}
+
+func TestReceiveWithJSONPathMessageID(t *testing.T) {
+	r := newReceiver(t, `{ name: svc, channel: '{{ .hook.name }}-events', messageID: { jsonPath: data.items.1.id }, insecureNoSignature: true }`, domain.RealSystemClock)
+	msg, err := r.Receive(context.Background(), http.Header{}, []byte(`{"data":{"items":[{"id":"a"},{"id":"msg-2"}]}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ChannelID("svc-events"), msg.ChannelID)
+	assert.Equal(t, domain.MessageID("msg-2"), msg.MessageID)
+
+	// Large numeric ID
+	msg, err = r.Receive(context.Background(), http.Header{}, []byte(`{"data":{"items":[{},{"id":12345678901234567890}]}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, domain.MessageID("12345678901234567890"), msg.MessageID)
+
+	for _, body := range []string{
+		`{"data":{"items":[{"id":"a"}]}}`,
+		`{"data":{"items":{"1":1}}}`,
+		`{"data":{"items":[{},{"id":{}}]}}`,
+		`{"data":{"items":[{},{"id":""}]}}`,
+		`{"data":"str"}`,
+	} {
+		_, err := r.Receive(context.Background(), http.Header{}, []byte(body))
+		assert.True(t, errors.Is(err, ErrInvalidRequest), body)
+		assert.Regexp(t, `could not extract message ID from "data.items.1.id"`, err)
+	}
+}
+
+func TestReceiveDerivesValidMessageID(t *testing.T) {
+	r := newReceiver(t, `{ name: stripe, channel: ch, messageID: { jsonPath: id }, insecureNoSignature: true }`, domain.RealSystemClock)
+	msg1, err := r.Receive(context.Background(), http.Header{}, []byte(`{"id":"evt_1NG8Du2eZvKYlo2CUI79vXWy"}`))
+	assert.NoError(t, err)
+	msg2, err := r.Receive(context.Background(), http.Header{}, []byte(`{"id":"evt_1NG8Du2eZvKYlo2CUI79vXWy","retried":true}`))
+	assert.NoError(t, err)
+	msg3, err := r.Receive(context.Background(), http.Header{}, []byte(`{"id":"evt_1NG8Du2eZvKYlo2CUI79vXWY"}`))
+	assert.NoError(t, err)
+
+	_, err = domain.ParseMessageID(string(msg1.MessageID))
+	assert.NoError(t, err)
+	assert.Equal(t, msg1.MessageID, msg2.MessageID) // Same ID for retried request
+	assert.NotEqual(t, msg1.MessageID, msg3.MessageID)
+}
+
+func TestReceiveInvalidRequest(t *testing.T) {
+	r := newReceiver(t, `{ name: svc, channel: '{{ .body.channel }}', messageID: { jsonPath: id }, insecureNoSignature: true }`, domain.RealSystemClock)
+	for body, errRegex := range map[string]string{
+		`not json`:      `request body is not JSON`,
+		`{"id":"a"} {}`: `request body is not JSON`,
+		`{"id":"a"}`:    `failed to evaluate channel template`,
+		`{"id":"a","channel":"Invalid Channel Name"}`: `invalid channel ID "Invalid Channel Name"`,
+	} {
+		_, err := r.Receive(context.Background(), http.Header{}, []byte(body))
+		assert.True(t, errors.Is(err, ErrInvalidRequest), body)
+		assert.Regexp(t, errRegex, err)
+	}
+}
+
+func TestReceiveWithSignature(t *testing.T) {
+	clock := dspstesting.NewStubClock(t)
+	body := []byte(`{"id":"msg-1"}`)
+
+	r := newReceiver(t, `{ name: svc, channel: ch, messageID: { jsonPath: id }, signature: { secrets: [ secret-1 ] } }`, clock)
+	header := http.Header{}
+	signature.Sign(header, body, []string{"secret-1"}, clock.Now().Time)
+	_, err := r.Receive(context.Background(), header, body)
+	assert.NoError(t, err)
+
+	clock.Add(5*time.Minute + time.Second)
+	_, err = r.Receive(context.Background(), header, body)
+	assert.True(t, errors.Is(err, ErrSignatureVerification))
+	assert.Regexp(t, `timestamp is out of tolerance`, err)
+
+	_, err = r.Receive(context.Background(), http.Header{}, body)
+	assert.True(t, errors.Is(err, ErrSignatureVerification))
+
+	mac := hmac.New(sha256.New, []byte("secret-1"))
+	mac.Write(body)
+	r = newReceiver(t, `{ name: gh, channel: ch, messageID: { jsonPath: id }, signature: { scheme: github, secrets: [ secret-1 ] } }`, clock)
+	_, err = r.Receive(context.Background(), http.Header{signature.GitHubSignatureHeader: []string{"sha256=" + hex.EncodeToString(mac.Sum(nil))}}, body)
+	assert.NoError(t, err)
+	_, err = r.Receive(context.Background(), http.Header{signature.GitHubSignatureHeader: []string{"sha256=00"}}, body)
+	assert.True(t, errors.Is(err, ErrSignatureVerification))
+
+	r = newReceiver(t, `{ name: stripe, channel: ch, messageID: { jsonPath: id }, signature: { scheme: stripe, secrets: [ secret-1 ] } }`, clock)
+	_, err = r.Receive(context.Background(), http.Header{signature.StripeSignatureHeader: []string{"t=1,v1=00"}}, body)
+	assert.True(t, errors.Is(err, ErrSignatureVerification))
+}
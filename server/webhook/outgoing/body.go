@@ -1,32 +1,247 @@
 package outgoing
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"regexp"
+	"sort"
 
 	"golang.org/x/xerrors"
 
+	"github.com/saiya/dsps/server/config"
 	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/logger"
 )
 
+const outgoingWebhookType = "dsps.channel.outgoing-webhook"
+
 // See server/doc/outgoing-webhook.md
 type outgoingWebhookBody struct {
 	Type      string          `json:"type"`
 	ChannelID string          `json:"channelID"`
 	MessageID string          `json:"messageID"`
 	Content   json.RawMessage `json:"content"`
+
+	// Omitted if JSON content, content is base64 encoded string if contentEncoding is "base64"
+	ContentType     string `json:"contentType,omitempty"`
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// W3C Trace Context of the publisher, omitted if not traced
+	TraceParent string `json:"traceparent,omitempty"`
+	TraceState  string `json:"tracestate,omitempty"`
+}
+
+// CloudEvents 1.0 structured content mode, see https://github.com/cloudevents/spec/blob/v1.0/json-format.md
+type cloudEventsBody struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      json.RawMessage `json:"data_base64,omitempty"` // Non-JSON data
+
+	// Distributed tracing extension, see https://github.com/cloudevents/spec/blob/v1.0/extensions/distributed-tracing.md
+	TraceParent string `json:"traceparent,omitempty"`
+	TraceState  string `json:"tracestate,omitempty"`
+
+	// Extension attributes made from message metadata, marshaled as top-level attributes
+	Extensions map[string]string `json:"-"`
+}
+
+type cloudEventsBodyAttributes cloudEventsBody // To marshal without MarshalJSON of cloudEventsBody
+
+func (body cloudEventsBody) MarshalJSON() ([]byte, error) {
+	encoded, err := json.Marshal(cloudEventsBodyAttributes(body))
+	if err != nil || len(body.Extensions) == 0 {
+		return encoded, err
+	}
+	attrs := make(map[string]json.RawMessage, len(body.Extensions)+8) // RawMessage keeps data as is
+	if err := json.Unmarshal(encoded, &attrs); err != nil {
+		return nil, err
+	}
+	for name, value := range body.Extensions {
+		if attrs[name], err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(attrs)
+}
+
+// CloudEvents attribute names that extension attributes must not overwrite
+var cloudEventsReservedAttributes = map[string]struct{}{
+	"specversion": {}, "id": {}, "source": {}, "type": {}, "datacontenttype": {}, "dataschema": {}, "subject": {}, "time": {},
+	"data": {}, "database64": {}, "traceparent": {}, "tracestate": {},
+}
+
+// cloudEventsExtensionsOf converts message metadata into CloudEvents extension attributes.
+// Because extension attribute name must consist of lower-case letters and digits, removes other characters from metadata keys (e.g. "schema-version" to "schemaversion").
+// Skips metadata that conflicts with standard attributes or with another metadata after the conversion.
+func cloudEventsExtensionsOf(ctx context.Context, msg domain.Message) map[string]string {
+	if len(msg.Metadata) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(msg.Metadata))
+	for key := range msg.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // To resolve conflicts deterministically
+	result := make(map[string]string, len(keys))
+	for _, key := range keys {
+		name := cloudEventsExtensionNameRegexp.ReplaceAllString(key, "")
+		_, reserved := cloudEventsReservedAttributes[name]
+		_, duplicated := result[name]
+		if name == "" || reserved || duplicated {
+			logger.Of(ctx).Debugf(logger.CatOutgoingWebhook, `skipped metadata "%s" of the message (channelID: %s, messageID: %s) because it cannot be a CloudEvents extension attribute`, key, msg.ChannelID, msg.MessageID)
+			continue
+		}
+		result[name] = msg.Metadata[key]
+	}
+	return result
+}
+
+var cloudEventsExtensionNameRegexp = regexp.MustCompile(`[^a-z0-9]`)
+
+// encodeWebhookRequest returns request body and HTTP headers
+func encodeWebhookRequest(ctx context.Context, format string, msg domain.Message) (string, map[string]string, error) {
+	switch format {
+	case config.OutgoingWebhookFormatCloudEventsBinary:
+		// https://github.com/cloudevents/spec/blob/v1.0/http-protocol-binding.md#31-binary-content-mode
+		data, err := msg.RawContent()
+		if err != nil {
+			return "", nil, xerrors.Errorf(`failed to make request body of outgoing-webhook (channelID: %s, messageID: %s): %w`, msg.ChannelID, msg.MessageID, err)
+		}
+		headers := map[string]string{
+			"Content-Type":   msg.MediaType(),
+			"Ce-Specversion": "1.0",
+			"Ce-Id":          string(msg.MessageID),
+			"Ce-Source":      string(msg.ChannelID),
+			"Ce-Type":        outgoingWebhookType,
+		}
+		for name, value := range cloudEventsExtensionsOf(ctx, msg) {
+			headers["Ce-"+name] = value
+		}
+		return string(data), headers, nil
+	case config.OutgoingWebhookFormatCloudEventsStructured:
+		body, err := marshalWebhookBody(msg, newCloudEventsBody(ctx, msg))
+		return body, map[string]string{"Content-Type": "application/cloudevents+json"}, err
+	default:
+		body, err := marshalWebhookBody(msg, newOutgoingWebhookBody(msg))
+		return body, map[string]string{"Content-Type": "application/json"}, err
+	}
+}
+
+// encodeWebhookBatchRequest returns request body and HTTP headers of batched messages
+func encodeWebhookBatchRequest(ctx context.Context, format string, msgs []domain.Message) (string, map[string]string, error) {
+	switch format {
+	case config.OutgoingWebhookFormatCloudEventsStructured:
+		// https://github.com/cloudevents/spec/blob/v1.0/http-protocol-binding.md#33-batched-content-mode
+		bodies := make([]cloudEventsBody, 0, len(msgs))
+		for _, msg := range msgs {
+			bodies = append(bodies, newCloudEventsBody(ctx, msg))
+		}
+		body, err := marshalWebhookBatchBody(msgs, bodies)
+		return body, map[string]string{"Content-Type": "application/cloudevents-batch+json"}, err
+	case config.OutgoingWebhookFormatDSPS:
+		bodies := make([]outgoingWebhookBody, 0, len(msgs))
+		for _, msg := range msgs {
+			bodies = append(bodies, newOutgoingWebhookBody(msg))
+		}
+		body, err := marshalWebhookBatchBody(msgs, bodies)
+		return body, map[string]string{"Content-Type": "application/json"}, err
+	default:
+		return "", nil, xerrors.Errorf(`"%s" format does not support batch`, format)
+	}
 }
 
-func encodeWebhookBody(ctx context.Context, msg domain.Message) (string, error) {
+func newOutgoingWebhookBody(msg domain.Message) outgoingWebhookBody {
 	body := outgoingWebhookBody{
-		Type:      "dsps.channel.outgoing-webhook",
+		Type:      outgoingWebhookType,
 		ChannelID: string(msg.ChannelID),
 		MessageID: string(msg.MessageID),
 		Content:   msg.Content,
+
+		ContentType: msg.ContentType,
+
+		Metadata: msg.Metadata,
+
+		TraceParent: msg.TraceContext.TraceParent,
+		TraceState:  msg.TraceContext.TraceState,
+	}
+	if !msg.IsJSON() {
+		body.ContentEncoding = "base64"
+	}
+	return body
+}
+
+func newCloudEventsBody(ctx context.Context, msg domain.Message) cloudEventsBody {
+	body := cloudEventsBody{
+		SpecVersion:     "1.0",
+		ID:              string(msg.MessageID),
+		Source:          string(msg.ChannelID),
+		Type:            outgoingWebhookType,
+		DataContentType: msg.MediaType(),
+
+		TraceParent: msg.TraceContext.TraceParent,
+		TraceState:  msg.TraceContext.TraceState,
+
+		Extensions: cloudEventsExtensionsOf(ctx, msg),
+	}
+	if msg.IsJSON() {
+		body.Data = msg.Content
+	} else {
+		// Content is already base64 encoded JSON string
+		body.DataBase64 = msg.Content
+	}
+	return body
+}
+
+func marshalWebhookBatchBody(msgs []domain.Message, bodies interface{}) (string, error) {
+	bytes, err := json.Marshal(bodies)
+	if err != nil {
+		return "", xerrors.Errorf(`failed to make request body of outgoing-webhook batch (%d messages): %w`, len(msgs), err)
 	}
+	return string(bytes), nil
+}
+
+// Response body of batched outgoing-webhook to report partial failure, see server/doc/outgoing-webhook.md
+type outgoingWebhookBatchResponse struct {
+	Failed []struct {
+		ChannelID string `json:"channelID"`
+		MessageID string `json:"messageID"`
+		Reason    string `json:"reason"`
+	} `json:"failed"`
+}
+
+// decodeWebhookBatchResponse returns errors of failed messages, treats unknown response body as success of all messages.
+func decodeWebhookBatchResponse(ctx context.Context, body []byte) map[domain.MessageLocator]error {
+	result := make(map[domain.MessageLocator]error)
+	if len(bytes.TrimSpace(body)) == 0 {
+		return result
+	}
+	var res outgoingWebhookBatchResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		logger.Of(ctx).Debugf(logger.CatOutgoingWebhook, "ignored non-JSON response body of outgoing webhook batch: %v", err)
+		return result
+	}
+	for _, failed := range res.Failed {
+		reason := failed.Reason
+		if reason == "" {
+			reason = "no reason given"
+		}
+		msg := domain.MessageLocator{ChannelID: domain.ChannelID(failed.ChannelID), MessageID: domain.MessageID(failed.MessageID)}
+		result[msg] = xerrors.Errorf("webhook receiver reported failure of the message: %s", reason)
+	}
+	return result
+}
+
+func marshalWebhookBody(msg domain.Message, body interface{}) (string, error) {
 	bytes, err := json.Marshal(body)
 	if err != nil {
-		return "", xerrors.Errorf(`failed to make request body of outgoing-webhook (channelID: %s, messageID: %s): %w`, body.ChannelID, body.MessageID, err)
+		return "", xerrors.Errorf(`failed to make request body of outgoing-webhook (channelID: %s, messageID: %s): %w`, msg.ChannelID, msg.MessageID, err)
 	}
 	return string(bytes), nil
 }
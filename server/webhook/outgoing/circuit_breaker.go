@@ -0,0 +1,182 @@
+package outgoing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/logger"
+	"github.com/saiya/dsps/server/telemetry"
+)
+
+// ErrCircuitBreakerOpen means outgoing-webhook skipped because the target is failing.
+var ErrCircuitBreakerOpen = errors.New("circuit breaker is open")
+
+// CircuitBreakerState is state of a circuit breaker, see server/doc/outgoing-webhook.md for spec.
+type CircuitBreakerState string
+
+// States of the circuit breaker
+const (
+	CircuitBreakerClosed   CircuitBreakerState = "closed"
+	CircuitBreakerOpen     CircuitBreakerState = "open"
+	CircuitBreakerHalfOpen CircuitBreakerState = "half-open"
+)
+
+// CircuitBreakerStatus is snapshot of a circuit breaker
+type CircuitBreakerStatus struct {
+	URL                 string              `json:"url"`
+	State               CircuitBreakerState `json:"state"`
+	ConsecutiveFailures int                 `json:"consecutiveFailures"`
+	LastError           string              `json:"lastError"`
+	OpenedAt            *domain.Time        `json:"openedAt,omitempty"` // nil if closed
+}
+
+// CircuitBreakerRegistry holds circuit breakers of outgoing-webhook targets, keyed by URL.
+// All channels share a registry, so that a failing target affects to all channels sending to it.
+type CircuitBreakerRegistry interface {
+	// Status returns status of circuit breakers that have recent failures, ordered by URL.
+	Status() []CircuitBreakerStatus
+	// Reset closes the circuit breaker of the URL, returns false if no such circuit breaker.
+	Reset(ctx context.Context, url string) bool
+
+	// Returns error if the request should be skipped.
+	// Caller must call record() after the request if this method returned nil.
+	allow(ctx context.Context, url string, cfg *config.OutgoingWebhookCircuitBreakerConfig) error
+	record(ctx context.Context, url string, cfg *config.OutgoingWebhookCircuitBreakerConfig, err error)
+}
+
+type circuitBreakerRegistry struct {
+	clock     domain.SystemClock
+	telemetry *telemetry.Telemetry
+
+	lock sync.Mutex
+	// Only has targets with recent failures, to not retain all URLs forever.
+	breakers map[string]*circuitBreaker
+}
+
+type circuitBreaker struct {
+	state               CircuitBreakerState
+	consecutiveFailures int
+	lastError           string
+	openedAt            time.Time
+	probing             bool // true if the half-open probe request is in-flight
+}
+
+// NewCircuitBreakerRegistry creates CircuitBreakerRegistry instance
+func NewCircuitBreakerRegistry(clock domain.SystemClock, telemetry *telemetry.Telemetry) CircuitBreakerRegistry {
+	return &circuitBreakerRegistry{
+		clock:     clock,
+		telemetry: telemetry,
+		breakers:  make(map[string]*circuitBreaker),
+	}
+}
+
+func (r *circuitBreakerRegistry) Status() []CircuitBreakerStatus {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result := make([]CircuitBreakerStatus, 0, len(r.breakers))
+	for url, b := range r.breakers {
+		status := CircuitBreakerStatus{
+			URL:                 url,
+			State:               b.state,
+			ConsecutiveFailures: b.consecutiveFailures,
+			LastError:           b.lastError,
+		}
+		if b.state != CircuitBreakerClosed {
+			status.OpenedAt = &domain.Time{Time: b.openedAt}
+		}
+		result = append(result, status)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].URL < result[j].URL })
+	return result
+}
+
+func (r *circuitBreakerRegistry) Reset(ctx context.Context, url string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.breakers[url]; !ok {
+		return false
+	}
+	delete(r.breakers, url)
+	logger.Of(ctx).Infof(logger.CatOutgoingWebhook, "circuit breaker of outgoing webhook to %s has been reset", url)
+	r.telemetry.RecordCircuitBreakerEvent(ctx, url, "reset")
+	return true
+}
+
+func (r *circuitBreakerRegistry) allow(ctx context.Context, url string, cfg *config.OutgoingWebhookCircuitBreakerConfig) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	b := r.breakers[url]
+	if b == nil {
+		return nil
+	}
+	switch b.state {
+	case CircuitBreakerOpen:
+		if r.clock.Now().Time.Before(b.openedAt.Add(cfg.OpenDuration.Duration)) {
+			break
+		}
+		b.state = CircuitBreakerHalfOpen
+		b.probing = true
+		logger.Of(ctx).Infof(logger.CatOutgoingWebhook, "circuit breaker of outgoing webhook to %s is half-open, sending probe request", url)
+		r.telemetry.RecordCircuitBreakerEvent(ctx, url, "half-opened")
+		return nil
+	case CircuitBreakerHalfOpen:
+		if b.probing {
+			break
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+	r.telemetry.RecordCircuitBreakerEvent(ctx, url, "rejected")
+	return xerrors.Errorf("outgoing webhook to %s skipped: %w", url, ErrCircuitBreakerOpen)
+}
+
+func (r *circuitBreakerRegistry) record(ctx context.Context, url string, cfg *config.OutgoingWebhookCircuitBreakerConfig, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	b := r.breakers[url]
+	if ctx.Err() != nil {
+		// Request aborted by the caller, it is not a failure of the target.
+		if b != nil {
+			b.probing = false
+		}
+		return
+	}
+	if err == nil {
+		if b != nil {
+			if b.state != CircuitBreakerClosed {
+				logger.Of(ctx).Infof(logger.CatOutgoingWebhook, "circuit breaker of outgoing webhook to %s closed", url)
+				r.telemetry.RecordCircuitBreakerEvent(ctx, url, "closed")
+			}
+			delete(r.breakers, url)
+		}
+		return
+	}
+
+	if b == nil {
+		b = &circuitBreaker{state: CircuitBreakerClosed}
+		r.breakers[url] = b
+	}
+	b.consecutiveFailures++
+	b.lastError = err.Error()
+	b.probing = false
+	if b.state == CircuitBreakerHalfOpen || (b.state == CircuitBreakerClosed && b.consecutiveFailures >= *cfg.FailureThreshold) {
+		b.state = CircuitBreakerOpen
+		b.openedAt = r.clock.Now().Time
+		logger.Of(ctx).WarnError(logger.CatOutgoingWebhook, fmt.Sprintf("circuit breaker of outgoing webhook to %s opened after %d consecutive failures, skips requests for %s: %%w", url, b.consecutiveFailures, cfg.OpenDuration), err)
+		r.telemetry.RecordCircuitBreakerEvent(ctx, url, "opened")
+	}
+}
@@ -0,0 +1,141 @@
+package outgoing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/telemetry"
+	dspstesting "github.com/saiya/dsps/server/testing"
+)
+
+func newCircuitBreakerConfig(threshold int, openDuration string) *config.OutgoingWebhookCircuitBreakerConfig {
+	return &config.OutgoingWebhookCircuitBreakerConfig{
+		FailureThreshold: &threshold,
+		OpenDuration:     dspstesting.MakeDurationPtr(openDuration),
+	}
+}
+
+func TestCircuitBreakerStateTransition(t *testing.T) {
+	ctx := context.Background()
+	clock := dspstesting.NewStubClock(t)
+	r := NewCircuitBreakerRegistry(clock, telemetry.NewEmptyTelemetry(t))
+	cfg := newCircuitBreakerConfig(3, "30s")
+	url := "http://localhost/webhook"
+	failure := errors.New("test failure")
+
+	// Success resets consecutive failures
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, r.allow(ctx, url, cfg))
+		r.record(ctx, url, cfg, failure)
+	}
+	assert.NoError(t, r.allow(ctx, url, cfg))
+	r.record(ctx, url, cfg, nil)
+	assert.Equal(t, 0, len(r.Status()))
+
+	// Opens after consecutive failures
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, r.allow(ctx, url, cfg))
+		r.record(ctx, url, cfg, failure)
+	}
+	if status := r.Status(); assert.Equal(t, 1, len(status)) {
+		assert.Equal(t, url, status[0].URL)
+		assert.Equal(t, CircuitBreakerOpen, status[0].State)
+		assert.Equal(t, 3, status[0].ConsecutiveFailures)
+		assert.Equal(t, "test failure", status[0].LastError)
+		assert.Equal(t, clock.Now().Time, status[0].OpenedAt.Time)
+	}
+	assert.True(t, errors.Is(r.allow(ctx, url, cfg), ErrCircuitBreakerOpen))
+	assert.NoError(t, r.allow(ctx, "http://localhost/another", cfg))
+
+	// Half-open after openDuration, allows only one probe
+	clock.Add(30 * time.Second)
+	assert.NoError(t, r.allow(ctx, url, cfg))
+	assert.Equal(t, CircuitBreakerHalfOpen, r.Status()[0].State)
+	assert.True(t, errors.Is(r.allow(ctx, url, cfg), ErrCircuitBreakerOpen))
+
+	// Probe failure opens again
+	r.record(ctx, url, cfg, failure)
+	assert.Equal(t, CircuitBreakerOpen, r.Status()[0].State)
+	assert.True(t, errors.Is(r.allow(ctx, url, cfg), ErrCircuitBreakerOpen))
+
+	// Probe success closes
+	clock.Add(30 * time.Second)
+	assert.NoError(t, r.allow(ctx, url, cfg))
+	r.record(ctx, url, cfg, nil)
+	assert.Equal(t, 0, len(r.Status()))
+	assert.NoError(t, r.allow(ctx, url, cfg))
+}
+
+func TestCircuitBreakerAbortedProbe(t *testing.T) {
+	clock := dspstesting.NewStubClock(t)
+	r := NewCircuitBreakerRegistry(clock, telemetry.NewEmptyTelemetry(t))
+	cfg := newCircuitBreakerConfig(1, "30s")
+	url := "http://localhost/webhook"
+
+	r.record(context.Background(), url, cfg, errors.New("test failure"))
+	clock.Add(30 * time.Second)
+	assert.NoError(t, r.allow(context.Background(), url, cfg))
+
+	// Cancellation of the probe is not a failure of the target, another probe can be sent
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.record(ctx, url, cfg, ctx.Err())
+	assert.Equal(t, CircuitBreakerHalfOpen, r.Status()[0].State)
+	assert.NoError(t, r.allow(context.Background(), url, cfg))
+}
+
+func TestCircuitBreakerReset(t *testing.T) {
+	ctx := context.Background()
+	r := NewCircuitBreakerRegistry(dspstesting.NewStubClock(t), telemetry.NewEmptyTelemetry(t))
+	cfg := newCircuitBreakerConfig(1, "30s")
+	url := "http://localhost/webhook"
+
+	assert.False(t, r.Reset(ctx, url))
+	r.record(ctx, url, cfg, errors.New("test failure"))
+	assert.Error(t, r.allow(ctx, url, cfg))
+	assert.True(t, r.Reset(ctx, url))
+	assert.NoError(t, r.allow(ctx, url, cfg))
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	var called int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&called, 1)
+		rw.WriteHeader(500)
+	}))
+	defer server.Close()
+
+	breakers := NewCircuitBreakerRegistry(dspstesting.NewStubClock(t), telemetry.NewEmptyTelemetry(t))
+	newClient := func(config string) Client {
+		tpl := newClientTemplateWithBreakers(t, `.+`, config, breakers)
+		client, err := tpl.NewClient(map[string]interface{}{})
+		assert.NoError(t, err)
+		return client
+	}
+	retry := `"retry": { "count": 1, "interval": "1ms", "intervalJitter": "1ms" }`
+	client := newClient(`{ "url": "` + server.URL + `", ` + retry + `, "circuitBreaker": { "failureThreshold": 2 } }`)
+	another := newClient(`{ "url": "` + server.URL + `", ` + retry + ` }`) // Other channel/webhook sending to the same URL
+	disabled := newClient(`{ "url": "` + server.URL + `", ` + retry + `, "circuitBreaker": { "disable": true } }`)
+
+	msg := templateTestMessage("msg-1", `{}`)
+	assert.Regexp(t, `status code 500 returned`, client.Send(context.Background(), msg))
+	assert.Regexp(t, `status code 500 returned`, client.Send(context.Background(), msg))
+	assert.Equal(t, int32(2*2), atomic.LoadInt32(&called))
+
+	// Short-circuited without HTTP request, shared by clients of the same URL
+	assert.True(t, errors.Is(client.Send(context.Background(), msg), ErrCircuitBreakerOpen))
+	assert.True(t, errors.Is(another.Send(context.Background(), msg), ErrCircuitBreakerOpen))
+	assert.Equal(t, int32(2*2), atomic.LoadInt32(&called))
+
+	// Disabled client always sends request
+	assert.Regexp(t, `status code 500 returned`, disabled.Send(context.Background(), msg))
+	assert.Equal(t, int32(3*2), atomic.LoadInt32(&called))
+}
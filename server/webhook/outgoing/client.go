@@ -1,8 +1,11 @@
 package outgoing
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"strings"
 	"sync/atomic"
@@ -10,10 +13,12 @@ import (
 
 	"golang.org/x/xerrors"
 
+	"github.com/saiya/dsps/server/config"
 	"github.com/saiya/dsps/server/domain"
 	"github.com/saiya/dsps/server/logger"
 	"github.com/saiya/dsps/server/sentry"
 	"github.com/saiya/dsps/server/telemetry"
+	"github.com/saiya/dsps/server/webhook/signature"
 )
 
 // Client is an outgoing-webhook client.
@@ -27,36 +32,62 @@ type Client interface {
 	String() string
 }
 
+// Max size of the response body to read
+const maxResponseBodySize = 1024 * 1024
+
 type clientImpl struct {
 	_isClosed int32 // 0: available, 1: closing
 
 	method  string
+	format  string
 	url     string
 	headers map[string]string
+	secrets []string // HMAC secrets to sign requests, empty if signing disabled
+	clock   domain.SystemClock
+	msgTpl  messageTemplates
 
 	timeout time.Duration
 	retry   retry
 
+	breakers   CircuitBreakerRegistry
+	breakerCfg *config.OutgoingWebhookCircuitBreakerConfig // nil if circuit breaker disabled
+
 	h         *http.Client // Note that Client does not own this object, ClientTemplate owns.
 	telemetry *telemetry.Telemetry
 	sentry    sentry.Sentry
 }
 
-func newClientImpl(tpl *clientTemplate, tplEnv domain.TemplateStringEnv) (Client, error) {
+func newClientImpl(tpl *clientTemplate, tplEnv domain.TemplateStringEnv) (*clientImpl, error) {
 	c := &clientImpl{
 		_isClosed: 0,
 
 		method:  tpl.Method,
+		format:  tpl.Format,
 		headers: make(map[string]string, len(tpl.Headers)),
 
 		timeout: tpl.Timeout.Duration,
 		retry:   newRetry(&tpl.Retry),
+		msgTpl: messageTemplates{
+			filter: tpl.Filter,
+			body:   tpl.Body,
+			tplEnv: tplEnv,
+		},
+
+		clock:    tpl.clock,
+		breakers: tpl.breakers,
 
 		h:         tpl.h,
 		telemetry: tpl.telemetry,
 		sentry:    tpl.sentry,
 	}
 
+	if tpl.Signing != nil {
+		c.secrets = tpl.Signing.Secrets
+	}
+	if !tpl.CircuitBreaker.Disable {
+		c.breakerCfg = &tpl.CircuitBreaker
+	}
+
 	var err error
 	c.url, err = tpl.URL.Execute(tplEnv)
 	if err != nil {
@@ -79,33 +110,126 @@ func (c *clientImpl) Send(ctx context.Context, msg domain.Message) error {
 	if c.isClosed() {
 		return xerrors.Errorf("outgoing-webhook client already closed")
 	}
+	if !c.msgTpl.accepts(ctx, msg) {
+		logger.Of(ctx).Debugf(logger.CatOutgoingWebhook, "outgoing webhook (channel: %s, messageID: %s) to %s filtered out", msg.ChannelID, msg.MessageID, c.url)
+		return nil
+	}
 	logger.Of(ctx).Debugf(logger.CatOutgoingWebhook, "sending outgoing webhook (channel: %s, messageID: %s) to %s", msg.ChannelID, msg.MessageID, c.url)
+	ctx, end := c.telemetry.StartMessageSpan(ctx, telemetry.WebhookSend, msg)
+	defer end()
 
-	body, err := encodeWebhookBody(ctx, msg)
+	var body string
+	var bodyHeaders map[string]string
+	var err error
+	if c.msgTpl.body != nil {
+		body, bodyHeaders, err = c.msgTpl.renderBody(msg)
+		if err != nil {
+			// Template failure never succeeds with retry, and should not block other webhooks.
+			logger.Of(ctx).Error(fmt.Sprintf("skipped outgoing-webhook (channel: %s, msgID: %s) due to body template failure", msg.ChannelID, msg.MessageID), err)
+			sentry.RecordError(ctx, err)
+			return nil
+		}
+	} else {
+		body, bodyHeaders, err = encodeWebhookRequest(ctx, c.format, msg)
+		if err != nil {
+			return xerrors.Errorf("failed to generate outgoing webhook body: %w", err)
+		}
+	}
+	// Webhook HTTP span overwrites traceparent if DSPS server tracing enabled, otherwise propagates publisher's one as is.
+	telemetry.SetTraceContextHeaders(bodyHeaders, msg.TraceContext)
+	_, err = c.send(ctx, body, bodyHeaders, false)
+	return err
+}
+
+// sendBatch sends messages as a single request.
+// Returns errors of each message that receiver reported as failed, see server/doc/outgoing-webhook.md for spec.
+func (c *clientImpl) sendBatch(ctx context.Context, msgs []domain.Message) (map[domain.MessageLocator]error, error) {
+	if c.isClosed() {
+		return nil, xerrors.Errorf("outgoing-webhook client already closed")
+	}
+	logger.Of(ctx).Debugf(logger.CatOutgoingWebhook, "sending outgoing webhook batch (%d messages) to %s", len(msgs), c.url)
+
+	body, bodyHeaders, err := encodeWebhookBatchRequest(ctx, c.format, msgs)
 	if err != nil {
-		return xerrors.Errorf("failed to generate outgoing webhook body: %w", err)
+		return nil, xerrors.Errorf("failed to generate outgoing webhook body: %w", err)
+	}
+	resBody, err := c.send(ctx, body, bodyHeaders, true)
+	if err != nil {
+		return nil, err
+	}
+	return decodeWebhookBatchResponse(ctx, resBody), nil
+}
+
+// send performs HTTP request with retry, returns response body only if readResponse is true.
+func (c *clientImpl) send(ctx context.Context, body string, bodyHeaders map[string]string, readResponse bool) ([]byte, error) {
+	recorder := requestRecorderOf(ctx)
+	if c.breakerCfg != nil {
+		if err := c.breakers.allow(ctx, c.url, c.breakerCfg); err != nil {
+			recorder.add(domain.WebhookRequestRecord{URL: c.url, Error: err.Error()})
+			return nil, err
+		}
 	}
 
-	return c.retry.Do(ctx, c.sentry, fmt.Sprintf("outgoing-webhook to %s", c.url), func() (*http.Request, *http.Response, error) {
+	var resBody []byte
+	err := c.retry.Do(ctx, c.sentry, fmt.Sprintf("outgoing-webhook to %s", c.url), func() (*http.Request, *http.Response, error) {
 		req, err := http.NewRequestWithContext(ctx, c.method, c.url, strings.NewReader(body))
 		if err != nil {
 			return req, nil, err
 		}
-		req.Header.Set("Content-Type", "application/json")
+		for name, value := range bodyHeaders {
+			req.Header.Set(name, value)
+		}
 		for name, value := range c.headers {
 			// Should overwrite default headers, thus use Set() rather than Add()
 			req.Header.Set(name, value)
 		}
+		if len(c.secrets) > 0 {
+			signature.Sign(req.Header, []byte(body), c.secrets, c.clock.Now().Time)
+		}
 
 		ctx, end := c.telemetry.StartHTTPSpan(ctx, false, req)
 		defer end()
+		startedAt := time.Now()
 		res, err := c.h.Do(req)
+		defer func() {
+			record := newWebhookRequestRecord(c.url, startedAt, res, err)
+			recorder.add(record)
+			c.telemetry.CountWebhookAttempt(ctx, record.Error == "")
+		}()
 		if res != nil {
 			logger.Of(ctx).Debugf(logger.CatOutgoingWebhook, "received outgoing webhook response (%s %d, contentLength: %d)", res.Proto, res.StatusCode, res.ContentLength)
 			c.telemetry.SetHTTPResponseAttributes(ctx, res.StatusCode, res.ContentLength)
+			if readResponse && err == nil {
+				resBody, err = ioutil.ReadAll(io.LimitReader(res.Body, maxResponseBodySize))
+				res.Body = ioutil.NopCloser(bytes.NewReader(resBody)) // retry.Do consumes body
+			}
 		}
 		return req, res, err
 	})
+	if c.breakerCfg != nil {
+		c.breakers.record(ctx, c.url, c.breakerCfg, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resBody, nil
+}
+
+func newWebhookRequestRecord(url string, startedAt time.Time, res *http.Response, err error) domain.WebhookRequestRecord {
+	record := domain.WebhookRequestRecord{
+		URL:     url,
+		Latency: domain.Duration{Duration: time.Since(startedAt)},
+	}
+	if res != nil {
+		record.StatusCode = res.StatusCode
+		if res.StatusCode < 200 || 299 < res.StatusCode {
+			record.Error = fmt.Sprintf("status code %d returned", res.StatusCode)
+		}
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	return record
 }
 
 func (c *clientImpl) Close(ctx context.Context) {
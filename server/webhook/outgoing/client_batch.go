@@ -0,0 +1,179 @@
+package outgoing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/telemetry"
+)
+
+// batchClient aggregates messages and sends them as a single request through batcher.
+// Send blocks until the batch that contains the message has been sent.
+type batchClient struct {
+	c *clientImpl // Client of this channel, note that batcher may use client of another channel
+	b *batcher
+}
+
+func (c *batchClient) String() string {
+	return fmt.Sprintf("%s (batch)", c.b.client.String())
+}
+
+func (c *batchClient) Send(ctx context.Context, msg domain.Message) error {
+	if !c.c.msgTpl.accepts(ctx, msg) {
+		return nil
+	}
+	ctx, end := c.c.telemetry.StartMessageSpan(ctx, telemetry.WebhookSend, msg)
+	defer end()
+	return c.b.send(ctx, msg)
+}
+
+func (c *batchClient) Close(ctx context.Context) {
+	// Do nothing because batcher is shared with other clients, ClientTemplate closes it.
+}
+
+// batcher holds a pending batch of a webhook destination.
+type batcher struct {
+	client      *clientImpl
+	maxMessages int
+	window      time.Duration
+
+	lock    sync.Mutex
+	pending *batch // nil if no pending messages
+}
+
+type batch struct {
+	ctx      context.Context
+	recorder *RequestRecorder // Records requests of the batch, to share them with all messages in it
+	msgs     []domain.Message
+
+	timer *time.Timer
+	done  chan struct{}
+
+	// Results, available after done closed
+	err    error
+	failed map[domain.MessageLocator]error
+}
+
+// batchKeyOf returns identity of the webhook destination, clients with same key share a batcher.
+func batchKeyOf(c *clientImpl) string {
+	names := make([]string, 0, len(c.headers))
+	for name := range c.headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	key := strings.Builder{}
+	key.WriteString(c.method + " " + c.url)
+	for _, name := range names {
+		key.WriteString(fmt.Sprintf("\n%s: %s", name, c.headers[name]))
+	}
+	return key.String()
+}
+
+func newBatcher(client *clientImpl, maxMessages int, window time.Duration) *batcher {
+	return &batcher{
+		client:      client,
+		maxMessages: maxMessages,
+		window:      window,
+	}
+}
+
+func (b *batcher) send(ctx context.Context, msg domain.Message) error {
+	var full bool
+	var bt *batch
+	func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		if b.pending == nil {
+			// Batch outlives the request that started it, thus should not be cancelled by it.
+			batchCtx, recorder := WithRequestRecorder(detachedContext{ctx})
+			created := &batch{
+				ctx:      batchCtx,
+				recorder: recorder,
+				done:     make(chan struct{}),
+			}
+			created.timer = time.AfterFunc(b.window, func() {
+				if b.take(created) {
+					b.flush(created)
+				}
+			})
+			b.pending = created
+		}
+		bt = b.pending
+		bt.msgs = append(bt.msgs, msg)
+		if len(bt.msgs) >= b.maxMessages {
+			full = true
+			bt.timer.Stop()
+			b.pending = nil
+		}
+	}()
+	if full {
+		b.flush(bt)
+	}
+
+	select {
+	case <-bt.done:
+		requestRecorderOf(ctx).add(bt.recorder.Records()...)
+		if bt.err != nil {
+			return bt.err
+		}
+		return bt.failed[msg.MessageLocator]
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// take removes given batch from pending state, returns false if already taken.
+func (b *batcher) take(bt *batch) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.pending != bt {
+		return false
+	}
+	b.pending = nil
+	return true
+}
+
+func (b *batcher) flush(bt *batch) {
+	defer close(bt.done)
+	bt.failed, bt.err = b.client.sendBatch(bt.ctx, bt.msgs)
+}
+
+// close sends pending messages immediately.
+func (b *batcher) close() {
+	b.lock.Lock()
+	bt := b.pending
+	b.pending = nil
+	b.lock.Unlock()
+
+	if bt != nil {
+		bt.timer.Stop()
+		b.flush(bt)
+	}
+}
+
+// detachedContext keeps values of the parent context but never be cancelled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (ctx detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (ctx detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (ctx detachedContext) Err() error {
+	return nil
+}
+
+func (ctx detachedContext) Value(key interface{}) interface{} {
+	return ctx.parent.Value(key)
+}
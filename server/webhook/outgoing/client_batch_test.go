@@ -0,0 +1,197 @@
+package outgoing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+func newBatchClientAndServer(t *testing.T, handler http.Handler, config string, h func(tpl ClientTemplate, client Client)) {
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	tpl := newClientTemplateByConfig(t, `.+`, strings.ReplaceAll(config, "${BASE_URL}", server.URL))
+	defer tpl.Close()
+
+	client, err := tpl.NewClient(map[string]interface{}{"channel": map[string]string{"id": "1234"}})
+	assert.NoError(t, err)
+	defer client.Close(context.Background())
+	assert.Contains(t, client.String(), "(batch)")
+
+	h(tpl, client)
+}
+
+func batchTestMessage(i int) domain.Message {
+	return domain.Message{
+		MessageLocator: domain.MessageLocator{
+			ChannelID: "chat-room-1234",
+			MessageID: domain.MessageID(fmt.Sprintf("msg-%d", i)),
+		},
+		Content: []byte(fmt.Sprintf(`{"i":%d}`, i)),
+	}
+}
+
+func sendConcurrently(t *testing.T, client Client, msgs []domain.Message) []error {
+	errs := make([]error, len(msgs))
+	wg := sync.WaitGroup{}
+	for i := range msgs {
+		wg.Add(1)
+		i := i
+		go func() {
+			defer wg.Done()
+			errs[i] = client.Send(context.Background(), msgs[i])
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestBatchClientMaxMessages(t *testing.T) {
+	lock := sync.Mutex{}
+	var received [][]domain.Message
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var body []domain.Message
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		lock.Lock()
+		defer lock.Unlock()
+		received = append(received, body)
+	}
+	newBatchClientAndServer(t, handler, `{ "url": "${BASE_URL}/", "batch": { "maxMessages": 3, "window": "1m" } }`, func(tpl ClientTemplate, client Client) {
+		msgs := []domain.Message{batchTestMessage(1), batchTestMessage(2), batchTestMessage(3)}
+		for _, err := range sendConcurrently(t, client, msgs) {
+			assert.NoError(t, err)
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		if assert.Equal(t, 1, len(received)) {
+			assert.ElementsMatch(t, msgs, received[0])
+		}
+	})
+}
+
+func TestBatchClientWindow(t *testing.T) {
+	lock := sync.Mutex{}
+	var received [][]domain.Message
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/cloudevents-batch+json", r.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		var events []struct {
+			ID     domain.MessageID `json:"id"`
+			Source domain.ChannelID `json:"source"`
+			Data   json.RawMessage  `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(body, &events))
+
+		msgs := make([]domain.Message, 0, len(events))
+		for _, e := range events {
+			msgs = append(msgs, domain.Message{MessageLocator: domain.MessageLocator{ChannelID: e.Source, MessageID: e.ID}, Content: e.Data})
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		received = append(received, msgs)
+	}
+	newBatchClientAndServer(t, handler, `{ "url": "${BASE_URL}/", "format": "cloudevents-structured", "batch": { "maxMessages": 100, "window": "100ms" } }`, func(tpl ClientTemplate, client Client) {
+		msgs := []domain.Message{batchTestMessage(1), batchTestMessage(2)}
+		started := time.Now()
+		for _, err := range sendConcurrently(t, client, msgs) {
+			assert.NoError(t, err)
+		}
+		assert.GreaterOrEqual(t, int64(time.Since(started)), int64(100*time.Millisecond))
+
+		lock.Lock()
+		defer lock.Unlock()
+		if assert.Equal(t, 1, len(received)) {
+			assert.ElementsMatch(t, msgs, received[0])
+		}
+	})
+}
+
+func TestBatchClientPartialFailure(t *testing.T) {
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		_, err := rw.Write([]byte(`{ "failed": [ { "channelID": "chat-room-1234", "messageID": "msg-2", "reason": "test failure" } ] }`))
+		assert.NoError(t, err)
+	}
+	newBatchClientAndServer(t, handler, `{ "url": "${BASE_URL}/", "batch": { "maxMessages": 3, "window": "1m" } }`, func(tpl ClientTemplate, client Client) {
+		errs := sendConcurrently(t, client, []domain.Message{batchTestMessage(1), batchTestMessage(2), batchTestMessage(3)})
+		assert.NoError(t, errs[0])
+		assert.Regexp(t, `test failure`, errs[1])
+		assert.NoError(t, errs[2])
+	})
+}
+
+func TestBatchClientRequestFailure(t *testing.T) {
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(403)
+	}
+	newBatchClientAndServer(t, handler, `{ "url": "${BASE_URL}/", "batch": { "maxMessages": 2, "window": "1m" } }`, func(tpl ClientTemplate, client Client) {
+		for _, err := range sendConcurrently(t, client, []domain.Message{batchTestMessage(1), batchTestMessage(2)}) {
+			assert.Regexp(t, `status code 403 returned`, err)
+		}
+	})
+}
+
+func TestBatchClientSharedBetweenClients(t *testing.T) {
+	lock := sync.Mutex{}
+	requests := 0
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		requests++
+	}
+	newBatchClientAndServer(t, handler, `{ "url": "${BASE_URL}/", "batch": { "maxMessages": 2, "window": "1m" } }`, func(tpl ClientTemplate, client Client) {
+		another, err := tpl.NewClient(map[string]interface{}{"channel": map[string]string{"id": "1234"}})
+		assert.NoError(t, err)
+		defer another.Close(context.Background())
+
+		wg := sync.WaitGroup{}
+		for i, c := range []Client{client, another} {
+			wg.Add(1)
+			i, c := i, c
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, c.Send(context.Background(), batchTestMessage(i)))
+			}()
+		}
+		wg.Wait()
+
+		lock.Lock()
+		defer lock.Unlock()
+		assert.Equal(t, 1, requests)
+	})
+}
+
+func TestBatchClientFlushOnClose(t *testing.T) {
+	lock := sync.Mutex{}
+	requests := 0
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		requests++
+	}
+	newBatchClientAndServer(t, handler, `{ "url": "${BASE_URL}/", "batch": { "maxMessages": 100, "window": "1h" } }`, func(tpl ClientTemplate, client Client) {
+		result := make(chan error)
+		go func() { result <- client.Send(context.Background(), batchTestMessage(1)) }()
+		time.Sleep(50 * time.Millisecond)
+
+		tpl.Close()
+		assert.NoError(t, <-result)
+		lock.Lock()
+		defer lock.Unlock()
+		assert.Equal(t, 1, requests)
+	})
+}
@@ -28,13 +28,26 @@ func (mux *multiplexClient) String() string {
 }
 
 func (mux *multiplexClient) Send(ctx context.Context, msg domain.Message) error {
+	// Send concurrently, because batching clients block until their batch is sent.
+	errs := make([]error, len(mux.clients))
+	wg := sync.WaitGroup{}
+	for i := range mux.clients {
+		wg.Add(1)
+		i := i
+		go func() {
+			defer wg.Done()
+			errs[i] = mux.clients[i].Send(ctx, msg)
+		}()
+	}
+	wg.Wait()
+
 	var lastError error = nil
-	for _, c := range mux.clients {
-		if err := c.Send(ctx, msg); err != nil {
+	for i, err := range errs {
+		if err != nil {
 			if lastError != nil {
 				logger.Of(ctx).WarnError(logger.CatOutgoingWebhook, "multiple error on outgoing-webhook multiplexer", lastError)
 			}
-			lastError = fmt.Errorf("%s: %w", c.String(), err)
+			lastError = fmt.Errorf("%s: %w", mux.clients[i].String(), err)
 		}
 	}
 	return lastError
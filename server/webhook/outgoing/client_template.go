@@ -3,6 +3,7 @@ package outgoing
 import (
 	"context"
 	"net/http"
+	"sync"
 
 	"github.com/saiya/dsps/server/config"
 	"github.com/saiya/dsps/server/domain"
@@ -24,28 +25,62 @@ type clientTemplate struct {
 	h        *http.Client
 	maxConns int
 
+	clock    domain.SystemClock
+	breakers CircuitBreakerRegistry
+
+	batchersLock sync.Mutex
+	batchers     map[string]*batcher // Batcher of each destination, empty if batch disabled
+
 	telemetry *telemetry.Telemetry
 	sentry    sentry.Sentry
 }
 
 // NewClientTemplate returns ClientTemplate instalce
-func NewClientTemplate(ctx context.Context, cfg *config.OutgoingWebhookConfig, telemetry *telemetry.Telemetry, sentry sentry.Sentry) (ClientTemplate, error) {
+func NewClientTemplate(ctx context.Context, cfg *config.OutgoingWebhookConfig, clock domain.SystemClock, breakers CircuitBreakerRegistry, telemetry *telemetry.Telemetry, sentry sentry.Sentry) (ClientTemplate, error) {
 	return &clientTemplate{
 		OutgoingWebhookConfig: cfg,
 
 		h:        newHTTPClientFor(ctx, cfg),
 		maxConns: *cfg.Connection.Max,
 
+		clock:    clock,
+		breakers: breakers,
+		batchers: make(map[string]*batcher),
+
 		telemetry: telemetry,
 		sentry:    sentry,
 	}, nil
 }
 
 func (tpl *clientTemplate) NewClient(tplEnv domain.TemplateStringEnv) (Client, error) {
-	return newClientImpl(tpl, tplEnv)
+	c, err := newClientImpl(tpl, tplEnv)
+	if err != nil {
+		return nil, err
+	}
+	if tpl.Batch == nil {
+		return c, nil
+	}
+
+	tpl.batchersLock.Lock()
+	defer tpl.batchersLock.Unlock()
+	key := batchKeyOf(c)
+	b, ok := tpl.batchers[key]
+	if !ok {
+		b = newBatcher(c, *tpl.Batch.MaxMessages, tpl.Batch.Window.Duration)
+		tpl.batchers[key] = b
+	}
+	return &batchClient{c: c, b: b}, nil
 }
 
 func (tpl *clientTemplate) Close() {
+	tpl.batchersLock.Lock()
+	batchers := tpl.batchers
+	tpl.batchers = make(map[string]*batcher)
+	tpl.batchersLock.Unlock()
+	for _, b := range batchers {
+		b.close()
+	}
+
 	tpl.h.CloseIdleConnections()
 }
 
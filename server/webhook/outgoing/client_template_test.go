@@ -9,11 +9,16 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/saiya/dsps/server/config"
+	"github.com/saiya/dsps/server/domain"
 	"github.com/saiya/dsps/server/sentry"
 	"github.com/saiya/dsps/server/telemetry"
 )
 
 func newClientTemplateByConfig(t *testing.T, channelRegex string, json string) ClientTemplate {
+	return newClientTemplateWithBreakers(t, channelRegex, json, NewCircuitBreakerRegistry(domain.RealSystemClock, telemetry.NewEmptyTelemetry(t)))
+}
+
+func newClientTemplateWithBreakers(t *testing.T, channelRegex string, json string, breakers CircuitBreakerRegistry) ClientTemplate {
 	ctx := context.Background()
 	yaml := strings.ReplaceAll(
 		fmt.Sprintf(
@@ -28,7 +33,7 @@ func newClientTemplateByConfig(t *testing.T, channelRegex string, json string) C
 	assert.NoError(t, err)
 
 	telemetry := telemetry.NewEmptyTelemetry(t)
-	tpl, err := NewClientTemplate(ctx, &cfg.Channels[0].Webhooks[0], telemetry, sentry.NewEmptySentry())
+	tpl, err := NewClientTemplate(ctx, &cfg.Channels[0].Webhooks[0], domain.RealSystemClock, breakers, telemetry, sentry.NewEmptySentry())
 	assert.NoError(t, err)
 	return tpl
 }
@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.opentelemetry.io/otel/trace"
@@ -17,6 +18,8 @@ import (
 	"github.com/saiya/dsps/server/domain"
 	"github.com/saiya/dsps/server/sentry"
 	"github.com/saiya/dsps/server/telemetry"
+	dspstesting "github.com/saiya/dsps/server/testing"
+	"github.com/saiya/dsps/server/webhook/signature"
 )
 
 func newClientAndServerByConfig(t *testing.T, handler http.Handler, tplEnv domain.TemplateStringEnv, config string, h func(client *clientImpl)) {
@@ -42,7 +45,8 @@ func TestClientImpl(t *testing.T) {
 			ChannelID: "chat-room-1234",
 			MessageID: "msg-1",
 		},
-		Content: []byte(`{"hi":"hello"}`),
+		Content:  []byte(`{"hi":"hello"}`),
+		Metadata: map[string]string{"content-type": "application/json"},
 	}
 	var received domain.Message
 	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
@@ -77,6 +81,156 @@ func TestClientImpl(t *testing.T) {
 	assert.EqualValues(t, msg, received)
 }
 
+func TestClientSigning(t *testing.T) {
+	msg := domain.Message{
+		MessageLocator: domain.MessageLocator{
+			ChannelID: "chat-room-1234",
+			MessageID: "msg-1",
+		},
+		Content: []byte(`{"hi":"hello"}`),
+	}
+	clock := dspstesting.NewStubClock(t)
+	clock.Set(time.Date(2020, 11, 17, 12, 0, 0, 0, time.UTC))
+	called := false
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		called = true
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%d", clock.Now().Unix()), r.Header.Get(signature.TimestampHeader)) // Signed with the system clock
+		assert.Equal(t, 2, len(strings.Split(r.Header.Get(signature.SignatureHeader), ",")))
+		assert.NoError(t, signature.Verify(r.Header, body, []string{"new-secret"}, signature.DefaultTolerance, clock.Now().Time))
+		assert.NoError(t, signature.Verify(r.Header, body, []string{"old-secret"}, signature.DefaultTolerance, clock.Now().Time))
+		assert.Equal(t, signature.ErrSignatureMismatch, signature.Verify(r.Header, body, []string{"unknown-secret"}, signature.DefaultTolerance, clock.Now().Time))
+	}
+	newClientAndServerByConfig(
+		t,
+		handler,
+		map[string]interface{}{"channel": map[string]string{"id": "1234"}},
+		`{
+			"url": "${BASE_URL}/you-got-message/room/{{.channel.id}}",
+			"headers": {
+				"X-DSPS-Signature": "must be overwritten"
+			},
+			"signing": {
+				"secrets": [ "new-secret", "old-secret" ]
+			}
+		}`,
+		func(client *clientImpl) {
+			client.clock = clock
+			assert.NoError(t, client.Send(context.Background(), msg))
+		},
+	)
+	assert.True(t, called)
+}
+
+func TestClientCloudEventsStructured(t *testing.T) {
+	msg := domain.Message{
+		MessageLocator: domain.MessageLocator{
+			ChannelID: "chat-room-1234",
+			MessageID: "msg-1",
+		},
+		Content:  []byte(`{"hi":"hello","id":12345678901234567890}`),
+		Metadata: map[string]string{"schema-version": "2", "id": "conflicts with standard attribute"},
+	}
+	called := false
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		called = true
+		assert.Equal(t, "application/cloudevents+json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "", r.Header.Get("Ce-Id"))
+
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{
+			"specversion": "1.0",
+			"id": "msg-1",
+			"source": "chat-room-1234",
+			"type": "dsps.channel.outgoing-webhook",
+			"datacontenttype": "application/json",
+			"data": {"hi":"hello","id":12345678901234567890},
+			"schemaversion": "2"
+		}`, string(body))
+		assert.Contains(t, string(body), `12345678901234567890`) // Must not lose precision
+	}
+	newClientAndServerByConfig(
+		t,
+		handler,
+		map[string]interface{}{"channel": map[string]string{"id": "1234"}},
+		`{ "url": "${BASE_URL}/", "format": "cloudevents-structured" }`,
+		func(client *clientImpl) {
+			assert.NoError(t, client.Send(context.Background(), msg))
+		},
+	)
+	assert.True(t, called)
+}
+
+func TestClientCloudEventsBinary(t *testing.T) {
+	msg := domain.Message{
+		MessageLocator: domain.MessageLocator{
+			ChannelID: "chat-room-1234",
+			MessageID: "msg-1",
+		},
+		Content:  []byte(`{"hi":"hello"}`),
+		Metadata: map[string]string{"schema-version": "2", "schema_version": "duplicated", "type": "conflicts with standard attribute"},
+	}
+	called := false
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		called = true
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "1.0", r.Header.Get("Ce-Specversion"))
+		assert.Equal(t, "msg-1", r.Header.Get("Ce-Id"))
+		assert.Equal(t, "chat-room-1234", r.Header.Get("Ce-Source"))
+		assert.Equal(t, "dsps.channel.outgoing-webhook", r.Header.Get("Ce-Type"))
+		assert.Equal(t, []string{"2"}, r.Header.Values("Ce-Schemaversion"))
+
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"hi":"hello"}`, string(body))
+	}
+	newClientAndServerByConfig(
+		t,
+		handler,
+		map[string]interface{}{"channel": map[string]string{"id": "1234"}},
+		`{ "url": "${BASE_URL}/", "format": "cloudevents-binary" }`,
+		func(client *clientImpl) {
+			assert.NoError(t, client.Send(context.Background(), msg))
+		},
+	)
+	assert.True(t, called)
+}
+
+func TestClientNonJSONContent(t *testing.T) {
+	msg := domain.Message{
+		MessageLocator: domain.MessageLocator{
+			ChannelID: "chat-room-1234",
+			MessageID: "msg-1",
+		},
+		Content:     domain.NewBinaryContent([]byte{0x08, 0x96, 0x01}),
+		ContentType: "application/x-protobuf",
+	}
+	received := map[string]string{}
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		received[r.URL.Path] = r.Header.Get("Content-Type") + " " + string(body)
+	}
+	for _, format := range []string{"dsps", "cloudevents-structured", "cloudevents-binary"} {
+		newClientAndServerByConfig(
+			t,
+			handler,
+			map[string]interface{}{},
+			`{ "url": "${BASE_URL}/`+format+`", "format": "`+format+`" }`,
+			func(client *clientImpl) {
+				assert.NoError(t, client.Send(context.Background(), msg))
+			},
+		)
+	}
+	assert.Equal(t, map[string]string{
+		"/dsps":                   `application/json {"type":"dsps.channel.outgoing-webhook","channelID":"chat-room-1234","messageID":"msg-1","content":"CJYB","contentType":"application/x-protobuf","contentEncoding":"base64"}`,
+		"/cloudevents-structured": `application/cloudevents+json {"specversion":"1.0","id":"msg-1","source":"chat-room-1234","type":"dsps.channel.outgoing-webhook","datacontenttype":"application/x-protobuf","data_base64":"CJYB"}`,
+		"/cloudevents-binary":     "application/x-protobuf \x08\x96\x01",
+	}, received)
+}
+
 func TestClientTracing(t *testing.T) {
 	msg := domain.Message{
 		MessageLocator: domain.MessageLocator{
@@ -0,0 +1,84 @@
+package outgoing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/logger"
+	"github.com/saiya/dsps/server/sentry"
+)
+
+// messageTemplates evaluates per-message templates (filter and body) of a webhook.
+type messageTemplates struct {
+	filter *domain.TemplateString // nil to send all messages
+	body   *domain.TemplateString // nil to use body of the format
+
+	tplEnv domain.TemplateStringEnv
+}
+
+// messageTemplateEnv returns template environment that has ".message" in addition to the channel template environment.
+func (t *messageTemplates) messageTemplateEnv(msg domain.Message) (domain.TemplateStringEnv, error) {
+	var content interface{}
+	if err := json.Unmarshal(msg.Content, &content); err != nil {
+		return nil, xerrors.Errorf("failed to decode message content: %w", err)
+	}
+
+	metadata := make(map[string]interface{}, len(msg.Metadata)) // Should not be nil to use index function in templates
+	for key, value := range msg.Metadata {
+		metadata[key] = value
+	}
+
+	env := make(map[string]interface{})
+	if channelEnv, ok := t.tplEnv.(map[string]interface{}); ok {
+		for key, value := range channelEnv {
+			env[key] = value
+		}
+	}
+	env["message"] = map[string]interface{}{
+		"channelID":   string(msg.ChannelID),
+		"messageID":   string(msg.MessageID),
+		"content":     content, // base64 encoded string if non-JSON content
+		"contentType": msg.MediaType(),
+		"metadata":    metadata,
+	}
+	return env, nil
+}
+
+// accepts returns true if the message should be sent.
+// Returns false on template failure, to not block delivery of the message to other webhooks.
+func (t *messageTemplates) accepts(ctx context.Context, msg domain.Message) bool {
+	if t.filter == nil {
+		return true
+	}
+	env, err := t.messageTemplateEnv(msg)
+	if err == nil {
+		var result string
+		result, err = t.filter.Execute(env)
+		if err == nil {
+			return strings.TrimSpace(result) == "true"
+		}
+	}
+
+	err = xerrors.Errorf(`failed to evaluate outgoing-webhook filter "%s": %w`, t.filter, err)
+	logger.Of(ctx).Error(fmt.Sprintf("skipped outgoing-webhook (channel: %s, msgID: %s) due to filter failure", msg.ChannelID, msg.MessageID), err)
+	sentry.RecordError(ctx, err)
+	return false
+}
+
+// renderBody returns request body and HTTP headers generated by the body template.
+func (t *messageTemplates) renderBody(msg domain.Message) (string, map[string]string, error) {
+	env, err := t.messageTemplateEnv(msg)
+	if err != nil {
+		return "", nil, err
+	}
+	body, err := t.body.Execute(env)
+	if err != nil {
+		return "", nil, xerrors.Errorf(`failed to evaluate outgoing-webhook body template "%s": %w`, t.body, err)
+	}
+	return body, map[string]string{"Content-Type": "application/json"}, nil
+}
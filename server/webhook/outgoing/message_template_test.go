@@ -0,0 +1,232 @@
+package outgoing
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/domain"
+	"github.com/saiya/dsps/server/sentry"
+)
+
+func templateTestMessage(id domain.MessageID, content string) domain.Message {
+	return domain.Message{
+		MessageLocator: domain.MessageLocator{ChannelID: "chat-room-1234", MessageID: id},
+		Content:        json.RawMessage(content),
+	}
+}
+
+func TestClientFilter(t *testing.T) {
+	lock := sync.Mutex{}
+	received := []domain.MessageID{}
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		var body domain.Message
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		lock.Lock()
+		defer lock.Unlock()
+		received = append(received, body.MessageID)
+	}
+	newClientAndServerByConfig(
+		t,
+		handler,
+		map[string]interface{}{"channel": map[string]string{"id": "1234"}},
+		`{
+			"url": "${BASE_URL}/",
+			"filter": "{{ and (eq .channel.id \"1234\") (eq (index .message.content \"severity\") \"critical\") }}"
+		}`,
+		func(client *clientImpl) {
+			ctx := context.Background()
+			assert.NoError(t, client.Send(ctx, templateTestMessage("msg-1", `{"severity":"critical"}`)))
+			assert.NoError(t, client.Send(ctx, templateTestMessage("msg-2", `{"severity":"info"}`)))
+			assert.NoError(t, client.Send(ctx, templateTestMessage("msg-3", `{}`)))
+		},
+	)
+	assert.Equal(t, []domain.MessageID{"msg-1"}, received)
+}
+
+func TestClientFilterFailure(t *testing.T) {
+	called := false
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		called = true
+	}
+	sentry := sentry.NewStubSentry()
+	newClientAndServerByConfig(
+		t,
+		handler,
+		map[string]interface{}{},
+		`{ "url": "${BASE_URL}/", "filter": "{{ eq .message.content.severity \"critical\" }}" }`,
+		func(client *clientImpl) {
+			ctx := sentry.WrapContext(context.Background())
+			// Template failure skips the message without error, not to retry other webhooks
+			assert.NoError(t, client.Send(ctx, templateTestMessage("msg-1", `{}`)))
+			assert.Regexp(t, `failed to evaluate outgoing-webhook filter`, sentry.GetLastError())
+		},
+	)
+	assert.False(t, called)
+}
+
+func TestClientBodyTemplate(t *testing.T) {
+	called := false
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		called = true
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"text":"[chat-room-1234/msg-1] say \"hi\""}`, string(body))
+	}
+	newClientAndServerByConfig(
+		t,
+		handler,
+		map[string]interface{}{},
+		`{ "url": "${BASE_URL}/", "body": "{\"text\":{{ json (printf \"[%s/%s] %s\" .message.channelID .message.messageID .message.content.text) }}}" }`,
+		func(client *clientImpl) {
+			assert.NoError(t, client.Send(context.Background(), templateTestMessage("msg-1", `{"text":"say \"hi\""}`)))
+		},
+	)
+	assert.True(t, called)
+}
+
+func TestClientTemplateMetadata(t *testing.T) {
+	received := []string{}
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		received = append(received, string(body))
+	}
+	newClientAndServerByConfig(
+		t,
+		handler,
+		map[string]interface{}{},
+		`{
+			"url": "${BASE_URL}/",
+			"filter": "{{ ne (index .message.metadata \"producer\") \"test\" }}",
+			"body": "{\"version\":{{ json (index .message.metadata \"schema-version\") }}}"
+		}`,
+		func(client *clientImpl) {
+			ctx := context.Background()
+			msg := templateTestMessage("msg-1", `{}`)
+			msg.Metadata = map[string]string{"schema-version": "2"}
+			assert.NoError(t, client.Send(ctx, msg))
+			msg = templateTestMessage("msg-2", `{}`) // No metadata
+			assert.NoError(t, client.Send(ctx, msg))
+			msg = templateTestMessage("msg-3", `{}`)
+			msg.Metadata = map[string]string{"producer": "test"}
+			assert.NoError(t, client.Send(ctx, msg))
+		},
+	)
+	assert.Equal(t, []string{`{"version":"2"}`, `{"version":null}`}, received)
+}
+
+func TestClientTemplateBinaryContent(t *testing.T) {
+	received := []string{}
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		received = append(received, string(body))
+	}
+	newClientAndServerByConfig(
+		t,
+		handler,
+		map[string]interface{}{},
+		`{
+			"url": "${BASE_URL}/",
+			"body": "{\"type\":{{ json .message.contentType }},\"data\":{{ json .message.content }}}"
+		}`,
+		func(client *clientImpl) {
+			ctx := context.Background()
+			assert.NoError(t, client.Send(ctx, templateTestMessage("msg-1", `{"hi":"hello"}`)))
+			msg := templateTestMessage("msg-2", string(domain.NewBinaryContent([]byte("hello"))))
+			msg.ContentType = "text/plain"
+			assert.NoError(t, client.Send(ctx, msg))
+		},
+	)
+	assert.Equal(t, []string{`{"type":"application/json","data":{"hi":"hello"}}`, `{"type":"text/plain","data":"aGVsbG8="}`}, received)
+}
+
+func TestClientBodyTemplateContentType(t *testing.T) {
+	called := false
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		called = true
+		assert.Equal(t, "text/plain", r.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", string(body))
+	}
+	newClientAndServerByConfig(
+		t,
+		handler,
+		map[string]interface{}{},
+		`{ "url": "${BASE_URL}/", "headers": { "Content-Type": "text/plain" }, "body": "{{ .message.content.text }}" }`,
+		func(client *clientImpl) {
+			assert.NoError(t, client.Send(context.Background(), templateTestMessage("msg-1", `{"text":"hello"}`)))
+		},
+	)
+	assert.True(t, called)
+}
+
+func TestBodyTemplateFailureNotBlocksOtherWebhooks(t *testing.T) {
+	sentry := sentry.NewStubSentry()
+	ctx := sentry.WrapContext(context.Background())
+
+	lock := sync.Mutex{}
+	received := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		received = append(received, r.URL.Path)
+	}))
+	defer server.Close()
+
+	clients := []Client{}
+	for _, cfg := range []string{
+		`{ "url": "${BASE_URL}/broken", "body": "{{ .message.content.no.such.field }}" }`,
+		`{ "url": "${BASE_URL}/ok" }`,
+	} {
+		tpl := newClientTemplateByConfig(t, `.+`, strings.ReplaceAll(cfg, "${BASE_URL}", server.URL))
+		defer tpl.Close()
+		client, err := tpl.NewClient(map[string]interface{}{})
+		assert.NoError(t, err)
+		clients = append(clients, client)
+	}
+	multiplex := NewMultiplexClient(clients)
+	defer multiplex.Close(ctx)
+
+	assert.NoError(t, multiplex.Send(ctx, templateTestMessage("msg-1", `{}`)))
+	assert.Equal(t, []string{"/ok"}, received)
+	assert.Regexp(t, `failed to evaluate outgoing-webhook body template`, sentry.GetLastError())
+}
+
+func TestBatchClientFilter(t *testing.T) {
+	lock := sync.Mutex{}
+	var received [][]domain.Message
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		var body []domain.Message
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		lock.Lock()
+		defer lock.Unlock()
+		received = append(received, body)
+	}
+	newBatchClientAndServer(t, handler, `{ "url": "${BASE_URL}/", "filter": "{{ index .message.content \"important\" }}", "batch": { "maxMessages": 2, "window": "1m" } }`, func(tpl ClientTemplate, client Client) {
+		msgs := []domain.Message{
+			templateTestMessage("msg-1", `{"important":true}`),
+			templateTestMessage("msg-2", `{"important":false}`),
+			templateTestMessage("msg-3", `{"important":true}`),
+		}
+		for _, err := range sendConcurrently(t, client, msgs) {
+			assert.NoError(t, err)
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		if assert.Equal(t, 1, len(received)) {
+			assert.ElementsMatch(t, []domain.Message{msgs[0], msgs[2]}, received[0])
+		}
+	})
+}
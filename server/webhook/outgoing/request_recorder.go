@@ -0,0 +1,49 @@
+package outgoing
+
+import (
+	"context"
+	"sync"
+
+	"github.com/saiya/dsps/server/domain"
+)
+
+type requestRecorderCtxKey struct{}
+
+// RequestRecorder collects outgoing-webhook HTTP requests sent with the context.
+type RequestRecorder struct {
+	lock    sync.Mutex
+	records []domain.WebhookRequestRecord
+}
+
+// WithRequestRecorder returns context that records outgoing-webhook HTTP requests sent with it.
+func WithRequestRecorder(ctx context.Context) (context.Context, *RequestRecorder) {
+	r := &RequestRecorder{records: []domain.WebhookRequestRecord{}}
+	return context.WithValue(ctx, requestRecorderCtxKey{}, r), r
+}
+
+// requestRecorderOf returns recorder of the context, returns nil if not exists.
+func requestRecorderOf(ctx context.Context) *RequestRecorder {
+	if r, ok := ctx.Value(requestRecorderCtxKey{}).(*RequestRecorder); ok {
+		return r
+	}
+	return nil
+}
+
+// Records returns requests recorded so far, in order of completion.
+func (r *RequestRecorder) Records() []domain.WebhookRequestRecord {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	result := make([]domain.WebhookRequestRecord, len(r.records))
+	copy(result, r.records)
+	return result
+}
+
+// add is no-op if the recorder is nil.
+func (r *RequestRecorder) add(records ...domain.WebhookRequestRecord) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.records = append(r.records, records...)
+}
@@ -0,0 +1,98 @@
+package outgoing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/saiya/dsps/server/telemetry"
+	dspstesting "github.com/saiya/dsps/server/testing"
+)
+
+func TestRequestRecorder(t *testing.T) {
+	handlerCalled := 0
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		handlerCalled++
+		if handlerCalled <= 2 {
+			rw.WriteHeader(503)
+		}
+	}
+	newClientAndServerByConfig(
+		t,
+		handler,
+		map[string]interface{}{},
+		`{ "url": "${BASE_URL}/hook", "retry": { "count": 3, "interval": "1ms", "intervalJitter": "1ms" } }`,
+		func(client *clientImpl) {
+			// Without recorder
+			assert.NoError(t, client.Send(context.Background(), templateTestMessage("msg-1", `{}`)))
+			handlerCalled = 0
+
+			ctx, recorder := WithRequestRecorder(context.Background())
+			assert.NoError(t, client.Send(ctx, templateTestMessage("msg-1", `{}`)))
+			records := recorder.Records()
+			if assert.Equal(t, 3, len(records)) {
+				assert.Equal(t, client.url, records[0].URL)
+				assert.Equal(t, 503, records[0].StatusCode)
+				assert.Equal(t, "status code 503 returned", records[0].Error)
+				assert.Greater(t, records[0].Latency.Nanoseconds(), int64(0))
+				assert.Equal(t, 503, records[1].StatusCode)
+				assert.Equal(t, 200, records[2].StatusCode)
+				assert.Equal(t, "", records[2].Error)
+			}
+		},
+	)
+}
+
+func TestRequestRecorderConnectionFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}))
+	server.Close() // Connection refused
+
+	breakers := NewCircuitBreakerRegistry(dspstesting.NewStubClock(t), telemetry.NewEmptyTelemetry(t))
+	tpl := newClientTemplateWithBreakers(t, `.+`, `{ "url": "`+server.URL+`", "retry": { "count": 1, "interval": "1ms", "intervalJitter": "1ms" }, "circuitBreaker": { "failureThreshold": 1 } }`, breakers)
+	defer tpl.Close()
+	client, err := tpl.NewClient(map[string]interface{}{})
+	assert.NoError(t, err)
+
+	ctx, recorder := WithRequestRecorder(context.Background())
+	assert.Error(t, client.Send(ctx, templateTestMessage("msg-1", `{}`)))
+	assert.Error(t, client.Send(ctx, templateTestMessage("msg-1", `{}`))) // Circuit breaker open
+	records := recorder.Records()
+	if assert.Equal(t, 1+1+1, len(records)) {
+		assert.Equal(t, 0, records[0].StatusCode)
+		assert.Regexp(t, `connection refused`, records[0].Error)
+		assert.Equal(t, 0, records[2].StatusCode)
+		assert.Regexp(t, `circuit breaker is open`, records[2].Error)
+		assert.Equal(t, int64(0), records[2].Latency.Nanoseconds())
+	}
+}
+
+func TestRequestRecorderBatch(t *testing.T) {
+	var handler http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {}
+	newBatchClientAndServer(t, handler, `{ "url": "${BASE_URL}/", "batch": { "maxMessages": 2, "window": "1m" } }`, func(tpl ClientTemplate, client Client) {
+		recorders := make([]*RequestRecorder, 2)
+		wg := sync.WaitGroup{}
+		for i := range recorders {
+			var ctx context.Context
+			ctx, recorders[i] = WithRequestRecorder(context.Background())
+			msg := batchTestMessage(i)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, client.Send(ctx, msg))
+			}()
+		}
+		wg.Wait()
+
+		// All messages in the batch share the request
+		for _, recorder := range recorders {
+			records := recorder.Records()
+			if assert.Equal(t, 1, len(records)) {
+				assert.Equal(t, 200, records[0].StatusCode)
+			}
+		}
+	})
+}
@@ -88,7 +88,11 @@ func (r *retry) Do(ctx context.Context, sentryInstance sentry.Sentry, descriptio
 
 		wait := r.computeRetryWait(attempt)
 		logger.Of(ctx).Infof(logger.CatOutgoingWebhook, "retrying outgoing webhook after %s: %w", wait, err)
-		time.Sleep(wait)
+		select {
+		case <-ctx.Done():
+			return xerrors.Errorf("outgoing webhook retry aborted: %w", ctx.Err())
+		case <-time.After(wait):
+		}
 		continue
 	}
 }
@@ -0,0 +1,91 @@
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTP headers of the outgoing-webhook signature, see server/doc/outgoing-webhook.md for spec.
+const (
+	TimestampHeader = "X-DSPS-Timestamp"
+	SignatureHeader = "X-DSPS-Signature"
+)
+
+const signatureVersion = "v1"
+
+// DefaultTolerance is recommended max difference between the timestamp and the current time.
+const DefaultTolerance = 5 * time.Minute
+
+// Errors returned by Verify
+var (
+	ErrNoSignature        = errors.New("signature or timestamp header not found")
+	ErrInvalidTimestamp   = errors.New("invalid timestamp header")
+	ErrTimestampTolerance = errors.New("timestamp is out of tolerance")
+	ErrSignatureMismatch  = errors.New("no signature matched")
+)
+
+// Sign computes HTTP headers to sign the body.
+// If multiple secrets given (e.g. secret rotation), signs with each secret.
+func Sign(header http.Header, body []byte, secrets []string, timestamp time.Time) {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	signatures := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		signatures = append(signatures, fmt.Sprintf("%s=%s", signatureVersion, compute(secret, ts, body)))
+	}
+	header.Set(TimestampHeader, ts)
+	header.Set(SignatureHeader, strings.Join(signatures, ","))
+}
+
+// Verify checks signature headers of the received webhook request.
+// Succeeds if any signature matches with any of given secrets.
+// tolerance is max difference between the timestamp and now to reject replayed requests.
+func Verify(header http.Header, body []byte, secrets []string, tolerance time.Duration, now time.Time) error {
+	ts := header.Get(TimestampHeader)
+	sigHeader := header.Get(SignatureHeader)
+	if ts == "" || sigHeader == "" {
+		return ErrNoSignature
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	diff := now.Sub(time.Unix(unix, 0))
+	if diff < -tolerance || tolerance < diff {
+		return ErrTimestampTolerance
+	}
+
+	for _, sig := range strings.Split(sigHeader, ",") {
+		kv := strings.SplitN(strings.TrimSpace(sig), "=", 2)
+		if len(kv) != 2 || kv[0] != signatureVersion {
+			continue
+		}
+		given, err := hex.DecodeString(kv[1])
+		if err != nil {
+			continue
+		}
+		for _, secret := range secrets {
+			expected, _ := hex.DecodeString(compute(secret, ts, body))
+			if hmac.Equal(given, expected) {
+				return nil
+			}
+		}
+	}
+	return ErrSignatureMismatch
+}
+
+// HMAC-SHA256 of "{timestamp}.{body}"
+func compute(secret string, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,63 @@
+package signature_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/saiya/dsps/server/webhook/signature"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"type":"dsps.channel.outgoing-webhook"}`)
+	now := time.Unix(1605633588, 0)
+	header := http.Header{}
+	Sign(header, body, []string{"secret-1"}, now)
+	assert.Equal(t, "1605633588", header.Get(TimestampHeader))
+	assert.Regexp(t, `^v1=[0-9a-f]{64}$`, header.Get(SignatureHeader))
+
+	assert.NoError(t, Verify(header, body, []string{"secret-1"}, DefaultTolerance, now))
+	assert.NoError(t, Verify(header, body, []string{"other", "secret-1"}, DefaultTolerance, now.Add(DefaultTolerance)))
+	assert.NoError(t, Verify(header, body, []string{"secret-1"}, DefaultTolerance, now.Add(-DefaultTolerance)))
+
+	assert.Equal(t, ErrSignatureMismatch, Verify(header, body, []string{"secret-2"}, DefaultTolerance, now))
+	assert.Equal(t, ErrSignatureMismatch, Verify(header, []byte(`{}`), []string{"secret-1"}, DefaultTolerance, now))
+	assert.Equal(t, ErrTimestampTolerance, Verify(header, body, []string{"secret-1"}, DefaultTolerance, now.Add(DefaultTolerance+time.Second)))
+	assert.Equal(t, ErrTimestampTolerance, Verify(header, body, []string{"secret-1"}, DefaultTolerance, now.Add(-DefaultTolerance-time.Second)))
+
+	// Timestamp is part of the signature
+	replayed := header.Clone()
+	replayed.Set(TimestampHeader, "1605633600")
+	assert.Equal(t, ErrSignatureMismatch, Verify(replayed, body, []string{"secret-1"}, DefaultTolerance, now))
+}
+
+func TestSignWithMultipleSecrets(t *testing.T) {
+	body := []byte(`{}`)
+	now := time.Now()
+	header := http.Header{}
+	Sign(header, body, []string{"new-secret", "old-secret"}, now)
+	assert.Equal(t, 2, len(strings.Split(header.Get(SignatureHeader), ",")))
+
+	// Receiver knows only one of secrets (e.g. during rotation)
+	assert.NoError(t, Verify(header, body, []string{"new-secret"}, DefaultTolerance, now))
+	assert.NoError(t, Verify(header, body, []string{"old-secret"}, DefaultTolerance, now))
+}
+
+func TestVerifyInvalidHeaders(t *testing.T) {
+	body := []byte(`{}`)
+	now := time.Now()
+	assert.Equal(t, ErrNoSignature, Verify(http.Header{}, body, []string{"secret"}, DefaultTolerance, now))
+
+	header := http.Header{}
+	Sign(header, body, []string{"secret"}, now)
+	header.Set(TimestampHeader, "not-a-number")
+	assert.Equal(t, ErrInvalidTimestamp, Verify(header, body, []string{"secret"}, DefaultTolerance, now))
+
+	header = http.Header{}
+	Sign(header, body, []string{"secret"}, now)
+	header.Set(SignatureHeader, "v0=abc, v1=zz, broken")
+	assert.Equal(t, ErrSignatureMismatch, Verify(header, body, []string{"secret"}, DefaultTolerance, now))
+}
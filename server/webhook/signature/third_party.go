@@ -0,0 +1,91 @@
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTP headers of third-party webhook signatures
+const (
+	GitHubSignatureHeader = "X-Hub-Signature-256"
+	StripeSignatureHeader = "Stripe-Signature"
+)
+
+// VerifyGitHub verifies "X-Hub-Signature-256" header of GitHub webhook.
+// Note that GitHub signature has no timestamp, receiver should deduplicate replayed requests (e.g. by delivery ID).
+func VerifyGitHub(header http.Header, body []byte, secrets []string) error {
+	sigHeader := header.Get(GitHubSignatureHeader)
+	if sigHeader == "" {
+		return ErrNoSignature
+	}
+	if !strings.HasPrefix(sigHeader, "sha256=") {
+		return ErrSignatureMismatch
+	}
+	given, err := hex.DecodeString(strings.TrimPrefix(sigHeader, "sha256="))
+	if err != nil {
+		return ErrSignatureMismatch
+	}
+	for _, secret := range secrets {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		if hmac.Equal(given, mac.Sum(nil)) {
+			return nil
+		}
+	}
+	return ErrSignatureMismatch
+}
+
+// VerifyStripe verifies "Stripe-Signature" header of Stripe webhook.
+// Stripe signs "{timestamp}.{body}" with HMAC-SHA256 as same as Sign() of this package, but uses a single header.
+func VerifyStripe(header http.Header, body []byte, secrets []string, tolerance time.Duration, now time.Time) error {
+	sigHeader := header.Get(StripeSignatureHeader)
+	if sigHeader == "" {
+		return ErrNoSignature
+	}
+
+	var ts string
+	signatures := make([]string, 0, 2)
+	for _, item := range strings.Split(sigHeader, ",") {
+		kv := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			ts = kv[1]
+		case signatureVersion:
+			signatures = append(signatures, kv[1])
+		}
+	}
+	if ts == "" || len(signatures) == 0 {
+		return ErrNoSignature
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	diff := now.Sub(time.Unix(unix, 0))
+	if diff < -tolerance || tolerance < diff {
+		return ErrTimestampTolerance
+	}
+
+	for _, sig := range signatures {
+		given, err := hex.DecodeString(sig)
+		if err != nil {
+			continue
+		}
+		for _, secret := range secrets {
+			expected, _ := hex.DecodeString(compute(secret, ts, body))
+			if hmac.Equal(given, expected) {
+				return nil
+			}
+		}
+	}
+	return ErrSignatureMismatch
+}
@@ -0,0 +1,62 @@
+package signature_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/saiya/dsps/server/webhook/signature"
+)
+
+func hmacHex(secret string, data string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyGitHub(t *testing.T) {
+	body := []byte(`{"action":"opened"}`)
+	header := http.Header{}
+	assert.Equal(t, ErrNoSignature, VerifyGitHub(header, body, []string{"secret-1"}))
+
+	header.Set(GitHubSignatureHeader, "sha256="+hmacHex("secret-1", string(body)))
+	assert.NoError(t, VerifyGitHub(header, body, []string{"secret-1"}))
+	assert.NoError(t, VerifyGitHub(header, body, []string{"other", "secret-1"}))
+	assert.Equal(t, ErrSignatureMismatch, VerifyGitHub(header, body, []string{"secret-2"}))
+	assert.Equal(t, ErrSignatureMismatch, VerifyGitHub(header, []byte(`{}`), []string{"secret-1"}))
+
+	header.Set(GitHubSignatureHeader, "sha1="+hmacHex("secret-1", string(body)))
+	assert.Equal(t, ErrSignatureMismatch, VerifyGitHub(header, body, []string{"secret-1"}))
+	header.Set(GitHubSignatureHeader, "sha256=not-a-hex")
+	assert.Equal(t, ErrSignatureMismatch, VerifyGitHub(header, body, []string{"secret-1"}))
+}
+
+func TestVerifyStripe(t *testing.T) {
+	body := []byte(`{"id":"evt_1"}`)
+	now := time.Unix(1605633588, 0)
+	header := http.Header{}
+	assert.Equal(t, ErrNoSignature, VerifyStripe(header, body, []string{"secret-1"}, DefaultTolerance, now))
+
+	sig := hmacHex("secret-1", "1605633588."+string(body))
+	header.Set(StripeSignatureHeader, fmt.Sprintf("t=1605633588,v1=%s,v0=ignored", sig))
+	assert.NoError(t, VerifyStripe(header, body, []string{"secret-1"}, DefaultTolerance, now))
+	assert.NoError(t, VerifyStripe(header, body, []string{"other", "secret-1"}, DefaultTolerance, now.Add(DefaultTolerance)))
+	assert.Equal(t, ErrSignatureMismatch, VerifyStripe(header, body, []string{"secret-2"}, DefaultTolerance, now))
+	assert.Equal(t, ErrSignatureMismatch, VerifyStripe(header, []byte(`{}`), []string{"secret-1"}, DefaultTolerance, now))
+	assert.Equal(t, ErrTimestampTolerance, VerifyStripe(header, body, []string{"secret-1"}, DefaultTolerance, now.Add(DefaultTolerance+time.Second)))
+
+	// Multiple signatures while rotating secrets
+	header.Set(StripeSignatureHeader, fmt.Sprintf("t=1605633588,v1=%s,v1=%s", hmacHex("secret-2", "1605633588."+string(body)), sig))
+	assert.NoError(t, VerifyStripe(header, body, []string{"secret-1"}, DefaultTolerance, now))
+
+	header.Set(StripeSignatureHeader, "t=abc,v1="+sig)
+	assert.Equal(t, ErrInvalidTimestamp, VerifyStripe(header, body, []string{"secret-1"}, DefaultTolerance, now))
+	header.Set(StripeSignatureHeader, "v1="+sig)
+	assert.Equal(t, ErrNoSignature, VerifyStripe(header, body, []string{"secret-1"}, DefaultTolerance, now))
+}